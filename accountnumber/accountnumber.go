@@ -0,0 +1,142 @@
+// Package accountnumber generates and validates IBAN-style account numbers:
+// a country code, two mod-97 check digits (ISO 7064) and the basic bank account
+// number made of a bank code and random digits, e.g. SB27SMPL012345678901.
+package accountnumber
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/hhow09/simple_bank/util"
+	"go.uber.org/fx"
+)
+
+const (
+	// MinLength and MaxLength are the bounds of IBANs of all countries
+	MinLength = 15
+	MaxLength = 34
+)
+
+var (
+	ErrMalformed = errors.New("account number is malformed")
+	ErrChecksum  = errors.New("account number check digits are wrong")
+)
+
+// Generator creates account numbers of the configured scheme
+type Generator struct {
+	countryCode string
+	bankCode    string
+	digits      int
+}
+
+// NewGenerator creates a generator of ACCOUNT_NUMBER_COUNTRY_CODE, ACCOUNT_NUMBER_BANK_CODE
+// followed by ACCOUNT_NUMBER_DIGITS random digits
+func NewGenerator(config util.Config) (Generator, error) {
+	g := Generator{
+		countryCode: config.AccountNumberCountryCode,
+		bankCode:    config.AccountNumberBankCode,
+		digits:      config.AccountNumberDigits,
+	}
+	if len(g.countryCode) != 2 || !isUpper(g.countryCode) {
+		return g, fmt.Errorf("account number country code %q must be 2 upper case letters", g.countryCode)
+	}
+	if !isAlphanumeric(g.bankCode) {
+		return g, fmt.Errorf("account number bank code %q must be upper case letters and digits", g.bankCode)
+	}
+	length := 4 + len(g.bankCode) + g.digits
+	if g.digits < 1 || length < MinLength || length > MaxLength {
+		return g, fmt.Errorf("account numbers of %d characters are not between %d and %d", length, MinLength, MaxLength)
+	}
+	return g, nil
+}
+
+// Generate returns a new random account number
+func (g Generator) Generate() (string, error) {
+	max := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(g.digits)), nil)
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+	bban := g.bankCode + fmt.Sprintf("%0*s", g.digits, n.String())
+	return g.countryCode + checkDigits(g.countryCode, bban) + bban, nil
+}
+
+// Validate checks the format and the check digits of an account number
+// in electronic format, upper case without spaces.
+// Numbers of any country and bank code are accepted, so numbers stay valid
+// when the configured scheme changes.
+func Validate(number string) error {
+	if len(number) < MinLength || len(number) > MaxLength ||
+		!isUpper(number[:2]) || !isDigits(number[2:4]) || !isAlphanumeric(number[4:]) {
+		return ErrMalformed
+	}
+	if mod97(number[4:]+number[:4]) != 1 {
+		return ErrChecksum
+	}
+	return nil
+}
+
+// Format returns the account number in groups of four characters, as it is printed
+func Format(number string) string {
+	var b strings.Builder
+	for i, r := range number {
+		if i > 0 && i%4 == 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// checkDigits computes the two check digits of the number with "00" in their place
+func checkDigits(countryCode string, bban string) string {
+	return fmt.Sprintf("%02d", 98-mod97(bban+countryCode+"00"))
+}
+
+// mod97 is the remainder of the number where letters are replaced by 10 (A) to 35 (Z),
+// computed piece by piece since the number doesn't fit in an integer
+func mod97(s string) int {
+	remainder := 0
+	for _, r := range s {
+		if r >= 'A' && r <= 'Z' {
+			remainder = (remainder*100 + int(r-'A') + 10) % 97
+		} else {
+			remainder = (remainder*10 + int(r-'0')) % 97
+		}
+	}
+	return remainder
+}
+
+func isUpper(s string) bool {
+	for _, r := range s {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func isAlphanumeric(s string) bool {
+	for _, r := range s {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+var Module = fx.Options(
+	fx.Provide(NewGenerator),
+)
@@ -0,0 +1,83 @@
+package accountnumber
+
+import (
+	"testing"
+
+	"github.com/hhow09/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func testConfig() util.Config {
+	return util.Config{
+		AccountNumberCountryCode: "SB",
+		AccountNumberBankCode:    "SMPL",
+		AccountNumberDigits:      12,
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	g, err := NewGenerator(testConfig())
+	require.NoError(t, err)
+
+	seen := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		number, err := g.Generate()
+		require.NoError(t, err)
+		require.Len(t, number, 20)
+		require.Regexp(t, `^SB[0-9]{2}SMPL[0-9]{12}$`, number)
+		require.NoError(t, Validate(number))
+		require.False(t, seen[number])
+		seen[number] = true
+	}
+}
+
+func TestNewGeneratorInvalidScheme(t *testing.T) {
+	for name, update := range map[string]func(config *util.Config){
+		"CountryCode": func(config *util.Config) { config.AccountNumberCountryCode = "s1" },
+		"BankCode":    func(config *util.Config) { config.AccountNumberBankCode = "sm-pl" },
+		"TooShort":    func(config *util.Config) { config.AccountNumberDigits = 2 },
+		"TooLong":     func(config *util.Config) { config.AccountNumberDigits = 40 },
+	} {
+		t.Run(name, func(t *testing.T) {
+			config := testConfig()
+			update(&config)
+			_, err := NewGenerator(config)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		name   string
+		number string
+		err    error
+	}{
+		// examples of the IBAN registry
+		{"GB", "GB82WEST12345698765432", nil},
+		{"DE", "DE89370400440532013000", nil},
+		{"NO", "NO9386011117947", nil},
+		{"WrongCheckDigits", "GB83WEST12345698765432", ErrChecksum},
+		{"Typo", "GB82WEST12345698765423", ErrChecksum},
+		{"LowerCase", "gb82west12345698765432", ErrMalformed},
+		{"Spaces", "GB82 WEST 1234 5698 7654 32", ErrMalformed},
+		{"TooShort", "GB82WEST123", ErrMalformed},
+		{"TooLong", "GB82WEST12345698765432123456789012345", ErrMalformed},
+		{"LettersInCheckDigits", "GBXXWEST12345698765432", ErrMalformed},
+		{"Empty", "", ErrMalformed},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Validate(tc.number)
+			if tc.err == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, tc.err)
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	require.Equal(t, "GB82 WEST 1234 5698 7654 32", Format("GB82WEST12345698765432"))
+}
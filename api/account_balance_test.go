@@ -0,0 +1,195 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/hhow09/simple_bank/apperror"
+	"github.com/hhow09/simple_bank/constants"
+	mockdb "github.com/hhow09/simple_bank/db/mock"
+	db "github.com/hhow09/simple_bank/db/sqlc"
+	"github.com/hhow09/simple_bank/token"
+	"github.com/stretchr/testify/require"
+)
+
+type accountBalanceBody struct {
+	AccountID      int64     `json:"account_id"`
+	Currency       string    `json:"currency"`
+	At             time.Time `json:"at"`
+	Balance        int64     `json:"balance"`
+	BalanceDecimal string    `json:"balance_decimal"`
+	SnapshotDate   *string   `json:"snapshot_date"`
+}
+
+func TestGetAccountBalanceAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+	account.Currency = "USD"
+	account.CreatedAt = time.Date(2024, 1, 10, 8, 0, 0, 0, time.UTC)
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	snapshot := db.AccountBalanceSnapshot{
+		AccountID: account.ID,
+		Date:      time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+		ClosingAt: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		Balance:   1000,
+	}
+
+	testCases := []struct {
+		name          string
+		query         string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "From Snapshot",
+			query: at.Format(time.RFC3339),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuth(t, request, tokenMaker, constants.AuthTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				store.EXPECT().GetLatestBalanceSnapshot(gomock.Any(), db.GetLatestBalanceSnapshotParams{AccountID: account.ID, At: at}).Times(1).Return(snapshot, nil)
+				store.EXPECT().SumEntriesBetween(gomock.Any(), db.SumEntriesBetweenParams{AccountID: account.ID, FromTime: snapshot.ClosingAt, UntilTime: at}).Times(1).Return(int64(-250), nil)
+				store.EXPECT().GetAccountBalanceAt(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got accountBalanceBody
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, account.ID, got.AccountID)
+				require.True(t, at.Equal(got.At))
+				require.Equal(t, int64(750), got.Balance)
+				require.Equal(t, "7.50", got.BalanceDecimal)
+				require.NotNil(t, got.SnapshotDate)
+				require.Equal(t, "2024-02-29", *got.SnapshotDate)
+			},
+		},
+		{
+			name:  "Without Snapshot",
+			query: at.Format(time.RFC3339),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuth(t, request, tokenMaker, constants.AuthTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				store.EXPECT().GetLatestBalanceSnapshot(gomock.Any(), gomock.Any()).Times(1).Return(db.AccountBalanceSnapshot{}, sql.ErrNoRows)
+				store.EXPECT().SumEntriesBetween(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().GetAccountBalanceAt(gomock.Any(), db.GetAccountBalanceAtParams{At: at, AccountID: account.ID}).Times(1).Return(int64(420), nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got accountBalanceBody
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, int64(420), got.Balance)
+				require.Nil(t, got.SnapshotDate)
+			},
+		},
+		{
+			name: "Default Now",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuth(t, request, tokenMaker, constants.AuthTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				store.EXPECT().GetLatestBalanceSnapshot(gomock.Any(), gomock.Any()).Times(1).Return(db.AccountBalanceSnapshot{}, sql.ErrNoRows)
+				store.EXPECT().GetAccountBalanceAt(gomock.Any(), gomock.Any()).Times(1).Return(account.Balance, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got accountBalanceBody
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, account.Balance, got.Balance)
+				require.WithinDuration(t, time.Now(), got.At, time.Minute)
+			},
+		},
+		{
+			name:  "Future",
+			query: time.Now().Add(time.Hour).Format(time.RFC3339),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuth(t, request, tokenMaker, constants.AuthTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				store.EXPECT().GetLatestBalanceSnapshot(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, apperror.CodeValidation)
+			},
+		},
+		{
+			name:  "Before Opened",
+			query: account.CreatedAt.Add(-time.Hour).Format(time.RFC3339),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuth(t, request, tokenMaker, constants.AuthTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				store.EXPECT().GetLatestBalanceSnapshot(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, apperror.CodeValidation)
+			},
+		},
+		{
+			name:  "Invalid Time",
+			query: "2024-03-01",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuth(t, request, tokenMaker, constants.AuthTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "Not Member",
+			query: at.Format(time.RFC3339),
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuth(t, request, tokenMaker, constants.AuthTypeBearer, "unauthorized_user", time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				store.EXPECT().GetLatestBalanceSnapshot(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusForbidden, apperror.CodeForbidden)
+			},
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			stubAuthUsers(store)
+			stubAccountMembers(store, account)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			path := fmt.Sprintf("/accounts/%d/balance", account.ID)
+			if tc.query != "" {
+				path += "?at=" + url.QueryEscape(tc.query)
+			}
+			request, err := http.NewRequest(http.MethodGet, path, nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
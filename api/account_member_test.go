@@ -0,0 +1,373 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/hhow09/simple_bank/apperror"
+	"github.com/hhow09/simple_bank/constants"
+	mockdb "github.com/hhow09/simple_bank/db/mock"
+	db "github.com/hhow09/simple_bank/db/sqlc"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListAccountMembersAPI(t *testing.T) {
+	owner, _ := randomUser(t)
+	account := randomAccount(owner.Username)
+	members := []db.AccountMember{
+		{AccountID: account.ID, Username: owner.Username, Role: constants.MemberRoleOwner},
+		{AccountID: account.ID, Username: "viewer", Role: constants.MemberRoleViewer},
+	}
+
+	testCases := []struct {
+		name          string
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: owner.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListAccountMembers(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(members, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got []db.AccountMember
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, members, got)
+			},
+		},
+		{
+			name:     "NotMember",
+			username: "stranger",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListAccountMembers(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusForbidden, apperror.CodeForbidden)
+			},
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			stubAuthUsers(store)
+			stubAccountMembers(store, account)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/members", account.ID)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			addAuth(t, request, server.tokenMaker, constants.AuthTypeBearer, tc.username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestAddAccountMemberAPI(t *testing.T) {
+	owner, _ := randomUser(t)
+	user, _ := randomUser(t)
+	account := randomAccount(owner.Username)
+	member := db.AccountMember{AccountID: account.ID, Username: user.Username, Role: constants.MemberRoleCoOwner}
+
+	testCases := []struct {
+		name          string
+		username      string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: owner.Username,
+			body:     gin.H{"username": user.Username, "role": constants.MemberRoleCoOwner},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				arg := db.CreateAccountMemberParams{
+					AccountID: account.ID,
+					Username:  user.Username,
+					Role:      constants.MemberRoleCoOwner,
+				}
+				store.EXPECT().CreateAccountMember(gomock.Any(), gomock.Eq(arg)).Times(1).Return(member, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got db.AccountMember
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, member, got)
+			},
+		},
+		{
+			name:     "NotOwner",
+			username: user.Username,
+			body:     gin.H{"username": user.Username, "role": constants.MemberRoleOwner},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccountMember(gomock.Any(), gomock.Eq(db.GetAccountMemberParams{AccountID: account.ID, Username: user.Username})).
+					Times(1).
+					Return(member, nil)
+				store.EXPECT().CreateAccountMember(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusForbidden, apperror.CodeForbidden)
+			},
+		},
+		{
+			name:     "UserNotFound",
+			username: owner.Username,
+			body:     gin.H{"username": user.Username, "role": constants.MemberRoleViewer},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(db.User{}, sql.ErrNoRows)
+				store.EXPECT().CreateAccountMember(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusNotFound, apperror.CodeNotFound)
+			},
+		},
+		{
+			name:     "DeletedUser",
+			username: owner.Username,
+			body:     gin.H{"username": user.Username, "role": constants.MemberRoleViewer},
+			buildStubs: func(store *mockdb.MockStore) {
+				deleted := user
+				deleted.DeletedAt = sql.NullTime{Time: time.Now(), Valid: true}
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(deleted, nil)
+				store.EXPECT().CreateAccountMember(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusNotFound, apperror.CodeNotFound)
+			},
+		},
+		{
+			name:     "AlreadyMember",
+			username: owner.Username,
+			body:     gin.H{"username": user.Username, "role": constants.MemberRoleViewer},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().CreateAccountMember(gomock.Any(), gomock.Any()).Times(1).Return(db.AccountMember{}, &pq.Error{Code: "23505"}) //unique_violation
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusConflict, apperror.CodeConflict)
+			},
+		},
+		{
+			name:     "InvalidRole",
+			username: owner.Username,
+			body:     gin.H{"username": user.Username, "role": "admin"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateAccountMember(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, apperror.CodeValidation)
+			},
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			stubAuthUsers(store)
+			stubAccountMembers(store, account)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/accounts/%d/members", account.ID)
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuth(t, request, server.tokenMaker, constants.AuthTypeBearer, tc.username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestUpdateAccountMemberAPI(t *testing.T) {
+	owner, _ := randomUser(t)
+	account := randomAccount(owner.Username)
+	member := db.AccountMember{AccountID: account.ID, Username: "member", Role: constants.MemberRoleViewer}
+
+	testCases := []struct {
+		name          string
+		member        string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "OK",
+			member: member.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.UpdateAccountMemberRoleParams{
+					Role:      constants.MemberRoleViewer,
+					AccountID: account.ID,
+					Username:  member.Username,
+				}
+				store.EXPECT().UpdateAccountMemberRoleTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(member, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:   "NotFound",
+			member: member.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateAccountMemberRoleTx(gomock.Any(), gomock.Any()).Times(1).Return(db.AccountMember{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusNotFound, apperror.CodeNotFound)
+			},
+		},
+		{
+			name:   "LastOwner",
+			member: owner.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateAccountMemberRoleTx(gomock.Any(), gomock.Any()).Times(1).Return(db.AccountMember{}, db.ErrLastAccountOwner)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusConflict, apperror.CodeConflict)
+			},
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			stubAuthUsers(store)
+			stubAccountMembers(store, account)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(gin.H{"role": constants.MemberRoleViewer})
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/accounts/%d/members/%s", account.ID, tc.member)
+			request, err := http.NewRequest(http.MethodPatch, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuth(t, request, server.tokenMaker, constants.AuthTypeBearer, owner.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestRemoveAccountMemberAPI(t *testing.T) {
+	owner, _ := randomUser(t)
+	account := randomAccount(owner.Username)
+	viewer := db.AccountMember{AccountID: account.ID, Username: "viewer", Role: constants.MemberRoleViewer}
+
+	testCases := []struct {
+		name          string
+		username      string
+		member        string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OwnerRemovesMember",
+			username: owner.Username,
+			member:   viewer.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.DeleteAccountMemberParams{AccountID: account.ID, Username: viewer.Username}
+				store.EXPECT().DeleteAccountMemberTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(viewer, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+			},
+		},
+		{
+			name:     "MemberLeaves",
+			username: viewer.Username,
+			member:   viewer.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.DeleteAccountMemberParams{AccountID: account.ID, Username: viewer.Username}
+				store.EXPECT().DeleteAccountMemberTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(viewer, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+			},
+		},
+		{
+			name:     "MemberRemovesOther",
+			username: viewer.Username,
+			member:   owner.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetAccountMember(gomock.Any(), gomock.Eq(db.GetAccountMemberParams{AccountID: account.ID, Username: viewer.Username})).
+					Times(1).
+					Return(viewer, nil)
+				store.EXPECT().DeleteAccountMemberTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusForbidden, apperror.CodeForbidden)
+			},
+		},
+		{
+			name:     "LastOwner",
+			username: owner.Username,
+			member:   owner.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().DeleteAccountMemberTx(gomock.Any(), gomock.Any()).Times(1).Return(db.AccountMember{}, db.ErrLastAccountOwner)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusConflict, apperror.CodeConflict)
+			},
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			stubAuthUsers(store)
+			stubAccountMembers(store, account)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/members/%s", account.ID, tc.member)
+			request, err := http.NewRequest(http.MethodDelete, url, nil)
+			require.NoError(t, err)
+
+			addAuth(t, request, server.tokenMaker, constants.AuthTypeBearer, tc.username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -2,22 +2,28 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
+	"github.com/hhow09/simple_bank/accountnumber"
+	"github.com/hhow09/simple_bank/apperror"
 	"github.com/hhow09/simple_bank/constants"
 	mockdb "github.com/hhow09/simple_bank/db/mock"
 	db "github.com/hhow09/simple_bank/db/sqlc"
+	"github.com/hhow09/simple_bank/money"
 	"github.com/hhow09/simple_bank/token"
 	"github.com/hhow09/simple_bank/util"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/require"
 )
 
@@ -43,6 +49,11 @@ func TestGetAccountAPI(t *testing.T) {
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
+				var got struct {
+					BalanceDecimal string `json:"balance_decimal"`
+				}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, money.Format(account.Balance, 2), got.BalanceDecimal)
 				requireBodyMatchAcoount(t, recorder.Body, account)
 			},
 		},
@@ -56,7 +67,7 @@ func TestGetAccountAPI(t *testing.T) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				requireProblem(t, recorder, http.StatusForbidden, apperror.CodeForbidden)
 			},
 		},
 		{
@@ -68,7 +79,7 @@ func TestGetAccountAPI(t *testing.T) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0).Return(account, nil)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				requireProblem(t, recorder, http.StatusUnauthorized, apperror.CodeUnauthorized)
 			},
 		},
 		{
@@ -81,7 +92,7 @@ func TestGetAccountAPI(t *testing.T) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusNotFound, recorder.Code)
+				requireProblem(t, recorder, http.StatusNotFound, apperror.CodeNotFound)
 			},
 		},
 		{
@@ -94,7 +105,7 @@ func TestGetAccountAPI(t *testing.T) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Account{}, sql.ErrConnDone)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+				requireProblem(t, recorder, http.StatusInternalServerError, apperror.CodeInternal)
 			},
 		},
 		{
@@ -107,7 +118,7 @@ func TestGetAccountAPI(t *testing.T) {
 				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireProblem(t, recorder, http.StatusBadRequest, apperror.CodeValidation)
 			},
 		},
 	}
@@ -120,6 +131,8 @@ func TestGetAccountAPI(t *testing.T) {
 			store := mockdb.NewMockStore(ctrl)
 			//build stubs
 			tc.buildStubs(store)
+			stubAuthUsers(store)
+			stubAccountMembers(store, account)
 
 			//start http server
 			server := newTestServer(t, store)
@@ -137,6 +150,88 @@ func TestGetAccountAPI(t *testing.T) {
 
 }
 
+func TestGetAccountByNumberAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	testCases := []struct {
+		name          string
+		number        string
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			number:   account.Number,
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Eq(account.Number)).Times(1).Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchAcoount(t, recorder.Body, account)
+			},
+		},
+		{
+			name:     "NotMember",
+			number:   account.Number,
+			username: "stranger",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Eq(account.Number)).Times(1).Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusForbidden, apperror.CodeForbidden)
+			},
+		},
+		{
+			name:     "NotFound",
+			number:   account.Number,
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Eq(account.Number)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusNotFound, apperror.CodeNotFound)
+			},
+		},
+		{
+			name:     "WrongCheckDigits",
+			number:   account.Number[:2] + "00" + account.Number[4:],
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, apperror.CodeValidation)
+			},
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			stubAuthUsers(store)
+			stubAccountMembers(store, account)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := "/accounts/number/" + tc.number
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			addAuth(t, request, server.tokenMaker, constants.AuthTypeBearer, tc.username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
 // response body should match input
 func requireBodyMatchAcoount(t *testing.T, body *bytes.Buffer, account db.Account) {
 	data, err := ioutil.ReadAll(body)
@@ -148,6 +243,31 @@ func requireBodyMatchAcoount(t *testing.T, body *bytes.Buffer, account db.Accoun
 	require.Equal(t, account, gotAccount)
 }
 
+type eqCreateAccountTxParamsMatcher struct {
+	arg db.CreateAccountTxParams
+}
+
+func (e eqCreateAccountTxParamsMatcher) Matches(x interface{}) bool {
+	res, ok := x.(db.CreateAccountTxParams)
+	if !ok {
+		return false
+	}
+	// the number is random, only its check digits can be compared
+	if accountnumber.Validate(res.Number) != nil {
+		return false
+	}
+	e.arg.Number = res.Number
+	return reflect.DeepEqual(res, e.arg)
+}
+
+func (e eqCreateAccountTxParamsMatcher) String() string {
+	return fmt.Sprintf("matches arg %v with a valid account number", e.arg)
+}
+
+func eqCreateAccountTxParams(arg db.CreateAccountTxParams) gomock.Matcher {
+	return eqCreateAccountTxParamsMatcher{arg}
+}
+
 func TestCreateAccountAPI(t *testing.T) {
 	user, _ := randomUser(t)
 	account := randomAccount(user.Username)
@@ -169,12 +289,16 @@ func TestCreateAccountAPI(t *testing.T) {
 				addAuth(t, request, tokenMaker, constants.AuthTypeBearer, user.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
-				arg := db.CreateAccountParams{
-					Owner:    account.Owner,
-					Currency: account.Currency,
-					Balance:  0,
+				arg := db.CreateAccountTxParams{
+					CreateAccountParams: db.CreateAccountParams{
+						Owner:    account.Owner,
+						Currency: account.Currency,
+						Balance:  0,
+						Type:     constants.AccountTypeChecking,
+					},
+					MaxPerCurrency: 1,
 				}
-				store.EXPECT().CreateAccount(gomock.Any(), arg).Times(1).Return(account, nil)
+				store.EXPECT().CreateAccountTx(gomock.Any(), eqCreateAccountTxParams(arg)).Times(1).Return(db.CreateAccountTxResult{Account: account}, nil)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
@@ -191,10 +315,104 @@ func TestCreateAccountAPI(t *testing.T) {
 				addAuth(t, request, tokenMaker, constants.AuthTypeBearer, user.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
-				store.EXPECT().CreateAccount(gomock.Any(), gomock.Any()).Times(1).Return(db.Account{}, sql.ErrConnDone)
+				store.EXPECT().CreateAccountTx(gomock.Any(), gomock.Any()).Times(1).Return(db.CreateAccountTxResult{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusInternalServerError, apperror.CodeInternal)
+			},
+		},
+		{
+			name: "NumberTaken",
+			body: gin.H{
+				"currency": account.Currency,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuth(t, request, tokenMaker, constants.AuthTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				taken := &pq.Error{Code: "23505", Constraint: "accounts_number_key"} //unique_violation
+				gomock.InOrder(
+					store.EXPECT().CreateAccountTx(gomock.Any(), gomock.Any()).Times(1).Return(db.CreateAccountTxResult{}, taken),
+					store.EXPECT().CreateAccountTx(gomock.Any(), gomock.Any()).Times(1).Return(db.CreateAccountTxResult{Account: account}, nil),
+				)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchAcoount(t, recorder.Body, account)
+			},
+		},
+		{
+			name: "Savings",
+			body: gin.H{
+				"currency": account.Currency,
+				"type":     constants.AccountTypeSavings,
+				"nickname": "Holiday",
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuth(t, request, tokenMaker, constants.AuthTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.CreateAccountTxParams{
+					CreateAccountParams: db.CreateAccountParams{
+						Owner:    account.Owner,
+						Currency: account.Currency,
+						Balance:  0,
+						Type:     constants.AccountTypeSavings,
+						Nickname: "Holiday",
+					},
+					MaxPerCurrency: 5,
+				}
+				store.EXPECT().CreateAccountTx(gomock.Any(), eqCreateAccountTxParams(arg)).Times(1).Return(db.CreateAccountTxResult{Account: account}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "LimitReached",
+			body: gin.H{
+				"currency": account.Currency,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuth(t, request, tokenMaker, constants.AuthTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateAccountTx(gomock.Any(), gomock.Any()).Times(1).Return(db.CreateAccountTxResult{}, db.ErrAccountLimitReached)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusConflict, apperror.CodeConflict)
+			},
+		},
+		{
+			name: "InvalidType",
+			body: gin.H{
+				"currency": account.Currency,
+				"type":     "brokerage",
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuth(t, request, tokenMaker, constants.AuthTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateAccountTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, apperror.CodeValidation)
+			},
+		},
+		{
+			name: "OwnerNotFound",
+			body: gin.H{
+				"owner":    account.Owner,
+				"currency": account.Currency,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuth(t, request, tokenMaker, constants.AuthTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateAccountTx(gomock.Any(), gomock.Any()).Times(1).Return(db.CreateAccountTxResult{}, &pq.Error{Code: "23503"}) //foreign_key_violation
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireProblem(t, recorder, http.StatusForbidden, apperror.CodeForbidden)
 			},
 		},
 		{
@@ -207,10 +425,10 @@ func TestCreateAccountAPI(t *testing.T) {
 				addAuth(t, request, tokenMaker, constants.AuthTypeBearer, user.Username, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
-				store.EXPECT().CreateAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateAccountTx(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireProblem(t, recorder, http.StatusBadRequest, apperror.CodeValidation)
 			},
 		},
 	}
@@ -223,6 +441,7 @@ func TestCreateAccountAPI(t *testing.T) {
 			store := mockdb.NewMockStore(ctrl)
 			//build stubs
 			tc.buildStubs(store)
+			stubAuthUsers(store)
 
 			//start http server
 			server := newTestServer(t, store)
@@ -254,6 +473,8 @@ func TestListAccountsAPI(t *testing.T) {
 	type Query struct {
 		pageID   int
 		pageSize int
+		typ      string
+		nickname string
 	}
 
 	testCases := []struct {
@@ -274,9 +495,9 @@ func TestListAccountsAPI(t *testing.T) {
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				arg := db.ListAccountsParams{
-					Owner:  user.Username,
-					Limit:  int32(n),
-					Offset: 0,
+					Username: user.Username,
+					Limit:    int32(n),
+					Offset:   0,
 				}
 
 				store.EXPECT().
@@ -289,6 +510,55 @@ func TestListAccountsAPI(t *testing.T) {
 				requireBodyMatchAccounts(t, recorder.Body, accounts)
 			},
 		},
+		{
+			name: "Filtered",
+			query: Query{
+				pageID:   1,
+				pageSize: n,
+				typ:      constants.AccountTypeSavings,
+				nickname: "holiday",
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuth(t, request, tokenMaker, constants.AuthTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListAccountsParams{
+					Username: user.Username,
+					Type:     sql.NullString{String: constants.AccountTypeSavings, Valid: true},
+					Nickname: sql.NullString{String: "holiday", Valid: true},
+					Limit:    int32(n),
+					Offset:   0,
+				}
+
+				store.EXPECT().
+					ListAccounts(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(accounts[:1], nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchAccounts(t, recorder.Body, accounts[:1])
+			},
+		},
+		{
+			name: "InvalidType",
+			query: Query{
+				pageID:   1,
+				pageSize: n,
+				typ:      "brokerage",
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuth(t, request, tokenMaker, constants.AuthTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAccounts(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, apperror.CodeValidation)
+			},
+		},
 		{
 			name: "InternalError",
 			query: Query{
@@ -305,7 +575,7 @@ func TestListAccountsAPI(t *testing.T) {
 					Return([]db.Account{}, sql.ErrConnDone)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+				requireProblem(t, recorder, http.StatusInternalServerError, apperror.CodeInternal)
 			},
 		},
 		{
@@ -323,7 +593,7 @@ func TestListAccountsAPI(t *testing.T) {
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireProblem(t, recorder, http.StatusBadRequest, apperror.CodeValidation)
 			},
 		},
 		{
@@ -341,7 +611,7 @@ func TestListAccountsAPI(t *testing.T) {
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireProblem(t, recorder, http.StatusBadRequest, apperror.CodeValidation)
 			},
 		},
 	}
@@ -355,6 +625,7 @@ func TestListAccountsAPI(t *testing.T) {
 
 			store := mockdb.NewMockStore(ctrl)
 			tc.buildStubs(store)
+			stubAuthUsers(store)
 
 			server := newTestServer(t, store)
 			recorder := httptest.NewRecorder()
@@ -367,6 +638,12 @@ func TestListAccountsAPI(t *testing.T) {
 			q := request.URL.Query()
 			q.Add("page_id", fmt.Sprintf("%d", tc.query.pageID))
 			q.Add("page_size", fmt.Sprintf("%d", tc.query.pageSize))
+			if tc.query.typ != "" {
+				q.Add("type", tc.query.typ)
+			}
+			if tc.query.nickname != "" {
+				q.Add("nickname", tc.query.nickname)
+			}
 			request.URL.RawQuery = q.Encode()
 
 			tc.setupAuth(t, request, server.tokenMaker)
@@ -376,13 +653,130 @@ func TestListAccountsAPI(t *testing.T) {
 	}
 }
 
+func TestUpdateAccountAPI(t *testing.T) {
+	owner, _ := randomUser(t)
+	account := randomAccount(owner.Username)
+
+	testCases := []struct {
+		name          string
+		username      string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: owner.Username,
+			body:     gin.H{"nickname": "Rent"},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.UpdateAccountNicknameParams{Nickname: "Rent", ID: account.ID}
+				renamed := account
+				renamed.Nickname = "Rent"
+				store.EXPECT().UpdateAccountNickname(gomock.Any(), gomock.Eq(arg)).Times(1).Return(renamed, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got db.Account
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, "Rent", got.Nickname)
+			},
+		},
+		{
+			name:     "RemoveNickname",
+			username: owner.Username,
+			body:     gin.H{"nickname": ""},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.UpdateAccountNicknameParams{Nickname: "", ID: account.ID}
+				store.EXPECT().UpdateAccountNickname(gomock.Any(), gomock.Eq(arg)).Times(1).Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "NotOwner",
+			username: "stranger",
+			body:     gin.H{"nickname": "Rent"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateAccountNickname(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusForbidden, apperror.CodeForbidden)
+			},
+		},
+		{
+			name:     "MissingNickname",
+			username: owner.Username,
+			body:     gin.H{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateAccountNickname(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, apperror.CodeValidation)
+			},
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			stubAuthUsers(store)
+			stubAccountMembers(store, account)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/accounts/%d", account.ID)
+			request, err := http.NewRequest(http.MethodPatch, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuth(t, request, server.tokenMaker, constants.AuthTypeBearer, tc.username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+// stubAccountMembers makes the owners of the accounts their only members
+func stubAccountMembers(store *mockdb.MockStore, accounts ...db.Account) {
+	store.EXPECT().
+		GetAccountMember(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		DoAndReturn(func(_ context.Context, arg db.GetAccountMemberParams) (db.AccountMember, error) {
+			for _, account := range accounts {
+				if account.ID == arg.AccountID && account.Owner == arg.Username {
+					return db.AccountMember{AccountID: account.ID, Username: account.Owner, Role: constants.MemberRoleOwner}, nil
+				}
+			}
+			return db.AccountMember{}, sql.ErrNoRows
+		})
+}
+
+// accountNumbers generates the numbers of random accounts
+var accountNumbers, _ = accountnumber.NewGenerator(util.Config{
+	AccountNumberCountryCode: "SB",
+	AccountNumberBankCode:    "SMPL",
+	AccountNumberDigits:      12,
+})
+
 // generate random account
 func randomAccount(owner string) db.Account {
+	number, _ := accountNumbers.Generate()
 	return db.Account{
 		ID:       util.RandomInt(1, 1000),
 		Owner:    owner,
 		Balance:  util.RandomMoney(),
 		Currency: util.RandomCurrency(),
+		Type:     constants.AccountTypeChecking,
+		Nickname: util.RandomString(6),
+		Number:   number,
 	}
 }
 
@@ -0,0 +1,330 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/hhow09/simple_bank/apperror"
+	"github.com/hhow09/simple_bank/constants"
+	mockdb "github.com/hhow09/simple_bank/db/mock"
+	db "github.com/hhow09/simple_bank/db/sqlc"
+	"github.com/hhow09/simple_bank/util"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+// randomBeneficiary saves the account as a beneficiary of the user, available for transfers
+func randomBeneficiary(username string, account db.Account) db.Beneficiary {
+	return db.Beneficiary{
+		ID:            util.RandomInt(1, 1000),
+		Username:      username,
+		AccountID:     account.ID,
+		AccountNumber: account.Number,
+		Label:         util.RandomOwner(),
+		AvailableAt:   time.Now().Add(-time.Hour).UTC().Truncate(time.Second),
+		CreatedAt:     time.Now().Add(-time.Hour).UTC().Truncate(time.Second),
+	}
+}
+
+func TestCreateBeneficiaryAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(util.RandomOwner())
+	beneficiary := randomBeneficiary(user.Username, account)
+
+	testCases := []struct {
+		name          string
+		coolingOff    string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:       "OK",
+			coolingOff: "0s",
+			body:       gin.H{"account_number": account.Number, "label": beneficiary.Label},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Eq(account.Number)).Times(1).Return(account, nil)
+				store.EXPECT().
+					CreateBeneficiary(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateBeneficiaryParams) (db.Beneficiary, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.Equal(t, account.ID, arg.AccountID)
+						require.Equal(t, account.Number, arg.AccountNumber)
+						require.Equal(t, beneficiary.Label, arg.Label)
+						require.WithinDuration(t, time.Now(), arg.AvailableAt, time.Second)
+						return beneficiary, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got db.Beneficiary
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, beneficiary, got)
+			},
+		},
+		{
+			name:       "CoolingOff",
+			coolingOff: "24h",
+			body:       gin.H{"account_number": account.Number, "label": beneficiary.Label},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Eq(account.Number)).Times(1).Return(account, nil)
+				store.EXPECT().
+					CreateBeneficiary(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateBeneficiaryParams) (db.Beneficiary, error) {
+						require.WithinDuration(t, time.Now().Add(24*time.Hour), arg.AvailableAt, time.Second)
+						return beneficiary, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:       "AccountNotFound",
+			coolingOff: "0s",
+			body:       gin.H{"account_number": account.Number, "label": beneficiary.Label},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Eq(account.Number)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().CreateBeneficiary(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusNotFound, apperror.CodeNotFound)
+			},
+		},
+		{
+			name:       "AlreadySaved",
+			coolingOff: "0s",
+			body:       gin.H{"account_number": account.Number, "label": beneficiary.Label},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Eq(account.Number)).Times(1).Return(account, nil)
+				store.EXPECT().CreateBeneficiary(gomock.Any(), gomock.Any()).Times(1).Return(db.Beneficiary{}, &pq.Error{Code: "23505"}) //unique_violation
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusConflict, apperror.CodeConflict)
+			},
+		},
+		{
+			name:       "FrozenAccount",
+			coolingOff: "0s",
+			body:       gin.H{"account_number": account.Number, "label": beneficiary.Label},
+			buildStubs: func(store *mockdb.MockStore) {
+				frozen := account
+				frozen.FrozenAt = sql.NullTime{Time: time.Now(), Valid: true}
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Eq(account.Number)).Times(1).Return(frozen, nil)
+				store.EXPECT().CreateBeneficiary(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusConflict, apperror.CodeConflict)
+			},
+		},
+		{
+			name:       "InvalidAccountNumber",
+			coolingOff: "0s",
+			body:       gin.H{"account_number": "SB00SMPL000000000000", "label": beneficiary.Label},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountByNumber(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, apperror.CodeValidation)
+			},
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("BENEFICIARY_COOLING_OFF_PERIOD", tc.coolingOff)
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			stubAuthUsers(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/beneficiaries", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuth(t, request, server.tokenMaker, constants.AuthTypeBearer, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestListBeneficiariesAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	beneficiaries := []db.Beneficiary{
+		randomBeneficiary(user.Username, randomAccount(util.RandomOwner())),
+		randomBeneficiary(user.Username, randomAccount(util.RandomOwner())),
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().ListBeneficiaries(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(beneficiaries, nil)
+	stubAuthUsers(store)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodGet, "/beneficiaries", nil)
+	require.NoError(t, err)
+
+	addAuth(t, request, server.tokenMaker, constants.AuthTypeBearer, user.Username, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var got []db.Beneficiary
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+	require.Equal(t, beneficiaries, got)
+}
+
+func TestBeneficiaryOwnershipAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	other, _ := randomUser(t)
+	beneficiary := randomBeneficiary(user.Username, randomAccount(util.RandomOwner()))
+
+	testCases := []struct {
+		name          string
+		method        string
+		body          gin.H
+		username      string
+		buildStubs    func(store *mockdb.MockStore, username string)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "Get",
+			method:   http.MethodGet,
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore, username string) {
+				arg := db.GetBeneficiaryParams{ID: beneficiary.ID, Username: username}
+				store.EXPECT().GetBeneficiary(gomock.Any(), gomock.Eq(arg)).Times(1).Return(beneficiary, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "GetOfOtherUser",
+			method:   http.MethodGet,
+			username: other.Username,
+			buildStubs: func(store *mockdb.MockStore, username string) {
+				arg := db.GetBeneficiaryParams{ID: beneficiary.ID, Username: username}
+				store.EXPECT().GetBeneficiary(gomock.Any(), gomock.Eq(arg)).Times(1).Return(db.Beneficiary{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusNotFound, apperror.CodeNotFound)
+			},
+		},
+		{
+			name:     "Update",
+			method:   http.MethodPatch,
+			body:     gin.H{"label": "rent"},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore, username string) {
+				arg := db.UpdateBeneficiaryLabelParams{Label: "rent", ID: beneficiary.ID, Username: username}
+				store.EXPECT().UpdateBeneficiaryLabel(gomock.Any(), gomock.Eq(arg)).Times(1).Return(beneficiary, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "UpdateOfOtherUser",
+			method:   http.MethodPatch,
+			body:     gin.H{"label": "rent"},
+			username: other.Username,
+			buildStubs: func(store *mockdb.MockStore, username string) {
+				arg := db.UpdateBeneficiaryLabelParams{Label: "rent", ID: beneficiary.ID, Username: username}
+				store.EXPECT().UpdateBeneficiaryLabel(gomock.Any(), gomock.Eq(arg)).Times(1).Return(db.Beneficiary{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusNotFound, apperror.CodeNotFound)
+			},
+		},
+		{
+			name:     "UpdateMissingLabel",
+			method:   http.MethodPatch,
+			body:     gin.H{},
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore, username string) {
+				store.EXPECT().UpdateBeneficiaryLabel(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, apperror.CodeValidation)
+			},
+		},
+		{
+			name:     "Delete",
+			method:   http.MethodDelete,
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore, username string) {
+				arg := db.DeleteBeneficiaryParams{ID: beneficiary.ID, Username: username}
+				store.EXPECT().DeleteBeneficiary(gomock.Any(), gomock.Eq(arg)).Times(1).Return(beneficiary, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+			},
+		},
+		{
+			name:     "DeleteOfOtherUser",
+			method:   http.MethodDelete,
+			username: other.Username,
+			buildStubs: func(store *mockdb.MockStore, username string) {
+				arg := db.DeleteBeneficiaryParams{ID: beneficiary.ID, Username: username}
+				store.EXPECT().DeleteBeneficiary(gomock.Any(), gomock.Eq(arg)).Times(1).Return(db.Beneficiary{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusNotFound, apperror.CodeNotFound)
+			},
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store, tc.username)
+			stubAuthUsers(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			var body *bytes.Reader
+			if tc.body != nil {
+				data, err := json.Marshal(tc.body)
+				require.NoError(t, err)
+				body = bytes.NewReader(data)
+			} else {
+				body = bytes.NewReader(nil)
+			}
+
+			url := fmt.Sprintf("/beneficiaries/%d", beneficiary.ID)
+			request, err := http.NewRequest(tc.method, url, body)
+			require.NoError(t, err)
+
+			addAuth(t, request, server.tokenMaker, constants.AuthTypeBearer, tc.username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -4,71 +4,144 @@ import (
 	"database/sql"
 	"errors"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/hhow09/simple_bank/accountnumber"
+	"github.com/hhow09/simple_bank/apperror"
 	"github.com/hhow09/simple_bank/constants"
 	db "github.com/hhow09/simple_bank/db/sqlc"
-	"github.com/hhow09/simple_bank/token"
+	"github.com/hhow09/simple_bank/money"
+	"github.com/hhow09/simple_bank/snapshot"
 	"github.com/hhow09/simple_bank/util"
 	"github.com/lib/pq"
 )
 
+// numberAttempts is how often a new account number is generated when it is already taken
+const numberAttempts = 3
+
 type AccountController struct {
-	store db.Store
+	store      db.Store
+	config     util.Config
+	numbers    accountnumber.Generator
+	currencies *money.Registry
 }
 
 // AccountController creates new account controller
-func NewAccountController(store db.Store) AccountController {
+func NewAccountController(store db.Store, config util.Config, numbers accountnumber.Generator, currencies *money.Registry) AccountController {
 	return AccountController{
-		store: store,
+		store:      store,
+		config:     config,
+		numbers:    numbers,
+		currencies: currencies,
+	}
+}
+
+// accountResponse adds the balance as a decimal string of major units
+type accountResponse struct {
+	db.Account
+	BalanceDecimal string     `json:"balance_decimal"`
+	FrozenAt       *time.Time `json:"frozen_at"`
+}
+
+func (c *AccountController) newAccountResponse(account db.Account) accountResponse {
+	return accountResponse{
+		Account:        account,
+		BalanceDecimal: c.currencies.Format(account.Currency, account.Balance),
+		FrozenAt:       nullTime(account.FrozenAt),
 	}
 }
 
 type CreateAccountRequest struct {
 	Currency string `json:"currency" binding:"required,currency"`
+	// Type is checking when empty
+	Type     string `json:"type" binding:"omitempty,oneof=checking savings"`
+	Nickname string `json:"nickname" binding:"max=64"`
 }
 
 // CreateAccount godoc
 // @Summary Create Account
-// @Description create account by a already-login user
+// @Description create account by a already-login user, who becomes its first owner.
+// @Description the number of accounts of each type per currency is limited by the configuration.
 // @Tags accounts
 // @Accept  json
 // @Produce  json
 // @Security authorization
 // @Param currency body string true "currency"
-// @Success 200 {object} CreateAccountRequest
-// @Failure 400 {object} gin.H
-// @Failure 403 {object} gin.H
+// @Param type body string false "checking (default) or savings"
+// @Param nickname body string false "nickname"
+// @Success 200 {object} accountResponse
+// @Failure 400 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
 // @Router /accounts [post]
 func (c *AccountController) CreateAccount(ctx *gin.Context) {
 	var req CreateAccountRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, util.ErrorResponse(err))
+		ctx.Error(apperror.FromBinding(err))
 		return
 	}
-	authPayload := ctx.MustGet(constants.AuthPayloadKey).(*token.Payload)
+	authUser := ctx.MustGet(constants.AuthUserKey).(db.User)
+
+	if req.Type == "" {
+		req.Type = constants.AccountTypeChecking
+	}
 
-	arg := db.CreateAccountParams{
-		Owner:    authPayload.Username,
-		Currency: req.Currency,
-		Balance:  0,
+	arg := db.CreateAccountTxParams{
+		CreateAccountParams: db.CreateAccountParams{
+			Owner:    authUser.Username,
+			Currency: req.Currency,
+			Balance:  0,
+			Type:     req.Type,
+			Nickname: req.Nickname,
+		},
+		MaxPerCurrency: c.maxPerCurrency(req.Type),
 	}
 
-	account, err := c.store.CreateAccount(ctx, arg)
+	result, err := c.createAccount(ctx, arg)
 	if err != nil {
-		if pqErr, ok := err.(*pq.Error); ok {
-			switch pqErr.Code.Name() {
-			case "foreign_key_violation", "unique_violation":
-				ctx.JSON(http.StatusForbidden, util.ErrorResponse(err))
-				return
-			}
+		if errors.Is(err, db.ErrAccountLimitReached) {
+			ctx.Error(&apperror.Error{Code: apperror.CodeConflict, Message: err.Error(), Err: err})
+			return
 		}
 
-		ctx.JSON(http.StatusBadRequest, util.ErrorResponse(err))
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code.Name() == "foreign_key_violation" {
+			ctx.Error(&apperror.Error{Code: apperror.CodeForbidden, Message: "account owner does not exist", Err: err})
+			return
+		}
+
+		ctx.Error(apperror.Internal(err))
 		return
 	}
 
-	ctx.JSON(http.StatusOK, account)
+	ctx.JSON(http.StatusOK, c.newAccountResponse(result.Account))
+}
+
+// createAccount creates the account with a new random number, regenerated when it is already taken
+func (c *AccountController) createAccount(ctx *gin.Context, arg db.CreateAccountTxParams) (db.CreateAccountTxResult, error) {
+	for attempt := 1; ; attempt++ {
+		number, err := c.numbers.Generate()
+		if err != nil {
+			return db.CreateAccountTxResult{}, err
+		}
+		arg.Number = number
+
+		result, err := c.store.CreateAccountTx(ctx, arg)
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Constraint == "accounts_number_key" && attempt < numberAttempts {
+			continue
+		}
+		return result, err
+	}
+}
+
+// maxPerCurrency is the number of accounts of the type a user can open in a currency
+func (c *AccountController) maxPerCurrency(accountType string) int64 {
+	if accountType == constants.AccountTypeSavings {
+		return c.config.AccountMaxSavingsPerCurrency
+	}
+	return c.config.AccountMaxCheckingPerCurrency
 }
 
 type getAccountRequest struct {
@@ -77,79 +150,546 @@ type getAccountRequest struct {
 
 // getAccount godoc
 // @Summary get Account
-// @Description get account by account id
+// @Description get account by account id, the current user must be a member of the account
 // @Tags accounts
 // @Accept  json
 // @Produce  json
 // @Security authorization
 // @Param id path integer true "Account ID"
-// @Success 200 {object} db.Account
-// @Failure 400 {object} gin.H
-// @Failure 403 {object} gin.H
+// @Success 200 {object} accountResponse
+// @Failure 400 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
 // @Router /accounts/:id [get]
 func (c *AccountController) GetAccount(ctx *gin.Context) {
 	var req getAccountRequest
 	if err := ctx.ShouldBindUri(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, util.ErrorResponse(err))
+		ctx.Error(apperror.FromBinding(err))
 		return
 	}
 
 	account, err := c.store.GetAccount(ctx, req.ID)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, util.ErrorResponse(err))
-			return
-		}
+		ctx.Error(apperror.From(err))
+		return
+	}
+
+	authUser := ctx.MustGet(constants.AuthUserKey).(db.User)
+	if _, appErr := getAccountMember(ctx, c.store, account.ID, authUser.Username); appErr != nil {
+		ctx.Error(appErr)
+		return
+	}
+	ctx.JSON(http.StatusOK, c.newAccountResponse(account))
+}
+
+type getAccountBalanceRequest struct {
+	At time.Time `form:"at" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+type accountBalanceResponse struct {
+	AccountID      int64     `json:"account_id"`
+	Currency       string    `json:"currency"`
+	At             time.Time `json:"at"`
+	Balance        int64     `json:"balance"`
+	BalanceDecimal string    `json:"balance_decimal"`
+	// SnapshotDate is the day of the snapshot the balance was computed from,
+	// null when it was computed back from the current balance
+	SnapshotDate *string `json:"snapshot_date"`
+}
+
+// GetAccountBalance godoc
+// @Summary get Account balance at a point in time
+// @Description get the balance of an account including the entries created up to at, the current user must be a member of the account
+// @Tags accounts
+// @Produce  json
+// @Security authorization
+// @Param id path integer true "Account ID"
+// @Param at query string false "RFC 3339 time, defaults to now"
+// @Success 200 {object} accountBalanceResponse
+// @Failure 400 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /accounts/:id/balance [get]
+func (c *AccountController) GetAccountBalance(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(apperror.FromBinding(err))
+		return
+	}
+	var req getAccountBalanceRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.Error(apperror.FromBinding(err))
+		return
+	}
+
+	account, err := c.store.GetAccount(ctx, uri.ID)
+	if err != nil {
+		ctx.Error(apperror.From(err))
+		return
+	}
+	authUser := ctx.MustGet(constants.AuthUserKey).(db.User)
+	if _, appErr := getAccountMember(ctx, c.store, account.ID, authUser.Username); appErr != nil {
+		ctx.Error(appErr)
+		return
+	}
 
-		ctx.JSON(http.StatusInternalServerError, util.ErrorResponse(err))
+	now := time.Now()
+	at := req.At
+	if at.IsZero() {
+		at = now
+	}
+	if at.After(now) {
+		ctx.Error(apperror.Validation("request validation failed",
+			apperror.FieldError{Field: "at", Rule: "past", Message: "must not be in the future"},
+		))
+		return
+	}
+	if at.Before(account.CreatedAt) {
+		ctx.Error(apperror.Validation("request validation failed",
+			apperror.FieldError{Field: "at", Rule: "opened", Message: "must not be before the account was opened"},
+		))
 		return
 	}
 
-	authPayload := ctx.MustGet(constants.AuthPayloadKey).(*token.Payload)
-	if account.Owner != authPayload.Username {
-		err := errors.New("account doesn't belongs to the authenticated user")
-		ctx.JSON(http.StatusUnauthorized, util.ErrorResponse(err))
+	balance, used, err := snapshot.BalanceAt(ctx, c.store, account.ID, at)
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
 		return
 	}
-	ctx.JSON(http.StatusOK, account)
+	rsp := accountBalanceResponse{
+		AccountID:      account.ID,
+		Currency:       account.Currency,
+		At:             at,
+		Balance:        balance,
+		BalanceDecimal: c.currencies.Format(account.Currency, balance),
+	}
+	if used != nil {
+		date := used.Date.Format(snapshot.DateFormat)
+		rsp.SnapshotDate = &date
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type getAccountByNumberRequest struct {
+	Number string `uri:"number" binding:"required,account_number"`
+}
+
+// GetAccountByNumber godoc
+// @Summary get Account by number
+// @Description get account by account number, the current user must be a member of the account
+// @Tags accounts
+// @Accept  json
+// @Produce  json
+// @Security authorization
+// @Param number path string true "Account number"
+// @Success 200 {object} accountResponse
+// @Failure 400 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /accounts/number/:number [get]
+func (c *AccountController) GetAccountByNumber(ctx *gin.Context) {
+	var req getAccountByNumberRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.Error(apperror.FromBinding(err))
+		return
+	}
+
+	account, err := c.store.GetAccountByNumber(ctx, req.Number)
+	if err != nil {
+		ctx.Error(apperror.From(err))
+		return
+	}
+
+	authUser := ctx.MustGet(constants.AuthUserKey).(db.User)
+	if _, appErr := getAccountMember(ctx, c.store, account.ID, authUser.Username); appErr != nil {
+		ctx.Error(appErr)
+		return
+	}
+	ctx.JSON(http.StatusOK, c.newAccountResponse(account))
+}
+
+type updateAccountRequest struct {
+	Nickname *string `json:"nickname" binding:"required,max=64"`
+}
+
+// UpdateAccount godoc
+// @Summary update Account
+// @Description change the nickname of an account, only owners of the account can rename it. an empty nickname removes it.
+// @Tags accounts
+// @Accept  json
+// @Produce  json
+// @Security authorization
+// @Param id path integer true "Account ID"
+// @Param nickname body string true "nickname"
+// @Success 200 {object} accountResponse
+// @Failure 400 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Router /accounts/:id [patch]
+func (c *AccountController) UpdateAccount(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(apperror.FromBinding(err))
+		return
+	}
+	var req updateAccountRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.FromBinding(err))
+		return
+	}
+	if appErr := c.requireAccountOwner(ctx, uri.ID); appErr != nil {
+		ctx.Error(appErr)
+		return
+	}
+
+	account, err := c.store.UpdateAccountNickname(ctx, db.UpdateAccountNicknameParams{
+		Nickname: *req.Nickname,
+		ID:       uri.ID,
+	})
+	if err != nil {
+		ctx.Error(apperror.From(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, c.newAccountResponse(account))
 }
 
 type listAccountRequest struct {
-	PageID   int32 `form:"page_id,default=1" binding:"min=1"`
-	PageSize int32 `form:"page_size,default=5" binding:"min=5,max=10"`
+	PageID   int32  `form:"page_id,default=1" binding:"min=1"`
+	PageSize int32  `form:"page_size,default=5" binding:"min=5,max=10"`
+	Type     string `form:"type" binding:"omitempty,oneof=checking savings"`
+	// Nickname matches case-insensitively any part of the nickname
+	Nickname string `form:"nickname" binding:"max=64"`
 }
 
 // listAccounts godoc
 // @Summary list Account
-// @Description list account under current user
+// @Description list accounts the current user is a member of
 // @Tags accounts
 // @Accept  json
 // @Produce  json
 // @Security authorization
 // @Param page_id query int true "page id minimum(1)"
 // @Param page_size query int true "page minimum(5) maximum(10)"
-// @Success 200 {object} []db.Account
-// @Failure 400 {object} gin.H
-// @Failure 403 {object} gin.H
+// @Param type query string false "only accounts of the type, checking or savings"
+// @Param nickname query string false "only accounts whose nickname contains the text"
+// @Success 200 {object} []accountResponse
+// @Failure 400 {object} apperror.Problem
 // @Router /accounts [get]
 func (c *AccountController) ListAccounts(ctx *gin.Context) {
 	var req listAccountRequest
 	if err := ctx.ShouldBindQuery(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, util.ErrorResponse(err))
+		ctx.Error(apperror.FromBinding(err))
 		return
 	}
-	authPayload := ctx.MustGet(constants.AuthPayloadKey).(*token.Payload)
+	authUser := ctx.MustGet(constants.AuthUserKey).(db.User)
 	arg := db.ListAccountsParams{
-		Owner:  authPayload.Username,
-		Limit:  req.PageSize,
-		Offset: (req.PageID - 1) * req.PageSize,
+		Username: authUser.Username,
+		Type:     sql.NullString{String: req.Type, Valid: req.Type != ""},
+		Nickname: sql.NullString{String: req.Nickname, Valid: req.Nickname != ""},
+		Limit:    req.PageSize,
+		Offset:   (req.PageID - 1) * req.PageSize,
 	}
 
 	accounts, err := c.store.ListAccounts(ctx, arg)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, util.ErrorResponse(err))
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+
+	rsp := make([]accountResponse, len(accounts))
+	for i, account := range accounts {
+		rsp[i] = c.newAccountResponse(account)
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type accountMembersRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// ListAccountMembers godoc
+// @Summary list Account members
+// @Description list the members of an account, the current user must be a member of the account
+// @Tags accounts
+// @Accept  json
+// @Produce  json
+// @Security authorization
+// @Param id path integer true "Account ID"
+// @Success 200 {object} []db.AccountMember
+// @Failure 400 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Router /accounts/:id/members [get]
+func (c *AccountController) ListAccountMembers(ctx *gin.Context) {
+	var req accountMembersRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.Error(apperror.FromBinding(err))
+		return
+	}
+	authUser := ctx.MustGet(constants.AuthUserKey).(db.User)
+	if _, appErr := getAccountMember(ctx, c.store, req.ID, authUser.Username); appErr != nil {
+		ctx.Error(appErr)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, accounts)
+	members, err := c.store.ListAccountMembers(ctx, req.ID)
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, members)
+}
+
+type addAccountMemberRequest struct {
+	Username string `json:"username" binding:"required,alphanum"`
+	Role     string `json:"role" binding:"required,oneof=owner co_owner viewer"`
+}
+
+// AddAccountMember godoc
+// @Summary add Account member
+// @Description share an account with another user, only owners of the account can add members.
+// @Description owners can send money and manage members, co_owners can send money and viewers can only view the account.
+// @Tags accounts
+// @Accept  json
+// @Produce  json
+// @Security authorization
+// @Param id path integer true "Account ID"
+// @Param request body addAccountMemberRequest true "member"
+// @Success 200 {object} db.AccountMember
+// @Failure 400 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Router /accounts/:id/members [post]
+func (c *AccountController) AddAccountMember(ctx *gin.Context) {
+	var uri accountMembersRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(apperror.FromBinding(err))
+		return
+	}
+	var req addAccountMemberRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.FromBinding(err))
+		return
+	}
+	if appErr := c.requireAccountOwner(ctx, uri.ID); appErr != nil {
+		ctx.Error(appErr)
+		return
+	}
+
+	user, err := c.store.GetUser(ctx, req.Username)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && user.DeletedAt.Valid) {
+		ctx.Error(apperror.NotFound("user not found"))
+		return
+	}
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+
+	member, err := c.store.CreateAccountMember(ctx, db.CreateAccountMemberParams{
+		AccountID: uri.ID,
+		Username:  user.Username,
+		Role:      req.Role,
+	})
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code.Name() == "unique_violation" {
+			ctx.Error(&apperror.Error{Code: apperror.CodeConflict, Message: "user is already a member of the account", Err: err})
+			return
+		}
+
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, member)
+}
+
+type accountMemberRequest struct {
+	ID       int64  `uri:"id" binding:"required,min=1"`
+	Username string `uri:"username" binding:"required"`
+}
+
+type updateAccountMemberRequest struct {
+	Role string `json:"role" binding:"required,oneof=owner co_owner viewer"`
+}
+
+// UpdateAccountMember godoc
+// @Summary update Account member
+// @Description change the role of a member, only owners of the account can change roles.
+// @Description the last owner of an account cannot be demoted.
+// @Tags accounts
+// @Accept  json
+// @Produce  json
+// @Security authorization
+// @Param id path integer true "Account ID"
+// @Param username path string true "username of the member"
+// @Param request body updateAccountMemberRequest true "role"
+// @Success 200 {object} db.AccountMember
+// @Failure 400 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Router /accounts/:id/members/:username [patch]
+func (c *AccountController) UpdateAccountMember(ctx *gin.Context) {
+	var uri accountMemberRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(apperror.FromBinding(err))
+		return
+	}
+	var req updateAccountMemberRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.FromBinding(err))
+		return
+	}
+	if appErr := c.requireAccountOwner(ctx, uri.ID); appErr != nil {
+		ctx.Error(appErr)
+		return
+	}
+
+	member, err := c.store.UpdateAccountMemberRoleTx(ctx, db.UpdateAccountMemberRoleParams{
+		Role:      req.Role,
+		AccountID: uri.ID,
+		Username:  uri.Username,
+	})
+	if err != nil {
+		ctx.Error(accountMemberError(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, member)
+}
+
+// RemoveAccountMember godoc
+// @Summary remove Account member
+// @Description remove a member from an account. owners can remove any member, other members can only leave the account.
+// @Description the last owner of an account cannot be removed.
+// @Tags accounts
+// @Accept  json
+// @Produce  json
+// @Security authorization
+// @Param id path integer true "Account ID"
+// @Param username path string true "username of the member"
+// @Success 204
+// @Failure 400 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Router /accounts/:id/members/:username [delete]
+func (c *AccountController) RemoveAccountMember(ctx *gin.Context) {
+	var uri accountMemberRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(apperror.FromBinding(err))
+		return
+	}
+	authUser := ctx.MustGet(constants.AuthUserKey).(db.User)
+	if uri.Username != authUser.Username {
+		if appErr := c.requireAccountOwner(ctx, uri.ID); appErr != nil {
+			ctx.Error(appErr)
+			return
+		}
+	}
+
+	_, err := c.store.DeleteAccountMemberTx(ctx, db.DeleteAccountMemberParams{
+		AccountID: uri.ID,
+		Username:  uri.Username,
+	})
+	if err != nil {
+		ctx.Error(accountMemberError(err))
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// requireAccountOwner checks that the current user is an owner of the account
+func (c *AccountController) requireAccountOwner(ctx *gin.Context, accountID int64) *apperror.Error {
+	authUser := ctx.MustGet(constants.AuthUserKey).(db.User)
+	member, appErr := getAccountMember(ctx, c.store, accountID, authUser.Username)
+	if appErr != nil {
+		return appErr
+	}
+	if member.Role != constants.MemberRoleOwner {
+		return apperror.Forbidden("only owners can manage the account")
+	}
+	return nil
+}
+
+func accountMemberError(err error) *apperror.Error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return &apperror.Error{Code: apperror.CodeNotFound, Message: "member not found", Err: err}
+	case errors.Is(err, db.ErrLastAccountOwner):
+		return &apperror.Error{Code: apperror.CodeConflict, Message: err.Error(), Err: err}
+	}
+	return apperror.Internal(err)
+}
+
+// getAccountMember returns the membership of the user in the account,
+// users who are not a member get a forbidden error
+func getAccountMember(ctx *gin.Context, store db.Store, accountID int64, username string) (db.AccountMember, *apperror.Error) {
+	member, err := store.GetAccountMember(ctx, db.GetAccountMemberParams{
+		AccountID: accountID,
+		Username:  username,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return member, apperror.Forbidden("account doesn't belong to the authenticated user")
+		}
+		return member, apperror.Internal(err)
+	}
+	return member, nil
+}
+
+// FreezeAccount godoc
+// @Summary Freeze Account
+// @Description block transfers from and to an account, admin only
+// @Tags admin
+// @Produce  json
+// @Security authorization
+// @Param id path integer true "Account ID"
+// @Success 200 {object} accountResponse
+// @Failure 400 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /admin/accounts/:id/freeze [post]
+func (c *AccountController) FreezeAccount(ctx *gin.Context) {
+	var req getAccountRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.Error(apperror.FromBinding(err))
+		return
+	}
+	account, err := c.store.FreezeAccount(ctx, req.ID)
+	if err != nil {
+		ctx.Error(apperror.From(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, c.newAccountResponse(account))
+}
+
+// UnfreezeAccount godoc
+// @Summary Unfreeze Account
+// @Description allow transfers from and to a frozen account again, admin only
+// @Tags admin
+// @Produce  json
+// @Security authorization
+// @Param id path integer true "Account ID"
+// @Success 200 {object} accountResponse
+// @Failure 400 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /admin/accounts/:id/unfreeze [post]
+func (c *AccountController) UnfreezeAccount(ctx *gin.Context) {
+	var req getAccountRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.Error(apperror.FromBinding(err))
+		return
+	}
+	account, err := c.store.UnfreezeAccount(ctx, req.ID)
+	if err != nil {
+		ctx.Error(apperror.From(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, c.newAccountResponse(account))
 }
@@ -0,0 +1,192 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hhow09/simple_bank/apperror"
+	"github.com/hhow09/simple_bank/constants"
+	db "github.com/hhow09/simple_bank/db/sqlc"
+	"github.com/hhow09/simple_bank/util"
+)
+
+type APIKeyController struct {
+	store db.Store
+}
+
+// NewAPIKeyController creates new api key controller
+func NewAPIKeyController(store db.Store) APIKeyController {
+	return APIKeyController{
+		store: store,
+	}
+}
+
+type apiKeyResponse struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func newAPIKeyResponse(apiKey db.ApiKey) apiKeyResponse {
+	return apiKeyResponse{
+		ID:         apiKey.ID,
+		Name:       apiKey.Name,
+		Prefix:     apiKey.Prefix,
+		Scopes:     apiKey.Scopes,
+		ExpiresAt:  nullTime(apiKey.ExpiresAt),
+		LastUsedAt: nullTime(apiKey.LastUsedAt),
+		RevokedAt:  nullTime(apiKey.RevokedAt),
+		CreatedAt:  apiKey.CreatedAt,
+	}
+}
+
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+type createAPIKeyRequest struct {
+	Name      string     `json:"name" binding:"required,max=64"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1,dive,oneof=accounts:read transfers:create"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type createAPIKeyResponse struct {
+	// Key is only returned once, it cannot be recovered later
+	Key    string         `json:"key"`
+	APIKey apiKeyResponse `json:"api_key"`
+}
+
+// createAPIKey godoc
+// @Summary Create API Key
+// @Description Create an API key for machine clients, use it with the "ApiKey <key>" authorization header. The key is only shown once.
+// @Tags api-keys
+// @Accept  json
+// @Produce  json
+// @Security authorization
+// @Param name body string true "name of the key"
+// @Param scopes body []string true "accounts:read, transfers:create"
+// @Param expires_at body string false "expiry time in RFC 3339, never expires when empty"
+// @Success 200 {object} createAPIKeyResponse
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /users/me/api_keys [post]
+func (c *APIKeyController) CreateAPIKey(ctx *gin.Context) {
+	var req createAPIKeyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.FromBinding(err))
+		return
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		ctx.Error(apperror.Validation("request validation failed",
+			apperror.FieldError{Field: "expires_at", Rule: "future", Message: "must be in the future"},
+		))
+		return
+	}
+	user := ctx.MustGet(constants.AuthUserKey).(db.User)
+
+	key, prefix, secret, err := util.NewAPIKey()
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+	arg := db.CreateAPIKeyParams{
+		Username:   user.Username,
+		Name:       req.Name,
+		Prefix:     prefix,
+		SecretHash: util.HashToken(secret),
+		Scopes:     req.Scopes,
+	}
+	if req.ExpiresAt != nil {
+		arg.ExpiresAt = sql.NullTime{Time: *req.ExpiresAt, Valid: true}
+	}
+
+	apiKey, err := c.store.CreateAPIKey(ctx, arg)
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, createAPIKeyResponse{
+		Key:    key,
+		APIKey: newAPIKeyResponse(apiKey),
+	})
+}
+
+// listAPIKeys godoc
+// @Summary List API Keys
+// @Description List the API keys of the current user, including revoked ones
+// @Tags api-keys
+// @Produce  json
+// @Security authorization
+// @Success 200 {object} []apiKeyResponse
+// @Failure 401 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /users/me/api_keys [get]
+func (c *APIKeyController) ListAPIKeys(ctx *gin.Context) {
+	user := ctx.MustGet(constants.AuthUserKey).(db.User)
+
+	apiKeys, err := c.store.ListAPIKeys(ctx, user.Username)
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+	rsp := make([]apiKeyResponse, len(apiKeys))
+	for i, apiKey := range apiKeys {
+		rsp[i] = newAPIKeyResponse(apiKey)
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type revokeAPIKeyRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// revokeAPIKey godoc
+// @Summary Revoke API Key
+// @Description Revoke an API key of the current user
+// @Tags api-keys
+// @Produce  json
+// @Security authorization
+// @Param id path int true "api key id"
+// @Success 200 {object} apiKeyResponse
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /users/me/api_keys/{id} [delete]
+func (c *APIKeyController) RevokeAPIKey(ctx *gin.Context) {
+	var req revokeAPIKeyRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.Error(apperror.FromBinding(err))
+		return
+	}
+	user := ctx.MustGet(constants.AuthUserKey).(db.User)
+
+	// keys of other users are reported as not found
+	apiKey, err := c.store.RevokeAPIKey(ctx, db.RevokeAPIKeyParams{
+		ID:       req.ID,
+		Username: user.Username,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.Error(&apperror.Error{Code: apperror.CodeNotFound, Message: fmt.Sprintf("active api key [%d] not found", req.ID), Err: err})
+			return
+		}
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, newAPIKeyResponse(apiKey))
+}
@@ -0,0 +1,231 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hhow09/simple_bank/apperror"
+	"github.com/hhow09/simple_bank/constants"
+	db "github.com/hhow09/simple_bank/db/sqlc"
+	"github.com/hhow09/simple_bank/util"
+	"github.com/lib/pq"
+)
+
+type BeneficiaryController struct {
+	store  db.Store
+	config util.Config
+}
+
+// NewBeneficiaryController creates new beneficiary controller
+func NewBeneficiaryController(store db.Store, config util.Config) BeneficiaryController {
+	return BeneficiaryController{
+		store:  store,
+		config: config,
+	}
+}
+
+type createBeneficiaryRequest struct {
+	AccountNumber string `json:"account_number" binding:"required,account_number"`
+	Label         string `json:"label" binding:"required,max=64"`
+}
+
+// createBeneficiary godoc
+// @Summary Create Beneficiary
+// @Description Save the account of a payee with a label, transfers then use its beneficiary_id.
+// @Description New beneficiaries can't receive transfers before the end of the cooling-off period.
+// @Description Frozen accounts can't be saved.
+// @Tags beneficiaries
+// @Accept  json
+// @Produce  json
+// @Security authorization
+// @Param account_number body string true "account number of the payee"
+// @Param label body string true "label"
+// @Success 200 {object} db.Beneficiary
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Router /beneficiaries [post]
+func (c *BeneficiaryController) CreateBeneficiary(ctx *gin.Context) {
+	var req createBeneficiaryRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.FromBinding(err))
+		return
+	}
+	user := ctx.MustGet(constants.AuthUserKey).(db.User)
+
+	account, err := c.store.GetAccountByNumber(ctx, req.AccountNumber)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.Error(&apperror.Error{Code: apperror.CodeNotFound, Message: fmt.Sprintf("account [%s] not found", req.AccountNumber), Err: err})
+			return
+		}
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+	// frozen accounts, including the accounts of erased users, can't receive transfers
+	if account.FrozenAt.Valid {
+		ctx.Error(apperror.Conflict(fmt.Sprintf("account [%s] is frozen", req.AccountNumber)))
+		return
+	}
+
+	beneficiary, err := c.store.CreateBeneficiary(ctx, db.CreateBeneficiaryParams{
+		Username:      user.Username,
+		AccountID:     account.ID,
+		AccountNumber: account.Number,
+		Label:         req.Label,
+		AvailableAt:   time.Now().Add(c.config.BeneficiaryCoolingOffPeriod),
+	})
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code.Name() == "unique_violation" {
+			ctx.Error(&apperror.Error{Code: apperror.CodeConflict, Message: "the account is already a beneficiary", Err: err})
+			return
+		}
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, beneficiary)
+}
+
+// listBeneficiaries godoc
+// @Summary List Beneficiaries
+// @Description List the beneficiaries of the current user by label
+// @Tags beneficiaries
+// @Produce  json
+// @Security authorization
+// @Success 200 {object} []db.Beneficiary
+// @Failure 401 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /beneficiaries [get]
+func (c *BeneficiaryController) ListBeneficiaries(ctx *gin.Context) {
+	user := ctx.MustGet(constants.AuthUserKey).(db.User)
+
+	beneficiaries, err := c.store.ListBeneficiaries(ctx, user.Username)
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, beneficiaries)
+}
+
+type beneficiaryRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// getBeneficiary godoc
+// @Summary Get Beneficiary
+// @Description Get a beneficiary of the current user
+// @Tags beneficiaries
+// @Produce  json
+// @Security authorization
+// @Param id path int true "beneficiary id"
+// @Success 200 {object} db.Beneficiary
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /beneficiaries/{id} [get]
+func (c *BeneficiaryController) GetBeneficiary(ctx *gin.Context) {
+	var req beneficiaryRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.Error(apperror.FromBinding(err))
+		return
+	}
+	user := ctx.MustGet(constants.AuthUserKey).(db.User)
+
+	// beneficiaries of other users are reported as not found
+	beneficiary, err := c.store.GetBeneficiary(ctx, db.GetBeneficiaryParams{
+		ID:       req.ID,
+		Username: user.Username,
+	})
+	if err != nil {
+		ctx.Error(beneficiaryError(req.ID, err))
+		return
+	}
+	ctx.JSON(http.StatusOK, beneficiary)
+}
+
+type updateBeneficiaryRequest struct {
+	Label string `json:"label" binding:"required,max=64"`
+}
+
+// updateBeneficiary godoc
+// @Summary Update Beneficiary
+// @Description Change the label of a beneficiary of the current user
+// @Tags beneficiaries
+// @Accept  json
+// @Produce  json
+// @Security authorization
+// @Param id path int true "beneficiary id"
+// @Param label body string true "label"
+// @Success 200 {object} db.Beneficiary
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /beneficiaries/{id} [patch]
+func (c *BeneficiaryController) UpdateBeneficiary(ctx *gin.Context) {
+	var uri beneficiaryRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(apperror.FromBinding(err))
+		return
+	}
+	var req updateBeneficiaryRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.FromBinding(err))
+		return
+	}
+	user := ctx.MustGet(constants.AuthUserKey).(db.User)
+
+	beneficiary, err := c.store.UpdateBeneficiaryLabel(ctx, db.UpdateBeneficiaryLabelParams{
+		Label:    req.Label,
+		ID:       uri.ID,
+		Username: user.Username,
+	})
+	if err != nil {
+		ctx.Error(beneficiaryError(uri.ID, err))
+		return
+	}
+	ctx.JSON(http.StatusOK, beneficiary)
+}
+
+// deleteBeneficiary godoc
+// @Summary Delete Beneficiary
+// @Description Delete a beneficiary of the current user, saving it again restarts the cooling-off period
+// @Tags beneficiaries
+// @Produce  json
+// @Security authorization
+// @Param id path int true "beneficiary id"
+// @Success 204
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /beneficiaries/{id} [delete]
+func (c *BeneficiaryController) DeleteBeneficiary(ctx *gin.Context) {
+	var req beneficiaryRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.Error(apperror.FromBinding(err))
+		return
+	}
+	user := ctx.MustGet(constants.AuthUserKey).(db.User)
+
+	_, err := c.store.DeleteBeneficiary(ctx, db.DeleteBeneficiaryParams{
+		ID:       req.ID,
+		Username: user.Username,
+	})
+	if err != nil {
+		ctx.Error(beneficiaryError(req.ID, err))
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func beneficiaryError(id int64, err error) *apperror.Error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return &apperror.Error{Code: apperror.CodeNotFound, Message: fmt.Sprintf("beneficiary [%d] not found", id), Err: err}
+	}
+	return apperror.Internal(err)
+}
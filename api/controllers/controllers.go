@@ -7,4 +7,14 @@ var Module = fx.Options(
 	fx.Provide(NewUserController),
 	fx.Provide(NewAccountController),
 	fx.Provide(NewTransferController),
+	fx.Provide(NewTwoFactorController),
+	fx.Provide(NewAPIKeyController),
+	fx.Provide(NewOAuthController),
+	fx.Provide(NewPrivacyController),
+	fx.Provide(NewBeneficiaryController),
+	fx.Provide(NewCurrencyController),
+	fx.Provide(NewLedgerController),
+	fx.Provide(NewReconciliationController),
+	fx.Provide(NewStatementController),
+	fx.Provide(NewInterestController),
 )
@@ -0,0 +1,30 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hhow09/simple_bank/money"
+)
+
+type CurrencyController struct {
+	currencies *money.Registry
+}
+
+// NewCurrencyController creates new currency controller
+func NewCurrencyController(currencies *money.Registry) CurrencyController {
+	return CurrencyController{
+		currencies: currencies,
+	}
+}
+
+// ListCurrencies godoc
+// @Summary List Currencies
+// @Description list the supported currencies with the number of decimals of their minor unit, amounts are given in minor units
+// @Tags currencies
+// @Produce  json
+// @Success 200 {object} []money.Currency
+// @Router /currencies [get]
+func (c *CurrencyController) ListCurrencies(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, c.currencies.Currencies())
+}
@@ -0,0 +1,251 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hhow09/simple_bank/apperror"
+	"github.com/hhow09/simple_bank/constants"
+	db "github.com/hhow09/simple_bank/db/sqlc"
+	"github.com/hhow09/simple_bank/interest"
+	"github.com/hhow09/simple_bank/money"
+	"github.com/hhow09/simple_bank/snapshot"
+	"github.com/hhow09/simple_bank/util"
+)
+
+type InterestController struct {
+	store      db.Store
+	currencies *money.Registry
+	clock      util.Clock
+}
+
+// NewInterestController creates new interest controller
+func NewInterestController(store db.Store, currencies *money.Registry, clock util.Clock) InterestController {
+	return InterestController{
+		store:      store,
+		currencies: currencies,
+		clock:      clock,
+	}
+}
+
+type interestRateResponse struct {
+	ID            int64  `json:"id"`
+	AccountType   string `json:"account_type"`
+	Currency      string `json:"currency"`
+	AnnualRateBps int32  `json:"annual_rate_bps"`
+	// EffectiveFrom is the first day of the rate, YYYY-MM-DD
+	EffectiveFrom string    `json:"effective_from"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func newInterestRateResponse(rate db.InterestRate) interestRateResponse {
+	return interestRateResponse{
+		ID:            rate.ID,
+		AccountType:   rate.AccountType,
+		Currency:      rate.Currency,
+		AnnualRateBps: rate.AnnualRateBps,
+		EffectiveFrom: rate.EffectiveFrom.Format(snapshot.DateFormat),
+		CreatedAt:     rate.CreatedAt,
+	}
+}
+
+// ListInterestRates godoc
+// @Summary List Interest Rates
+// @Description list the interest rates of the account types and currencies, latest first, admin only
+// @Tags admin
+// @Produce  json
+// @Security authorization
+// @Success 200 {object} []interestRateResponse
+// @Failure 403 {object} apperror.Problem
+// @Router /admin/interest_rates [get]
+func (c *InterestController) ListInterestRates(ctx *gin.Context) {
+	rates, err := c.store.ListInterestRates(ctx)
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+	rsp := make([]interestRateResponse, len(rates))
+	for i, rate := range rates {
+		rsp[i] = newInterestRateResponse(rate)
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type createInterestRateRequest struct {
+	AccountType string `json:"account_type" binding:"required,oneof=checking savings"`
+	Currency    string `json:"currency" binding:"required,currency"`
+	// AnnualRateBps is the annual rate in basis points, 125 for 1.25%
+	AnnualRateBps int32 `json:"annual_rate_bps" binding:"min=0,max=10000"`
+	// EffectiveFrom is the first day of the rate, YYYY-MM-DD
+	EffectiveFrom string `json:"effective_from" binding:"required"`
+}
+
+// CreateInterestRate godoc
+// @Summary Create Interest Rate
+// @Description set the interest rate of an account type and currency from a day on, admin only.
+// @Description The rate applies until the next rate, rates can't be backdated and a rate of the same day is replaced.
+// @Tags admin
+// @Accept  json
+// @Produce  json
+// @Security authorization
+// @Param account_type body string true "checking or savings"
+// @Param currency body string true "currency"
+// @Param annual_rate_bps body integer true "annual rate in basis points"
+// @Param effective_from body string true "first day, YYYY-MM-DD, today or later"
+// @Success 200 {object} interestRateResponse
+// @Failure 400 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Router /admin/interest_rates [post]
+func (c *InterestController) CreateInterestRate(ctx *gin.Context) {
+	var req createInterestRateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.FromBinding(err))
+		return
+	}
+	effectiveFrom, err := time.Parse(snapshot.DateFormat, req.EffectiveFrom)
+	if err != nil {
+		ctx.Error(apperror.Validation("request validation failed",
+			apperror.FieldError{Field: "effective_from", Rule: "date", Message: "must be a day as YYYY-MM-DD"},
+		))
+		return
+	}
+	// accruals of past days were computed with the rates of then
+	if effectiveFrom.Before(snapshot.Day(c.clock.Now())) {
+		ctx.Error(apperror.Validation("request validation failed",
+			apperror.FieldError{Field: "effective_from", Rule: "future", Message: "must not be in the past"},
+		))
+		return
+	}
+
+	rate, err := c.store.CreateInterestRate(ctx, db.CreateInterestRateParams{
+		AccountType:   req.AccountType,
+		Currency:      req.Currency,
+		AnnualRateBps: req.AnnualRateBps,
+		EffectiveFrom: effectiveFrom,
+	})
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, newInterestRateResponse(rate))
+}
+
+type interestRateRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// DeleteInterestRate godoc
+// @Summary Delete Interest Rate
+// @Description delete an interest rate which is not effective yet, admin only
+// @Tags admin
+// @Produce  json
+// @Security authorization
+// @Param id path integer true "Interest Rate ID"
+// @Success 200 {object} interestRateResponse
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Router /admin/interest_rates/:id [delete]
+func (c *InterestController) DeleteInterestRate(ctx *gin.Context) {
+	var req interestRateRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.Error(apperror.FromBinding(err))
+		return
+	}
+	rate, err := c.store.GetInterestRate(ctx, req.ID)
+	if err != nil {
+		ctx.Error(apperror.From(err))
+		return
+	}
+	if !rate.EffectiveFrom.After(snapshot.Day(c.clock.Now())) {
+		ctx.Error(apperror.Conflict("interest rate is already effective"))
+		return
+	}
+	rate, err = c.store.DeleteInterestRate(ctx, rate.ID)
+	if err != nil {
+		ctx.Error(apperror.From(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, newInterestRateResponse(rate))
+}
+
+type interestPostingResponse struct {
+	ID    int64  `json:"id"`
+	Month string `json:"month"`
+	// Accrued is the exact interest of the month in major units, Amount is it rounded to minor units
+	Accrued              string    `json:"accrued"`
+	Amount               int64     `json:"amount"`
+	AmountDecimal        string    `json:"amount_decimal"`
+	JournalTransactionID *int64    `json:"journal_transaction_id"`
+	CreatedAt            time.Time `json:"created_at"`
+}
+
+type listInterestPostingsRequest struct {
+	PageID   int32 `form:"page_id,default=1" binding:"min=1"`
+	PageSize int32 `form:"page_size,default=12" binding:"min=5,max=50"`
+}
+
+// ListInterestPostings godoc
+// @Summary List interest
+// @Description list the interest credited to an account every month, latest first, the current user must be a member of the account
+// @Tags accounts
+// @Produce  json
+// @Security authorization
+// @Param id path integer true "Account ID"
+// @Param page_id query integer false "page id"
+// @Param page_size query integer false "page size"
+// @Success 200 {object} []interestPostingResponse
+// @Failure 400 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /accounts/:id/interest [get]
+func (c *InterestController) ListInterestPostings(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(apperror.FromBinding(err))
+		return
+	}
+	var req listInterestPostingsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.Error(apperror.FromBinding(err))
+		return
+	}
+	account, err := c.store.GetAccount(ctx, uri.ID)
+	if err != nil {
+		ctx.Error(apperror.From(err))
+		return
+	}
+	authUser := ctx.MustGet(constants.AuthUserKey).(db.User)
+	if _, appErr := getAccountMember(ctx, c.store, account.ID, authUser.Username); appErr != nil {
+		ctx.Error(appErr)
+		return
+	}
+
+	postings, err := c.store.ListInterestPostings(ctx, db.ListInterestPostingsParams{
+		AccountID: account.ID,
+		Limit:     req.PageSize,
+		Offset:    (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+	exponent := 0
+	if currency, ok := c.currencies.Lookup(account.Currency); ok {
+		exponent = currency.Exponent
+	}
+	rsp := make([]interestPostingResponse, len(postings))
+	for i, posting := range postings {
+		rsp[i] = interestPostingResponse{
+			ID:                   posting.ID,
+			Month:                posting.Month.Format(interest.MonthFormat),
+			Accrued:              money.Format(posting.AccruedMicros, exponent+interest.MicrosDigits),
+			Amount:               posting.Amount,
+			AmountDecimal:        c.currencies.Format(account.Currency, posting.Amount),
+			JournalTransactionID: nullInt64(posting.JournalTransactionID),
+			CreatedAt:            posting.CreatedAt,
+		}
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
@@ -0,0 +1,204 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hhow09/simple_bank/apperror"
+	db "github.com/hhow09/simple_bank/db/sqlc"
+)
+
+type LedgerController struct {
+	store db.Store
+}
+
+// NewLedgerController creates new ledger controller
+func NewLedgerController(store db.Store) LedgerController {
+	return LedgerController{
+		store: store,
+	}
+}
+
+type postingResponse struct {
+	ID              int64     `json:"id"`
+	AccountID       *int64    `json:"account_id"`
+	LedgerAccountID *int64    `json:"ledger_account_id"`
+	EntryID         *int64    `json:"entry_id"`
+	Currency        string    `json:"currency"`
+	Amount          int64     `json:"amount"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+type journalTransactionResponse struct {
+	ID          int64             `json:"id"`
+	Kind        string            `json:"kind"`
+	Description string            `json:"description"`
+	Reference   string            `json:"reference"`
+	TransferID  *int64            `json:"transfer_id"`
+	Postings    []postingResponse `json:"postings"`
+	CreatedAt   time.Time         `json:"created_at"`
+}
+
+func newJournalTransactionResponse(transaction db.JournalTransaction, postings []db.Posting) journalTransactionResponse {
+	rsp := journalTransactionResponse{
+		ID:          transaction.ID,
+		Kind:        transaction.Kind,
+		Description: transaction.Description,
+		Reference:   transaction.Reference,
+		TransferID:  nullInt64(transaction.TransferID),
+		Postings:    make([]postingResponse, len(postings)),
+		CreatedAt:   transaction.CreatedAt,
+	}
+	for i, posting := range postings {
+		rsp.Postings[i] = postingResponse{
+			ID:              posting.ID,
+			AccountID:       nullInt64(posting.AccountID),
+			LedgerAccountID: nullInt64(posting.LedgerAccountID),
+			EntryID:         nullInt64(posting.EntryID),
+			Currency:        posting.Currency,
+			Amount:          posting.Amount,
+			CreatedAt:       posting.CreatedAt,
+		}
+	}
+	return rsp
+}
+
+func nullInt64(n sql.NullInt64) *int64 {
+	if !n.Valid {
+		return nil
+	}
+	return &n.Int64
+}
+
+// ListLedgerAccounts godoc
+// @Summary List Ledger Accounts
+// @Description list the system accounts of the chart of accounts with their balances, admin only
+// @Tags admin
+// @Produce  json
+// @Security authorization
+// @Success 200 {object} []db.LedgerAccount
+// @Failure 403 {object} apperror.Problem
+// @Router /admin/ledger_accounts [get]
+func (c *LedgerController) ListLedgerAccounts(ctx *gin.Context) {
+	accounts, err := c.store.ListLedgerAccounts(ctx)
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, accounts)
+}
+
+// postingRequest addresses either a customer account or a system account
+type postingRequest struct {
+	AccountID     int64  `json:"account_id" binding:"omitempty,min=1"`
+	LedgerAccount string `json:"ledger_account" binding:"omitempty,oneof=cash fees fx interest"`
+	// Currency is required for system accounts
+	Currency string `json:"currency" binding:"omitempty,currency"`
+	// Amount in minor units is added to the balance of the account
+	Amount int64 `json:"amount" binding:"required"`
+}
+
+type createJournalTransactionRequest struct {
+	Kind        string           `json:"kind" binding:"required,oneof=deposit withdrawal adjustment"`
+	Description string           `json:"description" binding:"max=140,memo"`
+	Reference   string           `json:"reference" binding:"max=35,reference"`
+	Postings    []postingRequest `json:"postings" binding:"required,min=2,max=20,dive"`
+}
+
+// CreateJournalTransaction godoc
+// @Summary Create Journal Transaction
+// @Description post a manual journal transaction such as a deposit, admin only.
+// @Description The amounts of the postings are added to the balances of their accounts and must sum to zero per currency, money paid in comes from the cash account.
+// @Tags admin
+// @Accept  json
+// @Produce  json
+// @Security authorization
+// @Param kind body string true "deposit, withdrawal or adjustment"
+// @Param description body string false "description"
+// @Param reference body string false "reference"
+// @Param postings body []postingRequest true "at least 2 postings"
+// @Success 200 {object} journalTransactionResponse
+// @Failure 400 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 422 {object} apperror.Problem
+// @Router /admin/journal_transactions [post]
+func (c *LedgerController) CreateJournalTransaction(ctx *gin.Context) {
+	var req createJournalTransactionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.FromBinding(err))
+		return
+	}
+	arg := db.JournalTxParams{
+		Kind:        req.Kind,
+		Description: req.Description,
+		Reference:   req.Reference,
+		Postings:    make([]db.PostingParams, len(req.Postings)),
+	}
+	for i, posting := range req.Postings {
+		arg.Postings[i] = db.PostingParams{
+			AccountID:     posting.AccountID,
+			LedgerAccount: posting.LedgerAccount,
+			Currency:      posting.Currency,
+			Amount:        posting.Amount,
+		}
+	}
+
+	result, err := c.store.JournalTx(ctx, arg)
+	if err != nil {
+		ctx.Error(journalError(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, newJournalTransactionResponse(result.Transaction, result.Postings))
+}
+
+// journalError maps the errors of JournalTx
+func journalError(err error) *apperror.Error {
+	switch {
+	case errors.Is(err, db.ErrInvalidPosting), errors.Is(err, db.ErrUnbalancedJournal):
+		return &apperror.Error{Code: apperror.CodeValidation, Message: err.Error(), Err: err}
+	case errors.Is(err, db.ErrPostingCurrencyMismatch):
+		return &apperror.Error{Code: apperror.CodeCurrencyMismatch, Message: err.Error(), Err: err}
+	case errors.Is(err, sql.ErrNoRows):
+		return &apperror.Error{Code: apperror.CodeNotFound, Message: "account not found", Err: err}
+	}
+	return apperror.Internal(err)
+}
+
+type getJournalTransactionRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// GetJournalTransaction godoc
+// @Summary Get Journal Transaction
+// @Description get a journal transaction with its postings, admin only
+// @Tags admin
+// @Produce  json
+// @Security authorization
+// @Param id path integer true "Journal transaction ID"
+// @Success 200 {object} journalTransactionResponse
+// @Failure 400 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /admin/journal_transactions/:id [get]
+func (c *LedgerController) GetJournalTransaction(ctx *gin.Context) {
+	var req getJournalTransactionRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.Error(apperror.FromBinding(err))
+		return
+	}
+	transaction, err := c.store.GetJournalTransaction(ctx, req.ID)
+	if err != nil {
+		ctx.Error(apperror.From(err))
+		return
+	}
+	postings, err := c.store.ListPostings(ctx, transaction.ID)
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, newJournalTransactionResponse(transaction, postings))
+}
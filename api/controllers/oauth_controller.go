@@ -0,0 +1,516 @@
+package controllers
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/hhow09/simple_bank/apperror"
+	"github.com/hhow09/simple_bank/constants"
+	db "github.com/hhow09/simple_bank/db/sqlc"
+	"github.com/hhow09/simple_bank/oauth"
+	"github.com/hhow09/simple_bank/token"
+	"github.com/hhow09/simple_bank/util"
+)
+
+type OAuthController struct {
+	store      db.Store
+	tokenMaker token.Maker
+	config     util.Config
+}
+
+// NewOAuthController creates new oauth controller
+func NewOAuthController(store db.Store, tokenMaker token.Maker, config util.Config) OAuthController {
+	return OAuthController{
+		store:      store,
+		tokenMaker: tokenMaker,
+		config:     config,
+	}
+}
+
+type oauthClientResponse struct {
+	ClientID     string    `json:"client_id"`
+	Name         string    `json:"name"`
+	RedirectURIs []string  `json:"redirect_uris"`
+	Scopes       []string  `json:"scopes"`
+	Confidential bool      `json:"confidential"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func newOAuthClientResponse(client db.OauthClient) oauthClientResponse {
+	return oauthClientResponse{
+		ClientID:     client.ID,
+		Name:         client.Name,
+		RedirectURIs: client.RedirectUris,
+		Scopes:       client.Scopes,
+		Confidential: client.SecretHash != "",
+		CreatedAt:    client.CreatedAt,
+	}
+}
+
+type createOAuthClientRequest struct {
+	Name         string   `json:"name" binding:"required,max=64"`
+	RedirectURIs []string `json:"redirect_uris" binding:"required,min=1,dive,url"`
+	Scopes       []string `json:"scopes" binding:"required,min=1,dive,oneof=accounts:read transfers:create"`
+	// Confidential clients get a secret and may use the client credentials grant
+	Confidential bool `json:"confidential"`
+}
+
+type createOAuthClientResponse struct {
+	// ClientSecret is only returned once, it is empty for public clients
+	ClientSecret string              `json:"client_secret,omitempty"`
+	Client       oauthClientResponse `json:"client"`
+}
+
+// createOAuthClient godoc
+// @Summary Register OAuth Client
+// @Description Register a third-party app. Confidential clients get a secret, which is only shown once, and may use the client credentials grant to act for the user registering them.
+// @Tags oauth
+// @Accept  json
+// @Produce  json
+// @Security authorization
+// @Param name body string true "name of the app"
+// @Param redirect_uris body []string true "exact redirect uris of the authorization code grant"
+// @Param scopes body []string true "accounts:read, transfers:create"
+// @Param confidential body bool false "whether the client can keep a secret"
+// @Success 200 {object} createOAuthClientResponse
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /oauth/clients [post]
+func (c *OAuthController) CreateClient(ctx *gin.Context) {
+	var req createOAuthClientRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.FromBinding(err))
+		return
+	}
+	user := ctx.MustGet(constants.AuthUserKey).(db.User)
+
+	clientID, err := util.RandomToken(16)
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+	arg := db.CreateOAuthClientParams{
+		ID:           clientID,
+		Owner:        user.Username,
+		Name:         req.Name,
+		RedirectUris: req.RedirectURIs,
+		Scopes:       oauth.Union(nil, req.Scopes),
+	}
+	var secret string
+	if req.Confidential {
+		secret, err = util.RandomToken(32)
+		if err != nil {
+			ctx.Error(apperror.Internal(err))
+			return
+		}
+		arg.SecretHash = util.HashToken(secret)
+	}
+
+	client, err := c.store.CreateOAuthClient(ctx, arg)
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, createOAuthClientResponse{
+		ClientSecret: secret,
+		Client:       newOAuthClientResponse(client),
+	})
+}
+
+// listOAuthClients godoc
+// @Summary List OAuth Clients
+// @Description List the OAuth clients registered by the current user
+// @Tags oauth
+// @Produce  json
+// @Security authorization
+// @Success 200 {object} []oauthClientResponse
+// @Failure 401 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /oauth/clients [get]
+func (c *OAuthController) ListClients(ctx *gin.Context) {
+	user := ctx.MustGet(constants.AuthUserKey).(db.User)
+
+	clients, err := c.store.ListOAuthClients(ctx, user.Username)
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+	rsp := make([]oauthClientResponse, len(clients))
+	for i, client := range clients {
+		rsp[i] = newOAuthClientResponse(client)
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type authorizeRequest struct {
+	ResponseType        string `json:"response_type" binding:"required,oneof=code"`
+	ClientID            string `json:"client_id" binding:"required"`
+	RedirectURI         string `json:"redirect_uri" binding:"required"`
+	Scope               string `json:"scope"`
+	State               string `json:"state"`
+	CodeChallenge       string `json:"code_challenge" binding:"required,len=43"`
+	CodeChallengeMethod string `json:"code_challenge_method" binding:"required,oneof=S256"`
+}
+
+type authorizeResponse struct {
+	Code  string `json:"code"`
+	State string `json:"state,omitempty"`
+	// RedirectTo is the redirect uri with the code and state appended,
+	// where the user agent should be sent
+	RedirectTo string `json:"redirect_to"`
+}
+
+// authorize godoc
+// @Summary Authorize OAuth Client
+// @Description Grant a client access to the current user's accounts and record the consent. Returns a single-use authorization code bound to the redirect uri and the S256 PKCE challenge.
+// @Tags oauth
+// @Accept  json
+// @Produce  json
+// @Security authorization
+// @Param response_type body string true "code"
+// @Param client_id body string true "client id"
+// @Param redirect_uri body string true "one of the registered redirect uris"
+// @Param scope body string false "space-delimited scopes, all scopes of the client when empty"
+// @Param state body string false "opaque value returned to the client"
+// @Param code_challenge body string true "base64url sha256 of the code verifier"
+// @Param code_challenge_method body string true "S256"
+// @Success 200 {object} authorizeResponse
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /oauth/authorize [post]
+func (c *OAuthController) Authorize(ctx *gin.Context) {
+	var req authorizeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.FromBinding(err))
+		return
+	}
+	user := ctx.MustGet(constants.AuthUserKey).(db.User)
+
+	client, err := c.store.GetOAuthClient(ctx, req.ClientID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.Error(&apperror.Error{Code: apperror.CodeNotFound, Message: fmt.Sprintf("oauth client [%s] not found", req.ClientID), Err: err})
+			return
+		}
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+	// redirect uris are compared exactly, a prefix match would leak codes
+	if !oauth.Contains(client.RedirectUris, req.RedirectURI) {
+		ctx.Error(apperror.Validation("request validation failed",
+			apperror.FieldError{Field: "redirect_uri", Rule: "registered", Message: "is not registered for the client"},
+		))
+		return
+	}
+	redirectTo, err := url.Parse(req.RedirectURI)
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+	scopes := oauth.ParseScope(req.Scope)
+	if len(scopes) == 0 {
+		scopes = client.Scopes
+	}
+	if !oauth.Subset(scopes, client.Scopes) {
+		ctx.Error(apperror.Validation("request validation failed",
+			apperror.FieldError{Field: "scope", Rule: "allowed", Message: fmt.Sprintf("must be a subset of [%s]", oauth.FormatScope(client.Scopes))},
+		))
+		return
+	}
+
+	// the consent covers every scope granted to the client so far
+	consent, err := c.store.GetOAuthConsent(ctx, db.GetOAuthConsentParams{
+		Username: user.Username,
+		ClientID: client.ID,
+	})
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+	_, err = c.store.UpsertOAuthConsent(ctx, db.UpsertOAuthConsentParams{
+		Username: user.Username,
+		ClientID: client.ID,
+		Scopes:   oauth.Union(consent.Scopes, scopes),
+	})
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+
+	code, err := util.RandomToken(32)
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+	_, err = c.store.CreateOAuthAuthorizationCode(ctx, db.CreateOAuthAuthorizationCodeParams{
+		CodeHash:      util.HashToken(code),
+		ClientID:      client.ID,
+		Username:      user.Username,
+		RedirectUri:   req.RedirectURI,
+		Scopes:        scopes,
+		CodeChallenge: req.CodeChallenge,
+		ExpiresAt:     time.Now().Add(c.config.OAuthAuthorizationCodeDuration),
+	})
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+
+	query := redirectTo.Query()
+	query.Set("code", code)
+	if req.State != "" {
+		query.Set("state", req.State)
+	}
+	redirectTo.RawQuery = query.Encode()
+	ctx.JSON(http.StatusOK, authorizeResponse{
+		Code:       code,
+		State:      req.State,
+		RedirectTo: redirectTo.String(),
+	})
+}
+
+type tokenRequest struct {
+	GrantType    string `form:"grant_type" binding:"required,oneof=authorization_code client_credentials"`
+	Code         string `form:"code"`
+	RedirectURI  string `form:"redirect_uri"`
+	CodeVerifier string `form:"code_verifier"`
+	Scope        string `form:"scope"`
+	// client credentials, unless sent with HTTP basic authentication
+	ClientID     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	Scope       string `json:"scope"`
+}
+
+// token godoc
+// @Summary OAuth Token
+// @Description Exchange an authorization code and its PKCE verifier, or the credentials of a confidential client, for an access token limited to the granted scopes. Clients authenticate with HTTP basic authentication or the client_id and client_secret parameters.
+// @Tags oauth
+// @Accept  x-www-form-urlencoded
+// @Produce  json
+// @Param grant_type formData string true "authorization_code or client_credentials"
+// @Param code formData string false "authorization code"
+// @Param redirect_uri formData string false "redirect uri of the authorization request"
+// @Param code_verifier formData string false "PKCE code verifier"
+// @Param scope formData string false "space-delimited scopes of the client credentials grant"
+// @Param client_id formData string false "client id"
+// @Param client_secret formData string false "client secret of confidential clients"
+// @Success 200 {object} tokenResponse
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /oauth/token [post]
+func (c *OAuthController) Token(ctx *gin.Context) {
+	var req tokenRequest
+	if err := ctx.ShouldBindWith(&req, binding.Form); err != nil {
+		ctx.Error(apperror.FromBinding(err))
+		return
+	}
+	client, appErr := c.authenticateClient(ctx, req)
+	if appErr != nil {
+		ctx.Error(appErr)
+		return
+	}
+
+	var (
+		username string
+		scopes   []string
+	)
+	switch req.GrantType {
+	case oauth.GrantTypeAuthorizationCode:
+		username, scopes, appErr = c.exchangeCode(ctx, client, req)
+	case oauth.GrantTypeClientCredentials:
+		username, scopes, appErr = c.clientCredentials(client, req)
+	}
+	if appErr != nil {
+		ctx.Error(appErr)
+		return
+	}
+
+	accessToken, err := c.tokenMaker.CreateScopedToken(username, client.ID, scopes, c.config.AccessTokenDuration)
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Header("Pragma", "no-cache")
+	ctx.JSON(http.StatusOK, tokenResponse{
+		AccessToken: accessToken,
+		TokenType:   oauth.TokenTypeBearer,
+		ExpiresIn:   int64(c.config.AccessTokenDuration.Seconds()),
+		Scope:       oauth.FormatScope(scopes),
+	})
+}
+
+// authenticateClient identifies the client, confidential clients must prove their secret
+func (c *OAuthController) authenticateClient(ctx *gin.Context, req tokenRequest) (db.OauthClient, *apperror.Error) {
+	clientID, secret, ok := ctx.Request.BasicAuth()
+	if !ok {
+		clientID, secret = req.ClientID, req.ClientSecret
+	}
+	if clientID == "" {
+		return db.OauthClient{}, apperror.Unauthorized("client authentication is required")
+	}
+
+	client, err := c.store.GetOAuthClient(ctx, clientID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return client, &apperror.Error{Code: apperror.CodeUnauthorized, Message: "invalid client credentials", Err: err}
+		}
+		return client, apperror.Internal(err)
+	}
+	if client.SecretHash != "" && subtle.ConstantTimeCompare([]byte(util.HashToken(secret)), []byte(client.SecretHash)) != 1 {
+		return client, apperror.Unauthorized("invalid client credentials")
+	}
+	return client, nil
+}
+
+// exchangeCode redeems an authorization code issued to the client
+func (c *OAuthController) exchangeCode(ctx *gin.Context, client db.OauthClient, req tokenRequest) (string, []string, *apperror.Error) {
+	var fields []apperror.FieldError
+	for _, param := range []struct{ field, value string }{
+		{"code", req.Code},
+		{"redirect_uri", req.RedirectURI},
+		{"code_verifier", req.CodeVerifier},
+	} {
+		if param.value == "" {
+			fields = append(fields, apperror.FieldError{Field: param.field, Rule: "required", Message: "is required"})
+		}
+	}
+	if len(fields) > 0 {
+		return "", nil, apperror.Validation("request validation failed", fields...)
+	}
+
+	// the code is consumed even if the exchange fails, so it cannot be retried
+	code, err := c.store.ConsumeOAuthAuthorizationCode(ctx, util.HashToken(req.Code))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil, &apperror.Error{Code: apperror.CodeValidation, Message: "authorization code is invalid or expired", Err: err}
+		}
+		return "", nil, apperror.Internal(err)
+	}
+	if code.ClientID != client.ID || code.RedirectUri != req.RedirectURI {
+		return "", nil, apperror.Validation("authorization code is invalid or expired")
+	}
+	if !oauth.VerifyCodeChallenge(req.CodeVerifier, code.CodeChallenge) {
+		return "", nil, apperror.Validation("request validation failed",
+			apperror.FieldError{Field: "code_verifier", Rule: "pkce", Message: "does not match the code challenge"},
+		)
+	}
+	return code.Username, code.Scopes, nil
+}
+
+// clientCredentials lets a confidential client act for the user who registered it
+func (c *OAuthController) clientCredentials(client db.OauthClient, req tokenRequest) (string, []string, *apperror.Error) {
+	if client.SecretHash == "" {
+		return "", nil, apperror.Forbidden("public clients cannot use the client credentials grant")
+	}
+	scopes := oauth.ParseScope(req.Scope)
+	if len(scopes) == 0 {
+		scopes = client.Scopes
+	}
+	if !oauth.Subset(scopes, client.Scopes) {
+		return "", nil, apperror.Validation("request validation failed",
+			apperror.FieldError{Field: "scope", Rule: "allowed", Message: fmt.Sprintf("must be a subset of [%s]", oauth.FormatScope(client.Scopes))},
+		)
+	}
+	return client.Owner, scopes, nil
+}
+
+type oauthConsentResponse struct {
+	ClientID  string    `json:"client_id"`
+	Scopes    []string  `json:"scopes"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func newOAuthConsentResponse(consent db.OauthConsent) oauthConsentResponse {
+	return oauthConsentResponse{
+		ClientID:  consent.ClientID,
+		Scopes:    consent.Scopes,
+		CreatedAt: consent.CreatedAt,
+		UpdatedAt: consent.UpdatedAt,
+	}
+}
+
+// listOAuthConsents godoc
+// @Summary List OAuth Consents
+// @Description List the clients the current user has granted access to
+// @Tags oauth
+// @Produce  json
+// @Security authorization
+// @Success 200 {object} []oauthConsentResponse
+// @Failure 401 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /users/me/oauth_consents [get]
+func (c *OAuthController) ListConsents(ctx *gin.Context) {
+	user := ctx.MustGet(constants.AuthUserKey).(db.User)
+
+	consents, err := c.store.ListOAuthConsents(ctx, user.Username)
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+	rsp := make([]oauthConsentResponse, len(consents))
+	for i, consent := range consents {
+		rsp[i] = newOAuthConsentResponse(consent)
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type revokeOAuthConsentRequest struct {
+	ClientID string `uri:"client_id" binding:"required"`
+}
+
+// revokeOAuthConsent godoc
+// @Summary Revoke OAuth Consent
+// @Description Revoke the access of a client, tokens issued to it for the current user stop working immediately
+// @Tags oauth
+// @Produce  json
+// @Security authorization
+// @Param client_id path string true "client id"
+// @Success 200 {object} oauthConsentResponse
+// @Failure 401 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /users/me/oauth_consents/{client_id} [delete]
+func (c *OAuthController) RevokeConsent(ctx *gin.Context) {
+	var req revokeOAuthConsentRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.Error(apperror.FromBinding(err))
+		return
+	}
+	user := ctx.MustGet(constants.AuthUserKey).(db.User)
+
+	consent, err := c.store.DeleteOAuthConsent(ctx, db.DeleteOAuthConsentParams{
+		Username: user.Username,
+		ClientID: req.ClientID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.Error(&apperror.Error{Code: apperror.CodeNotFound, Message: fmt.Sprintf("consent for client [%s] not found", req.ClientID), Err: err})
+			return
+		}
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, newOAuthConsentResponse(consent))
+}
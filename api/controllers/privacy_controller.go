@@ -0,0 +1,273 @@
+package controllers
+
+import (
+	"archive/zip"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hhow09/simple_bank/apperror"
+	"github.com/hhow09/simple_bank/constants"
+	db "github.com/hhow09/simple_bank/db/sqlc"
+	"github.com/hhow09/simple_bank/util"
+)
+
+const (
+	exportFormatJSON = "json"
+	exportFormatZIP  = "zip"
+)
+
+type PrivacyController struct {
+	store  db.Store
+	config util.Config
+	hasher util.PasswordHasher
+}
+
+// NewPrivacyController creates new privacy controller
+func NewPrivacyController(store db.Store, config util.Config) PrivacyController {
+	return PrivacyController{
+		store:  store,
+		config: config,
+		hasher: util.NewPasswordHasher(config),
+	}
+}
+
+type exportedUser struct {
+	userResponse
+	Role          string `json:"role"`
+	IsTotpEnabled bool   `json:"is_totp_enabled"`
+}
+
+// userExport is everything stored about a user, secrets are only described by their metadata
+type userExport struct {
+	ExportedAt    time.Time              `json:"exported_at"`
+	User          exportedUser           `json:"user"`
+	Accounts      []db.Account           `json:"accounts"`
+	Entries       []db.Entry             `json:"entries"`
+	Transfers     []db.Transfer          `json:"transfers"`
+	APIKeys       []apiKeyResponse       `json:"api_keys"`
+	OAuthClients  []oauthClientResponse  `json:"oauth_clients"`
+	OAuthConsents []oauthConsentResponse `json:"oauth_consents"`
+	LoginAttempts []db.LoginAttempt      `json:"login_attempts"`
+	Beneficiaries []db.Beneficiary       `json:"beneficiaries"`
+}
+
+type exportUserRequest struct {
+	Format string `form:"format" binding:"omitempty,oneof=json zip"`
+}
+
+// exportUser godoc
+// @Summary Export Personal Data
+// @Description Export everything stored about the current user as a JSON document, or as a ZIP archive with one JSON file per section
+// @Tags users
+// @Produce  json
+// @Produce  application/zip
+// @Security authorization
+// @Param format query string false "json (default) or zip"
+// @Success 200 {object} userExport
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /users/me/export [get]
+func (c *PrivacyController) ExportUser(ctx *gin.Context) {
+	var req exportUserRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.Error(apperror.FromBinding(err))
+		return
+	}
+	user := ctx.MustGet(constants.AuthUserKey).(db.User)
+
+	export, err := c.collect(ctx, user)
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+
+	filename := fmt.Sprintf("simple_bank-%s-%s", user.Username, export.ExportedAt.Format("20060102"))
+	if req.Format != exportFormatZIP {
+		ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+".json"))
+		ctx.JSON(http.StatusOK, export)
+		return
+	}
+
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"user.json", export.User},
+		{"accounts.json", export.Accounts},
+		{"entries.json", export.Entries},
+		{"transfers.json", export.Transfers},
+		{"api_keys.json", export.APIKeys},
+		{"oauth_clients.json", export.OAuthClients},
+		{"oauth_consents.json", export.OAuthConsents},
+		{"login_attempts.json", export.LoginAttempts},
+		{"beneficiaries.json", export.Beneficiaries},
+	}
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+".zip"))
+	ctx.Header("Content-Type", "application/zip")
+	ctx.Status(http.StatusOK)
+	archive := zip.NewWriter(ctx.Writer)
+	for _, file := range files {
+		w, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     file.name,
+			Method:   zip.Deflate,
+			Modified: export.ExportedAt,
+		})
+		if err != nil {
+			ctx.Error(apperror.Internal(err))
+			return
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.data); err != nil {
+			ctx.Error(apperror.Internal(err))
+			return
+		}
+	}
+	if err := archive.Close(); err != nil {
+		ctx.Error(apperror.Internal(err))
+	}
+}
+
+// collect reads the data of the export, nothing is written before all of it is read
+func (c *PrivacyController) collect(ctx *gin.Context, user db.User) (export userExport, err error) {
+	export.ExportedAt = time.Now().UTC()
+	export.User = exportedUser{
+		userResponse:  newUserResponse(user),
+		Role:          user.Role,
+		IsTotpEnabled: user.IsTotpEnabled,
+	}
+
+	if export.Accounts, err = c.store.ListAllAccounts(ctx, user.Username); err != nil {
+		return
+	}
+	if export.Entries, err = c.store.ListMemberEntries(ctx, user.Username); err != nil {
+		return
+	}
+	if export.Transfers, err = c.store.ListMemberTransfers(ctx, user.Username); err != nil {
+		return
+	}
+	if export.LoginAttempts, err = c.store.ListLoginAttempts(ctx, user.Username); err != nil {
+		return
+	}
+	if export.Beneficiaries, err = c.store.ListBeneficiaries(ctx, user.Username); err != nil {
+		return
+	}
+
+	apiKeys, err := c.store.ListAPIKeys(ctx, user.Username)
+	if err != nil {
+		return
+	}
+	export.APIKeys = make([]apiKeyResponse, len(apiKeys))
+	for i, apiKey := range apiKeys {
+		export.APIKeys[i] = newAPIKeyResponse(apiKey)
+	}
+
+	clients, err := c.store.ListOAuthClients(ctx, user.Username)
+	if err != nil {
+		return
+	}
+	export.OAuthClients = make([]oauthClientResponse, len(clients))
+	for i, client := range clients {
+		export.OAuthClients[i] = newOAuthClientResponse(client)
+	}
+
+	consents, err := c.store.ListOAuthConsents(ctx, user.Username)
+	if err != nil {
+		return
+	}
+	export.OAuthConsents = make([]oauthConsentResponse, len(consents))
+	for i, consent := range consents {
+		export.OAuthConsents[i] = newOAuthConsentResponse(consent)
+	}
+	return
+}
+
+type deleteUserRequest struct {
+	Password string `json:"password" binding:"required"`
+	// TOTPCode is required for users with 2FA enabled
+	TOTPCode string `json:"totp_code" binding:"omitempty,len=6,numeric"`
+}
+
+// deleteUser godoc
+// @Summary Delete User
+// @Description Erase the personal data of the current user. Accounts must be empty, they are kept with their entries and transfers under a pseudonym.
+// @Tags users
+// @Accept  json
+// @Produce  json
+// @Security authorization
+// @Param password body string true "current password"
+// @Param totp_code body string false "TOTP code, required with 2FA enabled"
+// @Success 204
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Failure 423 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /users/me [delete]
+func (c *PrivacyController) DeleteUser(ctx *gin.Context) {
+	var req deleteUserRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.FromBinding(err))
+		return
+	}
+	user := ctx.MustGet(constants.AuthUserKey).(db.User)
+
+	// wrong passwords and codes count as failed logins, a stolen access token must not allow guessing them
+	if lockedOut(ctx, c.store, c.config, user.Username) {
+		return
+	}
+	err := c.hasher.Check(req.Password, user.HashedPassword)
+	if err != nil {
+		loginFailed(ctx, c.store, user.Username, &apperror.Error{Code: apperror.CodeUnauthorized, Message: "password is incorrect", Err: err})
+		return
+	}
+	if user.IsTotpEnabled {
+		if req.TOTPCode == "" {
+			ctx.Error(apperror.TwoFactorRequired("a two-factor code is required to delete the user"))
+			return
+		}
+		if err := checkTOTP(ctx, c.store, user, req.TOTPCode); err != nil {
+			if appErr := apperror.From(err); appErr.Code == apperror.CodeValidation {
+				loginFailed(ctx, c.store, user.Username, &apperror.Error{Code: apperror.CodeUnauthorized, Message: "invalid two-factor code", Err: err})
+				return
+			}
+			ctx.Error(err)
+			return
+		}
+	}
+
+	pseudonym, err := newPseudonym()
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+	_, err = c.store.DeleteUserTx(ctx, db.DeleteUserTxParams{
+		Username:  user.Username,
+		Pseudonym: pseudonym,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrAccountsNotEmpty) {
+			ctx.Error(&apperror.Error{Code: apperror.CodeConflict, Message: "accounts must be empty before the user is deleted", Err: err})
+			return
+		}
+		ctx.Error(apperror.From(err))
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// newPseudonym returns a random username for the accounts of a deleted user
+func newPseudonym() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate pseudonym: %w", err)
+	}
+	return "deleted" + hex.EncodeToString(b), nil
+}
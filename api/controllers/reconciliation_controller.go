@@ -0,0 +1,223 @@
+package controllers
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hhow09/simple_bank/apperror"
+	db "github.com/hhow09/simple_bank/db/sqlc"
+	"github.com/hhow09/simple_bank/reconcile"
+)
+
+type ReconciliationController struct {
+	store      db.Store
+	reconciler *reconcile.Reconciler
+}
+
+// NewReconciliationController creates new reconciliation controller
+func NewReconciliationController(store db.Store, reconciler *reconcile.Reconciler) ReconciliationController {
+	return ReconciliationController{
+		store:      store,
+		reconciler: reconciler,
+	}
+}
+
+type reconciliationRunResponse struct {
+	ID                    int64      `json:"id"`
+	Status                string     `json:"status"`
+	AccountsChecked       int64      `json:"accounts_checked"`
+	TransfersChecked      int64      `json:"transfers_checked"`
+	LedgerAccountsChecked int64      `json:"ledger_accounts_checked"`
+	Discrepancies         int64      `json:"discrepancies"`
+	FrozenAccounts        int64      `json:"frozen_accounts"`
+	Error                 string     `json:"error"`
+	StartedAt             time.Time  `json:"started_at"`
+	FinishedAt            *time.Time `json:"finished_at"`
+}
+
+func newReconciliationRunResponse(run db.ReconciliationRun) reconciliationRunResponse {
+	return reconciliationRunResponse{
+		ID:                    run.ID,
+		Status:                run.Status,
+		AccountsChecked:       run.AccountsChecked,
+		TransfersChecked:      run.TransfersChecked,
+		LedgerAccountsChecked: run.LedgerAccountsChecked,
+		Discrepancies:         run.Discrepancies,
+		FrozenAccounts:        run.FrozenAccounts,
+		Error:                 run.Error,
+		StartedAt:             run.StartedAt,
+		FinishedAt:            nullTime(run.FinishedAt),
+	}
+}
+
+type discrepancyResponse struct {
+	ID              int64     `json:"id"`
+	Kind            string    `json:"kind"`
+	AccountID       *int64    `json:"account_id"`
+	TransferID      *int64    `json:"transfer_id"`
+	LedgerAccountID *int64    `json:"ledger_account_id"`
+	Expected        int64     `json:"expected"`
+	Actual          int64     `json:"actual"`
+	Details         string    `json:"details"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+type reconciliationReportResponse struct {
+	Run           reconciliationRunResponse `json:"run"`
+	Discrepancies []discrepancyResponse     `json:"discrepancies"`
+}
+
+func newReconciliationReportResponse(run db.ReconciliationRun, discrepancies []db.ReconciliationDiscrepancy) reconciliationReportResponse {
+	rsp := reconciliationReportResponse{
+		Run:           newReconciliationRunResponse(run),
+		Discrepancies: make([]discrepancyResponse, len(discrepancies)),
+	}
+	for i, discrepancy := range discrepancies {
+		rsp.Discrepancies[i] = discrepancyResponse{
+			ID:              discrepancy.ID,
+			Kind:            discrepancy.Kind,
+			AccountID:       nullInt64(discrepancy.AccountID),
+			TransferID:      nullInt64(discrepancy.TransferID),
+			LedgerAccountID: nullInt64(discrepancy.LedgerAccountID),
+			Expected:        discrepancy.Expected,
+			Actual:          discrepancy.Actual,
+			Details:         discrepancy.Details,
+			CreatedAt:       discrepancy.CreatedAt,
+		}
+	}
+	return rsp
+}
+
+type runReconciliationRequest struct {
+	// Freeze freezes the accounts with discrepancies
+	Freeze bool `json:"freeze"`
+}
+
+// RunReconciliation godoc
+// @Summary Run Reconciliation
+// @Description check that account balances are the sum of their entries, that every transfer has matching entries and that system account balances are the sum of their postings, admin only.
+// @Description The run is recorded with the discrepancies found, which can optionally freeze their accounts.
+// @Tags admin
+// @Accept  json
+// @Produce  json
+// @Security authorization
+// @Param freeze body boolean false "freeze the accounts with discrepancies"
+// @Success 200 {object} reconciliationReportResponse
+// @Failure 400 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /admin/reconciliations [post]
+func (c *ReconciliationController) RunReconciliation(ctx *gin.Context) {
+	var req runReconciliationRequest
+	// the body is optional
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.Error(apperror.FromBinding(err))
+			return
+		}
+	}
+	report, err := c.reconciler.Run(ctx, reconcile.Options{Freeze: req.Freeze})
+	if err != nil {
+		if errors.Is(err, reconcile.ErrRunning) {
+			ctx.Error(&apperror.Error{Code: apperror.CodeConflict, Message: err.Error(), Err: err})
+			return
+		}
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, newReconciliationReportResponse(report.Run, report.Discrepancies))
+}
+
+type listReconciliationsRequest struct {
+	PageID   int32 `form:"page_id,default=1" binding:"min=1"`
+	PageSize int32 `form:"page_size,default=10" binding:"min=5,max=50"`
+}
+
+// ListReconciliations godoc
+// @Summary List Reconciliations
+// @Description list the reconciliation runs, latest first, admin only
+// @Tags admin
+// @Produce  json
+// @Security authorization
+// @Param page_id query integer false "page id"
+// @Param page_size query integer false "page size"
+// @Success 200 {object} []reconciliationRunResponse
+// @Failure 400 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Router /admin/reconciliations [get]
+func (c *ReconciliationController) ListReconciliations(ctx *gin.Context) {
+	var req listReconciliationsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.Error(apperror.FromBinding(err))
+		return
+	}
+	runs, err := c.store.ListReconciliationRuns(ctx, db.ListReconciliationRunsParams{
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+	rsp := make([]reconciliationRunResponse, len(runs))
+	for i, run := range runs {
+		rsp[i] = newReconciliationRunResponse(run)
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type getReconciliationRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// GetReconciliation godoc
+// @Summary Get Reconciliation
+// @Description get a reconciliation run with the discrepancies it found, admin only
+// @Tags admin
+// @Produce  json
+// @Security authorization
+// @Param id path integer true "Reconciliation run ID"
+// @Success 200 {object} reconciliationReportResponse
+// @Failure 400 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /admin/reconciliations/:id [get]
+func (c *ReconciliationController) GetReconciliation(ctx *gin.Context) {
+	var req getReconciliationRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.Error(apperror.FromBinding(err))
+		return
+	}
+	run, err := c.store.GetReconciliationRun(ctx, req.ID)
+	if err != nil {
+		ctx.Error(apperror.From(err))
+		return
+	}
+	discrepancies, err := c.store.ListReconciliationDiscrepancies(ctx, run.ID)
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, newReconciliationReportResponse(run, discrepancies))
+}
+
+// Metrics godoc
+// @Summary Metrics
+// @Description results of the last reconciliation run and the number of frozen accounts in the Prometheus text format
+// @Tags metrics
+// @Produce  plain
+// @Success 200 {string} string
+// @Failure 500 {object} apperror.Problem
+// @Router /metrics [get]
+func (c *ReconciliationController) Metrics(ctx *gin.Context) {
+	var buf bytes.Buffer
+	if err := reconcile.WriteMetrics(ctx, &buf, c.store); err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+	ctx.Data(http.StatusOK, reconcile.MetricsContentType, buf.Bytes())
+}
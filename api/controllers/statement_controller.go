@@ -0,0 +1,255 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hhow09/simple_bank/apperror"
+	"github.com/hhow09/simple_bank/constants"
+	db "github.com/hhow09/simple_bank/db/sqlc"
+	"github.com/hhow09/simple_bank/money"
+	"github.com/hhow09/simple_bank/snapshot"
+	"github.com/hhow09/simple_bank/statement"
+	"github.com/hhow09/simple_bank/util"
+)
+
+type StatementController struct {
+	store      db.Store
+	config     util.Config
+	currencies *money.Registry
+}
+
+// NewStatementController creates new statement controller
+func NewStatementController(store db.Store, config util.Config, currencies *money.Registry) StatementController {
+	return StatementController{
+		store:      store,
+		config:     config,
+		currencies: currencies,
+	}
+}
+
+type getStatementRequest struct {
+	From   time.Time `form:"from" time_format:"2006-01-02" time_utc:"1" binding:"required"`
+	To     time.Time `form:"to" time_format:"2006-01-02" time_utc:"1" binding:"required"`
+	Format string    `form:"format,default=csv" binding:"oneof=csv ofx camt053"`
+}
+
+// GetStatement godoc
+// @Summary get Account statement
+// @Description export the entries of an account over the days from from to to (UTC, both included) with the opening and closing balances, the current user must be a member of the account. the statement of the current day ends now.
+// @Tags accounts
+// @Produce  text/csv,application/x-ofx,application/xml
+// @Security authorization
+// @Param id path integer true "Account ID"
+// @Param from query string true "first day, YYYY-MM-DD"
+// @Param to query string true "last day, YYYY-MM-DD"
+// @Param format query string false "csv (default), ofx or camt053"
+// @Success 200 {file} file
+// @Failure 400 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /accounts/:id/statements [get]
+func (c *StatementController) GetStatement(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(apperror.FromBinding(err))
+		return
+	}
+	var req getStatementRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.Error(apperror.FromBinding(err))
+		return
+	}
+	now := time.Now()
+	if req.To.Before(req.From) {
+		ctx.Error(apperror.Validation("request validation failed",
+			apperror.FieldError{Field: "to", Rule: "gtefield", Message: "must not be before from"},
+		))
+		return
+	}
+	if req.From.After(snapshot.Day(now)) {
+		ctx.Error(apperror.Validation("request validation failed",
+			apperror.FieldError{Field: "from", Rule: "past", Message: "must not be in the future"},
+		))
+		return
+	}
+
+	account, appErr := c.getMemberAccount(ctx, uri.ID)
+	if appErr != nil {
+		ctx.Error(appErr)
+		return
+	}
+	owner, err := c.store.GetUser(ctx, account.Owner)
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+
+	currency, ok := c.currencies.Lookup(account.Currency)
+	if !ok {
+		currency = money.Currency{Code: account.Currency}
+	}
+	st, err := statement.New(ctx, c.store, account, currency, req.From, req.To, now)
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+	st.Holder = owner.FullName
+	st.BankID = c.config.AccountNumberBankCode
+
+	format := statement.Format(req.Format)
+	ctx.Header("Content-Type", format.ContentType())
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, st.Filename(format)))
+	ctx.Status(http.StatusOK)
+	if err := st.Write(ctx, c.store, ctx.Writer, format); err != nil {
+		// once the statement is being sent the error can only end it early
+		if !ctx.Writer.Written() {
+			ctx.Writer.Header().Del("Content-Disposition")
+		}
+		ctx.Error(apperror.Internal(err))
+	}
+}
+
+// getMemberAccount returns the account when the current user is a member of it
+func (c *StatementController) getMemberAccount(ctx *gin.Context, accountID int64) (db.Account, *apperror.Error) {
+	account, err := c.store.GetAccount(ctx, accountID)
+	if err != nil {
+		return account, apperror.From(err)
+	}
+	authUser := ctx.MustGet(constants.AuthUserKey).(db.User)
+	if _, appErr := getAccountMember(ctx, c.store, account.ID, authUser.Username); appErr != nil {
+		return account, appErr
+	}
+	return account, nil
+}
+
+type monthlyStatementResponse struct {
+	ID                    int64  `json:"id"`
+	AccountID             int64  `json:"account_id"`
+	Month                 string `json:"month"`
+	PeriodStart           string `json:"period_start"`
+	PeriodEnd             string `json:"period_end"`
+	OpeningBalance        int64  `json:"opening_balance"`
+	OpeningBalanceDecimal string `json:"opening_balance_decimal"`
+	ClosingBalance        int64  `json:"closing_balance"`
+	ClosingBalanceDecimal string `json:"closing_balance_decimal"`
+	// Size is the size of the pdf in bytes
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type listMonthlyStatementsRequest struct {
+	PageID   int32 `form:"page_id,default=1" binding:"min=1"`
+	PageSize int32 `form:"page_size,default=12" binding:"min=5,max=50"`
+}
+
+// ListMonthlyStatements godoc
+// @Summary List monthly statements
+// @Description list the pdf statements generated for every closed month of an account, latest first, the current user must be a member of the account
+// @Tags accounts
+// @Produce  json
+// @Security authorization
+// @Param id path integer true "Account ID"
+// @Param page_id query integer false "page id"
+// @Param page_size query integer false "page size"
+// @Success 200 {object} []monthlyStatementResponse
+// @Failure 400 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /accounts/:id/monthly-statements [get]
+func (c *StatementController) ListMonthlyStatements(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(apperror.FromBinding(err))
+		return
+	}
+	var req listMonthlyStatementsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.Error(apperror.FromBinding(err))
+		return
+	}
+	account, appErr := c.getMemberAccount(ctx, uri.ID)
+	if appErr != nil {
+		ctx.Error(appErr)
+		return
+	}
+
+	statements, err := c.store.ListAccountStatements(ctx, db.ListAccountStatementsParams{
+		AccountID: account.ID,
+		Limit:     req.PageSize,
+		Offset:    (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+	rsp := make([]monthlyStatementResponse, len(statements))
+	for i, st := range statements {
+		rsp[i] = monthlyStatementResponse{
+			ID:                    st.ID,
+			AccountID:             st.AccountID,
+			Month:                 st.PeriodStart.Format(statement.MonthFormat),
+			PeriodStart:           st.PeriodStart.Format(snapshot.DateFormat),
+			PeriodEnd:             st.PeriodEnd.Format(snapshot.DateFormat),
+			OpeningBalance:        st.OpeningBalance,
+			OpeningBalanceDecimal: c.currencies.Format(account.Currency, st.OpeningBalance),
+			ClosingBalance:        st.ClosingBalance,
+			ClosingBalanceDecimal: c.currencies.Format(account.Currency, st.ClosingBalance),
+			Size:                  st.Size,
+			CreatedAt:             st.CreatedAt,
+		}
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type getMonthlyStatementRequest struct {
+	ID    int64  `uri:"id" binding:"required,min=1"`
+	Month string `uri:"month" binding:"required"`
+}
+
+// GetMonthlyStatement godoc
+// @Summary Download monthly statement
+// @Description download the pdf statement of a month of an account, the current user must be a member of the account
+// @Tags accounts
+// @Produce  application/pdf
+// @Security authorization
+// @Param id path integer true "Account ID"
+// @Param month path string true "YYYY-MM"
+// @Success 200 {file} file
+// @Failure 400 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /accounts/:id/monthly-statements/:month [get]
+func (c *StatementController) GetMonthlyStatement(ctx *gin.Context) {
+	var req getMonthlyStatementRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.Error(apperror.FromBinding(err))
+		return
+	}
+	month, err := time.Parse(statement.MonthFormat, req.Month)
+	if err != nil {
+		ctx.Error(apperror.Validation("request validation failed",
+			apperror.FieldError{Field: "month", Rule: "month", Message: "must be a month as YYYY-MM"},
+		))
+		return
+	}
+	account, appErr := c.getMemberAccount(ctx, req.ID)
+	if appErr != nil {
+		ctx.Error(appErr)
+		return
+	}
+
+	st, err := c.store.GetAccountStatement(ctx, db.GetAccountStatementParams{
+		AccountID:   account.ID,
+		PeriodStart: month,
+	})
+	if err != nil {
+		ctx.Error(apperror.From(err))
+		return
+	}
+	filename := fmt.Sprintf("statement-%s-%s.pdf", account.Number, req.Month)
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	ctx.Data(http.StatusOK, statement.FormatPDF.ContentType(), st.Content)
+}
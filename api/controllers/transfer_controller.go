@@ -2,104 +2,486 @@ package controllers
 
 import (
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/hhow09/simple_bank/apperror"
 	"github.com/hhow09/simple_bank/constants"
 	db "github.com/hhow09/simple_bank/db/sqlc"
+	"github.com/hhow09/simple_bank/fee"
+	"github.com/hhow09/simple_bank/money"
 	"github.com/hhow09/simple_bank/token"
 	"github.com/hhow09/simple_bank/util"
 )
 
 type TransferController struct {
-	store  db.Store
-	config util.Config
+	store      db.Store
+	config     util.Config
+	currencies *money.Registry
+	fees       *fee.Schedule
 }
 
-func NewTransferController(store db.Store, tokenMaker token.Maker, config util.Config) TransferController {
+func NewTransferController(store db.Store, tokenMaker token.Maker, config util.Config, currencies *money.Registry, fees *fee.Schedule) TransferController {
 	return TransferController{
-		store:  store,
-		config: config,
+		store:      store,
+		config:     config,
+		currencies: currencies,
+		fees:       fees,
 	}
 }
 
+// transferRequest addresses each account either by id or by number,
+// the to account also by beneficiary
 type transferRequest struct {
-	FromAccountID int64  `json:"from_account_id" binding:"required,min=1"`
-	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1"`
-	Amount        int64  `json:"amount" binding:"required,gt=1"`
-	Currency      string `json:"currency" binding:"required,currency"`
+	FromAccountID     int64  `json:"from_account_id" binding:"omitempty,min=1"`
+	FromAccountNumber string `json:"from_account_number" binding:"omitempty,account_number"`
+	ToAccountID       int64  `json:"to_account_id" binding:"omitempty,min=1,nefield=FromAccountID"`
+	ToAccountNumber   string `json:"to_account_number" binding:"omitempty,account_number"`
+	// BeneficiaryID addresses the to account through a saved payee of the current user
+	BeneficiaryID int64 `json:"beneficiary_id" binding:"omitempty,min=1"`
+	// Amount is an integer of minor units or a decimal string of major units of the currency
+	Amount   money.Amount `json:"amount" swaggertype:"string"`
+	Currency string       `json:"currency" binding:"required,currency"`
+	// TOTPCode is required above the step-up threshold for users with 2FA enabled
+	TOTPCode string `json:"totp_code" binding:"omitempty,len=6,numeric"`
+	// Description is a free text memo, copied to both entries
+	Description string `json:"description" binding:"max=140,memo"`
+	// Reference is an external reference such as an invoice number
+	Reference string `json:"reference" binding:"max=35,reference"`
+	// Metadata holds string key-value pairs of the client, returned as is
+	Metadata map[string]string `json:"metadata" binding:"max=20,dive,keys,min=1,max=40,endkeys,max=500"`
 }
 
 // CreateTransfer godoc
 // @Summary Create Transfer
-// @Description Create transfer from from_account_id to to_account_id which has same currency
+// @Description Create transfer from from_account_id to to_account_id which has same currency, the accounts must differ.
+// @Description Accounts are addressed either by id or by account number, the to account also by beneficiary_id.
+// @Description Transfers to a beneficiary are blocked during its cooling-off period.
+// @Description The current user must be an owner or co_owner of from_account_id.
+// @Description The fee of the fee schedule is taken from from_account_id on top of the amount, unless it is waived for the tier of its owner.
+// @Description Users with 2FA enabled must provide a TOTP code for amounts above the step-up threshold.
+// @Description Wrong TOTP codes count as failed logins and lock the user out like the login.
 // @Tags transfers
 // @Accept  json
 // @Produce  json
 // @Security authorization
-// @Param from_account_id body integer true "from_account_id"
-// @Param to_account_id body integer true "to_account_id"
-// @Param amount body integer true "amount"
+// @Param from_account_id body integer false "from_account_id, or from_account_number"
+// @Param from_account_number body string false "from_account_number"
+// @Param to_account_id body integer false "to_account_id, or to_account_number"
+// @Param to_account_number body string false "to_account_number"
+// @Param beneficiary_id body integer false "beneficiary_id, a saved payee instead of to_account_id"
+// @Param amount body string true "amount, an integer of minor units (1234) or a decimal string of major units (\"12.34\")"
 // @Param currency body string true "currency"
+// @Param totp_code body string false "TOTP code for step-up authentication"
+// @Param description body string false "memo of at most 140 printable characters"
+// @Param reference body string false "external reference of at most 35 characters of the SWIFT character set"
+// @Param metadata body object false "at most 20 string key-value pairs"
 // @Success 200 {object} db.TransferTxResult
-// @Failure 400 {object} gin.H
-// @Failure 403 {object} gin.H
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Failure 422 {object} apperror.Problem
+// @Failure 423 {object} apperror.Problem
+// @Failure 429 {object} apperror.Problem
 // @Router /transfers [post]
 func (c *TransferController) CreateTransfer(ctx *gin.Context) {
 	var req transferRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, util.ErrorResponse(err))
+		ctx.Error(apperror.FromBinding(err))
 		return
 	}
-	fromAccount, valid := c.validAccount(ctx, req.FromAccountID, req.Currency)
+	quote, valid := c.quoteTransfer(ctx, req)
 	if !valid {
 		return
 	}
-	authPayload := ctx.MustGet(constants.AuthPayloadKey).(*token.Payload)
-	if fromAccount.Owner != authPayload.Username {
-		err := errors.New("from account doesn't belongs to the current user")
-		ctx.JSON(http.StatusUnauthorized, err)
+	if quote.fromAccount.Balance < quote.total {
+		ctx.Error(apperror.InsufficientFunds(fmt.Sprintf("account [%d] has insufficient funds", quote.fromAccount.ID)))
 		return
 	}
-
-	_, valid = c.validAccount(ctx, req.ToAccountID, req.Currency)
+	toAccount, valid := c.toAccount(ctx, req, quote.fromAccount, quote.amount)
 	if !valid {
 		return
 	}
 
+	user := ctx.MustGet(constants.AuthUserKey).(db.User)
+	if user.IsTotpEnabled && quote.amount > c.config.TwoFactorTransferThreshold {
+		if req.TOTPCode == "" {
+			ctx.Error(apperror.TwoFactorRequired("a two-factor code is required for this amount"))
+			return
+		}
+		// wrong codes count as failed logins, so step-up codes can't be guessed past the lockout
+		if lockedOut(ctx, c.store, c.config, user.Username) {
+			return
+		}
+		if err := checkTOTP(ctx, c.store, user, req.TOTPCode); err != nil {
+			if appErr := apperror.From(err); appErr.Code == apperror.CodeValidation {
+				loginFailed(ctx, c.store, user.Username, &apperror.Error{Code: apperror.CodeUnauthorized, Message: "invalid two-factor code", Err: err})
+				return
+			}
+			ctx.Error(err)
+			return
+		}
+	}
+
 	arg := db.TransferTxParams{
-		FromAccountID: req.FromAccountID,
-		ToAccountID:   req.ToAccountID,
-		Amount:        req.Amount,
+		FromAccountID: quote.fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        quote.amount,
+		Description:   req.Description,
+		Reference:     req.Reference,
+		Fee:           quote.fee.Fee,
+		FeeWaived:     quote.fee.Waived,
+	}
+	if len(req.Metadata) > 0 {
+		metadata, err := json.Marshal(req.Metadata)
+		if err != nil {
+			ctx.Error(apperror.Internal(err))
+			return
+		}
+		arg.Metadata = metadata
 	}
 
 	result, err := c.store.TransferTx(ctx, arg)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, util.ErrorResponse(err))
+		if errors.Is(err, db.ErrSameAccount) {
+			ctx.Error(sameAccount(err))
+			return
+		}
+		if errors.Is(err, db.ErrAccountFrozen) {
+			ctx.Error(&apperror.Error{Code: apperror.CodeConflict, Message: "account is frozen", Err: err})
+			return
+		}
+		if errors.Is(err, db.ErrInsufficientFunds) {
+			ctx.Error(&apperror.Error{
+				Code:    apperror.CodeInsufficientFunds,
+				Message: fmt.Sprintf("account [%d] has insufficient funds", quote.fromAccount.ID),
+				Err:     err,
+			})
+			return
+		}
+		ctx.Error(apperror.From(err))
 		return
 	}
 
 	ctx.JSON(http.StatusOK, result)
 }
 
-func (c *TransferController) validAccount(ctx *gin.Context, accountID int64, currency string) (db.Account, bool) {
-	account, err := c.store.GetAccount(ctx, accountID)
+type transferPreviewResponse struct {
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	Currency      string `json:"currency"`
+	Amount        int64  `json:"amount"`
+	AmountDecimal string `json:"amount_decimal"`
+	// Fee is taken from the from account on top of the amount, 0 when it is waived
+	Fee        int64  `json:"fee"`
+	FeeDecimal string `json:"fee_decimal"`
+	FeeWaived  bool   `json:"fee_waived"`
+	// Total is the amount plus the fee
+	Total        int64  `json:"total"`
+	TotalDecimal string `json:"total_decimal"`
+	// SufficientFunds reports whether the balance of the from account covers the total
+	SufficientFunds bool `json:"sufficient_funds"`
+}
+
+// PreviewTransfer godoc
+// @Summary Preview Transfer
+// @Description Validate a transfer like Create Transfer without making it and return its fee and total.
+// @Description Insufficient funds are reported by sufficient_funds and no TOTP code is required.
+// @Tags transfers
+// @Accept  json
+// @Produce  json
+// @Security authorization
+// @Param from_account_id body integer false "from_account_id, or from_account_number"
+// @Param from_account_number body string false "from_account_number"
+// @Param to_account_id body integer false "to_account_id, or to_account_number"
+// @Param to_account_number body string false "to_account_number"
+// @Param beneficiary_id body integer false "beneficiary_id, a saved payee instead of to_account_id"
+// @Param amount body string true "amount, an integer of minor units (1234) or a decimal string of major units (\"12.34\")"
+// @Param currency body string true "currency"
+// @Success 200 {object} transferPreviewResponse
+// @Failure 400 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Router /transfers/preview [post]
+func (c *TransferController) PreviewTransfer(ctx *gin.Context) {
+	var req transferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.FromBinding(err))
+		return
+	}
+	quote, valid := c.quoteTransfer(ctx, req)
+	if !valid {
+		return
+	}
+	toAccount, valid := c.toAccount(ctx, req, quote.fromAccount, quote.amount)
+	if !valid {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, transferPreviewResponse{
+		FromAccountID:   quote.fromAccount.ID,
+		ToAccountID:     toAccount.ID,
+		Currency:        req.Currency,
+		Amount:          quote.amount,
+		AmountDecimal:   c.currencies.Format(req.Currency, quote.amount),
+		Fee:             quote.fee.Fee,
+		FeeDecimal:      c.currencies.Format(req.Currency, quote.fee.Fee),
+		FeeWaived:       quote.fee.Waived,
+		Total:           quote.total,
+		TotalDecimal:    c.currencies.Format(req.Currency, quote.total),
+		SufficientFunds: quote.fromAccount.Balance >= quote.total,
+	})
+}
+
+// transferQuote is the from account, amount and fee of a transfer request
+type transferQuote struct {
+	fromAccount db.Account
+	amount      int64
+	fee         fee.Quote
+	// total is taken from the from account
+	total int64
+}
+
+// quoteTransfer checks the account references, the amount and that the current user can send money
+// from the from account, then quotes the fee of the amount for the tier of the owner of the account
+func (c *TransferController) quoteTransfer(ctx *gin.Context, req transferRequest) (transferQuote, bool) {
+	var quote transferQuote
+	if err := checkAccountRefs(req); err != nil {
+		ctx.Error(err)
+		return quote, false
+	}
+	amount, appErr := c.minorAmount(req.Amount, req.Currency)
+	if appErr != nil {
+		ctx.Error(appErr)
+		return quote, false
+	}
+	fromAccount, valid := c.validAccount(ctx, req.FromAccountID, req.FromAccountNumber, req.Currency)
+	if !valid {
+		return quote, false
+	}
+	authUser := ctx.MustGet(constants.AuthUserKey).(db.User)
+	member, appErr := getAccountMember(ctx, c.store, fromAccount.ID, authUser.Username)
+	if appErr != nil {
+		ctx.Error(appErr)
+		return quote, false
+	}
+	if member.Role == constants.MemberRoleViewer {
+		ctx.Error(apperror.Forbidden("viewers cannot send money from the account"))
+		return quote, false
+	}
+
+	amountFee, err := c.fees.TransferFee(req.Currency, amount)
+	if err != nil {
+		ctx.Error(&apperror.Error{Code: apperror.CodeConflict, Message: "transfer fee would overflow", Err: err})
+		return quote, false
+	}
+	quote = transferQuote{fromAccount: fromAccount, amount: amount, fee: fee.Quote{Fee: amountFee}}
+	if amountFee > 0 && c.fees.HasWaivers(constants.FeeKindTransfer) {
+		// fees are waived for the tier of the owner of the account, who pays them
+		owner := authUser
+		if fromAccount.Owner != authUser.Username {
+			if owner, err = c.store.GetUser(ctx, fromAccount.Owner); err != nil {
+				ctx.Error(apperror.Internal(err))
+				return quote, false
+			}
+		}
+		quote.fee = c.fees.Apply(amountFee, owner.Tier, constants.FeeKindTransfer)
+	}
+	if quote.total, err = money.Add(amount, quote.fee.Fee); err != nil {
+		ctx.Error(&apperror.Error{Code: apperror.CodeConflict, Message: "transfer total would overflow", Err: err})
+		return quote, false
+	}
+	return quote, true
+}
+
+// toAccount loads the to account of a transfer request, addressed by id, number or beneficiary,
+// and checks that it can receive the amount
+func (c *TransferController) toAccount(ctx *gin.Context, req transferRequest, fromAccount db.Account, amount int64) (db.Account, bool) {
+	toAccountID := req.ToAccountID
+	if req.BeneficiaryID != 0 {
+		authUser := ctx.MustGet(constants.AuthUserKey).(db.User)
+		beneficiary, err := c.availableBeneficiary(ctx, req.BeneficiaryID, authUser.Username)
+		if err != nil {
+			ctx.Error(err)
+			return db.Account{}, false
+		}
+		toAccountID = beneficiary.AccountID
+	}
+	toAccount, valid := c.validAccount(ctx, toAccountID, req.ToAccountNumber, req.Currency)
+	if !valid {
+		return toAccount, false
+	}
+	// numbers and beneficiaries may address the from account as well
+	if toAccount.ID == fromAccount.ID {
+		ctx.Error(sameAccount(nil))
+		return toAccount, false
+	}
+	if _, err := money.Add(toAccount.Balance, amount); err != nil {
+		ctx.Error(&apperror.Error{Code: apperror.CodeConflict, Message: fmt.Sprintf("account [%d] balance would overflow", toAccount.ID), Err: err})
+		return toAccount, false
+	}
+	return toAccount, true
+}
+
+// sameAccount rejects a transfer from an account to itself
+func sameAccount(cause error) *apperror.Error {
+	return &apperror.Error{
+		Code:    apperror.CodeValidation,
+		Message: "request validation failed",
+		Fields:  []apperror.FieldError{{Field: "to_account_id", Rule: "nefield", Message: "must differ from the from account"}},
+		Err:     cause,
+	}
+}
+
+// checkAccountRefs checks that each account is addressed exactly once
+func checkAccountRefs(req transferRequest) *apperror.Error {
+	var fields []apperror.FieldError
+	if count(req.FromAccountID != 0, req.FromAccountNumber != "") != 1 {
+		fields = append(fields, apperror.FieldError{
+			Field:   "from_account_id",
+			Rule:    "account_ref",
+			Message: "exactly one of from_account_id and from_account_number is required",
+		})
+	}
+	if count(req.ToAccountID != 0, req.ToAccountNumber != "", req.BeneficiaryID != 0) != 1 {
+		fields = append(fields, apperror.FieldError{
+			Field:   "to_account_id",
+			Rule:    "account_ref",
+			Message: "exactly one of to_account_id, to_account_number and beneficiary_id is required",
+		})
+	}
+	if len(fields) > 0 {
+		return apperror.Validation("request validation failed", fields...)
+	}
+	return nil
+}
+
+// minorAmount converts the amount of the request to minor units of the currency
+func (c *TransferController) minorAmount(amount money.Amount, code string) (int64, *apperror.Error) {
+	currency, _ := c.currencies.Lookup(code)
+	minor, err := amount.Minor(currency)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, util.ErrorResponse(err))
+		return 0, apperror.Validation("request validation failed", apperror.FieldError{
+			Field:   "amount",
+			Rule:    "amount",
+			Message: fmt.Sprintf("is not an amount of %s: %v", code, err),
+		})
+	}
+	if minor <= 1 {
+		return 0, apperror.Validation("request validation failed", apperror.FieldError{
+			Field:   "amount",
+			Rule:    "gt",
+			Message: "must be greater than 1",
+		})
+	}
+	return minor, nil
+}
+
+// count returns the number of true values
+func count(values ...bool) int {
+	n := 0
+	for _, v := range values {
+		if v {
+			n++
+		}
+	}
+	return n
+}
+
+// availableBeneficiary loads a beneficiary of the user whose cooling-off period is over
+func (c *TransferController) availableBeneficiary(ctx *gin.Context, id int64, username string) (db.Beneficiary, *apperror.Error) {
+	// beneficiaries of other users are reported as not found
+	beneficiary, err := c.store.GetBeneficiary(ctx, db.GetBeneficiaryParams{
+		ID:       id,
+		Username: username,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return beneficiary, &apperror.Error{Code: apperror.CodeNotFound, Message: fmt.Sprintf("beneficiary [%d] not found", id), Err: err}
+		}
+		return beneficiary, apperror.Internal(err)
+	}
+	if time.Now().Before(beneficiary.AvailableAt) {
+		return beneficiary, apperror.Forbidden(fmt.Sprintf("transfers to beneficiary [%d] are allowed from %s", id, beneficiary.AvailableAt.UTC().Format(time.RFC3339)))
+	}
+	return beneficiary, nil
+}
+
+// validAccount loads the account by number when it is given, otherwise by id
+func (c *TransferController) validAccount(ctx *gin.Context, accountID int64, number string, currency string) (db.Account, bool) {
+	var account db.Account
+	var err error
+	ref := fmt.Sprint(accountID)
+	if number != "" {
+		ref = number
+		account, err = c.store.GetAccountByNumber(ctx, number)
+	} else {
+		account, err = c.store.GetAccount(ctx, accountID)
+	}
+	if err != nil {
+		if appErr := apperror.From(err); appErr.Code == apperror.CodeNotFound {
+			ctx.Error(&apperror.Error{Code: apperror.CodeNotFound, Message: fmt.Sprintf("account [%s] not found", ref), Err: err})
 			return account, false
 		}
 
-		ctx.JSON(http.StatusInternalServerError, util.ErrorResponse(err))
+		ctx.Error(apperror.Internal(err))
 		return account, false
 	}
 	if account.Currency != currency {
-		err := fmt.Errorf("account [%d] currency mismatch: %s vs %s", account.ID, account.Currency, currency)
-		ctx.JSON(http.StatusBadRequest, util.ErrorResponse(err))
+		ctx.Error(apperror.CurrencyMismatch(fmt.Sprintf("account [%s] currency mismatch: %s vs %s", ref, account.Currency, currency)))
+		return account, false
+	}
+	if account.FrozenAt.Valid {
+		ctx.Error(apperror.Conflict(fmt.Sprintf("account [%s] is frozen", ref)))
 		return account, false
 	}
 
 	return account, true
 }
+
+type listTransfersRequest struct {
+	Reference string `form:"reference" binding:"required,max=35,reference"`
+	PageID    int32  `form:"page_id,default=1" binding:"min=1"`
+	PageSize  int32  `form:"page_size,default=5" binding:"min=5,max=10"`
+}
+
+// ListTransfers godoc
+// @Summary List Transfers
+// @Description list the transfers with a reference from or to accounts the current user is a member of
+// @Tags transfers
+// @Produce  json
+// @Security authorization
+// @Param reference query string true "reference"
+// @Param page_id query integer false "page id"
+// @Param page_size query integer false "page size"
+// @Success 200 {object} []db.Transfer
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Router /transfers [get]
+func (c *TransferController) ListTransfers(ctx *gin.Context) {
+	var req listTransfersRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.Error(apperror.FromBinding(err))
+		return
+	}
+	authUser := ctx.MustGet(constants.AuthUserKey).(db.User)
+	transfers, err := c.store.ListTransfersByReference(ctx, db.ListTransfersByReferenceParams{
+		Reference: req.Reference,
+		Username:  authUser.Username,
+		Limit:     req.PageSize,
+		Offset:    (req.PageID - 1) * req.PageSize,
+	})
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, transfers)
+}
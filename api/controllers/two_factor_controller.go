@@ -0,0 +1,196 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hhow09/simple_bank/apperror"
+	"github.com/hhow09/simple_bank/constants"
+	db "github.com/hhow09/simple_bank/db/sqlc"
+	"github.com/hhow09/simple_bank/totp"
+	"github.com/hhow09/simple_bank/util"
+)
+
+// recoveryCodeCount is the number of recovery codes handed out when 2FA is enabled
+const recoveryCodeCount = 10
+
+type TwoFactorController struct {
+	store  db.Store
+	config util.Config
+}
+
+// NewTwoFactorController creates new two-factor controller
+func NewTwoFactorController(store db.Store, config util.Config) TwoFactorController {
+	return TwoFactorController{
+		store:  store,
+		config: config,
+	}
+}
+
+type enrollTOTPResponse struct {
+	Secret     string `json:"secret"`
+	OtpauthURI string `json:"otpauth_uri"`
+}
+
+// enrollTOTP godoc
+// @Summary Enroll TOTP
+// @Description Generate a TOTP secret for the current user, 2FA is enabled once a code is confirmed
+// @Tags two-factor
+// @Produce  json
+// @Security authorization
+// @Success 200 {object} enrollTOTPResponse
+// @Failure 401 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /users/me/totp [post]
+func (c *TwoFactorController) EnrollTOTP(ctx *gin.Context) {
+	user := ctx.MustGet(constants.AuthUserKey).(db.User)
+	if user.IsTotpEnabled {
+		ctx.Error(apperror.Conflict("two-factor authentication is already enabled"))
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+	_, err = c.store.SetUserTOTPSecret(ctx, db.SetUserTOTPSecretParams{
+		TotpSecret: secret,
+		Username:   user.Username,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.Error(&apperror.Error{Code: apperror.CodeConflict, Message: "two-factor authentication is already enabled", Err: err})
+			return
+		}
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, enrollTOTPResponse{
+		Secret:     secret,
+		OtpauthURI: totp.URI(c.config.TOTPIssuer, user.Username, secret),
+	})
+}
+
+type confirmTOTPRequest struct {
+	Code string `json:"code" binding:"required,len=6,numeric"`
+}
+
+type confirmTOTPResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// confirmTOTP godoc
+// @Summary Confirm TOTP
+// @Description Enable 2FA with a code of the enrolled secret, the returned recovery codes are only shown once
+// @Tags two-factor
+// @Accept  json
+// @Produce  json
+// @Security authorization
+// @Param code body string true "TOTP code"
+// @Success 200 {object} confirmTOTPResponse
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /users/me/totp/confirm [post]
+func (c *TwoFactorController) ConfirmTOTP(ctx *gin.Context) {
+	var req confirmTOTPRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.FromBinding(err))
+		return
+	}
+	user := ctx.MustGet(constants.AuthUserKey).(db.User)
+	if user.IsTotpEnabled {
+		ctx.Error(apperror.Conflict("two-factor authentication is already enabled"))
+		return
+	}
+	if user.TotpSecret == "" {
+		ctx.Error(apperror.Conflict("two-factor enrollment has not been started"))
+		return
+	}
+
+	step, ok := totp.Validate(user.TotpSecret, req.Code, time.Now())
+	if !ok {
+		ctx.Error(invalidTOTPCode(nil))
+		return
+	}
+
+	codes, err := totp.RecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = util.HashToken(totp.NormalizeRecoveryCode(code))
+	}
+
+	_, err = c.store.EnableTOTPTx(ctx, db.EnableTOTPTxParams{
+		Username:           user.Username,
+		Step:               step,
+		RecoveryCodeHashes: hashes,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.Error(invalidTOTPCode(err))
+			return
+		}
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, confirmTOTPResponse{RecoveryCodes: codes})
+}
+
+// checkTOTP verifies a code of the user and marks its time step as used so it cannot be replayed
+func checkTOTP(ctx *gin.Context, store db.Store, user db.User, code string) error {
+	step, ok := totp.Validate(user.TotpSecret, code, time.Now())
+	if !ok {
+		return invalidTOTPCode(nil)
+	}
+	_, err := store.UseUserTOTPStep(ctx, db.UseUserTOTPStepParams{
+		Step:     step,
+		Username: user.Username,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return invalidTOTPCode(err)
+		}
+		return apperror.Internal(err)
+	}
+	return nil
+}
+
+// checkRecoveryCode uses up one of the recovery codes of the user
+func checkRecoveryCode(ctx *gin.Context, store db.Store, user db.User, code string) error {
+	_, err := store.UseRecoveryCode(ctx, db.UseRecoveryCodeParams{
+		Username: user.Username,
+		CodeHash: util.HashToken(totp.NormalizeRecoveryCode(code)),
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &apperror.Error{
+				Code:    apperror.CodeValidation,
+				Message: "recovery code is invalid or already used",
+				Fields:  []apperror.FieldError{{Field: "recovery_code", Rule: "valid", Message: "is invalid or already used"}},
+				Err:     err,
+			}
+		}
+		return apperror.Internal(err)
+	}
+	return nil
+}
+
+func invalidTOTPCode(err error) *apperror.Error {
+	return &apperror.Error{
+		Code:    apperror.CodeValidation,
+		Message: "two-factor code is invalid",
+		Fields:  []apperror.FieldError{{Field: "code", Rule: "valid", Message: "is invalid or already used"}},
+		Err:     err,
+	}
+}
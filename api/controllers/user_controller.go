@@ -2,11 +2,20 @@ package controllers
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/hhow09/simple_bank/apperror"
+	"github.com/hhow09/simple_bank/breach"
+	"github.com/hhow09/simple_bank/constants"
 	db "github.com/hhow09/simple_bank/db/sqlc"
+	"github.com/hhow09/simple_bank/mail"
 	"github.com/hhow09/simple_bank/token"
 	"github.com/hhow09/simple_bank/util"
 	"github.com/lib/pq"
@@ -16,21 +25,29 @@ type UserController struct {
 	store      db.Store
 	tokenMaker token.Maker
 	config     util.Config
+	mailer     mail.Mailer
+	hasher     util.PasswordHasher
+	policy     util.PasswordPolicy
+	breach     breach.Checker
 }
 
 // NewUserController creates new account controller
-func NewUserController(store db.Store, tokenMaker token.Maker, config util.Config) UserController {
+func NewUserController(store db.Store, tokenMaker token.Maker, config util.Config, mailer mail.Mailer, breachChecker breach.Checker) UserController {
 	return UserController{
 		store:      store,
 		tokenMaker: tokenMaker,
 		config:     config,
+		mailer:     mailer,
+		hasher:     util.NewPasswordHasher(config),
+		policy:     util.NewPasswordPolicy(config),
+		breach:     breachChecker,
 	}
 }
 
 // alphanum: username should contian ASCII alphanumeric characters only
 type createUserRequest struct {
 	Username string `json:"username" binding:"required,alphanum"`
-	Password string `json:"password" binding:"required,min=6"`
+	Password string `json:"password" binding:"required,password"`
 	FullName string `json:"fullname" binding:"required"`
 	Email    string `json:"email" binding:"required,email"`
 }
@@ -39,6 +56,9 @@ type userResponse struct {
 	Username          string    `json:"username"`
 	FullName          string    `json:"full_name"`
 	Email             string    `json:"email"`
+	IsEmailVerified   bool      `json:"is_email_verified"`
+	Tier              string    `json:"tier"`
+	PendingEmail      string    `json:"pending_email"`
 	PasswordChangedAt time.Time `json:"password_changed_at"`
 	CreatedAt         time.Time `json:"created_at"`
 }
@@ -48,6 +68,9 @@ func newUserResponse(user db.User) userResponse {
 		Username:          user.Username,
 		FullName:          user.FullName,
 		Email:             user.Email,
+		IsEmailVerified:   user.IsEmailVerified,
+		Tier:              user.Tier,
+		PendingEmail:      user.PendingEmail,
 		PasswordChangedAt: user.PasswordChangedAt,
 		CreatedAt:         user.CreatedAt,
 	}
@@ -55,49 +78,66 @@ func newUserResponse(user db.User) userResponse {
 
 // createUser godoc
 // @Summary Create a User
-// @Description Create User by json user params
+// @Description Create User by json user params, a verification token is sent to the email
 // @Tags users
 // @Accept  json
 // @Produce  json
 // @Param username body string true "user name"
-// @Param password body string true "passward minLength(6)"
+// @Param password body string true "password, it must meet the password policy and not appear in a data breach"
 // @Param fullname body string true "full name"
 // @Param email body string true "email"
 // @Success 200 {object} userResponse
-// @Failure 400 {object} gin.H
-// @Failure 500 {object} gin.H
+// @Failure 400 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
 // @Router /users [post]
 func (c *UserController) CreateUser(ctx *gin.Context) {
 	var req createUserRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, util.ErrorResponse(err))
+		ctx.Error(apperror.FromBinding(err))
+		return
+	}
+	if err := c.checkBreached(ctx, "password", req.Password); err != nil {
+		ctx.Error(err)
+		return
+	}
+	hashedPassword, err := c.hasher.Hash(req.Password)
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
 		return
 	}
-	hashedPassword, err := util.HashPassword(req.Password)
+	verifyToken, err := util.RandomToken(32)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, util.ErrorResponse(err))
+		ctx.Error(apperror.Internal(err))
+		return
 	}
-	arg := db.CreateUserParams{
-		Username:       req.Username,
-		HashedPassword: hashedPassword,
-		FullName:       req.FullName,
-		Email:          req.Email,
+	arg := db.CreateUserTxParams{
+		CreateUserParams: db.CreateUserParams{
+			Username:       req.Username,
+			HashedPassword: hashedPassword,
+			FullName:       req.FullName,
+			Email:          req.Email,
+		},
+		VerifyEmailTokenHash: util.HashToken(verifyToken),
+		VerifyEmailExpiresAt: time.Now().Add(c.config.EmailVerificationTokenDuration),
+		// the user is not created when the verification email cannot be sent
+		AfterCreate: func(user db.User) error {
+			return c.sendVerifyEmail(ctx, user, user.Email, verifyToken)
+		},
 	}
 
-	user, err := c.store.CreateUser(ctx, arg)
+	result, err := c.store.CreateUserTx(ctx, arg)
 	if err != nil {
-		if pqErr, ok := err.(*pq.Error); ok {
-			switch pqErr.Code.Name() {
-			case "unique_violation":
-				ctx.JSON(http.StatusForbidden, util.ErrorResponse(err))
-				return
-			}
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code.Name() == "unique_violation" {
+			ctx.Error(&apperror.Error{Code: apperror.CodeConflict, Message: "username or email already exists", Err: err})
+			return
 		}
 
-		ctx.JSON(http.StatusBadRequest, util.ErrorResponse(err))
+		ctx.Error(apperror.Internal(err))
 		return
 	}
-	res := newUserResponse(user)
+	res := newUserResponse(result.User)
 	ctx.JSON(http.StatusOK, res)
 }
 
@@ -111,44 +151,103 @@ type loginUserResponse struct {
 	User        userResponse `json:"user"`
 }
 
+// maxLockoutDuration caps the progressive login lockout
+const maxLockoutDuration = 24 * time.Hour
+
 // loginUser godoc
 // @Summary User Login
-// @Description Login with username and password
+// @Description Login with username and password. When 2FA is enabled a challenge token is returned instead, to be completed at /users/login/2fa
 // @Tags users
 // @Accept  json
 // @Produce  json
 // @Param username body string true "user name"
 // @Param password body string true "passward minLength(6)"
 // @Success 200 {object} loginUserResponse
-// @Failure 400 {object} gin.H
-// @Failure 401 {object} gin.H
-// @Failure 404 {object} gin.H
-// @Failure 500 {object} gin.H
+// @Success 202 {object} loginChallengeResponse
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 423 {object} apperror.Problem
+// @Failure 429 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
 // @Router /users/login [post]
 func (c *UserController) LoginUser(ctx *gin.Context) {
 	var req loginUserRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, util.ErrorResponse(err))
+		ctx.Error(apperror.FromBinding(err))
 		return
 	}
+
+	if lockedOut(ctx, c.store, c.config, req.Username) {
+		return
+	}
+
 	user, err := c.store.GetUser(ctx, req.Username)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, util.ErrorResponse(err))
+		if !errors.Is(err, sql.ErrNoRows) {
+			ctx.Error(apperror.Internal(err))
+			return
 		}
-		ctx.JSON(http.StatusInternalServerError, util.ErrorResponse(err))
+		// spend as much time as for an existing user to prevent username enumeration
+		c.hasher.CheckDummy(req.Password)
+		loginFailed(ctx, c.store, req.Username, invalidCredentials(err))
 		return
 	}
 
-	err = util.CheckPassword(req.Password, user.HashedPassword)
+	err = c.hasher.Check(req.Password, user.HashedPassword)
 	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, util.ErrorResponse(err))
+		loginFailed(ctx, c.store, req.Username, invalidCredentials(err))
+		return
+	}
+	c.rehashPassword(ctx, user, req.Password)
+
+	// the login only succeeds after the second factor, failures are kept until then
+	if user.IsTotpEnabled {
+		c.loginChallenge(ctx, user)
+		return
+	}
+	c.loginSucceeded(ctx, user)
+}
+
+// rehashPassword upgrades a hash of an outdated algorithm or parameters while the
+// plain password is known. It does not revoke tokens and a failure does not fail the login.
+func (c *UserController) rehashPassword(ctx *gin.Context, user db.User, password string) {
+	if !c.hasher.NeedsRehash(user.HashedPassword) {
+		return
+	}
+	hashedPassword, err := c.hasher.Hash(password)
+	if err == nil {
+		// a concurrent password change wins over the rehash
+		err = c.store.RehashUserPassword(ctx, db.RehashUserPasswordParams{
+			NewHashedPassword: hashedPassword,
+			Username:          user.Username,
+			OldHashedPassword: user.HashedPassword,
+		})
+	}
+	if err != nil {
+		log.Printf("failed to rehash password of user %s: %v", user.Username, err)
+	}
+}
+
+// loginSucceeded records the successful attempt and responds with a new access token
+func (c *UserController) loginSucceeded(ctx *gin.Context, user db.User) {
+	_, err := c.store.CreateLoginAttempt(ctx, db.CreateLoginAttemptParams{
+		Username: user.Username,
+		ClientIp: ctx.ClientIP(),
+		Success:  true,
+	})
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+	err = c.store.ClearLoginFailures(ctx, user.Username)
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
 		return
 	}
 
 	accessToken, err := c.tokenMaker.CreateToken(user.Username, c.config.AccessTokenDuration)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, util.ErrorResponse(err))
+		ctx.Error(apperror.Internal(err))
 		return
 	}
 
@@ -158,3 +257,702 @@ func (c *UserController) LoginUser(ctx *gin.Context) {
 	}
 	ctx.JSON(http.StatusOK, rsp)
 }
+
+type loginChallengeResponse struct {
+	TwoFactorRequired bool      `json:"two_factor_required"`
+	ChallengeToken    string    `json:"challenge_token"`
+	ExpiresAt         time.Time `json:"expires_at"`
+}
+
+// loginChallenge starts the second login step of a user with 2FA enabled
+func (c *UserController) loginChallenge(ctx *gin.Context, user db.User) {
+	challengeToken, err := util.RandomToken(32)
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+	challenge, err := c.store.CreateLoginChallenge(ctx, db.CreateLoginChallengeParams{
+		Username:  user.Username,
+		TokenHash: util.HashToken(challengeToken),
+		ExpiresAt: time.Now().Add(c.config.TwoFactorChallengeDuration),
+	})
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+	ctx.JSON(http.StatusAccepted, loginChallengeResponse{
+		TwoFactorRequired: true,
+		ChallengeToken:    challengeToken,
+		ExpiresAt:         challenge.ExpiresAt,
+	})
+}
+
+type loginTwoFactorRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"omitempty,len=6,numeric"`
+	RecoveryCode   string `json:"recovery_code"`
+}
+
+// loginTwoFactor godoc
+// @Summary User Login Second Step
+// @Description Complete a login challenge with a TOTP code or one of the recovery codes
+// @Tags users
+// @Accept  json
+// @Produce  json
+// @Param challenge_token body string true "challenge token of /users/login"
+// @Param code body string false "TOTP code"
+// @Param recovery_code body string false "recovery code, instead of the TOTP code"
+// @Success 200 {object} loginUserResponse
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 423 {object} apperror.Problem
+// @Failure 429 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /users/login/2fa [post]
+func (c *UserController) LoginTwoFactor(ctx *gin.Context) {
+	var req loginTwoFactorRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.FromBinding(err))
+		return
+	}
+	if req.Code == "" && req.RecoveryCode == "" {
+		ctx.Error(apperror.Validation("code or recovery_code is required",
+			apperror.FieldError{Field: "code", Rule: "required", Message: "is required without recovery_code"},
+		))
+		return
+	}
+
+	challengeHash := util.HashToken(req.ChallengeToken)
+	challenge, err := c.store.GetLoginChallenge(ctx, challengeHash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.Error(&apperror.Error{Code: apperror.CodeUnauthorized, Message: "login challenge is invalid or expired", Err: err})
+			return
+		}
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+	if lockedOut(ctx, c.store, c.config, challenge.Username) {
+		return
+	}
+
+	user, err := c.store.GetUser(ctx, challenge.Username)
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+
+	if req.Code != "" {
+		err = checkTOTP(ctx, c.store, user, req.Code)
+	} else {
+		err = checkRecoveryCode(ctx, c.store, user, req.RecoveryCode)
+	}
+	if err != nil {
+		if appErr := apperror.From(err); appErr.Code == apperror.CodeValidation {
+			loginFailed(ctx, c.store, user.Username, &apperror.Error{Code: apperror.CodeUnauthorized, Message: "invalid two-factor code", Err: err})
+			return
+		}
+		ctx.Error(err)
+		return
+	}
+
+	// a challenge completes a single login
+	_, err = c.store.ConsumeLoginChallenge(ctx, challengeHash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.Error(&apperror.Error{Code: apperror.CodeUnauthorized, Message: "login challenge is invalid or expired", Err: err})
+			return
+		}
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+	c.loginSucceeded(ctx, user)
+}
+
+// lockedOut responds with 423 when the username has too many recent login failures,
+// wrong passwords and two-factor codes confirming sensitive actions count as login failures
+func lockedOut(ctx *gin.Context, store db.Store, config util.Config, username string) bool {
+	if config.LoginMaxFailedAttempts <= 0 {
+		return false
+	}
+	failures, err := store.GetLoginFailures(ctx, db.GetLoginFailuresParams{
+		Username: username,
+		Since:    time.Now().Add(-config.LoginFailureWindow),
+	})
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return true
+	}
+	if lockedUntil := lockedUntil(config, failures); time.Now().Before(lockedUntil) {
+		ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(time.Until(lockedUntil).Seconds()))))
+		ctx.Error(apperror.AccountLocked("too many failed login attempts, retry later"))
+		return true
+	}
+	return false
+}
+
+// invalidCredentials is the same for unknown users and wrong passwords
+func invalidCredentials(cause error) *apperror.Error {
+	return &apperror.Error{Code: apperror.CodeUnauthorized, Message: "invalid username or password", Err: cause}
+}
+
+// loginFailed records the failed attempt and responds with the given error
+func loginFailed(ctx *gin.Context, store db.Store, username string, failure *apperror.Error) {
+	_, err := store.CreateLoginAttempt(ctx, db.CreateLoginAttemptParams{
+		Username: username,
+		ClientIp: ctx.ClientIP(),
+		Success:  false,
+	})
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+	ctx.Error(failure)
+}
+
+// lockedUntil returns the end of the lockout caused by the recent failures,
+// the lockout doubles with every failure above the allowed attempts
+func lockedUntil(config util.Config, failures db.GetLoginFailuresRow) time.Time {
+	maxAttempts := int64(config.LoginMaxFailedAttempts)
+	if failures.Failures < maxAttempts {
+		return time.Time{}
+	}
+	lockout := config.LoginLockoutDuration
+	for i := maxAttempts; i < failures.Failures && lockout < maxLockoutDuration; i++ {
+		lockout *= 2
+	}
+	if lockout > maxLockoutDuration {
+		lockout = maxLockoutDuration
+	}
+	return failures.LastFailedAt.Add(lockout)
+}
+
+type unlockUserRequest struct {
+	Username string `uri:"username" binding:"required,alphanum"`
+}
+
+// unlockUser godoc
+// @Summary Unlock User
+// @Description Clear the failed login attempts of a locked out user, admin only
+// @Tags admin
+// @Accept  json
+// @Produce  json
+// @Security authorization
+// @Param username path string true "user name"
+// @Success 200 {object} userResponse
+// @Failure 400 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /admin/users/:username/unlock [post]
+func (c *UserController) UnlockUser(ctx *gin.Context) {
+	var req unlockUserRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.Error(apperror.FromBinding(err))
+		return
+	}
+	user, err := c.store.GetUser(ctx, req.Username)
+	if err != nil {
+		ctx.Error(apperror.From(err))
+		return
+	}
+	err = c.store.ClearLoginFailures(ctx, user.Username)
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, newUserResponse(user))
+}
+
+type setUserTierRequest struct {
+	Tier string `json:"tier" binding:"required,oneof=standard premium private"`
+}
+
+// setUserTier godoc
+// @Summary Set User Tier
+// @Description Set the tier of a user, fees are waived per tier of the owner of an account, admin only
+// @Tags admin
+// @Accept  json
+// @Produce  json
+// @Security authorization
+// @Param username path string true "user name"
+// @Param tier body string true "standard, premium or private"
+// @Success 200 {object} userResponse
+// @Failure 400 {object} apperror.Problem
+// @Failure 403 {object} apperror.Problem
+// @Failure 404 {object} apperror.Problem
+// @Router /admin/users/:username/tier [put]
+func (c *UserController) SetUserTier(ctx *gin.Context) {
+	var uri unlockUserRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.Error(apperror.FromBinding(err))
+		return
+	}
+	var req setUserTierRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.FromBinding(err))
+		return
+	}
+	user, err := c.store.SetUserTier(ctx, db.SetUserTierParams{
+		Tier:     req.Tier,
+		Username: uri.Username,
+	})
+	if err != nil {
+		ctx.Error(apperror.From(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, newUserResponse(user))
+}
+
+type changePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,password"`
+}
+
+// changePassword godoc
+// @Summary Change Password
+// @Description Change the password of the current user, every other session is revoked and a new access token is returned
+// @Tags users
+// @Accept  json
+// @Produce  json
+// @Security authorization
+// @Param current_password body string true "current password"
+// @Param new_password body string true "new password, it must meet the password policy and not appear in a data breach"
+// @Success 200 {object} loginUserResponse
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 423 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /users/me/password [put]
+func (c *UserController) ChangePassword(ctx *gin.Context) {
+	var req changePasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.FromBinding(err))
+		return
+	}
+	user := ctx.MustGet(constants.AuthUserKey).(db.User)
+
+	// a stolen access token must not allow guessing the password
+	if lockedOut(ctx, c.store, c.config, user.Username) {
+		return
+	}
+	err := c.hasher.Check(req.CurrentPassword, user.HashedPassword)
+	if err != nil {
+		loginFailed(ctx, c.store, user.Username, &apperror.Error{Code: apperror.CodeUnauthorized, Message: "current password is incorrect", Err: err})
+		return
+	}
+
+	if err := c.checkNewPassword(ctx, "new_password", req.NewPassword, user); err != nil {
+		ctx.Error(err)
+		return
+	}
+
+	user, err = c.updatePassword(ctx, user.Username, req.NewPassword)
+	if err != nil {
+		ctx.Error(apperror.From(err))
+		return
+	}
+
+	// tokens issued before the change are revoked, hand out a new one for this session
+	accessToken, err := c.tokenMaker.CreateToken(user.Username, c.config.AccessTokenDuration)
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, loginUserResponse{
+		AccessToken: accessToken,
+		User:        newUserResponse(user),
+	})
+}
+
+// checkNewPassword checks a new password of an existing user
+func (c *UserController) checkNewPassword(ctx *gin.Context, field string, password string, user db.User) error {
+	if err := c.checkPersonalInfo(field, password, user); err != nil {
+		return err
+	}
+	return c.checkBreached(ctx, field, password)
+}
+
+// checkPersonalInfo rejects passwords containing the username or email of the user,
+// the binding of requests without those fields cannot check it
+func (c *UserController) checkPersonalInfo(field string, password string, user db.User) error {
+	if err := c.policy.Check(password, user.Username, user.Email); err != nil {
+		return apperror.Validation("request validation failed", apperror.FieldError{Field: field, Rule: "password", Message: err.Error()})
+	}
+	return nil
+}
+
+// checkBreached rejects passwords which are known from a data breach
+func (c *UserController) checkBreached(ctx *gin.Context, field string, password string) error {
+	breached, err := c.breach.Breached(ctx, password)
+	if err != nil {
+		return apperror.Internal(err)
+	}
+	if breached {
+		return apperror.Validation("request validation failed", apperror.FieldError{
+			Field:   field,
+			Rule:    "breached",
+			Message: "has appeared in a data breach, choose another password",
+		})
+	}
+	return nil
+}
+
+// updatePassword stores the new password, which revokes every token issued before
+// and the pending password resets
+func (c *UserController) updatePassword(ctx *gin.Context, username string, password string) (db.User, error) {
+	hashedPassword, err := c.hasher.Hash(password)
+	if err != nil {
+		return db.User{}, apperror.Internal(err)
+	}
+	return c.store.ChangePasswordTx(ctx, db.UpdateUserPasswordParams{
+		HashedPassword:    hashedPassword,
+		PasswordChangedAt: time.Now(),
+		Username:          username,
+	})
+}
+
+type requestPasswordResetRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// requestPasswordReset godoc
+// @Summary Request Password Reset
+// @Description Email a single-use password reset token. Only verified emails receive a token, the response is the same whether the email is registered or not.
+// @Tags users
+// @Accept  json
+// @Produce  json
+// @Param email body string true "email"
+// @Success 202 {object} gin.H
+// @Failure 400 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /users/password_reset [post]
+func (c *UserController) RequestPasswordReset(ctx *gin.Context) {
+	var req requestPasswordResetRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.FromBinding(err))
+		return
+	}
+	accepted := gin.H{"message": "if the email is registered, a reset token has been sent"}
+
+	user, err := c.store.GetUserByEmail(ctx, req.Email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.JSON(http.StatusAccepted, accepted)
+			return
+		}
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+	// only a verified email proves that it belongs to the user
+	if !user.IsEmailVerified {
+		ctx.JSON(http.StatusAccepted, accepted)
+		return
+	}
+
+	resetToken, err := util.RandomToken(32)
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+	_, err = c.store.CreatePasswordResetToken(ctx, db.CreatePasswordResetTokenParams{
+		Username:  user.Username,
+		TokenHash: util.HashToken(resetToken),
+		ExpiresAt: time.Now().Add(c.config.PasswordResetTokenDuration),
+	})
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+
+	err = c.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Reset your Simple Bank password",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nUse the following token to reset your password, it expires in %s:\n\n%s\n\nSubmit it to POST /users/password_reset/confirm. If you did not request a reset, ignore this email.",
+			user.FullName, c.config.PasswordResetTokenDuration, resetToken,
+		),
+	})
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+	ctx.JSON(http.StatusAccepted, accepted)
+}
+
+type resetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,password"`
+}
+
+// resetPassword godoc
+// @Summary Reset Password
+// @Description Set a new password with a reset token, every session of the user is revoked
+// @Tags users
+// @Accept  json
+// @Produce  json
+// @Param token body string true "reset token"
+// @Param new_password body string true "new password, it must meet the password policy and not appear in a data breach"
+// @Success 200 {object} userResponse
+// @Failure 400 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /users/password_reset/confirm [post]
+func (c *UserController) ResetPassword(ctx *gin.Context) {
+	var req resetPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.FromBinding(err))
+		return
+	}
+	if err := c.checkBreached(ctx, "new_password", req.NewPassword); err != nil {
+		ctx.Error(err)
+		return
+	}
+	hashedPassword, err := c.hasher.Hash(req.NewPassword)
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+
+	user, err := c.store.ResetPasswordTx(ctx, db.ResetPasswordTxParams{
+		TokenHash:         util.HashToken(req.Token),
+		HashedPassword:    hashedPassword,
+		PasswordChangedAt: time.Now(),
+		// the owner of the token is only known here, the token is kept when the password is rejected
+		BeforeUpdate: func(user db.User) error {
+			return c.checkPersonalInfo("new_password", req.NewPassword, user)
+		},
+	})
+	if err != nil {
+		var appErr *apperror.Error
+		if errors.As(err, &appErr) {
+			ctx.Error(appErr)
+			return
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.Error(&apperror.Error{
+				Code:    apperror.CodeValidation,
+				Message: "reset token is invalid or expired",
+				Fields:  []apperror.FieldError{{Field: "token", Rule: "valid", Message: "is invalid or expired"}},
+				Err:     err,
+			})
+			return
+		}
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, newUserResponse(user))
+}
+
+// sendVerifyEmail emails the verification token to the user
+func (c *UserController) sendVerifyEmail(ctx *gin.Context, user db.User, email string, verifyToken string) error {
+	return c.mailer.Send(ctx, mail.Message{
+		To:      email,
+		Subject: "Verify your Simple Bank email",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nUse the following token to verify your email, it expires in %s:\n\n%s\n\nSubmit it to GET /users/verify_email?token=<token>.",
+			user.FullName, c.config.EmailVerificationTokenDuration, verifyToken,
+		),
+	})
+}
+
+type verifyEmailRequest struct {
+	Token string `form:"token" binding:"required"`
+}
+
+// verifyEmail godoc
+// @Summary Verify Email
+// @Description Verify the email of a user with the token sent by email, a verified pending email replaces the email
+// @Tags users
+// @Produce  json
+// @Param token query string true "verification token"
+// @Success 200 {object} userResponse
+// @Failure 400 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /users/verify_email [get]
+func (c *UserController) VerifyEmail(ctx *gin.Context) {
+	var req verifyEmailRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.Error(apperror.FromBinding(err))
+		return
+	}
+
+	user, err := c.store.VerifyEmailTx(ctx, util.HashToken(req.Token))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ctx.Error(&apperror.Error{
+				Code:    apperror.CodeValidation,
+				Message: "verification token is invalid or expired",
+				Fields:  []apperror.FieldError{{Field: "token", Rule: "valid", Message: "is invalid or expired"}},
+				Err:     err,
+			})
+			return
+		}
+		// another user took the pending email since it was requested
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code.Name() == "unique_violation" {
+			ctx.Error(&apperror.Error{Code: apperror.CodeConflict, Message: "email already exists", Err: err})
+			return
+		}
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, newUserResponse(user))
+}
+
+// resendVerifyEmail godoc
+// @Summary Resend Verification Email
+// @Description Send a new email verification token to the current user
+// @Tags users
+// @Produce  json
+// @Security authorization
+// @Success 202 {object} gin.H
+// @Failure 401 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /users/me/verify_email [post]
+func (c *UserController) ResendVerifyEmail(ctx *gin.Context) {
+	user := ctx.MustGet(constants.AuthUserKey).(db.User)
+	if user.IsEmailVerified {
+		ctx.Error(apperror.Conflict("email is already verified"))
+		return
+	}
+
+	verifyToken, err := util.RandomToken(32)
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+	_, err = c.store.CreateVerifyEmail(ctx, db.CreateVerifyEmailParams{
+		Username:  user.Username,
+		Email:     user.Email,
+		TokenHash: util.HashToken(verifyToken),
+		ExpiresAt: time.Now().Add(c.config.EmailVerificationTokenDuration),
+	})
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+
+	err = c.sendVerifyEmail(ctx, user, user.Email, verifyToken)
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+	ctx.JSON(http.StatusAccepted, gin.H{"message": "a verification token has been sent"})
+}
+
+// getProfile godoc
+// @Summary Get Profile
+// @Description Get the profile of the current user
+// @Tags users
+// @Produce  json
+// @Security authorization
+// @Success 200 {object} userResponse
+// @Failure 401 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /users/me [get]
+func (c *UserController) GetProfile(ctx *gin.Context) {
+	user := ctx.MustGet(constants.AuthUserKey).(db.User)
+	ctx.JSON(http.StatusOK, newUserResponse(user))
+}
+
+// omitted fields are left unchanged
+type updateProfileRequest struct {
+	FullName *string `json:"full_name" binding:"omitempty,min=1"`
+	Email    *string `json:"email" binding:"omitempty,email"`
+	// CurrentPassword is required to change the email
+	CurrentPassword string `json:"current_password"`
+}
+
+// updateProfile godoc
+// @Summary Update Profile
+// @Description Update the full name or email of the current user. Changing the email requires the current password,
+// @Description the new email is pending and only replaces the email when the token sent to it is used.
+// @Tags users
+// @Accept  json
+// @Produce  json
+// @Security authorization
+// @Param full_name body string false "full name"
+// @Param email body string false "email"
+// @Param current_password body string false "current password, required with email"
+// @Success 200 {object} userResponse
+// @Failure 400 {object} apperror.Problem
+// @Failure 401 {object} apperror.Problem
+// @Failure 409 {object} apperror.Problem
+// @Failure 423 {object} apperror.Problem
+// @Failure 500 {object} apperror.Problem
+// @Router /users/me [patch]
+func (c *UserController) UpdateProfile(ctx *gin.Context) {
+	var req updateProfileRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.Error(apperror.FromBinding(err))
+		return
+	}
+	user := ctx.MustGet(constants.AuthUserKey).(db.User)
+
+	arg := db.UpdateUserTxParams{
+		UpdateUserParams: db.UpdateUserParams{Username: user.Username},
+	}
+	if req.FullName != nil && *req.FullName != user.FullName {
+		arg.FullName = sql.NullString{String: *req.FullName, Valid: true}
+	}
+	if req.Email != nil && *req.Email != user.Email {
+		if !c.checkEmailChange(ctx, user, *req.Email, req.CurrentPassword) {
+			return
+		}
+		verifyToken, err := util.RandomToken(32)
+		if err != nil {
+			ctx.Error(apperror.Internal(err))
+			return
+		}
+		arg.PendingEmail = sql.NullString{String: *req.Email, Valid: true}
+		arg.VerifyEmailTokenHash = util.HashToken(verifyToken)
+		arg.VerifyEmailExpiresAt = time.Now().Add(c.config.EmailVerificationTokenDuration)
+		// the email is not pending when the verification email cannot be sent
+		arg.AfterUpdate = func(user db.User) error {
+			return c.sendVerifyEmail(ctx, user, user.PendingEmail, verifyToken)
+		}
+	}
+	if !arg.FullName.Valid && !arg.PendingEmail.Valid {
+		ctx.JSON(http.StatusOK, newUserResponse(user))
+		return
+	}
+
+	result, err := c.store.UpdateUserTx(ctx, arg)
+	if err != nil {
+		ctx.Error(apperror.Internal(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, newUserResponse(result.User))
+}
+
+// checkEmailChange checks the current password like a login, so a stolen token can't change the email
+// and take over the account with a password reset. Emails of other users are rejected.
+func (c *UserController) checkEmailChange(ctx *gin.Context, user db.User, email string, currentPassword string) bool {
+	if currentPassword == "" {
+		ctx.Error(apperror.Validation("request validation failed", apperror.FieldError{
+			Field:   "current_password",
+			Rule:    "required",
+			Message: "is required to change the email",
+		}))
+		return false
+	}
+	if lockedOut(ctx, c.store, c.config, user.Username) {
+		return false
+	}
+	if err := c.hasher.Check(currentPassword, user.HashedPassword); err != nil {
+		loginFailed(ctx, c.store, user.Username, &apperror.Error{Code: apperror.CodeUnauthorized, Message: "current password is incorrect", Err: err})
+		return false
+	}
+
+	_, err := c.store.GetUserByEmail(ctx, email)
+	if err == nil {
+		ctx.Error(apperror.Conflict("email already exists"))
+		return false
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		ctx.Error(apperror.Internal(err))
+		return false
+	}
+	return true
+}
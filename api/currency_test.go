@@ -0,0 +1,36 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/hhow09/simple_bank/db/mock"
+	"github.com/hhow09/simple_bank/money"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListCurrenciesAPI(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodGet, "/currencies", nil)
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var currencies []money.Currency
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &currencies))
+	require.Equal(t, []money.Currency{
+		{Code: "CAD", Exponent: 2, Symbol: "CA$", Rounding: money.RoundHalfEven},
+		{Code: "EUR", Exponent: 2, Symbol: "€", Rounding: money.RoundHalfEven},
+		{Code: "USD", Exponent: 2, Symbol: "$", Rounding: money.RoundHalfEven},
+	}, currencies)
+}
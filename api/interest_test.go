@@ -0,0 +1,301 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/hhow09/simple_bank/apperror"
+	"github.com/hhow09/simple_bank/constants"
+	mockdb "github.com/hhow09/simple_bank/db/mock"
+	db "github.com/hhow09/simple_bank/db/sqlc"
+	"github.com/hhow09/simple_bank/token"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateInterestRateAPI(t *testing.T) {
+	admin, _ := randomUser(t)
+	admin.Role = constants.RoleAdmin
+	user, _ := randomUser(t)
+	tomorrow := time.Now().UTC().AddDate(0, 0, 1)
+	effectiveFrom := time.Date(tomorrow.Year(), tomorrow.Month(), tomorrow.Day(), 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name          string
+		username      string
+		body          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: admin.Username,
+			body:     fmt.Sprintf(`{"account_type": "savings", "currency": "USD", "annual_rate_bps": 125, "effective_from": "%s"}`, effectiveFrom.Format("2006-01-02")),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(admin.Username)).Times(1).Return(admin, nil)
+				arg := db.CreateInterestRateParams{
+					AccountType:   constants.AccountTypeSavings,
+					Currency:      "USD",
+					AnnualRateBps: 125,
+					EffectiveFrom: effectiveFrom,
+				}
+				store.EXPECT().CreateInterestRate(gomock.Any(), gomock.Eq(arg)).Times(1).
+					Return(db.InterestRate{ID: 1, AccountType: arg.AccountType, Currency: arg.Currency, AnnualRateBps: arg.AnnualRateBps, EffectiveFrom: arg.EffectiveFrom}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got map[string]interface{}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, effectiveFrom.Format("2006-01-02"), got["effective_from"])
+				require.Equal(t, float64(125), got["annual_rate_bps"])
+			},
+		},
+		{
+			name:     "Backdated",
+			username: admin.Username,
+			body:     `{"account_type": "savings", "currency": "USD", "annual_rate_bps": 125, "effective_from": "2020-01-01"}`,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(admin.Username)).Times(1).Return(admin, nil)
+				store.EXPECT().CreateInterestRate(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, apperror.CodeValidation)
+			},
+		},
+		{
+			name:     "InvalidDate",
+			username: admin.Username,
+			body:     `{"account_type": "savings", "currency": "USD", "annual_rate_bps": 125, "effective_from": "01/01/2030"}`,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(admin.Username)).Times(1).Return(admin, nil)
+				store.EXPECT().CreateInterestRate(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, apperror.CodeValidation)
+			},
+		},
+		{
+			name:     "InvalidRate",
+			username: admin.Username,
+			body:     `{"account_type": "savings", "currency": "USD", "annual_rate_bps": -5, "effective_from": "2030-01-01"}`,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(admin.Username)).Times(1).Return(admin, nil)
+				store.EXPECT().CreateInterestRate(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, apperror.CodeValidation)
+			},
+		},
+		{
+			name:     "UnsupportedCurrency",
+			username: admin.Username,
+			body:     `{"account_type": "checking", "currency": "XYZ", "annual_rate_bps": 5, "effective_from": "2030-01-01"}`,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(admin.Username)).Times(1).Return(admin, nil)
+				store.EXPECT().CreateInterestRate(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, apperror.CodeValidation)
+			},
+		},
+		{
+			name:     "NotAdmin",
+			username: user.Username,
+			body:     `{"account_type": "savings", "currency": "USD", "annual_rate_bps": 125, "effective_from": "2030-01-01"}`,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().CreateInterestRate(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusForbidden, apperror.CodeForbidden)
+			},
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodPost, "/admin/interest_rates", bytes.NewReader([]byte(tc.body)))
+			require.NoError(t, err)
+
+			addAuth(t, request, server.tokenMaker, constants.AuthTypeBearer, tc.username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestDeleteInterestRateAPI(t *testing.T) {
+	admin, _ := randomUser(t)
+	admin.Role = constants.RoleAdmin
+	future := db.InterestRate{ID: 1, AccountType: constants.AccountTypeSavings, Currency: "USD", AnnualRateBps: 125, EffectiveFrom: time.Now().UTC().AddDate(0, 0, 2)}
+	effective := db.InterestRate{ID: 2, AccountType: constants.AccountTypeSavings, Currency: "USD", AnnualRateBps: 100, EffectiveFrom: time.Now().UTC().AddDate(0, 0, -2)}
+
+	testCases := []struct {
+		name          string
+		id            int64
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			id:   future.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetInterestRate(gomock.Any(), gomock.Eq(future.ID)).Times(1).Return(future, nil)
+				store.EXPECT().DeleteInterestRate(gomock.Any(), gomock.Eq(future.ID)).Times(1).Return(future, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "AlreadyEffective",
+			id:   effective.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetInterestRate(gomock.Any(), gomock.Eq(effective.ID)).Times(1).Return(effective, nil)
+				store.EXPECT().DeleteInterestRate(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusConflict, apperror.CodeConflict)
+			},
+		},
+		{
+			name: "NotFound",
+			id:   3,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetInterestRate(gomock.Any(), gomock.Eq(int64(3))).Times(1).Return(db.InterestRate{}, sql.ErrNoRows)
+				store.EXPECT().DeleteInterestRate(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusNotFound, apperror.CodeNotFound)
+			},
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetUser(gomock.Any(), gomock.Eq(admin.Username)).Times(1).Return(admin, nil)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("/admin/interest_rates/%d", tc.id), nil)
+			require.NoError(t, err)
+
+			addAuth(t, request, server.tokenMaker, constants.AuthTypeBearer, admin.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestListInterestPostingsAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+	account.Currency = "USD"
+	postings := []db.InterestPosting{
+		{
+			ID:                   1,
+			AccountID:            account.ID,
+			Month:                time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+			AccruedMicros:        1_234_567_890,
+			Amount:               1235,
+			JournalTransactionID: sql.NullInt64{Int64: 9, Valid: true},
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuth(t, request, tokenMaker, constants.AuthTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				arg := db.ListInterestPostingsParams{AccountID: account.ID, Limit: 12, Offset: 0}
+				store.EXPECT().ListInterestPostings(gomock.Any(), arg).Times(1).Return(postings, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var rsp []map[string]interface{}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Len(t, rsp, 1)
+				require.Equal(t, "2024-02", rsp[0]["month"])
+				require.Equal(t, "12.34567890", rsp[0]["accrued"])
+				require.Equal(t, "12.35", rsp[0]["amount_decimal"])
+				require.Equal(t, float64(9), rsp[0]["journal_transaction_id"])
+			},
+		},
+		{
+			name: "Not Member",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuth(t, request, tokenMaker, constants.AuthTypeBearer, "unauthorized_user", time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(account, nil)
+				store.EXPECT().ListInterestPostings(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusForbidden, apperror.CodeForbidden)
+			},
+		},
+		{
+			name: "Not Found",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuth(t, request, tokenMaker, constants.AuthTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), account.ID).Times(1).Return(db.Account{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusNotFound, apperror.CodeNotFound)
+			},
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			stubAuthUsers(store)
+			stubAccountMembers(store, account)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/accounts/%d/interest", account.ID), nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -0,0 +1,301 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/hhow09/simple_bank/apperror"
+	"github.com/hhow09/simple_bank/constants"
+	mockdb "github.com/hhow09/simple_bank/db/mock"
+	db "github.com/hhow09/simple_bank/db/sqlc"
+	"github.com/hhow09/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateJournalTransactionAPI(t *testing.T) {
+	admin, _ := randomUser(t)
+	admin.Role = constants.RoleAdmin
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	deposit := gin.H{
+		"kind":        constants.JournalKindDeposit,
+		"description": "cash deposit",
+		"postings": []gin.H{
+			{"account_id": account.ID, "amount": 1000},
+			{"ledger_account": constants.LedgerAccountCash, "currency": account.Currency, "amount": -1000},
+		},
+	}
+	depositParams := db.JournalTxParams{
+		Kind:        constants.JournalKindDeposit,
+		Description: "cash deposit",
+		Postings: []db.PostingParams{
+			{AccountID: account.ID, Amount: 1000},
+			{LedgerAccount: constants.LedgerAccountCash, Currency: account.Currency, Amount: -1000},
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		username      string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: admin.Username,
+			body:     deposit,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(admin.Username)).Times(1).Return(admin, nil)
+				result := db.JournalTxResult{
+					Transaction: db.JournalTransaction{ID: 1, Kind: constants.JournalKindDeposit, Description: "cash deposit"},
+					Postings: []db.Posting{
+						{ID: 1, JournalTransactionID: 1, AccountID: sql.NullInt64{Int64: account.ID, Valid: true}, EntryID: sql.NullInt64{Int64: 7, Valid: true}, Currency: account.Currency, Amount: 1000},
+						{ID: 2, JournalTransactionID: 1, LedgerAccountID: sql.NullInt64{Int64: 3, Valid: true}, Currency: account.Currency, Amount: -1000},
+					},
+				}
+				store.EXPECT().JournalTx(gomock.Any(), gomock.Eq(depositParams)).Times(1).Return(result, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got struct {
+					ID       int64 `json:"id"`
+					Postings []struct {
+						AccountID       *int64 `json:"account_id"`
+						LedgerAccountID *int64 `json:"ledger_account_id"`
+						Amount          int64  `json:"amount"`
+					} `json:"postings"`
+				}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, int64(1), got.ID)
+				require.Len(t, got.Postings, 2)
+				require.Equal(t, account.ID, *got.Postings[0].AccountID)
+				require.Nil(t, got.Postings[0].LedgerAccountID)
+				require.Nil(t, got.Postings[1].AccountID)
+				require.Equal(t, int64(-1000), got.Postings[1].Amount)
+			},
+		},
+		{
+			name:     "NotAdmin",
+			username: user.Username,
+			body:     deposit,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().JournalTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusForbidden, apperror.CodeForbidden)
+			},
+		},
+		{
+			name:     "Unbalanced",
+			username: admin.Username,
+			body:     deposit,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(admin.Username)).Times(1).Return(admin, nil)
+				store.EXPECT().JournalTx(gomock.Any(), gomock.Any()).Times(1).Return(db.JournalTxResult{}, fmt.Errorf("%w: postings in USD sum to 1", db.ErrUnbalancedJournal))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, apperror.CodeValidation)
+			},
+		},
+		{
+			name:     "CurrencyMismatch",
+			username: admin.Username,
+			body:     deposit,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(admin.Username)).Times(1).Return(admin, nil)
+				store.EXPECT().JournalTx(gomock.Any(), gomock.Any()).Times(1).Return(db.JournalTxResult{}, db.ErrPostingCurrencyMismatch)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusUnprocessableEntity, apperror.CodeCurrencyMismatch)
+			},
+		},
+		{
+			name:     "AccountNotFound",
+			username: admin.Username,
+			body:     deposit,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(admin.Username)).Times(1).Return(admin, nil)
+				store.EXPECT().JournalTx(gomock.Any(), gomock.Any()).Times(1).Return(db.JournalTxResult{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusNotFound, apperror.CodeNotFound)
+			},
+		},
+		{
+			name:     "TransferKind",
+			username: admin.Username,
+			body: gin.H{
+				"kind":     constants.JournalKindTransfer,
+				"postings": deposit["postings"],
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(admin.Username)).Times(1).Return(admin, nil)
+				store.EXPECT().JournalTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, apperror.CodeValidation)
+			},
+		},
+		{
+			name:     "SinglePosting",
+			username: admin.Username,
+			body: gin.H{
+				"kind":     constants.JournalKindAdjustment,
+				"postings": []gin.H{{"account_id": account.ID, "amount": 1000}},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(admin.Username)).Times(1).Return(admin, nil)
+				store.EXPECT().JournalTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, apperror.CodeValidation)
+			},
+		},
+		{
+			name:     "UnknownLedgerAccount",
+			username: admin.Username,
+			body: gin.H{
+				"kind": constants.JournalKindAdjustment,
+				"postings": []gin.H{
+					{"account_id": account.ID, "amount": 1000},
+					{"ledger_account": "bonus", "currency": account.Currency, "amount": -1000},
+				},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(admin.Username)).Times(1).Return(admin, nil)
+				store.EXPECT().JournalTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, apperror.CodeValidation)
+			},
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/admin/journal_transactions", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuth(t, request, server.tokenMaker, constants.AuthTypeBearer, tc.username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestGetJournalTransactionAPI(t *testing.T) {
+	admin, _ := randomUser(t)
+	admin.Role = constants.RoleAdmin
+	transaction := db.JournalTransaction{
+		ID:         util.RandomInt(1, 1000),
+		Kind:       constants.JournalKindTransfer,
+		TransferID: sql.NullInt64{Int64: util.RandomInt(1, 1000), Valid: true},
+	}
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetJournalTransaction(gomock.Any(), gomock.Eq(transaction.ID)).Times(1).Return(transaction, nil)
+				store.EXPECT().ListPostings(gomock.Any(), gomock.Eq(transaction.ID)).Times(1).Return([]db.Posting{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got struct {
+					TransferID int64 `json:"transfer_id"`
+				}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, transaction.TransferID.Int64, got.TransferID)
+			},
+		},
+		{
+			name: "NotFound",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetJournalTransaction(gomock.Any(), gomock.Eq(transaction.ID)).Times(1).Return(db.JournalTransaction{}, sql.ErrNoRows)
+				store.EXPECT().ListPostings(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusNotFound, apperror.CodeNotFound)
+			},
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetUser(gomock.Any(), gomock.Eq(admin.Username)).AnyTimes().Return(admin, nil)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/admin/journal_transactions/%d", transaction.ID)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			addAuth(t, request, server.tokenMaker, constants.AuthTypeBearer, admin.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestListLedgerAccountsAPI(t *testing.T) {
+	admin, _ := randomUser(t)
+	admin.Role = constants.RoleAdmin
+	accounts := []db.LedgerAccount{
+		{ID: 1, Code: constants.LedgerAccountCash, Name: "Cash", Currency: util.USD, Balance: -1000},
+		{ID: 2, Code: constants.LedgerAccountFees, Name: "Fee income", Currency: util.USD, Balance: 25},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetUser(gomock.Any(), gomock.Eq(admin.Username)).Times(1).Return(admin, nil)
+	store.EXPECT().ListLedgerAccounts(gomock.Any()).Times(1).Return(accounts, nil)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodGet, "/admin/ledger_accounts", nil)
+	require.NoError(t, err)
+
+	addAuth(t, request, server.tokenMaker, constants.AuthTypeBearer, admin.Username, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var got []db.LedgerAccount
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+	require.Equal(t, accounts, got)
+}
@@ -1,13 +1,26 @@
 package api
 
 import (
+	"encoding/json"
+	"net/http/httptest"
 	"os"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/hhow09/simple_bank/accountnumber"
+	"github.com/hhow09/simple_bank/apperror"
+	"github.com/hhow09/simple_bank/breach"
 	db "github.com/hhow09/simple_bank/db/sqlc"
+	"github.com/hhow09/simple_bank/fee"
+	"github.com/hhow09/simple_bank/interest"
 	"github.com/hhow09/simple_bank/lib"
+	"github.com/hhow09/simple_bank/mail"
+	"github.com/hhow09/simple_bank/money"
+	"github.com/hhow09/simple_bank/ratelimit"
+	"github.com/hhow09/simple_bank/reconcile"
+	"github.com/hhow09/simple_bank/snapshot"
+	"github.com/hhow09/simple_bank/statement"
 	"github.com/hhow09/simple_bank/token"
 	"github.com/hhow09/simple_bank/util"
 	_ "github.com/lib/pq"
@@ -21,7 +34,8 @@ func TestMain(m *testing.M) {
 	os.Exit(m.Run())
 }
 
-func newTestServer(t *testing.T, mockstore db.Store) *Server {
+// newTestServer creates a server of the mock store, configure changes the config of app.env
+func newTestServer(t *testing.T, mockstore db.Store, configure ...func(*util.Config)) *Server {
 	var s *Server
 	fx.New(
 		fx.Provide(func() util.ConfigPath {
@@ -32,17 +46,54 @@ func newTestServer(t *testing.T, mockstore db.Store) *Server {
 			require.NoError(t, err)
 			config.TokenSymmetricKey = util.RandomString(32)
 			config.AccessTokenDuration = time.Minute
+			config.Mailer = mail.MailerFile
+			config.MailOutboxDir = t.TempDir()
+			for _, fn := range configure {
+				fn(&config)
+			}
 			return config
 		}),
+		fx.Provide(util.NewClock),
 		token.Module,
 		// user mock store
 		fx.Provide(func() db.Store {
 			return mockstore
 		}),
 		lib.Module,
+		ratelimit.Module,
+		mail.Module,
+		breach.Module,
+		accountnumber.Module,
+		money.Module,
+		reconcile.Module,
+		snapshot.Module,
+		statement.Module,
+		interest.Module,
+		fee.Module,
 		Module,
 		fx.Populate(&s),
 	)
 
 	return s
 }
+
+// requireProblem checks that the response is a problem+json document with the expected status and code
+func requireProblem(t *testing.T, recorder *httptest.ResponseRecorder, status int, code apperror.Code) {
+	require.Equal(t, status, recorder.Code)
+	require.Equal(t, apperror.ContentType, recorder.Header().Get("Content-Type"))
+
+	var problem apperror.Problem
+	err := json.Unmarshal(recorder.Body.Bytes(), &problem)
+	require.NoError(t, err)
+	require.Equal(t, status, problem.Status)
+	require.Equal(t, code, problem.Code)
+}
+
+// outbox reads the emails sent to the given address by the test server
+func outbox(t *testing.T, server *Server, to string) []mail.Message {
+	mailer, err := mail.NewFileMailer("", server.config.MailOutboxDir)
+	require.NoError(t, err)
+	messages, err := mailer.Messages(to)
+	require.NoError(t, err)
+	return messages
+}
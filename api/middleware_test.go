@@ -1,6 +1,8 @@
 package api
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -8,9 +10,14 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
 	"github.com/hhow09/simple_bank/api/middlewares"
+	"github.com/hhow09/simple_bank/apperror"
 	"github.com/hhow09/simple_bank/constants"
+	mockdb "github.com/hhow09/simple_bank/db/mock"
+	db "github.com/hhow09/simple_bank/db/sqlc"
 	"github.com/hhow09/simple_bank/token"
+	"github.com/hhow09/simple_bank/util"
 	"github.com/stretchr/testify/require"
 )
 
@@ -26,27 +33,82 @@ func addAuth(t *testing.T, request *http.Request, tokenMaker token.Maker, authTy
 	request.Header.Set(constants.AuthHeaderKey, authHeader)
 }
 
+// stubAuthUsers stubs the user lookup of the auth middleware for any username,
+// it must be called after the stubs of the test case so they take precedence
+func stubAuthUsers(store *mockdb.MockStore) {
+	store.EXPECT().
+		GetUser(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		DoAndReturn(func(_ context.Context, username string) (db.User, error) {
+			return db.User{Username: username}, nil
+		})
+}
+
 func TestAuthMiddleware(t *testing.T) {
+	user := db.User{Username: "user"}
+
 	testCases := []struct {
 		name          string
 		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
 		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
 	}{
 		{
 			name: "OK",
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuth(t, request, tokenMaker, constants.AuthTypeBearer, "user", time.Minute)
+				addAuth(t, request, tokenMaker, constants.AuthTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, recorder.Code, http.StatusOK)
 			},
 		},
+		{
+			name: "Revoked Token",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuth(t, request, tokenMaker, constants.AuthTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				changed := user
+				changed.PasswordChangedAt = time.Now().Add(time.Second)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(changed, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusUnauthorized, apperror.CodeUnauthorized)
+			},
+		},
+		{
+			name: "User Not Found",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuth(t, request, tokenMaker, constants.AuthTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(db.User{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusUnauthorized, apperror.CodeUnauthorized)
+			},
+		},
+		{
+			name: "Get User Error",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuth(t, request, tokenMaker, constants.AuthTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(db.User{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusInternalServerError, apperror.CodeInternal)
+			},
+		},
 		{
 			name: "No Auth",
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				require.Equal(t, recorder.Code, http.StatusUnauthorized)
+				requireProblem(t, recorder, http.StatusUnauthorized, apperror.CodeUnauthorized)
 			},
 		},
 		{
@@ -55,7 +117,7 @@ func TestAuthMiddleware(t *testing.T) {
 				addAuth(t, request, tokenMaker, "unsupported", "user", time.Minute)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				require.Equal(t, recorder.Code, http.StatusUnauthorized)
+				requireProblem(t, recorder, http.StatusUnauthorized, apperror.CodeUnauthorized)
 			},
 		},
 		{
@@ -64,7 +126,7 @@ func TestAuthMiddleware(t *testing.T) {
 				addAuth(t, request, tokenMaker, "", "user", time.Minute)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				require.Equal(t, recorder.Code, http.StatusUnauthorized)
+				requireProblem(t, recorder, http.StatusUnauthorized, apperror.CodeUnauthorized)
 			},
 		},
 		{
@@ -73,16 +135,24 @@ func TestAuthMiddleware(t *testing.T) {
 				addAuth(t, request, tokenMaker, constants.AuthTypeBearer, "user", -time.Minute)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				require.Equal(t, recorder.Code, http.StatusUnauthorized)
+				requireProblem(t, recorder, http.StatusUnauthorized, apperror.CodeUnauthorized)
 			},
 		},
 	}
 	for i := range testCases {
 		tc := testCases[i]
 		t.Run(tc.name, func(t *testing.T) {
-			server := newTestServer(t, nil)
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			if tc.buildStubs != nil {
+				tc.buildStubs(store)
+			}
+
+			server := newTestServer(t, store)
 			//setup simple test route
-			authMiddleware := middlewares.NewAuthMiddleware(server.tokenMaker)
+			authMiddleware := middlewares.NewAuthMiddleware(server.tokenMaker, store)
 			server.router.GET(authPath, authMiddleware.Handler(), func(ctx *gin.Context) {
 				//simple response
 				ctx.JSON(http.StatusOK, gin.H{})
@@ -98,3 +168,361 @@ func TestAuthMiddleware(t *testing.T) {
 
 	}
 }
+
+func TestVerifiedEmailMiddleware(t *testing.T) {
+	unverified := db.User{Username: "user"}
+	verified := db.User{Username: "user", IsEmailVerified: true}
+
+	testCases := []struct {
+		name          string
+		required      bool
+		user          db.User
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "Verified",
+			required: true,
+			user:     verified,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "Not Verified",
+			required: true,
+			user:     unverified,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusForbidden, apperror.CodeEmailNotVerified)
+			},
+		},
+		{
+			name:     "Not Required",
+			required: false,
+			user:     unverified,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetUser(gomock.Any(), gomock.Eq(tc.user.Username)).Times(1).Return(tc.user, nil)
+
+			server := newTestServer(t, store)
+			config := server.config
+			config.EmailVerificationRequired = tc.required
+			//setup simple test route
+			authMiddleware := middlewares.NewAuthMiddleware(server.tokenMaker, store)
+			verifiedEmailMiddleware := middlewares.NewVerifiedEmailMiddleware(config)
+			server.router.GET(authPath, authMiddleware.Handler(), verifiedEmailMiddleware.Handler(), func(ctx *gin.Context) {
+				//simple response
+				ctx.JSON(http.StatusOK, gin.H{})
+			})
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, authPath, nil)
+			require.NoError(t, err)
+
+			addAuth(t, request, server.tokenMaker, constants.AuthTypeBearer, tc.user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+// randomAPIKey returns the key and its stored row for the user
+func randomAPIKey(t *testing.T, username string, scopes ...string) (string, db.ApiKey) {
+	key, prefix, secret, err := util.NewAPIKey()
+	require.NoError(t, err)
+	return key, db.ApiKey{
+		ID:         util.RandomInt(1, 1000),
+		Username:   username,
+		Name:       "integration",
+		Prefix:     prefix,
+		SecretHash: util.HashToken(secret),
+		Scopes:     scopes,
+		CreatedAt:  time.Now(),
+	}
+}
+
+func addAPIKeyAuth(request *http.Request, key string) {
+	request.Header.Set(constants.AuthHeaderKey, "ApiKey "+key)
+}
+
+func TestAPIKeyAuthMiddleware(t *testing.T) {
+	user := db.User{Username: "user"}
+	key, apiKey := randomAPIKey(t, user.Username, constants.ScopeAccountsRead)
+
+	revoked := apiKey
+	revoked.RevokedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	expired := apiKey
+	expired.ExpiresAt = sql.NullTime{Time: time.Now().Add(-time.Minute), Valid: true}
+	notExpired := apiKey
+	notExpired.ExpiresAt = sql.NullTime{Time: time.Now().Add(time.Minute), Valid: true}
+
+	testCases := []struct {
+		name          string
+		key           string
+		scopes        []string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "OK",
+			key:    key,
+			scopes: []string{constants.ScopeAccountsRead},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAPIKeyByPrefix(gomock.Any(), gomock.Eq(apiKey.Prefix)).Times(1).Return(notExpired, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().TouchAPIKey(gomock.Any(), gomock.Eq(apiKey.ID)).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:   "Missing Scope",
+			key:    key,
+			scopes: []string{constants.ScopeTransfersCreate},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAPIKeyByPrefix(gomock.Any(), gomock.Any()).Times(1).Return(apiKey, nil)
+				store.EXPECT().TouchAPIKey(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusForbidden, apperror.CodeForbidden)
+			},
+		},
+		{
+			name: "Route Without Scopes",
+			key:  key,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAPIKeyByPrefix(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusForbidden, apperror.CodeForbidden)
+			},
+		},
+		{
+			name:   "Revoked",
+			key:    key,
+			scopes: []string{constants.ScopeAccountsRead},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAPIKeyByPrefix(gomock.Any(), gomock.Any()).Times(1).Return(revoked, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusUnauthorized, apperror.CodeUnauthorized)
+			},
+		},
+		{
+			name:   "Expired",
+			key:    key,
+			scopes: []string{constants.ScopeAccountsRead},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAPIKeyByPrefix(gomock.Any(), gomock.Any()).Times(1).Return(expired, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusUnauthorized, apperror.CodeUnauthorized)
+			},
+		},
+		{
+			name:   "Wrong Secret",
+			key:    "sb_" + apiKey.Prefix + "_wrong",
+			scopes: []string{constants.ScopeAccountsRead},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAPIKeyByPrefix(gomock.Any(), gomock.Eq(apiKey.Prefix)).Times(1).Return(apiKey, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusUnauthorized, apperror.CodeUnauthorized)
+			},
+		},
+		{
+			name:   "Unknown Key",
+			key:    key,
+			scopes: []string{constants.ScopeAccountsRead},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAPIKeyByPrefix(gomock.Any(), gomock.Any()).Times(1).Return(db.ApiKey{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusUnauthorized, apperror.CodeUnauthorized)
+			},
+		},
+		{
+			name:   "Malformed Key",
+			key:    "not-a-key",
+			scopes: []string{constants.ScopeAccountsRead},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAPIKeyByPrefix(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusUnauthorized, apperror.CodeUnauthorized)
+			},
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			//setup simple test route
+			authMiddleware := middlewares.NewAuthMiddleware(server.tokenMaker, store)
+			server.router.GET(authPath, authMiddleware.Handler(tc.scopes...), func(ctx *gin.Context) {
+				//simple response
+				ctx.JSON(http.StatusOK, gin.H{})
+			})
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, authPath, nil)
+			require.NoError(t, err)
+
+			addAPIKeyAuth(request, tc.key)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func addScopedAuth(t *testing.T, request *http.Request, tokenMaker token.Maker, username string, clientID string, scopes ...string) {
+	token, err := tokenMaker.CreateScopedToken(username, clientID, scopes, time.Minute)
+	require.NoError(t, err)
+
+	request.Header.Set(constants.AuthHeaderKey, fmt.Sprintf("%s %s", constants.AuthTypeBearer, token))
+}
+
+func TestScopedTokenAuthMiddleware(t *testing.T) {
+	user := db.User{Username: "user"}
+	client := db.OauthClient{ID: util.RandomString(16), Owner: "owner", Scopes: []string{constants.ScopeAccountsRead}}
+	ownClient := db.OauthClient{ID: client.ID, Owner: user.Username, Scopes: client.Scopes}
+	consent := db.OauthConsent{
+		Username:  user.Username,
+		ClientID:  client.ID,
+		Scopes:    []string{constants.ScopeAccountsRead},
+		CreatedAt: time.Now().Add(-time.Hour),
+	}
+	newerConsent := consent
+	newerConsent.CreatedAt = time.Now().Add(time.Minute)
+
+	testCases := []struct {
+		name          string
+		scopes        []string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "OK",
+			scopes: []string{constants.ScopeAccountsRead},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Eq(client.ID)).Times(1).Return(client, nil)
+				store.EXPECT().
+					GetOAuthConsent(gomock.Any(), gomock.Eq(db.GetOAuthConsentParams{Username: user.Username, ClientID: client.ID})).
+					Times(1).
+					Return(consent, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			// client credentials tokens act for the owner of the client without a consent
+			name:   "Client Owner",
+			scopes: []string{constants.ScopeAccountsRead},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Eq(client.ID)).Times(1).Return(ownClient, nil)
+				store.EXPECT().GetOAuthConsent(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "Route Without Scopes",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusForbidden, apperror.CodeForbidden)
+			},
+		},
+		{
+			name:   "Missing Scope",
+			scopes: []string{constants.ScopeTransfersCreate},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusForbidden, apperror.CodeForbidden)
+			},
+		},
+		{
+			name:   "Client Deleted",
+			scopes: []string{constants.ScopeAccountsRead},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Any()).Times(1).Return(db.OauthClient{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusUnauthorized, apperror.CodeUnauthorized)
+			},
+		},
+		{
+			name:   "Consent Revoked",
+			scopes: []string{constants.ScopeAccountsRead},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Any()).Times(1).Return(client, nil)
+				store.EXPECT().GetOAuthConsent(gomock.Any(), gomock.Any()).Times(1).Return(db.OauthConsent{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusUnauthorized, apperror.CodeUnauthorized)
+			},
+		},
+		{
+			// the consent was revoked and granted again after the token was issued
+			name:   "Consent Newer Than Token",
+			scopes: []string{constants.ScopeAccountsRead},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Any()).Times(1).Return(client, nil)
+				store.EXPECT().GetOAuthConsent(gomock.Any(), gomock.Any()).Times(1).Return(newerConsent, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusUnauthorized, apperror.CodeUnauthorized)
+			},
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			//setup simple test route
+			authMiddleware := middlewares.NewAuthMiddleware(server.tokenMaker, store)
+			server.router.GET(authPath, authMiddleware.Handler(tc.scopes...), func(ctx *gin.Context) {
+				//simple response
+				ctx.JSON(http.StatusOK, gin.H{})
+			})
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, authPath, nil)
+			require.NoError(t, err)
+
+			addScopedAuth(t, request, server.tokenMaker, user.Username, client.ID, constants.ScopeAccountsRead)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package middlewares
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/hhow09/simple_bank/apperror"
+	"github.com/hhow09/simple_bank/constants"
+	db "github.com/hhow09/simple_bank/db/sqlc"
+)
+
+type AdminMiddleware struct{}
+
+// Setup sets up admin middleware
+func (m AdminMiddleware) Setup() {}
+
+// Handler only lets admins through, it must be placed after the auth middleware
+func (m AdminMiddleware) Handler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user := ctx.MustGet(constants.AuthUserKey).(db.User)
+		if user.Role != constants.RoleAdmin {
+			ctx.Error(apperror.Forbidden("admin role is required"))
+			ctx.Abort()
+			return
+		}
+		ctx.Next()
+	}
+}
+
+func NewAdminMiddleware() AdminMiddleware {
+	return AdminMiddleware{}
+}
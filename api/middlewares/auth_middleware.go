@@ -1,61 +1,199 @@
 package middlewares
 
 import (
+	"crypto/subtle"
+	"database/sql"
 	"errors"
 	"fmt"
-	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/hhow09/simple_bank/apperror"
 	"github.com/hhow09/simple_bank/constants"
+	db "github.com/hhow09/simple_bank/db/sqlc"
+	"github.com/hhow09/simple_bank/oauth"
 	"github.com/hhow09/simple_bank/token"
 	"github.com/hhow09/simple_bank/util"
 )
 
 type AuthMiddleware struct {
 	tokenMaker token.Maker
+	store      db.Store
 }
 
 // Setup sets up jwt auth middleware
 func (m AuthMiddleware) Setup() {}
 
-// Handler handles middleware functionality
-func (m AuthMiddleware) Handler() gin.HandlerFunc {
+// Handler authenticates bearer access tokens. API keys and tokens issued to
+// OAuth clients are only accepted when the route lists scopes, and they must
+// have all of them.
+func (m AuthMiddleware) Handler(scopes ...string) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		authHeader := ctx.GetHeader(constants.AuthHeaderKey)
 		if len(authHeader) == 0 {
-			err := errors.New("authorization header is not provided")
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, util.ErrorResponse(err))
+			ctx.Error(apperror.Unauthorized("authorization header is not provided"))
+			ctx.Abort()
 			return
 		}
 		fields := strings.Fields(authHeader) //split authHeader
 		if len(fields) < 2 {
-			err := errors.New("invalid authorization header format")
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, util.ErrorResponse(err))
+			ctx.Error(apperror.Unauthorized("invalid authorization header format"))
+			ctx.Abort()
 			return
 		}
 
+		var (
+			user   db.User
+			appErr *apperror.Error
+		)
 		authType := strings.ToLower(fields[0])
-		if authType != constants.AuthTypeBearer {
-			err := fmt.Errorf("unsupported authorization type %s", authType)
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, util.ErrorResponse(err))
-			return
+		switch authType {
+		case constants.AuthTypeBearer:
+			user, appErr = m.bearerUser(ctx, fields[1], scopes)
+		case constants.AuthTypeAPIKey:
+			if len(scopes) == 0 {
+				appErr = apperror.Forbidden("api keys are not accepted on this route")
+				break
+			}
+			user, appErr = m.apiKeyUser(ctx, fields[1], scopes)
+		default:
+			appErr = apperror.Unauthorized(fmt.Sprintf("unsupported authorization type %s", authType))
 		}
-		accessToken := fields[1]
-		payload, err := m.tokenMaker.VerifyToken(accessToken)
-		if err != nil {
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, util.ErrorResponse(err))
+		if appErr != nil {
+			ctx.Error(appErr)
+			ctx.Abort()
 			return
 		}
-		ctx.Set(constants.AuthPayloadKey, payload)
+
+		ctx.Set(constants.AuthUserKey, user)
 		ctx.Next()
 	}
 }
 
+func (m AuthMiddleware) bearerUser(ctx *gin.Context, accessToken string, scopes []string) (db.User, *apperror.Error) {
+	payload, err := m.tokenMaker.VerifyToken(accessToken)
+	if err != nil {
+		return db.User{}, &apperror.Error{Code: apperror.CodeUnauthorized, Message: err.Error(), Err: err}
+	}
+	if payload.IsScoped() {
+		if len(scopes) == 0 {
+			return db.User{}, apperror.Forbidden("oauth tokens are not accepted on this route")
+		}
+		for _, scope := range scopes {
+			if !oauth.Contains(payload.Scopes, scope) {
+				return db.User{}, apperror.Forbidden(fmt.Sprintf("token is missing the %s scope", scope))
+			}
+		}
+	}
+
+	user, appErr := m.user(ctx, payload.Username)
+	if appErr != nil {
+		return user, appErr
+	}
+	// changing the password revokes every token issued before
+	if payload.IssuedAt.Before(user.PasswordChangedAt) {
+		return user, apperror.Unauthorized("token has been revoked")
+	}
+	if payload.IsScoped() {
+		if appErr := m.checkGrant(ctx, payload); appErr != nil {
+			return user, appErr
+		}
+	}
+
+	ctx.Set(constants.AuthPayloadKey, payload)
+	return user, nil
+}
+
+// checkGrant makes sure the client of a scoped token still exists and, unless
+// the client acts for its owner, that the user has not revoked the consent
+func (m AuthMiddleware) checkGrant(ctx *gin.Context, payload *token.Payload) *apperror.Error {
+	client, err := m.store.GetOAuthClient(ctx, payload.ClientID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &apperror.Error{Code: apperror.CodeUnauthorized, Message: "oauth client of the token does not exist", Err: err}
+		}
+		return apperror.Internal(err)
+	}
+	if client.Owner == payload.Username {
+		return nil
+	}
+
+	consent, err := m.store.GetOAuthConsent(ctx, db.GetOAuthConsentParams{
+		Username: payload.Username,
+		ClientID: client.ID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &apperror.Error{Code: apperror.CodeUnauthorized, Message: "consent has been revoked", Err: err}
+		}
+		return apperror.Internal(err)
+	}
+	if payload.IssuedAt.Before(consent.CreatedAt) || !oauth.Subset(payload.Scopes, consent.Scopes) {
+		return apperror.Unauthorized("consent has been revoked")
+	}
+	return nil
+}
+
+func (m AuthMiddleware) apiKeyUser(ctx *gin.Context, key string, scopes []string) (db.User, *apperror.Error) {
+	prefix, secret, ok := util.ParseAPIKey(key)
+	if !ok {
+		return db.User{}, apperror.Unauthorized("invalid api key")
+	}
+	apiKey, err := m.store.GetAPIKeyByPrefix(ctx, prefix)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.User{}, &apperror.Error{Code: apperror.CodeUnauthorized, Message: "invalid api key", Err: err}
+		}
+		return db.User{}, apperror.Internal(err)
+	}
+	if subtle.ConstantTimeCompare([]byte(util.HashToken(secret)), []byte(apiKey.SecretHash)) != 1 {
+		return db.User{}, apperror.Unauthorized("invalid api key")
+	}
+	if apiKey.RevokedAt.Valid {
+		return db.User{}, apperror.Unauthorized("api key has been revoked")
+	}
+	if apiKey.ExpiresAt.Valid && time.Now().After(apiKey.ExpiresAt.Time) {
+		return db.User{}, apperror.Unauthorized("api key has expired")
+	}
+	for _, scope := range scopes {
+		if !oauth.Contains(apiKey.Scopes, scope) {
+			return db.User{}, apperror.Forbidden(fmt.Sprintf("api key is missing the %s scope", scope))
+		}
+	}
+
+	user, appErr := m.user(ctx, apiKey.Username)
+	if appErr != nil {
+		return user, appErr
+	}
+	if err := m.store.TouchAPIKey(ctx, apiKey.ID); err != nil {
+		return user, apperror.Internal(err)
+	}
+
+	ctx.Set(constants.AuthAPIKeyKey, apiKey)
+	return user, nil
+}
+
+func (m AuthMiddleware) user(ctx *gin.Context, username string) (db.User, *apperror.Error) {
+	user, err := m.store.GetUser(ctx, username)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return user, &apperror.Error{Code: apperror.CodeUnauthorized, Message: "user of the token does not exist", Err: err}
+		}
+		return user, apperror.Internal(err)
+	}
+	if user.DeletedAt.Valid {
+		return user, apperror.Unauthorized("user of the token was deleted")
+	}
+	return user, nil
+}
+
 func NewAuthMiddleware(
 	tokenMaker token.Maker,
+	store db.Store,
 ) AuthMiddleware {
 	return AuthMiddleware{
 		tokenMaker: tokenMaker,
+		store:      store,
 	}
 }
@@ -0,0 +1,38 @@
+package middlewares
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/hhow09/simple_bank/apperror"
+	"github.com/hhow09/simple_bank/lib"
+)
+
+type ErrorMiddleware struct {
+	requestHandler lib.RequestHandler
+}
+
+// Setup registers the error middleware on every route
+func (m ErrorMiddleware) Setup() {
+	m.requestHandler.Gin.Use(m.Handler())
+}
+
+// Handler renders the last error attached to the context as problem+json
+func (m ErrorMiddleware) Handler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Next()
+
+		if len(ctx.Errors) == 0 || ctx.Writer.Written() {
+			return
+		}
+		problem := apperror.NewProblem(ctx.Errors.Last().Err, ctx.Request.URL.Path)
+		ctx.Header("Content-Type", apperror.ContentType)
+		ctx.JSON(problem.Status, problem)
+	}
+}
+
+func NewErrorMiddleware(
+	requestHandler lib.RequestHandler,
+) ErrorMiddleware {
+	return ErrorMiddleware{
+		requestHandler: requestHandler,
+	}
+}
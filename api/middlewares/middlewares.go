@@ -4,7 +4,11 @@ import "go.uber.org/fx"
 
 // Module Middleware exported
 var Module = fx.Options(
+	fx.Provide(NewErrorMiddleware),
 	fx.Provide(NewAuthMiddleware),
+	fx.Provide(NewRateLimitMiddleware),
+	fx.Provide(NewAdminMiddleware),
+	fx.Provide(NewVerifiedEmailMiddleware),
 	fx.Provide(NewMiddlewares),
 )
 
@@ -17,10 +21,18 @@ type IMiddleware interface {
 type Middlewares []IMiddleware
 
 func NewMiddlewares(
+	errorMiddleware ErrorMiddleware,
 	authMiddleware AuthMiddleware,
+	rateLimitMiddleware RateLimitMiddleware,
+	adminMiddleware AdminMiddleware,
+	verifiedEmailMiddleware VerifiedEmailMiddleware,
 ) Middlewares {
 	return Middlewares{
+		errorMiddleware,
 		authMiddleware,
+		rateLimitMiddleware,
+		adminMiddleware,
+		verifiedEmailMiddleware,
 	}
 }
 
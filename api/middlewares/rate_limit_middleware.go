@@ -0,0 +1,91 @@
+package middlewares
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hhow09/simple_bank/apperror"
+	"github.com/hhow09/simple_bank/constants"
+	db "github.com/hhow09/simple_bank/db/sqlc"
+	"github.com/hhow09/simple_bank/ratelimit"
+	"github.com/hhow09/simple_bank/util"
+)
+
+// keyFunc identifies the client a bucket belongs to
+type keyFunc func(ctx *gin.Context) string
+
+type RateLimitMiddleware struct {
+	backend        ratelimit.Backend
+	loginPolicy    ratelimit.Policy
+	transferPolicy ratelimit.Policy
+}
+
+// Setup sets up rate limit middleware
+func (m RateLimitMiddleware) Setup() {}
+
+// Login limits login attempts per client IP
+func (m RateLimitMiddleware) Login() gin.HandlerFunc {
+	return m.handler("login", m.loginPolicy, clientIPKey)
+}
+
+// Transfer limits money movements per authenticated user,
+// it must be placed after the auth middleware
+func (m RateLimitMiddleware) Transfer() gin.HandlerFunc {
+	return m.handler("transfer", m.transferPolicy, usernameKey)
+}
+
+func (m RateLimitMiddleware) handler(route string, policy ratelimit.Policy, key keyFunc) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !policy.Enabled() {
+			ctx.Next()
+			return
+		}
+		result, err := m.backend.Take(ctx, route+":"+key(ctx), policy)
+		if err != nil {
+			ctx.Error(apperror.Internal(err))
+			ctx.Abort()
+			return
+		}
+		if !result.Allowed {
+			retryAfter := int(math.Ceil(result.RetryAfter.Seconds()))
+			ctx.Header("Retry-After", strconv.Itoa(retryAfter))
+			ctx.Error(apperror.TooManyRequests("rate limit exceeded, retry later"))
+			ctx.Abort()
+			return
+		}
+		ctx.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		ctx.Next()
+	}
+}
+
+func clientIPKey(ctx *gin.Context) string {
+	return "ip:" + ctx.ClientIP()
+}
+
+// usernameKey falls back to the client IP for unauthenticated requests
+func usernameKey(ctx *gin.Context) string {
+	if user, ok := ctx.Get(constants.AuthUserKey); ok {
+		return "user:" + user.(db.User).Username
+	}
+	return clientIPKey(ctx)
+}
+
+func NewRateLimitMiddleware(
+	backend ratelimit.Backend,
+	config util.Config,
+) (RateLimitMiddleware, error) {
+	loginPolicy, err := ratelimit.ParsePolicy(config.RateLimitLogin)
+	if err != nil {
+		return RateLimitMiddleware{}, err
+	}
+	transferPolicy, err := ratelimit.ParsePolicy(config.RateLimitTransfer)
+	if err != nil {
+		return RateLimitMiddleware{}, err
+	}
+	return RateLimitMiddleware{
+		backend:        backend,
+		loginPolicy:    loginPolicy,
+		transferPolicy: transferPolicy,
+	}, nil
+}
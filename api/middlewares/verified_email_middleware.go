@@ -0,0 +1,38 @@
+package middlewares
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/hhow09/simple_bank/apperror"
+	"github.com/hhow09/simple_bank/constants"
+	db "github.com/hhow09/simple_bank/db/sqlc"
+	"github.com/hhow09/simple_bank/util"
+)
+
+type VerifiedEmailMiddleware struct {
+	required bool
+}
+
+// Setup sets up verified email middleware
+func (m VerifiedEmailMiddleware) Setup() {}
+
+// Handler rejects users whose email is not verified when EMAIL_VERIFICATION_REQUIRED is set,
+// it must be placed after the auth middleware
+func (m VerifiedEmailMiddleware) Handler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !m.required {
+			ctx.Next()
+			return
+		}
+		user := ctx.MustGet(constants.AuthUserKey).(db.User)
+		if !user.IsEmailVerified {
+			ctx.Error(apperror.EmailNotVerified("email must be verified first"))
+			ctx.Abort()
+			return
+		}
+		ctx.Next()
+	}
+}
+
+func NewVerifiedEmailMiddleware(config util.Config) VerifiedEmailMiddleware {
+	return VerifiedEmailMiddleware{required: config.EmailVerificationRequired}
+}
@@ -0,0 +1,700 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/hhow09/simple_bank/apperror"
+	"github.com/hhow09/simple_bank/constants"
+	mockdb "github.com/hhow09/simple_bank/db/mock"
+	db "github.com/hhow09/simple_bank/db/sqlc"
+	"github.com/hhow09/simple_bank/oauth"
+	"github.com/hhow09/simple_bank/token"
+	"github.com/hhow09/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+// randomOAuthClient returns a client with its secret, the secret is empty for public clients
+func randomOAuthClient(t *testing.T, owner string, confidential bool) (db.OauthClient, string) {
+	client := db.OauthClient{
+		ID:           util.RandomString(22),
+		Owner:        owner,
+		Name:         util.RandomOwner(),
+		RedirectUris: []string{"https://example.com/callback"},
+		Scopes:       []string{constants.ScopeAccountsRead, constants.ScopeTransfersCreate},
+		CreatedAt:    time.Now(),
+	}
+	if !confidential {
+		return client, ""
+	}
+	secret, err := util.RandomToken(32)
+	require.NoError(t, err)
+	client.SecretHash = util.HashToken(secret)
+	return client, secret
+}
+
+func TestCreateOAuthClientAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	clientID := util.RandomString(16)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Confidential",
+			body: gin.H{
+				"name":          "budget app",
+				"redirect_uris": []string{"https://example.com/callback"},
+				"scopes":        []string{constants.ScopeAccountsRead, constants.ScopeAccountsRead},
+				"confidential":  true,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuth(t, request, tokenMaker, constants.AuthTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().
+					CreateOAuthClient(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateOAuthClientParams) (db.OauthClient, error) {
+						require.NotEmpty(t, arg.ID)
+						require.Equal(t, user.Username, arg.Owner)
+						require.NotEmpty(t, arg.SecretHash)
+						require.Equal(t, []string{constants.ScopeAccountsRead}, arg.Scopes)
+						return db.OauthClient{ID: clientID, Owner: arg.Owner, Name: arg.Name, SecretHash: arg.SecretHash, RedirectUris: arg.RedirectUris, Scopes: arg.Scopes}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.NotContains(t, recorder.Body.String(), "secret_hash")
+
+				var rsp struct {
+					ClientSecret string `json:"client_secret"`
+					Client       struct {
+						ClientID     string `json:"client_id"`
+						Confidential bool   `json:"confidential"`
+					} `json:"client"`
+				}
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.NotEmpty(t, rsp.ClientSecret)
+				require.Equal(t, clientID, rsp.Client.ClientID)
+				require.True(t, rsp.Client.Confidential)
+			},
+		},
+		{
+			name: "Public",
+			body: gin.H{
+				"name":          "mobile app",
+				"redirect_uris": []string{"com.example.app://callback"},
+				"scopes":        []string{constants.ScopeAccountsRead},
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuth(t, request, tokenMaker, constants.AuthTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().
+					CreateOAuthClient(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateOAuthClientParams) (db.OauthClient, error) {
+						require.Empty(t, arg.SecretHash)
+						return db.OauthClient{ID: arg.ID, Owner: arg.Owner, RedirectUris: arg.RedirectUris, Scopes: arg.Scopes}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.NotContains(t, recorder.Body.String(), "client_secret")
+				require.Contains(t, recorder.Body.String(), `"confidential":false`)
+			},
+		},
+		{
+			name: "InvalidRedirectURI",
+			body: gin.H{
+				"name":          "budget app",
+				"redirect_uris": []string{"not a url"},
+				"scopes":        []string{constants.ScopeAccountsRead},
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuth(t, request, tokenMaker, constants.AuthTypeBearer, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().CreateOAuthClient(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, apperror.CodeValidation)
+			},
+		},
+		{
+			// clients cannot register further clients
+			name: "ScopedToken",
+			body: gin.H{
+				"name":          "budget app",
+				"redirect_uris": []string{"https://example.com/callback"},
+				"scopes":        []string{constants.ScopeAccountsRead},
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addScopedAuth(t, request, tokenMaker, user.Username, clientID, constants.ScopeAccountsRead)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateOAuthClient(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusForbidden, apperror.CodeForbidden)
+			},
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/oauth/clients", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestAuthorizeAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	owner, _ := randomUser(t)
+	client, _ := randomOAuthClient(t, owner.Username, false)
+	challenge := oauth.CodeChallenge(util.RandomString(43))
+
+	validBody := func() gin.H {
+		return gin.H{
+			"response_type":         oauth.ResponseTypeCode,
+			"client_id":             client.ID,
+			"redirect_uri":          client.RedirectUris[0],
+			"scope":                 constants.ScopeAccountsRead,
+			"state":                 "xyz",
+			"code_challenge":        challenge,
+			"code_challenge_method": oauth.CodeChallengeMethodS256,
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		body          func() gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: validBody,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Eq(client.ID)).Times(1).Return(client, nil)
+				// scopes granted before are kept in the consent
+				store.EXPECT().
+					GetOAuthConsent(gomock.Any(), gomock.Eq(db.GetOAuthConsentParams{Username: user.Username, ClientID: client.ID})).
+					Times(1).
+					Return(db.OauthConsent{Scopes: []string{constants.ScopeTransfersCreate}}, nil)
+				store.EXPECT().
+					UpsertOAuthConsent(gomock.Any(), gomock.Eq(db.UpsertOAuthConsentParams{
+						Username: user.Username,
+						ClientID: client.ID,
+						Scopes:   []string{constants.ScopeTransfersCreate, constants.ScopeAccountsRead},
+					})).
+					Times(1)
+				store.EXPECT().
+					CreateOAuthAuthorizationCode(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateOAuthAuthorizationCodeParams) (db.OauthAuthorizationCode, error) {
+						require.Equal(t, client.ID, arg.ClientID)
+						require.Equal(t, user.Username, arg.Username)
+						require.Equal(t, client.RedirectUris[0], arg.RedirectUri)
+						require.Equal(t, []string{constants.ScopeAccountsRead}, arg.Scopes)
+						require.Equal(t, challenge, arg.CodeChallenge)
+						require.WithinDuration(t, time.Now().Add(time.Minute), arg.ExpiresAt, 5*time.Second)
+						return db.OauthAuthorizationCode{}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp struct {
+					Code       string `json:"code"`
+					State      string `json:"state"`
+					RedirectTo string `json:"redirect_to"`
+				}
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.NotEmpty(t, rsp.Code)
+				require.Equal(t, "xyz", rsp.State)
+
+				redirectTo, err := url.Parse(rsp.RedirectTo)
+				require.NoError(t, err)
+				require.Equal(t, "example.com", redirectTo.Host)
+				require.Equal(t, rsp.Code, redirectTo.Query().Get("code"))
+				require.Equal(t, "xyz", redirectTo.Query().Get("state"))
+			},
+		},
+		{
+			name: "UnknownClient",
+			body: validBody,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Any()).Times(1).Return(db.OauthClient{}, sql.ErrNoRows)
+				store.EXPECT().CreateOAuthAuthorizationCode(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusNotFound, apperror.CodeNotFound)
+			},
+		},
+		{
+			name: "UnregisteredRedirectURI",
+			body: func() gin.H {
+				body := validBody()
+				body["redirect_uri"] = client.RedirectUris[0] + "/evil"
+				return body
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Any()).Times(1).Return(client, nil)
+				store.EXPECT().UpsertOAuthConsent(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateOAuthAuthorizationCode(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, apperror.CodeValidation)
+			},
+		},
+		{
+			name: "ScopeNotAllowed",
+			body: func() gin.H {
+				body := validBody()
+				body["scope"] = "accounts:read accounts:delete"
+				return body
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Any()).Times(1).Return(client, nil)
+				store.EXPECT().UpsertOAuthConsent(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateOAuthAuthorizationCode(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, apperror.CodeValidation)
+			},
+		},
+		{
+			name: "PlainChallenge",
+			body: func() gin.H {
+				body := validBody()
+				body["code_challenge_method"] = "plain"
+				return body
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, apperror.CodeValidation)
+			},
+		},
+		{
+			name: "MissingChallenge",
+			body: func() gin.H {
+				body := validBody()
+				delete(body, "code_challenge")
+				return body
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, apperror.CodeValidation)
+			},
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body())
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/oauth/authorize", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuth(t, request, server.tokenMaker, constants.AuthTypeBearer, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestOAuthTokenAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	owner, _ := randomUser(t)
+	publicClient, _ := randomOAuthClient(t, owner.Username, false)
+	client, secret := randomOAuthClient(t, owner.Username, true)
+
+	verifier := util.RandomString(43)
+	code := util.RandomString(43)
+	authorizationCode := db.OauthAuthorizationCode{
+		CodeHash:      util.HashToken(code),
+		ClientID:      publicClient.ID,
+		Username:      user.Username,
+		RedirectUri:   publicClient.RedirectUris[0],
+		Scopes:        []string{constants.ScopeAccountsRead},
+		CodeChallenge: oauth.CodeChallenge(verifier),
+	}
+
+	codeForm := func() url.Values {
+		return url.Values{
+			"grant_type":    {oauth.GrantTypeAuthorizationCode},
+			"client_id":     {publicClient.ID},
+			"code":          {code},
+			"redirect_uri":  {publicClient.RedirectUris[0]},
+			"code_verifier": {verifier},
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		form          func() url.Values
+		setupAuth     func(request *http.Request)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker)
+	}{
+		{
+			name: "AuthorizationCode",
+			form: codeForm,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Eq(publicClient.ID)).Times(1).Return(publicClient, nil)
+				store.EXPECT().
+					ConsumeOAuthAuthorizationCode(gomock.Any(), gomock.Eq(util.HashToken(code))).
+					Times(1).
+					Return(authorizationCode, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "no-store", recorder.Header().Get("Cache-Control"))
+
+				payload := requireTokenResponse(t, recorder, tokenMaker, constants.ScopeAccountsRead)
+				require.Equal(t, user.Username, payload.Username)
+				require.Equal(t, publicClient.ID, payload.ClientID)
+			},
+		},
+		{
+			name: "WrongVerifier",
+			form: func() url.Values {
+				form := codeForm()
+				form.Set("code_verifier", util.RandomString(43))
+				return form
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Any()).Times(1).Return(publicClient, nil)
+				store.EXPECT().ConsumeOAuthAuthorizationCode(gomock.Any(), gomock.Any()).Times(1).Return(authorizationCode, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				requireProblem(t, recorder, http.StatusBadRequest, apperror.CodeValidation)
+			},
+		},
+		{
+			name: "WrongRedirectURI",
+			form: func() url.Values {
+				form := codeForm()
+				form.Set("redirect_uri", "https://example.com/other")
+				return form
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Any()).Times(1).Return(publicClient, nil)
+				store.EXPECT().ConsumeOAuthAuthorizationCode(gomock.Any(), gomock.Any()).Times(1).Return(authorizationCode, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				requireProblem(t, recorder, http.StatusBadRequest, apperror.CodeValidation)
+			},
+		},
+		{
+			// a code issued to another client cannot be redeemed
+			name: "CodeOfOtherClient",
+			form: func() url.Values {
+				form := codeForm()
+				form.Set("client_id", client.ID)
+				form.Set("client_secret", secret)
+				return form
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Eq(client.ID)).Times(1).Return(client, nil)
+				store.EXPECT().ConsumeOAuthAuthorizationCode(gomock.Any(), gomock.Any()).Times(1).Return(authorizationCode, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				requireProblem(t, recorder, http.StatusBadRequest, apperror.CodeValidation)
+			},
+		},
+		{
+			name: "UsedCode",
+			form: codeForm,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Any()).Times(1).Return(publicClient, nil)
+				store.EXPECT().ConsumeOAuthAuthorizationCode(gomock.Any(), gomock.Any()).Times(1).Return(db.OauthAuthorizationCode{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				requireProblem(t, recorder, http.StatusBadRequest, apperror.CodeValidation)
+			},
+		},
+		{
+			name: "MissingVerifier",
+			form: func() url.Values {
+				form := codeForm()
+				form.Del("code_verifier")
+				return form
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Any()).Times(1).Return(publicClient, nil)
+				store.EXPECT().ConsumeOAuthAuthorizationCode(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				requireProblem(t, recorder, http.StatusBadRequest, apperror.CodeValidation)
+			},
+		},
+		{
+			name: "ClientCredentials",
+			form: func() url.Values {
+				return url.Values{"grant_type": {oauth.GrantTypeClientCredentials}, "scope": {constants.ScopeTransfersCreate}}
+			},
+			setupAuth: func(request *http.Request) {
+				request.SetBasicAuth(client.ID, secret)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Eq(client.ID)).Times(1).Return(client, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				payload := requireTokenResponse(t, recorder, tokenMaker, constants.ScopeTransfersCreate)
+				require.Equal(t, owner.Username, payload.Username)
+				require.Equal(t, client.ID, payload.ClientID)
+			},
+		},
+		{
+			name: "ClientCredentialsScopeNotAllowed",
+			form: func() url.Values {
+				return url.Values{"grant_type": {oauth.GrantTypeClientCredentials}, "scope": {"accounts:delete"}}
+			},
+			setupAuth: func(request *http.Request) {
+				request.SetBasicAuth(client.ID, secret)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Eq(client.ID)).Times(1).Return(client, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				requireProblem(t, recorder, http.StatusBadRequest, apperror.CodeValidation)
+			},
+		},
+		{
+			name: "WrongSecret",
+			form: func() url.Values {
+				return url.Values{"grant_type": {oauth.GrantTypeClientCredentials}}
+			},
+			setupAuth: func(request *http.Request) {
+				request.SetBasicAuth(client.ID, "wrong")
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Eq(client.ID)).Times(1).Return(client, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				requireProblem(t, recorder, http.StatusUnauthorized, apperror.CodeUnauthorized)
+			},
+		},
+		{
+			name: "PublicClientCredentials",
+			form: func() url.Values {
+				return url.Values{"grant_type": {oauth.GrantTypeClientCredentials}, "client_id": {publicClient.ID}}
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Eq(publicClient.ID)).Times(1).Return(publicClient, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				requireProblem(t, recorder, http.StatusForbidden, apperror.CodeForbidden)
+			},
+		},
+		{
+			name: "UnknownClient",
+			form: codeForm,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Any()).Times(1).Return(db.OauthClient{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				requireProblem(t, recorder, http.StatusUnauthorized, apperror.CodeUnauthorized)
+			},
+		},
+		{
+			name: "UnsupportedGrantType",
+			form: func() url.Values {
+				return url.Values{"grant_type": {"password"}, "client_id": {publicClient.ID}}
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker) {
+				requireProblem(t, recorder, http.StatusBadRequest, apperror.CodeValidation)
+			},
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodPost, "/oauth/token", strings.NewReader(tc.form().Encode()))
+			require.NoError(t, err)
+			request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tc.setupAuth != nil {
+				tc.setupAuth(request)
+			}
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder, server.tokenMaker)
+		})
+	}
+}
+
+// requireTokenResponse checks the token response and returns the payload of the access token
+func requireTokenResponse(t *testing.T, recorder *httptest.ResponseRecorder, tokenMaker token.Maker, scopes ...string) *token.Payload {
+	var rsp struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int64  `json:"expires_in"`
+		Scope       string `json:"scope"`
+	}
+	err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+	require.NoError(t, err)
+	require.Equal(t, oauth.TokenTypeBearer, rsp.TokenType)
+	require.Equal(t, int64(60), rsp.ExpiresIn)
+	require.Equal(t, oauth.FormatScope(scopes), rsp.Scope)
+
+	payload, err := tokenMaker.VerifyToken(rsp.AccessToken)
+	require.NoError(t, err)
+	require.Equal(t, scopes, payload.Scopes)
+	return payload
+}
+
+func TestRevokeOAuthConsentAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	clientID := util.RandomString(22)
+	consent := db.OauthConsent{Username: user.Username, ClientID: clientID, Scopes: []string{constants.ScopeAccountsRead}}
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					DeleteOAuthConsent(gomock.Any(), gomock.Eq(db.DeleteOAuthConsentParams{Username: user.Username, ClientID: clientID})).
+					Times(1).
+					Return(consent, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Contains(t, recorder.Body.String(), clientID)
+			},
+		},
+		{
+			name: "NotFound",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().DeleteOAuthConsent(gomock.Any(), gomock.Any()).Times(1).Return(db.OauthConsent{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusNotFound, apperror.CodeNotFound)
+			},
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("/users/me/oauth_consents/%s", clientID), nil)
+			require.NoError(t, err)
+
+			addAuth(t, request, server.tokenMaker, constants.AuthTypeBearer, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestListAccountsWithOAuthToken(t *testing.T) {
+	user, _ := randomUser(t)
+	owner, _ := randomUser(t)
+	client, _ := randomOAuthClient(t, owner.Username, false)
+	consent := db.OauthConsent{
+		Username:  user.Username,
+		ClientID:  client.ID,
+		Scopes:    []string{constants.ScopeAccountsRead},
+		CreatedAt: time.Now().Add(-time.Minute),
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+	store.EXPECT().GetOAuthClient(gomock.Any(), gomock.Eq(client.ID)).Times(1).Return(client, nil)
+	store.EXPECT().GetOAuthConsent(gomock.Any(), gomock.Any()).Times(1).Return(consent, nil)
+	store.EXPECT().ListAccounts(gomock.Any(), gomock.Any()).Times(1).Return([]db.Account{randomAccount(user.Username)}, nil)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodGet, "/accounts?page_id=1&page_size=5", nil)
+	require.NoError(t, err)
+
+	addScopedAuth(t, request, server.tokenMaker, user.Username, client.ID, constants.ScopeAccountsRead)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+}
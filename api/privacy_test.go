@@ -0,0 +1,339 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/hhow09/simple_bank/apperror"
+	"github.com/hhow09/simple_bank/constants"
+	mockdb "github.com/hhow09/simple_bank/db/mock"
+	db "github.com/hhow09/simple_bank/db/sqlc"
+	"github.com/hhow09/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportUserAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+	entry := db.Entry{ID: util.RandomInt(1, 1000), AccountID: account.ID, Amount: util.RandomMoney()}
+	transfer := db.Transfer{ID: util.RandomInt(1, 1000), FromAccountID: util.RandomInt(1001, 2000), ToAccountID: account.ID, Amount: entry.Amount}
+	_, apiKey := randomAPIKey(t, user.Username, "accounts:read")
+	client, _ := randomOAuthClient(t, user.Username, true)
+	attempt := db.LoginAttempt{ID: util.RandomInt(1, 1000), Username: user.Username, ClientIp: "192.0.2.1", Success: true}
+	beneficiary := randomBeneficiary(user.Username, randomAccount(util.RandomOwner()))
+
+	buildStubs := func(store *mockdb.MockStore) {
+		store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+		store.EXPECT().ListAllAccounts(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return([]db.Account{account}, nil)
+		store.EXPECT().ListMemberEntries(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return([]db.Entry{entry}, nil)
+		store.EXPECT().ListMemberTransfers(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return([]db.Transfer{transfer}, nil)
+		store.EXPECT().ListLoginAttempts(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return([]db.LoginAttempt{attempt}, nil)
+		store.EXPECT().ListBeneficiaries(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return([]db.Beneficiary{beneficiary}, nil)
+		store.EXPECT().ListAPIKeys(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return([]db.ApiKey{apiKey}, nil)
+		store.EXPECT().ListOAuthClients(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return([]db.OauthClient{client}, nil)
+		store.EXPECT().ListOAuthConsents(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return([]db.OauthConsent{}, nil)
+	}
+
+	testCases := []struct {
+		name          string
+		query         string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:       "JSON",
+			buildStubs: buildStubs,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Contains(t, recorder.Header().Get("Content-Disposition"), ".json")
+
+				var export struct {
+					User          gin.H             `json:"user"`
+					Accounts      []db.Account      `json:"accounts"`
+					Entries       []db.Entry        `json:"entries"`
+					Transfers     []db.Transfer     `json:"transfers"`
+					APIKeys       []gin.H           `json:"api_keys"`
+					OAuthClients  []gin.H           `json:"oauth_clients"`
+					OAuthConsents []gin.H           `json:"oauth_consents"`
+					LoginAttempts []db.LoginAttempt `json:"login_attempts"`
+					Beneficiaries []db.Beneficiary  `json:"beneficiaries"`
+				}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &export))
+				require.Equal(t, user.Username, export.User["username"])
+				require.Equal(t, user.Email, export.User["email"])
+				require.Equal(t, user.Role, export.User["role"])
+				require.Len(t, export.Accounts, 1)
+				require.Equal(t, account.ID, export.Accounts[0].ID)
+				require.Len(t, export.Entries, 1)
+				require.Equal(t, entry.ID, export.Entries[0].ID)
+				require.Len(t, export.Transfers, 1)
+				require.Len(t, export.APIKeys, 1)
+				require.Len(t, export.OAuthClients, 1)
+				require.Empty(t, export.OAuthConsents)
+				require.Len(t, export.LoginAttempts, 1)
+				require.Len(t, export.Beneficiaries, 1)
+
+				// secrets are never exported
+				body := recorder.Body.String()
+				require.NotContains(t, body, user.HashedPassword)
+				require.NotContains(t, body, apiKey.SecretHash)
+				require.NotContains(t, body, "secret_hash")
+			},
+		},
+		{
+			name:       "ZIP",
+			query:      "?format=zip",
+			buildStubs: buildStubs,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "application/zip", recorder.Header().Get("Content-Type"))
+				require.Contains(t, recorder.Header().Get("Content-Disposition"), ".zip")
+
+				data := recorder.Body.Bytes()
+				archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+				require.NoError(t, err)
+
+				files := map[string][]byte{}
+				for _, file := range archive.File {
+					r, err := file.Open()
+					require.NoError(t, err)
+					files[file.Name], err = io.ReadAll(r)
+					require.NoError(t, err)
+					r.Close()
+				}
+				require.Len(t, files, 9)
+
+				var accounts []db.Account
+				require.NoError(t, json.Unmarshal(files["accounts.json"], &accounts))
+				require.Equal(t, []db.Account{account}, accounts)
+
+				var exported gin.H
+				require.NoError(t, json.Unmarshal(files["user.json"], &exported))
+				require.Equal(t, user.Username, exported["username"])
+			},
+		},
+		{
+			name:  "InvalidFormat",
+			query: "?format=xml",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().ListAllAccounts(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, apperror.CodeValidation)
+			},
+		},
+		{
+			name: "InternalError",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().ListAllAccounts(gomock.Any(), gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusInternalServerError, apperror.CodeInternal)
+			},
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/users/me/export"+tc.query, nil)
+			require.NoError(t, err)
+
+			addAuth(t, request, server.tokenMaker, constants.AuthTypeBearer, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestDeleteUserAPI(t *testing.T) {
+	user, password := randomUser(t)
+	totpUser, totpPassword := randomTOTPUser(t)
+
+	testCases := []struct {
+		name          string
+		user          db.User
+		body          func(t *testing.T) gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			user: user,
+			body: func(t *testing.T) gin.H {
+				return gin.H{"password": password}
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().GetLoginFailures(gomock.Any(), gomock.Any()).Times(1)
+				store.EXPECT().
+					DeleteUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.DeleteUserTxParams) (db.DeleteUserTxResult, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.True(t, strings.HasPrefix(arg.Pseudonym, "deleted"))
+						require.NotContains(t, arg.Pseudonym, user.Username)
+						return db.DeleteUserTxResult{}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+			},
+		},
+		{
+			name: "IncorrectPassword",
+			user: user,
+			body: func(t *testing.T) gin.H {
+				return gin.H{"password": "incorrect"}
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().GetLoginFailures(gomock.Any(), gomock.Any()).Times(1)
+				store.EXPECT().CreateLoginAttempt(gomock.Any(), eqLoginAttempt(user.Username, false)).Times(1)
+				store.EXPECT().DeleteUserTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusUnauthorized, apperror.CodeUnauthorized)
+			},
+		},
+		{
+			name: "LockedOut",
+			user: user,
+			body: func(t *testing.T) gin.H {
+				return gin.H{"password": password}
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().
+					GetLoginFailures(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.GetLoginFailuresRow{Failures: 5, LastFailedAt: time.Now()}, nil)
+				store.EXPECT().DeleteUserTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusLocked, apperror.CodeAccountLocked)
+			},
+		},
+		{
+			name: "AccountsNotEmpty",
+			user: user,
+			body: func(t *testing.T) gin.H {
+				return gin.H{"password": password}
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().GetLoginFailures(gomock.Any(), gomock.Any()).Times(1)
+				store.EXPECT().DeleteUserTx(gomock.Any(), gomock.Any()).Times(1).Return(db.DeleteUserTxResult{}, db.ErrAccountsNotEmpty)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusConflict, apperror.CodeConflict)
+			},
+		},
+		{
+			name: "TwoFactorRequired",
+			user: totpUser,
+			body: func(t *testing.T) gin.H {
+				return gin.H{"password": totpPassword}
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(totpUser.Username)).Times(1).Return(totpUser, nil)
+				store.EXPECT().GetLoginFailures(gomock.Any(), gomock.Any()).Times(1)
+				store.EXPECT().DeleteUserTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusForbidden, apperror.CodeTwoFactorRequired)
+			},
+		},
+		{
+			name: "TwoFactorOK",
+			user: totpUser,
+			body: func(t *testing.T) gin.H {
+				return gin.H{"password": totpPassword, "totp_code": currentTOTPCode(t, totpUser.TotpSecret)}
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(totpUser.Username)).Times(1).Return(totpUser, nil)
+				store.EXPECT().GetLoginFailures(gomock.Any(), gomock.Any()).Times(1)
+				store.EXPECT().UseUserTOTPStep(gomock.Any(), gomock.Any()).Times(1).Return(totpUser, nil)
+				store.EXPECT().DeleteUserTx(gomock.Any(), gomock.Any()).Times(1).Return(db.DeleteUserTxResult{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+			},
+		},
+		{
+			name: "UsedTOTPCode",
+			user: totpUser,
+			body: func(t *testing.T) gin.H {
+				return gin.H{"password": totpPassword, "totp_code": currentTOTPCode(t, totpUser.TotpSecret)}
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(totpUser.Username)).Times(1).Return(totpUser, nil)
+				store.EXPECT().GetLoginFailures(gomock.Any(), gomock.Any()).Times(1)
+				store.EXPECT().UseUserTOTPStep(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, sql.ErrNoRows)
+				store.EXPECT().CreateLoginAttempt(gomock.Any(), eqLoginAttempt(totpUser.Username, false)).Times(1)
+				store.EXPECT().DeleteUserTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusUnauthorized, apperror.CodeUnauthorized)
+			},
+		},
+		{
+			name: "InternalError",
+			user: user,
+			body: func(t *testing.T) gin.H {
+				return gin.H{"password": password}
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().GetLoginFailures(gomock.Any(), gomock.Any()).Times(1)
+				store.EXPECT().DeleteUserTx(gomock.Any(), gomock.Any()).Times(1).Return(db.DeleteUserTxResult{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusInternalServerError, apperror.CodeInternal)
+			},
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body(t))
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodDelete, "/users/me", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuth(t, request, server.tokenMaker, constants.AuthTypeBearer, tc.user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
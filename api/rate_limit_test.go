@@ -0,0 +1,142 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/hhow09/simple_bank/api/middlewares"
+	"github.com/hhow09/simple_bank/apperror"
+	"github.com/hhow09/simple_bank/constants"
+	mockdb "github.com/hhow09/simple_bank/db/mock"
+	"github.com/hhow09/simple_bank/ratelimit"
+	"github.com/hhow09/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	loginLimitPath    = "/login_limit"
+	transferLimitPath = "/transfer_limit"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	stubAuthUsers(store)
+
+	server := newTestServer(t, store)
+	config := util.Config{RateLimitLogin: "2/1m", RateLimitTransfer: "1/1m"}
+	rateLimitMiddleware, err := middlewares.NewRateLimitMiddleware(ratelimit.NewMemoryBackend(), config)
+	require.NoError(t, err)
+	authMiddleware := middlewares.NewAuthMiddleware(server.tokenMaker, store)
+
+	ok := func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, gin.H{})
+	}
+	server.router.POST(loginLimitPath, rateLimitMiddleware.Login(), ok)
+	server.router.POST(transferLimitPath, authMiddleware.Handler(), rateLimitMiddleware.Transfer(), ok)
+
+	send := func(path string, remoteAddr string, username string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodPost, path, nil)
+		require.NoError(t, err)
+		request.RemoteAddr = remoteAddr
+		if username != "" {
+			addAuth(t, request, server.tokenMaker, constants.AuthTypeBearer, username, time.Minute)
+		}
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	t.Run("Login Limited By IP", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			recorder := send(loginLimitPath, "10.0.0.1:1234", "")
+			require.Equal(t, http.StatusOK, recorder.Code)
+		}
+		recorder := send(loginLimitPath, "10.0.0.1:1234", "")
+		requireProblem(t, recorder, http.StatusTooManyRequests, apperror.CodeTooManyRequests)
+		require.Equal(t, "30", recorder.Header().Get("Retry-After"))
+
+		recorder = send(loginLimitPath, "10.0.0.2:1234", "")
+		require.Equal(t, http.StatusOK, recorder.Code)
+	})
+
+	t.Run("Spoofed Forwarded For", func(t *testing.T) {
+		// no proxy is trusted, so rotating X-Forwarded-For doesn't give a new bucket
+		for i := 0; i < 3; i++ {
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodPost, loginLimitPath, nil)
+			require.NoError(t, err)
+			request.RemoteAddr = "10.0.0.6:1234"
+			request.Header.Set("X-Forwarded-For", fmt.Sprintf("203.0.113.%d", i))
+			server.router.ServeHTTP(recorder, request)
+			if i < 2 {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				continue
+			}
+			requireProblem(t, recorder, http.StatusTooManyRequests, apperror.CodeTooManyRequests)
+		}
+	})
+
+	t.Run("Transfer Limited By User", func(t *testing.T) {
+		recorder := send(transferLimitPath, "10.0.0.3:1234", "user1")
+		require.Equal(t, http.StatusOK, recorder.Code)
+
+		// same user from another IP shares the bucket
+		recorder = send(transferLimitPath, "10.0.0.4:1234", "user1")
+		requireProblem(t, recorder, http.StatusTooManyRequests, apperror.CodeTooManyRequests)
+		require.Equal(t, "60", recorder.Header().Get("Retry-After"))
+
+		recorder = send(transferLimitPath, "10.0.0.3:1234", "user2")
+		require.Equal(t, http.StatusOK, recorder.Code)
+	})
+
+	t.Run("Disabled Policy", func(t *testing.T) {
+		rateLimitMiddleware, err := middlewares.NewRateLimitMiddleware(ratelimit.NewMemoryBackend(), util.Config{})
+		require.NoError(t, err)
+		server.router.POST("/unlimited", rateLimitMiddleware.Login(), ok)
+		for i := 0; i < 10; i++ {
+			recorder := send("/unlimited", "10.0.0.5:1234", "")
+			require.Equal(t, http.StatusOK, recorder.Code)
+		}
+	})
+}
+
+func TestRateLimitTrustedProxies(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := newTestServer(t, mockdb.NewMockStore(ctrl), func(config *util.Config) {
+		config.TrustedProxies = "10.0.0.0/24"
+	})
+	rateLimitMiddleware, err := middlewares.NewRateLimitMiddleware(ratelimit.NewMemoryBackend(), util.Config{RateLimitLogin: "1/1m"})
+	require.NoError(t, err)
+	server.router.POST(loginLimitPath, rateLimitMiddleware.Login(), func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, gin.H{})
+	})
+
+	send := func(remoteAddr string, forwardedFor string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodPost, loginLimitPath, nil)
+		require.NoError(t, err)
+		request.RemoteAddr = remoteAddr
+		request.Header.Set("X-Forwarded-For", forwardedFor)
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	// the client IP is forwarded by a trusted proxy
+	require.Equal(t, http.StatusOK, send("10.0.0.1:1234", "203.0.113.1").Code)
+	require.Equal(t, http.StatusOK, send("10.0.0.1:1234", "203.0.113.2").Code)
+	requireProblem(t, send("10.0.0.2:1234", "203.0.113.1"), http.StatusTooManyRequests, apperror.CodeTooManyRequests)
+
+	// other peers can't forward
+	require.Equal(t, http.StatusOK, send("192.0.2.1:1234", "203.0.113.3").Code)
+	requireProblem(t, send("192.0.2.1:1234", "203.0.113.4"), http.StatusTooManyRequests, apperror.CodeTooManyRequests)
+}
@@ -0,0 +1,392 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/hhow09/simple_bank/apperror"
+	"github.com/hhow09/simple_bank/constants"
+	mockdb "github.com/hhow09/simple_bank/db/mock"
+	db "github.com/hhow09/simple_bank/db/sqlc"
+	"github.com/hhow09/simple_bank/reconcile"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunReconciliationAPI(t *testing.T) {
+	admin, _ := randomUser(t)
+	admin.Role = constants.RoleAdmin
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+	run := db.ReconciliationRun{ID: 1, Status: constants.ReconciliationRunning}
+
+	// stubScan finds one account whose balance is not the sum of its entries
+	stubScan := func(store *mockdb.MockStore) {
+		store.EXPECT().CreateReconciliationRun(gomock.Any()).Times(1).Return(run, nil)
+		store.EXPECT().CheckAccountBalances(gomock.Any(), gomock.Any()).Times(1).Return([]db.CheckAccountBalancesRow{
+			{ID: account.ID, Balance: account.Balance, EntriesTotal: account.Balance - 1},
+		}, nil)
+		store.EXPECT().CheckTransferEntries(gomock.Any(), gomock.Any()).Times(1).Return([]db.CheckTransferEntriesRow{}, nil)
+		store.EXPECT().CheckLedgerAccountBalances(gomock.Any(), gomock.Any()).Times(1).Return([]db.CheckLedgerAccountBalancesRow{}, nil)
+		store.EXPECT().CreateReconciliationDiscrepancy(gomock.Any(), gomock.Any()).Times(1).
+			Return(db.ReconciliationDiscrepancy{ID: 1, RunID: run.ID, Kind: constants.DiscrepancyAccountBalance, AccountID: sql.NullInt64{Int64: account.ID, Valid: true}}, nil)
+	}
+
+	testCases := []struct {
+		name          string
+		username      string
+		body          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: admin.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(admin.Username)).Times(1).Return(admin, nil)
+				stubScan(store)
+				store.EXPECT().FreezeAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().FinishReconciliationRun(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.ReconciliationRun{ID: run.ID, Status: constants.ReconciliationCompleted, AccountsChecked: 1, Discrepancies: 1}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got struct {
+					Run struct {
+						Status        string     `json:"status"`
+						Discrepancies int64      `json:"discrepancies"`
+						FinishedAt    *time.Time `json:"finished_at"`
+					} `json:"run"`
+					Discrepancies []struct {
+						Kind       string `json:"kind"`
+						AccountID  *int64 `json:"account_id"`
+						TransferID *int64 `json:"transfer_id"`
+					} `json:"discrepancies"`
+				}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, constants.ReconciliationCompleted, got.Run.Status)
+				require.Equal(t, int64(1), got.Run.Discrepancies)
+				require.Len(t, got.Discrepancies, 1)
+				require.Equal(t, constants.DiscrepancyAccountBalance, got.Discrepancies[0].Kind)
+				require.Equal(t, account.ID, *got.Discrepancies[0].AccountID)
+				require.Nil(t, got.Discrepancies[0].TransferID)
+			},
+		},
+		{
+			name:     "Freeze",
+			username: admin.Username,
+			body:     `{"freeze": true}`,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(admin.Username)).Times(1).Return(admin, nil)
+				stubScan(store)
+				store.EXPECT().FreezeAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().FinishReconciliationRun(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ interface{}, arg db.FinishReconciliationRunParams) (db.ReconciliationRun, error) {
+						require.Equal(t, int64(1), arg.FrozenAccounts)
+						return db.ReconciliationRun{ID: run.ID, Status: arg.Status, FrozenAccounts: arg.FrozenAccounts}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "NotAdmin",
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().CreateReconciliationRun(gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusForbidden, apperror.CodeForbidden)
+			},
+		},
+		{
+			name:     "InvalidBody",
+			username: admin.Username,
+			body:     `{"freeze": "yes"}`,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(admin.Username)).Times(1).Return(admin, nil)
+				store.EXPECT().CreateReconciliationRun(gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, apperror.CodeValidation)
+			},
+		},
+		{
+			name:     "Failed",
+			username: admin.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(admin.Username)).Times(1).Return(admin, nil)
+				store.EXPECT().CreateReconciliationRun(gomock.Any()).Times(1).Return(run, nil)
+				store.EXPECT().CheckAccountBalances(gomock.Any(), gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+				store.EXPECT().FinishReconciliationRun(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.ReconciliationRun{ID: run.ID, Status: constants.ReconciliationFailed, Error: sql.ErrConnDone.Error()}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusInternalServerError, apperror.CodeInternal)
+			},
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			var body io.Reader
+			if tc.body != "" {
+				body = bytes.NewReader([]byte(tc.body))
+			}
+			request, err := http.NewRequest(http.MethodPost, "/admin/reconciliations", body)
+			require.NoError(t, err)
+
+			addAuth(t, request, server.tokenMaker, constants.AuthTypeBearer, tc.username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestGetReconciliationAPI(t *testing.T) {
+	admin, _ := randomUser(t)
+	admin.Role = constants.RoleAdmin
+	run := db.ReconciliationRun{
+		ID:         7,
+		Status:     constants.ReconciliationCompleted,
+		StartedAt:  time.Now().UTC().Truncate(time.Second),
+		FinishedAt: sql.NullTime{Time: time.Now().UTC().Truncate(time.Second), Valid: true},
+	}
+	discrepancies := []db.ReconciliationDiscrepancy{
+		{ID: 1, RunID: run.ID, Kind: constants.DiscrepancyTransferEntries, TransferID: sql.NullInt64{Int64: 3, Valid: true}, Expected: 1},
+	}
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetReconciliationRun(gomock.Any(), gomock.Eq(run.ID)).Times(1).Return(run, nil)
+				store.EXPECT().ListReconciliationDiscrepancies(gomock.Any(), gomock.Eq(run.ID)).Times(1).Return(discrepancies, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got struct {
+					Run struct {
+						ID         int64      `json:"id"`
+						FinishedAt *time.Time `json:"finished_at"`
+					} `json:"run"`
+					Discrepancies []struct {
+						TransferID *int64 `json:"transfer_id"`
+					} `json:"discrepancies"`
+				}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, run.ID, got.Run.ID)
+				require.Equal(t, run.FinishedAt.Time, got.Run.FinishedAt.UTC())
+				require.Len(t, got.Discrepancies, 1)
+				require.Equal(t, int64(3), *got.Discrepancies[0].TransferID)
+			},
+		},
+		{
+			name: "NotFound",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetReconciliationRun(gomock.Any(), gomock.Eq(run.ID)).Times(1).Return(db.ReconciliationRun{}, sql.ErrNoRows)
+				store.EXPECT().ListReconciliationDiscrepancies(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusNotFound, apperror.CodeNotFound)
+			},
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetUser(gomock.Any(), gomock.Eq(admin.Username)).AnyTimes().Return(admin, nil)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/admin/reconciliations/%d", run.ID)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			addAuth(t, request, server.tokenMaker, constants.AuthTypeBearer, admin.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestListReconciliationsAPI(t *testing.T) {
+	admin, _ := randomUser(t)
+	admin.Role = constants.RoleAdmin
+	runs := []db.ReconciliationRun{
+		{ID: 2, Status: constants.ReconciliationRunning},
+		{ID: 1, Status: constants.ReconciliationCompleted, FinishedAt: sql.NullTime{Time: time.Now(), Valid: true}},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetUser(gomock.Any(), gomock.Eq(admin.Username)).Times(1).Return(admin, nil)
+	store.EXPECT().ListReconciliationRuns(gomock.Any(), gomock.Eq(db.ListReconciliationRunsParams{Limit: 5, Offset: 5})).Times(1).Return(runs, nil)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodGet, "/admin/reconciliations?page_id=2&page_size=5", nil)
+	require.NoError(t, err)
+
+	addAuth(t, request, server.tokenMaker, constants.AuthTypeBearer, admin.Username, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var got []struct {
+		ID         int64      `json:"id"`
+		FinishedAt *time.Time `json:"finished_at"`
+	}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+	require.Len(t, got, 2)
+	require.Nil(t, got[0].FinishedAt)
+	require.NotNil(t, got[1].FinishedAt)
+}
+
+func TestFreezeAccountAPI(t *testing.T) {
+	admin, _ := randomUser(t)
+	admin.Role = constants.RoleAdmin
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+	frozen := account
+	frozen.FrozenAt = sql.NullTime{Time: time.Now().UTC().Truncate(time.Second), Valid: true}
+
+	testCases := []struct {
+		name          string
+		username      string
+		action        string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "Freeze",
+			username: admin.Username,
+			action:   "freeze",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(admin.Username)).Times(1).Return(admin, nil)
+				store.EXPECT().FreezeAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(frozen, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got struct {
+					FrozenAt *time.Time `json:"frozen_at"`
+				}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.NotNil(t, got.FrozenAt)
+				require.Equal(t, frozen.FrozenAt.Time, got.FrozenAt.UTC())
+			},
+		},
+		{
+			name:     "Unfreeze",
+			username: admin.Username,
+			action:   "unfreeze",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(admin.Username)).Times(1).Return(admin, nil)
+				store.EXPECT().UnfreezeAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got struct {
+					FrozenAt *time.Time `json:"frozen_at"`
+				}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Nil(t, got.FrozenAt)
+			},
+		},
+		{
+			name:     "NotFound",
+			username: admin.Username,
+			action:   "freeze",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(admin.Username)).Times(1).Return(admin, nil)
+				store.EXPECT().FreezeAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusNotFound, apperror.CodeNotFound)
+			},
+		},
+		{
+			name:     "NotAdmin",
+			username: user.Username,
+			action:   "unfreeze",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().UnfreezeAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusForbidden, apperror.CodeForbidden)
+			},
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/admin/accounts/%d/%s", account.ID, tc.action)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+
+			addAuth(t, request, server.tokenMaker, constants.AuthTypeBearer, tc.username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestMetricsAPI(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().CountFrozenAccounts(gomock.Any()).Times(1).Return(int64(2), nil)
+	store.EXPECT().GetLatestReconciliationRun(gomock.Any()).Times(1).Return(db.ReconciliationRun{}, sql.ErrNoRows)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodGet, "/metrics", nil)
+	require.NoError(t, err)
+	server.router.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, reconcile.MetricsContentType, recorder.Header().Get("Content-Type"))
+	require.Contains(t, recorder.Body.String(), "reconciliation_frozen_accounts 2\n")
+}
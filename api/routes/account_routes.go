@@ -3,31 +3,42 @@ package routes
 import (
 	"github.com/hhow09/simple_bank/api/controllers"
 	"github.com/hhow09/simple_bank/api/middlewares"
+	"github.com/hhow09/simple_bank/constants"
 	"github.com/hhow09/simple_bank/lib"
 )
 
 type AccountRotes struct {
-	controller     controllers.AccountController
-	requestHandler lib.RequestHandler
-	authMiddleware middlewares.AuthMiddleware
+	controller              controllers.AccountController
+	requestHandler          lib.RequestHandler
+	authMiddleware          middlewares.AuthMiddleware
+	verifiedEmailMiddleware middlewares.VerifiedEmailMiddleware
 }
 
 // Setup user routes
 func (r AccountRotes) Setup() {
-	accountRoutes := r.requestHandler.Gin.Group("/accounts").Use(r.authMiddleware.Handler())
-	accountRoutes.POST("", r.controller.CreateAccount)
-	accountRoutes.GET("/:id", r.controller.GetAccount)
-	accountRoutes.GET("", r.controller.ListAccounts)
+	accountRoutes := r.requestHandler.Gin.Group("/accounts")
+	accountRoutes.POST("", r.authMiddleware.Handler(), r.verifiedEmailMiddleware.Handler(), r.controller.CreateAccount)
+	accountRoutes.GET("/:id", r.authMiddleware.Handler(constants.ScopeAccountsRead), r.controller.GetAccount)
+	accountRoutes.GET("/:id/balance", r.authMiddleware.Handler(constants.ScopeAccountsRead), r.controller.GetAccountBalance)
+	accountRoutes.GET("/number/:number", r.authMiddleware.Handler(constants.ScopeAccountsRead), r.controller.GetAccountByNumber)
+	accountRoutes.PATCH("/:id", r.authMiddleware.Handler(), r.controller.UpdateAccount)
+	accountRoutes.GET("", r.authMiddleware.Handler(constants.ScopeAccountsRead), r.controller.ListAccounts)
+	accountRoutes.GET("/:id/members", r.authMiddleware.Handler(constants.ScopeAccountsRead), r.controller.ListAccountMembers)
+	accountRoutes.POST("/:id/members", r.authMiddleware.Handler(), r.controller.AddAccountMember)
+	accountRoutes.PATCH("/:id/members/:username", r.authMiddleware.Handler(), r.controller.UpdateAccountMember)
+	accountRoutes.DELETE("/:id/members/:username", r.authMiddleware.Handler(), r.controller.RemoveAccountMember)
 }
 
 func NewAccountRoutes(
 	controller controllers.AccountController,
 	requestHandler lib.RequestHandler,
 	authMiddleware middlewares.AuthMiddleware,
+	verifiedEmailMiddleware middlewares.VerifiedEmailMiddleware,
 ) AccountRotes {
 	return AccountRotes{
 		controller,
 		requestHandler,
 		authMiddleware,
+		verifiedEmailMiddleware,
 	}
 }
@@ -0,0 +1,58 @@
+package routes
+
+import (
+	"github.com/hhow09/simple_bank/api/controllers"
+	"github.com/hhow09/simple_bank/api/middlewares"
+	"github.com/hhow09/simple_bank/lib"
+)
+
+type AdminRoutes struct {
+	userController           controllers.UserController
+	accountController        controllers.AccountController
+	ledgerController         controllers.LedgerController
+	reconciliationController controllers.ReconciliationController
+	interestController       controllers.InterestController
+	requestHandler           lib.RequestHandler
+	authMiddleware           middlewares.AuthMiddleware
+	adminMiddleware          middlewares.AdminMiddleware
+}
+
+// Setup admin routes
+func (r AdminRoutes) Setup() {
+	adminRoutes := r.requestHandler.Gin.Group("/admin").Use(r.authMiddleware.Handler(), r.adminMiddleware.Handler())
+	adminRoutes.POST("/users/:username/unlock", r.userController.UnlockUser)
+	adminRoutes.PUT("/users/:username/tier", r.userController.SetUserTier)
+	adminRoutes.GET("/ledger_accounts", r.ledgerController.ListLedgerAccounts)
+	adminRoutes.POST("/journal_transactions", r.ledgerController.CreateJournalTransaction)
+	adminRoutes.GET("/journal_transactions/:id", r.ledgerController.GetJournalTransaction)
+	adminRoutes.POST("/accounts/:id/freeze", r.accountController.FreezeAccount)
+	adminRoutes.POST("/accounts/:id/unfreeze", r.accountController.UnfreezeAccount)
+	adminRoutes.POST("/reconciliations", r.reconciliationController.RunReconciliation)
+	adminRoutes.GET("/reconciliations", r.reconciliationController.ListReconciliations)
+	adminRoutes.GET("/reconciliations/:id", r.reconciliationController.GetReconciliation)
+	adminRoutes.GET("/interest_rates", r.interestController.ListInterestRates)
+	adminRoutes.POST("/interest_rates", r.interestController.CreateInterestRate)
+	adminRoutes.DELETE("/interest_rates/:id", r.interestController.DeleteInterestRate)
+}
+
+func NewAdminRoutes(
+	userController controllers.UserController,
+	accountController controllers.AccountController,
+	ledgerController controllers.LedgerController,
+	reconciliationController controllers.ReconciliationController,
+	interestController controllers.InterestController,
+	requestHandler lib.RequestHandler,
+	authMiddleware middlewares.AuthMiddleware,
+	adminMiddleware middlewares.AdminMiddleware,
+) AdminRoutes {
+	return AdminRoutes{
+		userController,
+		accountController,
+		ledgerController,
+		reconciliationController,
+		interestController,
+		requestHandler,
+		authMiddleware,
+		adminMiddleware,
+	}
+}
@@ -0,0 +1,33 @@
+package routes
+
+import (
+	"github.com/hhow09/simple_bank/api/controllers"
+	"github.com/hhow09/simple_bank/api/middlewares"
+	"github.com/hhow09/simple_bank/lib"
+)
+
+type APIKeyRoutes struct {
+	controller     controllers.APIKeyController
+	requestHandler lib.RequestHandler
+	authMiddleware middlewares.AuthMiddleware
+}
+
+// Setup api key routes, keys are managed with access tokens only
+func (r APIKeyRoutes) Setup() {
+	apiKeyRoutes := r.requestHandler.Gin.Group("/users/me/api_keys").Use(r.authMiddleware.Handler())
+	apiKeyRoutes.POST("", r.controller.CreateAPIKey)
+	apiKeyRoutes.GET("", r.controller.ListAPIKeys)
+	apiKeyRoutes.DELETE("/:id", r.controller.RevokeAPIKey)
+}
+
+func NewAPIKeyRoutes(
+	controller controllers.APIKeyController,
+	requestHandler lib.RequestHandler,
+	authMiddleware middlewares.AuthMiddleware,
+) APIKeyRoutes {
+	return APIKeyRoutes{
+		controller,
+		requestHandler,
+		authMiddleware,
+	}
+}
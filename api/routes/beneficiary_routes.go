@@ -0,0 +1,35 @@
+package routes
+
+import (
+	"github.com/hhow09/simple_bank/api/controllers"
+	"github.com/hhow09/simple_bank/api/middlewares"
+	"github.com/hhow09/simple_bank/lib"
+)
+
+type BeneficiaryRoutes struct {
+	controller     controllers.BeneficiaryController
+	requestHandler lib.RequestHandler
+	authMiddleware middlewares.AuthMiddleware
+}
+
+// Setup beneficiary routes, beneficiaries are managed with access tokens only
+func (r BeneficiaryRoutes) Setup() {
+	beneficiaryRoutes := r.requestHandler.Gin.Group("/beneficiaries").Use(r.authMiddleware.Handler())
+	beneficiaryRoutes.POST("", r.controller.CreateBeneficiary)
+	beneficiaryRoutes.GET("", r.controller.ListBeneficiaries)
+	beneficiaryRoutes.GET("/:id", r.controller.GetBeneficiary)
+	beneficiaryRoutes.PATCH("/:id", r.controller.UpdateBeneficiary)
+	beneficiaryRoutes.DELETE("/:id", r.controller.DeleteBeneficiary)
+}
+
+func NewBeneficiaryRoutes(
+	controller controllers.BeneficiaryController,
+	requestHandler lib.RequestHandler,
+	authMiddleware middlewares.AuthMiddleware,
+) BeneficiaryRoutes {
+	return BeneficiaryRoutes{
+		controller,
+		requestHandler,
+		authMiddleware,
+	}
+}
@@ -0,0 +1,27 @@
+package routes
+
+import (
+	"github.com/hhow09/simple_bank/api/controllers"
+	"github.com/hhow09/simple_bank/lib"
+)
+
+type CurrencyRoutes struct {
+	controller     controllers.CurrencyController
+	requestHandler lib.RequestHandler
+}
+
+// Setup currency routes, the currencies are public
+func (r CurrencyRoutes) Setup() {
+	currencyRoutes := r.requestHandler.Gin.Group("/currencies")
+	currencyRoutes.GET("", r.controller.ListCurrencies)
+}
+
+func NewCurrencyRoutes(
+	controller controllers.CurrencyController,
+	requestHandler lib.RequestHandler,
+) CurrencyRoutes {
+	return CurrencyRoutes{
+		controller,
+		requestHandler,
+	}
+}
@@ -0,0 +1,32 @@
+package routes
+
+import (
+	"github.com/hhow09/simple_bank/api/controllers"
+	"github.com/hhow09/simple_bank/api/middlewares"
+	"github.com/hhow09/simple_bank/constants"
+	"github.com/hhow09/simple_bank/lib"
+)
+
+type InterestRoutes struct {
+	controller     controllers.InterestController
+	requestHandler lib.RequestHandler
+	authMiddleware middlewares.AuthMiddleware
+}
+
+// Setup interest routes
+func (r InterestRoutes) Setup() {
+	interestRoutes := r.requestHandler.Gin.Group("/accounts")
+	interestRoutes.GET("/:id/interest", r.authMiddleware.Handler(constants.ScopeAccountsRead), r.controller.ListInterestPostings)
+}
+
+func NewInterestRoutes(
+	controller controllers.InterestController,
+	requestHandler lib.RequestHandler,
+	authMiddleware middlewares.AuthMiddleware,
+) InterestRoutes {
+	return InterestRoutes{
+		controller,
+		requestHandler,
+		authMiddleware,
+	}
+}
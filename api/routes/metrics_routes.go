@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"github.com/hhow09/simple_bank/api/controllers"
+	"github.com/hhow09/simple_bank/lib"
+)
+
+type MetricsRoutes struct {
+	controller     controllers.ReconciliationController
+	requestHandler lib.RequestHandler
+}
+
+// Setup metrics routes, they are scraped without authentication and only expose aggregates
+func (r MetricsRoutes) Setup() {
+	r.requestHandler.Gin.GET("/metrics", r.controller.Metrics)
+}
+
+func NewMetricsRoutes(
+	controller controllers.ReconciliationController,
+	requestHandler lib.RequestHandler,
+) MetricsRoutes {
+	return MetricsRoutes{
+		controller,
+		requestHandler,
+	}
+}
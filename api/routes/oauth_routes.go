@@ -0,0 +1,40 @@
+package routes
+
+import (
+	"github.com/hhow09/simple_bank/api/controllers"
+	"github.com/hhow09/simple_bank/api/middlewares"
+	"github.com/hhow09/simple_bank/lib"
+)
+
+type OAuthRoutes struct {
+	controller     controllers.OAuthController
+	requestHandler lib.RequestHandler
+	authMiddleware middlewares.AuthMiddleware
+}
+
+// Setup oauth routes, clients and consents are managed with access tokens of users only
+func (r OAuthRoutes) Setup() {
+	oauthRoutes := r.requestHandler.Gin.Group("/oauth")
+	oauthRoutes.POST("/token", r.controller.Token)
+
+	authorized := oauthRoutes.Group("").Use(r.authMiddleware.Handler())
+	authorized.POST("/clients", r.controller.CreateClient)
+	authorized.GET("/clients", r.controller.ListClients)
+	authorized.POST("/authorize", r.controller.Authorize)
+
+	consentRoutes := r.requestHandler.Gin.Group("/users/me/oauth_consents").Use(r.authMiddleware.Handler())
+	consentRoutes.GET("", r.controller.ListConsents)
+	consentRoutes.DELETE("/:client_id", r.controller.RevokeConsent)
+}
+
+func NewOAuthRoutes(
+	controller controllers.OAuthController,
+	requestHandler lib.RequestHandler,
+	authMiddleware middlewares.AuthMiddleware,
+) OAuthRoutes {
+	return OAuthRoutes{
+		controller,
+		requestHandler,
+		authMiddleware,
+	}
+}
@@ -0,0 +1,32 @@
+package routes
+
+import (
+	"github.com/hhow09/simple_bank/api/controllers"
+	"github.com/hhow09/simple_bank/api/middlewares"
+	"github.com/hhow09/simple_bank/lib"
+)
+
+type PrivacyRoutes struct {
+	controller     controllers.PrivacyController
+	requestHandler lib.RequestHandler
+	authMiddleware middlewares.AuthMiddleware
+}
+
+// Setup privacy routes, only users themselves can export or delete their data
+func (r PrivacyRoutes) Setup() {
+	me := r.requestHandler.Gin.Group("/users/me").Use(r.authMiddleware.Handler())
+	me.GET("/export", r.controller.ExportUser)
+	me.DELETE("", r.controller.DeleteUser)
+}
+
+func NewPrivacyRoutes(
+	controller controllers.PrivacyController,
+	requestHandler lib.RequestHandler,
+	authMiddleware middlewares.AuthMiddleware,
+) PrivacyRoutes {
+	return PrivacyRoutes{
+		controller,
+		requestHandler,
+		authMiddleware,
+	}
+}
@@ -6,6 +6,16 @@ var Module = fx.Options(
 	fx.Provide(NewUserRoutes),
 	fx.Provide(NewAccountRoutes),
 	fx.Provide(NewTransferRoutes),
+	fx.Provide(NewAdminRoutes),
+	fx.Provide(NewTwoFactorRoutes),
+	fx.Provide(NewAPIKeyRoutes),
+	fx.Provide(NewOAuthRoutes),
+	fx.Provide(NewPrivacyRoutes),
+	fx.Provide(NewBeneficiaryRoutes),
+	fx.Provide(NewCurrencyRoutes),
+	fx.Provide(NewMetricsRoutes),
+	fx.Provide(NewStatementRoutes),
+	fx.Provide(NewInterestRoutes),
 	// add more here
 	fx.Provide(NewSwaggerRoutes),
 	fx.Provide(NewRoutes),
@@ -24,11 +34,31 @@ func NewRoutes(
 	swaggerRoutes SwaggerRoutes,
 	accountRoutes AccountRotes,
 	transferRoutes TransferRoutes,
+	adminRoutes AdminRoutes,
+	twoFactorRoutes TwoFactorRoutes,
+	apiKeyRoutes APIKeyRoutes,
+	oauthRoutes OAuthRoutes,
+	privacyRoutes PrivacyRoutes,
+	beneficiaryRoutes BeneficiaryRoutes,
+	currencyRoutes CurrencyRoutes,
+	metricsRoutes MetricsRoutes,
+	statementRoutes StatementRoutes,
+	interestRoutes InterestRoutes,
 ) Routes {
 	return Routes{
 		userRoutes,
 		accountRoutes,
 		transferRoutes,
+		adminRoutes,
+		twoFactorRoutes,
+		apiKeyRoutes,
+		oauthRoutes,
+		privacyRoutes,
+		beneficiaryRoutes,
+		currencyRoutes,
+		metricsRoutes,
+		statementRoutes,
+		interestRoutes,
 		swaggerRoutes,
 	}
 }
@@ -0,0 +1,34 @@
+package routes
+
+import (
+	"github.com/hhow09/simple_bank/api/controllers"
+	"github.com/hhow09/simple_bank/api/middlewares"
+	"github.com/hhow09/simple_bank/constants"
+	"github.com/hhow09/simple_bank/lib"
+)
+
+type StatementRoutes struct {
+	controller     controllers.StatementController
+	requestHandler lib.RequestHandler
+	authMiddleware middlewares.AuthMiddleware
+}
+
+// Setup statement routes
+func (r StatementRoutes) Setup() {
+	statementRoutes := r.requestHandler.Gin.Group("/accounts")
+	statementRoutes.GET("/:id/statements", r.authMiddleware.Handler(constants.ScopeAccountsRead), r.controller.GetStatement)
+	statementRoutes.GET("/:id/monthly-statements", r.authMiddleware.Handler(constants.ScopeAccountsRead), r.controller.ListMonthlyStatements)
+	statementRoutes.GET("/:id/monthly-statements/:month", r.authMiddleware.Handler(constants.ScopeAccountsRead), r.controller.GetMonthlyStatement)
+}
+
+func NewStatementRoutes(
+	controller controllers.StatementController,
+	requestHandler lib.RequestHandler,
+	authMiddleware middlewares.AuthMiddleware,
+) StatementRoutes {
+	return StatementRoutes{
+		controller,
+		requestHandler,
+		authMiddleware,
+	}
+}
@@ -3,29 +3,38 @@ package routes
 import (
 	"github.com/hhow09/simple_bank/api/controllers"
 	"github.com/hhow09/simple_bank/api/middlewares"
+	"github.com/hhow09/simple_bank/constants"
 	"github.com/hhow09/simple_bank/lib"
 )
 
 type TransferRoutes struct {
-	controller     controllers.TransferController
-	requestHandler lib.RequestHandler
-	authMiddleware middlewares.AuthMiddleware
+	controller              controllers.TransferController
+	requestHandler          lib.RequestHandler
+	authMiddleware          middlewares.AuthMiddleware
+	rateLimitMiddleware     middlewares.RateLimitMiddleware
+	verifiedEmailMiddleware middlewares.VerifiedEmailMiddleware
 }
 
 // Setup user routes
 func (r TransferRoutes) Setup() {
-	transferRoutes := r.requestHandler.Gin.Group("/transfers").Use(r.authMiddleware.Handler())
-	transferRoutes.POST("", r.controller.CreateTransfer)
+	transferRoutes := r.requestHandler.Gin.Group("/transfers")
+	transferRoutes.POST("", r.authMiddleware.Handler(constants.ScopeTransfersCreate), r.rateLimitMiddleware.Transfer(), r.verifiedEmailMiddleware.Handler(), r.controller.CreateTransfer)
+	transferRoutes.POST("/preview", r.authMiddleware.Handler(constants.ScopeTransfersCreate), r.controller.PreviewTransfer)
+	transferRoutes.GET("", r.authMiddleware.Handler(constants.ScopeAccountsRead), r.controller.ListTransfers)
 }
 
 func NewTransferRoutes(
 	controller controllers.TransferController,
 	requestHandler lib.RequestHandler,
 	authMiddleware middlewares.AuthMiddleware,
+	rateLimitMiddleware middlewares.RateLimitMiddleware,
+	verifiedEmailMiddleware middlewares.VerifiedEmailMiddleware,
 ) TransferRoutes {
 	return TransferRoutes{
 		controller,
 		requestHandler,
 		authMiddleware,
+		rateLimitMiddleware,
+		verifiedEmailMiddleware,
 	}
 }
@@ -0,0 +1,32 @@
+package routes
+
+import (
+	"github.com/hhow09/simple_bank/api/controllers"
+	"github.com/hhow09/simple_bank/api/middlewares"
+	"github.com/hhow09/simple_bank/lib"
+)
+
+type TwoFactorRoutes struct {
+	controller     controllers.TwoFactorController
+	requestHandler lib.RequestHandler
+	authMiddleware middlewares.AuthMiddleware
+}
+
+// Setup two-factor routes
+func (r TwoFactorRoutes) Setup() {
+	totpRoutes := r.requestHandler.Gin.Group("/users/me/totp").Use(r.authMiddleware.Handler())
+	totpRoutes.POST("", r.controller.EnrollTOTP)
+	totpRoutes.POST("/confirm", r.controller.ConfirmTOTP)
+}
+
+func NewTwoFactorRoutes(
+	controller controllers.TwoFactorController,
+	requestHandler lib.RequestHandler,
+	authMiddleware middlewares.AuthMiddleware,
+) TwoFactorRoutes {
+	return TwoFactorRoutes{
+		controller,
+		requestHandler,
+		authMiddleware,
+	}
+}
@@ -2,27 +2,44 @@ package routes
 
 import (
 	"github.com/hhow09/simple_bank/api/controllers"
+	"github.com/hhow09/simple_bank/api/middlewares"
 	"github.com/hhow09/simple_bank/lib"
 )
 
 type UserRoutes struct {
-	controller     controllers.UserController
-	requestHandler lib.RequestHandler
+	controller          controllers.UserController
+	requestHandler      lib.RequestHandler
+	authMiddleware      middlewares.AuthMiddleware
+	rateLimitMiddleware middlewares.RateLimitMiddleware
 }
 
 // Setup user routes
 func (r UserRoutes) Setup() {
 	users := r.requestHandler.Gin.Group("/users")
 	users.POST("", r.controller.CreateUser)
-	users.POST("/login", r.controller.LoginUser)
+	users.POST("/login", r.rateLimitMiddleware.Login(), r.controller.LoginUser)
+	users.POST("/login/2fa", r.rateLimitMiddleware.Login(), r.controller.LoginTwoFactor)
+	users.POST("/password_reset", r.rateLimitMiddleware.Login(), r.controller.RequestPasswordReset)
+	users.POST("/password_reset/confirm", r.controller.ResetPassword)
+	users.GET("/verify_email", r.controller.VerifyEmail)
+
+	me := users.Group("/me").Use(r.authMiddleware.Handler())
+	me.GET("", r.controller.GetProfile)
+	me.PATCH("", r.controller.UpdateProfile)
+	me.PUT("/password", r.controller.ChangePassword)
+	me.POST("/verify_email", r.controller.ResendVerifyEmail)
 }
 
 func NewUserRoutes(
 	controller controllers.UserController,
 	requestHandler lib.RequestHandler,
+	authMiddleware middlewares.AuthMiddleware,
+	rateLimitMiddleware middlewares.RateLimitMiddleware,
 ) UserRoutes {
 	return UserRoutes{
 		controller,
 		requestHandler,
+		authMiddleware,
+		rateLimitMiddleware,
 	}
 }
@@ -13,6 +13,7 @@ import (
 	"github.com/hhow09/simple_bank/api/routes"
 	db "github.com/hhow09/simple_bank/db/sqlc"
 	"github.com/hhow09/simple_bank/lib"
+	"github.com/hhow09/simple_bank/money"
 	"github.com/hhow09/simple_bank/token"
 	"github.com/hhow09/simple_bank/util"
 	"go.uber.org/fx"
@@ -42,12 +43,18 @@ type Server struct {
 	tokenMaker token.Maker
 }
 
-func NewServer(config util.Config, store db.Store, tokenMaker token.Maker, requestHandler lib.RequestHandler) (*Server, error) {
+func NewServer(config util.Config, store db.Store, tokenMaker token.Maker, requestHandler lib.RequestHandler, currencies *money.Registry) (*Server, error) {
 	server := &Server{store: store, tokenMaker: tokenMaker, config: config, router: requestHandler.Gin}
 	//binding custom validator
 	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
 		//registor validator to gin
-		v.RegisterValidation("currency", validCurrency)
+		v.RegisterValidation("currency", validCurrency(currencies))
+		v.RegisterValidation("account_number", validAccountNumber)
+		v.RegisterValidation("reference", validReference)
+		v.RegisterValidation("memo", validMemo)
+		v.RegisterValidation("password", validPassword(util.NewPasswordPolicy(config)))
+		// report request field names instead of struct field names in validation errors
+		v.RegisterTagNameFunc(requestFieldName)
 	}
 	// server.setupRouter()
 	return server, nil
@@ -93,7 +100,8 @@ var Module = fx.Options(
 	routes.Module,
 	middlewares.Module,
 	fx.Provide(NewServer),
-	fx.Invoke(setupRoutes),
+	// global middlewares must be registered before routes
 	fx.Invoke(setupMiddleware),
+	fx.Invoke(setupRoutes),
 	fx.Invoke(registerHooks),
 )
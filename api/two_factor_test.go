@@ -0,0 +1,621 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/hhow09/simple_bank/apperror"
+	"github.com/hhow09/simple_bank/constants"
+	mockdb "github.com/hhow09/simple_bank/db/mock"
+	db "github.com/hhow09/simple_bank/db/sqlc"
+	"github.com/hhow09/simple_bank/totp"
+	"github.com/hhow09/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+// randomTOTPUser returns a user with 2FA enabled
+func randomTOTPUser(t *testing.T) (user db.User, password string) {
+	user, password = randomUser(t)
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+	user.TotpSecret = secret
+	user.IsTotpEnabled = true
+	return
+}
+
+func currentTOTPCode(t *testing.T, secret string) string {
+	code, err := totp.Code(secret, totp.Step(time.Now()))
+	require.NoError(t, err)
+	return code
+}
+
+func TestEnrollTOTPAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	enabled, _ := randomTOTPUser(t)
+
+	testCases := []struct {
+		name          string
+		user          db.User
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			user: user,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					SetUserTOTPSecret(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.SetUserTOTPSecretParams) (db.User, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.NotEmpty(t, arg.TotpSecret)
+						return user, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp struct {
+					Secret     string `json:"secret"`
+					OtpauthURI string `json:"otpauth_uri"`
+				}
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.NotEmpty(t, rsp.Secret)
+
+				uri, err := url.Parse(rsp.OtpauthURI)
+				require.NoError(t, err)
+				require.Equal(t, "otpauth", uri.Scheme)
+				require.Equal(t, rsp.Secret, uri.Query().Get("secret"))
+			},
+		},
+		{
+			name: "AlreadyEnabled",
+			user: enabled,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().SetUserTOTPSecret(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusConflict, apperror.CodeConflict)
+			},
+		},
+		{
+			name: "InternalError",
+			user: user,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().SetUserTOTPSecret(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusInternalServerError, apperror.CodeInternal)
+			},
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetUser(gomock.Any(), gomock.Eq(tc.user.Username)).Times(1).Return(tc.user, nil)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodPost, "/users/me/totp", nil)
+			require.NoError(t, err)
+
+			addAuth(t, request, server.tokenMaker, constants.AuthTypeBearer, tc.user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestConfirmTOTPAPI(t *testing.T) {
+	pending, _ := randomTOTPUser(t)
+	pending.IsTotpEnabled = false
+	notEnrolled, _ := randomUser(t)
+	enabled, _ := randomTOTPUser(t)
+
+	testCases := []struct {
+		name          string
+		user          db.User
+		code          func() string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			user: pending,
+			code: func() string { return currentTOTPCode(t, pending.TotpSecret) },
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					EnableTOTPTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.EnableTOTPTxParams) (db.User, error) {
+						require.Equal(t, pending.Username, arg.Username)
+						require.Len(t, arg.RecoveryCodeHashes, 10)
+						return pending, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp struct {
+					RecoveryCodes []string `json:"recovery_codes"`
+				}
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Len(t, rsp.RecoveryCodes, 10)
+			},
+		},
+		{
+			name: "WrongCode",
+			user: pending,
+			code: func() string {
+				code, err := totp.Code(pending.TotpSecret, totp.Step(time.Now())+5)
+				require.NoError(t, err)
+				return code
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().EnableTOTPTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, apperror.CodeValidation)
+			},
+		},
+		{
+			name: "ReplayedCode",
+			user: pending,
+			code: func() string { return currentTOTPCode(t, pending.TotpSecret) },
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().EnableTOTPTx(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, apperror.CodeValidation)
+			},
+		},
+		{
+			name: "NotEnrolled",
+			user: notEnrolled,
+			code: func() string { return "123456" },
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().EnableTOTPTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusConflict, apperror.CodeConflict)
+			},
+		},
+		{
+			name: "AlreadyEnabled",
+			user: enabled,
+			code: func() string { return currentTOTPCode(t, enabled.TotpSecret) },
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().EnableTOTPTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusConflict, apperror.CodeConflict)
+			},
+		},
+		{
+			name: "InvalidCodeFormat",
+			user: pending,
+			code: func() string { return "12ab" },
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().EnableTOTPTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, apperror.CodeValidation)
+			},
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetUser(gomock.Any(), gomock.Eq(tc.user.Username)).Times(1).Return(tc.user, nil)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(gin.H{"code": tc.code()})
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/users/me/totp/confirm", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuth(t, request, server.tokenMaker, constants.AuthTypeBearer, tc.user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestLoginTOTPChallengeAPI(t *testing.T) {
+	user, password := randomTOTPUser(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetLoginFailures(gomock.Any(), gomock.Any()).Times(1)
+	store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+	// failures are only cleared once the second factor is verified
+	store.EXPECT().CreateLoginAttempt(gomock.Any(), gomock.Any()).Times(0)
+	store.EXPECT().ClearLoginFailures(gomock.Any(), gomock.Any()).Times(0)
+	store.EXPECT().
+		CreateLoginChallenge(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ interface{}, arg db.CreateLoginChallengeParams) (db.LoginChallenge, error) {
+			require.Equal(t, user.Username, arg.Username)
+			return db.LoginChallenge{Username: arg.Username, TokenHash: arg.TokenHash, ExpiresAt: arg.ExpiresAt}, nil
+		})
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	data, err := json.Marshal(gin.H{"username": user.Username, "password": password})
+	require.NoError(t, err)
+	request, err := http.NewRequest(http.MethodPost, "/users/login", bytes.NewReader(data))
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusAccepted, recorder.Code)
+
+	var rsp struct {
+		TwoFactorRequired bool   `json:"two_factor_required"`
+		ChallengeToken    string `json:"challenge_token"`
+		AccessToken       string `json:"access_token"`
+	}
+	err = json.Unmarshal(recorder.Body.Bytes(), &rsp)
+	require.NoError(t, err)
+	require.True(t, rsp.TwoFactorRequired)
+	require.NotEmpty(t, rsp.ChallengeToken)
+	require.Empty(t, rsp.AccessToken)
+}
+
+func TestLoginTwoFactorAPI(t *testing.T) {
+	user, _ := randomTOTPUser(t)
+	challengeToken, err := util.RandomToken(32)
+	require.NoError(t, err)
+	challenge := db.LoginChallenge{
+		Username:  user.Username,
+		TokenHash: util.HashToken(challengeToken),
+		ExpiresAt: time.Now().Add(time.Minute),
+	}
+	recoveryCode := "abcde-fghij"
+
+	testCases := []struct {
+		name          string
+		body          func() gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: func() gin.H {
+				return gin.H{"challenge_token": challengeToken, "code": currentTOTPCode(t, user.TotpSecret)}
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetLoginChallenge(gomock.Any(), gomock.Eq(challenge.TokenHash)).Times(1).Return(challenge, nil)
+				store.EXPECT().GetLoginFailures(gomock.Any(), gomock.Any()).Times(1)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().
+					UseUserTOTPStep(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ interface{}, arg db.UseUserTOTPStepParams) (db.User, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.InDelta(t, totp.Step(time.Now()), arg.Step, 1)
+						return user, nil
+					})
+				store.EXPECT().ConsumeLoginChallenge(gomock.Any(), gomock.Eq(challenge.TokenHash)).Times(1).Return(challenge, nil)
+				store.EXPECT().CreateLoginAttempt(gomock.Any(), eqLoginAttempt(user.Username, true)).Times(1)
+				store.EXPECT().ClearLoginFailures(gomock.Any(), gomock.Eq(user.Username)).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp struct {
+					AccessToken string `json:"access_token"`
+				}
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.NotEmpty(t, rsp.AccessToken)
+			},
+		},
+		{
+			name: "RecoveryCode",
+			body: func() gin.H {
+				return gin.H{"challenge_token": challengeToken, "recovery_code": "ABCDE-FGHIJ"}
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetLoginChallenge(gomock.Any(), gomock.Any()).Times(1).Return(challenge, nil)
+				store.EXPECT().GetLoginFailures(gomock.Any(), gomock.Any()).Times(1)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().
+					UseRecoveryCode(gomock.Any(), gomock.Eq(db.UseRecoveryCodeParams{
+						Username: user.Username,
+						CodeHash: util.HashToken(totp.NormalizeRecoveryCode(recoveryCode)),
+					})).
+					Times(1)
+				store.EXPECT().ConsumeLoginChallenge(gomock.Any(), gomock.Any()).Times(1).Return(challenge, nil)
+				store.EXPECT().CreateLoginAttempt(gomock.Any(), eqLoginAttempt(user.Username, true)).Times(1)
+				store.EXPECT().ClearLoginFailures(gomock.Any(), gomock.Eq(user.Username)).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "WrongCode",
+			body: func() gin.H {
+				code, err := totp.Code(user.TotpSecret, totp.Step(time.Now())+5)
+				require.NoError(t, err)
+				return gin.H{"challenge_token": challengeToken, "code": code}
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetLoginChallenge(gomock.Any(), gomock.Any()).Times(1).Return(challenge, nil)
+				store.EXPECT().GetLoginFailures(gomock.Any(), gomock.Any()).Times(1)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().UseUserTOTPStep(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ConsumeLoginChallenge(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateLoginAttempt(gomock.Any(), eqLoginAttempt(user.Username, false)).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusUnauthorized, apperror.CodeUnauthorized)
+			},
+		},
+		{
+			name: "ReplayedCode",
+			body: func() gin.H {
+				return gin.H{"challenge_token": challengeToken, "code": currentTOTPCode(t, user.TotpSecret)}
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetLoginChallenge(gomock.Any(), gomock.Any()).Times(1).Return(challenge, nil)
+				store.EXPECT().GetLoginFailures(gomock.Any(), gomock.Any()).Times(1)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().UseUserTOTPStep(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, sql.ErrNoRows)
+				store.EXPECT().ConsumeLoginChallenge(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateLoginAttempt(gomock.Any(), eqLoginAttempt(user.Username, false)).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusUnauthorized, apperror.CodeUnauthorized)
+			},
+		},
+		{
+			name: "UsedRecoveryCode",
+			body: func() gin.H {
+				return gin.H{"challenge_token": challengeToken, "recovery_code": recoveryCode}
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetLoginChallenge(gomock.Any(), gomock.Any()).Times(1).Return(challenge, nil)
+				store.EXPECT().GetLoginFailures(gomock.Any(), gomock.Any()).Times(1)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().UseRecoveryCode(gomock.Any(), gomock.Any()).Times(1).Return(db.RecoveryCode{}, sql.ErrNoRows)
+				store.EXPECT().CreateLoginAttempt(gomock.Any(), eqLoginAttempt(user.Username, false)).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusUnauthorized, apperror.CodeUnauthorized)
+			},
+		},
+		{
+			name: "InvalidChallenge",
+			body: func() gin.H {
+				return gin.H{"challenge_token": challengeToken, "code": currentTOTPCode(t, user.TotpSecret)}
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetLoginChallenge(gomock.Any(), gomock.Any()).Times(1).Return(db.LoginChallenge{}, sql.ErrNoRows)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusUnauthorized, apperror.CodeUnauthorized)
+			},
+		},
+		{
+			name: "LockedOut",
+			body: func() gin.H {
+				return gin.H{"challenge_token": challengeToken, "code": currentTOTPCode(t, user.TotpSecret)}
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetLoginChallenge(gomock.Any(), gomock.Any()).Times(1).Return(challenge, nil)
+				store.EXPECT().
+					GetLoginFailures(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.GetLoginFailuresRow{Failures: 5, LastFailedAt: time.Now()}, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusLocked, apperror.CodeAccountLocked)
+			},
+		},
+		{
+			name: "MissingCode",
+			body: func() gin.H {
+				return gin.H{"challenge_token": challengeToken}
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetLoginChallenge(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusBadRequest, apperror.CodeValidation)
+			},
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body())
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/users/login/2fa", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestTransferStepUpAPI(t *testing.T) {
+	user, _ := randomTOTPUser(t)
+	recipient, _ := randomUser(t)
+
+	account1 := randomAccount(user.Username)
+	account2 := randomAccount(recipient.Username)
+	account1.Currency = util.USD
+	account2.Currency = util.USD
+	account1.Balance = 10_000_000
+
+	testCases := []struct {
+		name          string
+		amount        int64
+		code          func() string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "BelowThreshold",
+			amount: 10,
+			code:   func() string { return "" },
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UseUserTOTPStep(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:   "CodeRequired",
+			amount: 1_000_000,
+			code:   func() string { return "" },
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusForbidden, apperror.CodeTwoFactorRequired)
+			},
+		},
+		{
+			name:   "ValidCode",
+			amount: 1_000_000,
+			code:   func() string { return currentTOTPCode(t, user.TotpSecret) },
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetLoginFailures(gomock.Any(), gomock.Any()).Times(1)
+				store.EXPECT().UseUserTOTPStep(gomock.Any(), gomock.Any()).Times(1).Return(user, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:   "WrongCode",
+			amount: 1_000_000,
+			code: func() string {
+				code, err := totp.Code(user.TotpSecret, totp.Step(time.Now())+5)
+				require.NoError(t, err)
+				return code
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetLoginFailures(gomock.Any(), gomock.Any()).Times(1)
+				store.EXPECT().CreateLoginAttempt(gomock.Any(), eqLoginAttempt(user.Username, false)).Times(1)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusUnauthorized, apperror.CodeUnauthorized)
+			},
+		},
+		{
+			name:   "ReplayedCode",
+			amount: 1_000_000,
+			code:   func() string { return currentTOTPCode(t, user.TotpSecret) },
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetLoginFailures(gomock.Any(), gomock.Any()).Times(1)
+				store.EXPECT().UseUserTOTPStep(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, sql.ErrNoRows)
+				store.EXPECT().CreateLoginAttempt(gomock.Any(), eqLoginAttempt(user.Username, false)).Times(1)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusUnauthorized, apperror.CodeUnauthorized)
+			},
+		},
+		{
+			name:   "LockedOut",
+			amount: 1_000_000,
+			code:   func() string { return currentTOTPCode(t, user.TotpSecret) },
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginFailures(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.GetLoginFailuresRow{Failures: 5, LastFailedAt: time.Now()}, nil)
+				store.EXPECT().UseUserTOTPStep(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireProblem(t, recorder, http.StatusLocked, apperror.CodeAccountLocked)
+				require.NotEmpty(t, recorder.Header().Get("Retry-After"))
+			},
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+			store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+			store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+			tc.buildStubs(store)
+			stubAccountMembers(store, account1, account2)
+
+			server := newTestServer(t, store)
+			require.Less(t, server.config.TwoFactorTransferThreshold, int64(1_000_000))
+			recorder := httptest.NewRecorder()
+
+			body := gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          tc.amount,
+				"currency":        util.USD,
+			}
+			if code := tc.code(); code != "" {
+				body["totp_code"] = code
+			}
+			data, err := json.Marshal(body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/transfers", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuth(t, request, server.tokenMaker, constants.AuthTypeBearer, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
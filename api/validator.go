@@ -1,14 +1,91 @@
 package api
 
 import (
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
 	"github.com/go-playground/validator/v10"
+	"github.com/hhow09/simple_bank/accountnumber"
+	"github.com/hhow09/simple_bank/money"
 	"github.com/hhow09/simple_bank/util"
 )
 
-var validCurrency validator.Func = func(fieldLevel validator.FieldLevel) bool {
-	if currency, ok := fieldLevel.Field().Interface().(string); ok {
-		//check if currency is supported
-		return util.IsSupportedCurrency(currency)
+// validCurrency accepts the currencies of the registry
+func validCurrency(currencies *money.Registry) validator.Func {
+	return func(fieldLevel validator.FieldLevel) bool {
+		if currency, ok := fieldLevel.Field().Interface().(string); ok {
+			return currencies.IsSupported(currency)
+		}
+		return false
+	}
+}
+
+var validAccountNumber validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if number, ok := fieldLevel.Field().Interface().(string); ok {
+		return accountnumber.Validate(number) == nil
+	}
+	return false
+}
+
+// referencePattern is the SWIFT character set, references are passed on to other banks
+var referencePattern = regexp.MustCompile(`^[A-Za-z0-9/\-?:().,'+ ]*$`)
+
+var validReference validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	if reference, ok := fieldLevel.Field().Interface().(string); ok {
+		return referencePattern.MatchString(reference)
 	}
 	return false
 }
+
+// validMemo accepts any printable text, control characters like new lines are rejected
+var validMemo validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	memo, ok := fieldLevel.Field().Interface().(string)
+	if !ok || !utf8.ValidString(memo) {
+		return false
+	}
+	for _, r := range memo {
+		if !unicode.IsPrint(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// validPassword checks the password policy, the username and email
+// of the same request are passed as personal info
+func validPassword(policy util.PasswordPolicy) validator.Func {
+	return func(fieldLevel validator.FieldLevel) bool {
+		password, ok := fieldLevel.Field().Interface().(string)
+		if !ok {
+			return false
+		}
+		var personal []string
+		parent := reflect.Indirect(fieldLevel.Parent())
+		if parent.Kind() == reflect.Struct {
+			for _, name := range []string{"Username", "Email"} {
+				if field := parent.FieldByName(name); field.IsValid() && field.Kind() == reflect.String {
+					personal = append(personal, field.String())
+				}
+			}
+		}
+		return policy.Check(password, personal...) == nil
+	}
+}
+
+// requestFieldName returns the name of the field as seen by clients,
+// taken from the json, uri or form tag.
+func requestFieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "uri", "form"} {
+		name := strings.SplitN(field.Tag.Get(tag), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
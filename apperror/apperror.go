@@ -0,0 +1,160 @@
+package apperror
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/lib/pq"
+)
+
+// Code is the machine-readable identifier of a domain error.
+type Code string
+
+const (
+	CodeValidation        Code = "validation"
+	CodeUnauthorized      Code = "unauthorized"
+	CodeForbidden         Code = "forbidden"
+	CodeNotFound          Code = "not_found"
+	CodeConflict          Code = "conflict"
+	CodeInsufficientFunds Code = "insufficient_funds"
+	CodeCurrencyMismatch  Code = "currency_mismatch"
+	CodeTooManyRequests   Code = "too_many_requests"
+	CodeAccountLocked     Code = "account_locked"
+	CodeEmailNotVerified  Code = "email_not_verified"
+	CodeTwoFactorRequired Code = "two_factor_required"
+	CodeInternal          Code = "internal"
+)
+
+// status maps every code to the HTTP status it is served with.
+var status = map[Code]int{
+	CodeValidation:        http.StatusBadRequest,
+	CodeUnauthorized:      http.StatusUnauthorized,
+	CodeForbidden:         http.StatusForbidden,
+	CodeNotFound:          http.StatusNotFound,
+	CodeConflict:          http.StatusConflict,
+	CodeInsufficientFunds: http.StatusUnprocessableEntity,
+	CodeCurrencyMismatch:  http.StatusUnprocessableEntity,
+	CodeTooManyRequests:   http.StatusTooManyRequests,
+	CodeAccountLocked:     http.StatusLocked,
+	CodeEmailNotVerified:  http.StatusForbidden,
+	CodeTwoFactorRequired: http.StatusForbidden,
+	CodeInternal:          http.StatusInternalServerError,
+}
+
+// FieldError describes why a single request field was rejected.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Error is a domain error carrying a code, a client-safe message and
+// optionally the underlying cause, which is never exposed to clients.
+type Error struct {
+	Code    Code
+	Message string
+	Fields  []FieldError
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %s: %v", e.Code, e.Message, e.Err)
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Status returns the HTTP status of the error.
+func (e *Error) Status() int {
+	if s, ok := status[e.Code]; ok {
+		return s
+	}
+	return http.StatusInternalServerError
+}
+
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+func NotFound(message string) *Error {
+	return New(CodeNotFound, message)
+}
+
+func Unauthorized(message string) *Error {
+	return New(CodeUnauthorized, message)
+}
+
+func Forbidden(message string) *Error {
+	return New(CodeForbidden, message)
+}
+
+func Conflict(message string) *Error {
+	return New(CodeConflict, message)
+}
+
+func InsufficientFunds(message string) *Error {
+	return New(CodeInsufficientFunds, message)
+}
+
+func CurrencyMismatch(message string) *Error {
+	return New(CodeCurrencyMismatch, message)
+}
+
+func TooManyRequests(message string) *Error {
+	return New(CodeTooManyRequests, message)
+}
+
+func AccountLocked(message string) *Error {
+	return New(CodeAccountLocked, message)
+}
+
+func EmailNotVerified(message string) *Error {
+	return New(CodeEmailNotVerified, message)
+}
+
+func TwoFactorRequired(message string) *Error {
+	return New(CodeTwoFactorRequired, message)
+}
+
+// Validation creates a validation error with per-field details.
+func Validation(message string, fields ...FieldError) *Error {
+	return &Error{Code: CodeValidation, Message: message, Fields: fields}
+}
+
+// Internal wraps an unexpected error, hiding its details from clients.
+func Internal(err error) *Error {
+	return &Error{Code: CodeInternal, Message: "internal server error", Err: err}
+}
+
+// From converts any error into a domain error.
+// Errors which are already domain errors are returned as is; well-known
+// database errors are mapped to their domain counterpart and everything
+// else is treated as internal.
+func From(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return &Error{Code: CodeNotFound, Message: "resource not found", Err: err}
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code.Name() {
+		case "unique_violation":
+			return &Error{Code: CodeConflict, Message: "resource already exists", Err: err}
+		case "foreign_key_violation":
+			return &Error{Code: CodeForbidden, Message: "referenced resource is not available", Err: err}
+		}
+	}
+	if fields, ok := bindingFields(err); ok {
+		return &Error{Code: CodeValidation, Message: "request validation failed", Fields: fields, Err: err}
+	}
+	return Internal(err)
+}
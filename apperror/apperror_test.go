@@ -0,0 +1,77 @@
+package apperror
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFrom(t *testing.T) {
+	testCases := []struct {
+		name   string
+		err    error
+		code   Code
+		status int
+	}{
+		{"DomainError", InsufficientFunds("not enough"), CodeInsufficientFunds, http.StatusUnprocessableEntity},
+		{"WrappedDomainError", fmt.Errorf("wrap: %w", Forbidden("nope")), CodeForbidden, http.StatusForbidden},
+		{"NoRows", sql.ErrNoRows, CodeNotFound, http.StatusNotFound},
+		{"UniqueViolation", &pq.Error{Code: "23505"}, CodeConflict, http.StatusConflict},
+		{"ForeignKeyViolation", &pq.Error{Code: "23503"}, CodeForbidden, http.StatusForbidden},
+		{"Unknown", sql.ErrConnDone, CodeInternal, http.StatusInternalServerError},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			appErr := From(tc.err)
+			require.Equal(t, tc.code, appErr.Code)
+			require.Equal(t, tc.status, appErr.Status())
+		})
+	}
+}
+
+func TestInternalHidesCause(t *testing.T) {
+	cause := errors.New("pq: password authentication failed for user root")
+	problem := NewProblem(cause, "/accounts")
+
+	require.Equal(t, http.StatusInternalServerError, problem.Status)
+	require.Equal(t, CodeInternal, problem.Code)
+	require.Equal(t, "/accounts", problem.Instance)
+	require.NotContains(t, problem.Detail, "pq")
+}
+
+func TestFromBinding(t *testing.T) {
+	type request struct {
+		Amount int64 `validate:"required,gt=1"`
+	}
+	err := validator.New().Struct(request{})
+	require.Error(t, err)
+
+	appErr := FromBinding(err)
+	require.Equal(t, CodeValidation, appErr.Code)
+	require.Len(t, appErr.Fields, 1)
+	require.Equal(t, "Amount", appErr.Fields[0].Field)
+	require.Equal(t, "required", appErr.Fields[0].Rule)
+	require.NotContains(t, appErr.Message, "Key:")
+}
+
+func TestFromBindingFieldParam(t *testing.T) {
+	type request struct {
+		FromAccountID int64
+		ToAccountID   int64 `validate:"nefield=FromAccountID"`
+	}
+	err := validator.New().Struct(request{FromAccountID: 1, ToAccountID: 1})
+	require.Error(t, err)
+
+	// the parameter is named like the JSON field
+	appErr := FromBinding(err)
+	require.Len(t, appErr.Fields, 1)
+	require.Equal(t, "nefield", appErr.Fields[0].Rule)
+	require.Equal(t, "must differ from from_account_id", appErr.Fields[0].Message)
+}
@@ -0,0 +1,109 @@
+package apperror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// FromBinding converts the error of gin's ShouldBind* family into a
+// validation error, so that raw decoder and validator messages are not
+// leaked to clients.
+func FromBinding(err error) *Error {
+	fields, ok := bindingFields(err)
+	if !ok {
+		fields = nil
+	}
+	return &Error{Code: CodeValidation, Message: "request validation failed", Fields: fields, Err: err}
+}
+
+func bindingFields(err error) ([]FieldError, bool) {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		fields := make([]FieldError, 0, len(validationErrs))
+		for _, fe := range validationErrs {
+			fields = append(fields, FieldError{
+				Field:   fe.Field(),
+				Rule:    fe.Tag(),
+				Message: fieldMessage(fe),
+			})
+		}
+		return fields, true
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return []FieldError{{
+			Field:   typeErr.Field,
+			Rule:    "type",
+			Message: fmt.Sprintf("must be of type %s", typeErr.Type),
+		}}, true
+	}
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return []FieldError{{Field: "body", Rule: "json", Message: "malformed JSON body"}}, true
+	}
+
+	var numErr *strconv.NumError
+	if errors.As(err, &numErr) {
+		return []FieldError{{Field: numErr.Num, Rule: "type", Message: "must be a number"}}, true
+	}
+	return nil, false
+}
+
+func fieldMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "min", "gte":
+		return fmt.Sprintf("must be at least %s", fe.Param())
+	case "max", "lte":
+		return fmt.Sprintf("must be at most %s", fe.Param())
+	case "gt":
+		return fmt.Sprintf("must be greater than %s", fe.Param())
+	case "lt":
+		return fmt.Sprintf("must be less than %s", fe.Param())
+	case "len":
+		return fmt.Sprintf("must be %s characters long", fe.Param())
+	case "numeric":
+		return "must contain digits only"
+	case "oneof":
+		return fmt.Sprintf("must be one of [%s]", fe.Param())
+	case "email":
+		return "must be a valid email address"
+	case "alphanum":
+		return "must contain ASCII alphanumeric characters only"
+	case "currency":
+		return "is not a supported currency"
+	case "password":
+		return "does not meet the password policy"
+	case "account_number":
+		return "is not a valid account number"
+	case "reference":
+		return "may only contain letters, digits, spaces and / - ? : ( ) . , ' +"
+	case "memo":
+		return "must not contain control characters"
+	case "nefield":
+		return fmt.Sprintf("must differ from %s", snakeCase(fe.Param()))
+	}
+	return fmt.Sprintf("failed on the %q rule", fe.Tag())
+}
+
+// snakeCase converts the Go field name of a rule parameter to its JSON name, e.g. FromAccountID to from_account_id
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
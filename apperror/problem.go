@@ -0,0 +1,32 @@
+package apperror
+
+import "net/http"
+
+// ContentType is the media type of RFC 7807 problem details.
+const ContentType = "application/problem+json"
+
+// Problem is the RFC 7807 representation of an error returned to clients.
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Code     Code         `json:"code"`
+	Errors   []FieldError `json:"errors,omitempty"`
+}
+
+// NewProblem builds the problem details of err for the given request path.
+func NewProblem(err error, instance string) Problem {
+	appErr := From(err)
+	s := appErr.Status()
+	return Problem{
+		Type:     "/problems/" + string(appErr.Code),
+		Title:    http.StatusText(s),
+		Status:   s,
+		Detail:   appErr.Message,
+		Instance: instance,
+		Code:     appErr.Code,
+		Errors:   appErr.Fields,
+	}
+}
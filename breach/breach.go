@@ -0,0 +1,34 @@
+// Package breach checks passwords against an offline copy of a breached
+// password corpus, such as the Pwned Passwords list.
+package breach
+
+import (
+	"context"
+
+	"github.com/hhow09/simple_bank/util"
+	"go.uber.org/fx"
+)
+
+// Checker reports whether a password is known from a data breach
+type Checker interface {
+	Breached(ctx context.Context, password string) (bool, error)
+}
+
+// NewChecker creates a checker of PASSWORD_BREACH_FILE,
+// passwords are not checked when no file is configured
+func NewChecker(config util.Config) (Checker, error) {
+	if config.PasswordBreachFile == "" {
+		return noopChecker{}, nil
+	}
+	return NewFileChecker(config.PasswordBreachFile)
+}
+
+type noopChecker struct{}
+
+func (noopChecker) Breached(ctx context.Context, password string) (bool, error) {
+	return false, nil
+}
+
+var Module = fx.Options(
+	fx.Provide(NewChecker),
+)
@@ -0,0 +1,133 @@
+package breach
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// PrefixLength is the length of the hash prefix of a range lookup
+const PrefixLength = 5
+
+// FileChecker looks up passwords in a file of upper case SHA-1 hashes sorted
+// in ascending order, one "<hash>:<count>" per line as produced by the
+// Pwned Passwords downloader. Like the k-anonymity range API, the range of
+// hashes sharing the first PrefixLength characters is located by binary
+// search and the suffix is only compared within that range, so the file
+// is never loaded into memory.
+type FileChecker struct {
+	path string
+}
+
+// NewFileChecker creates a checker of the hash file at path
+func NewFileChecker(path string) (*FileChecker, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open password breach file: %w", err)
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("password breach file %s is a directory", path)
+	}
+	return &FileChecker{path: path}, nil
+}
+
+func (c *FileChecker) Breached(ctx context.Context, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:PrefixLength], hash[PrefixLength:]
+
+	suffixes, err := c.Range(prefix)
+	if err != nil {
+		return false, err
+	}
+	for _, s := range suffixes {
+		if s == suffix {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Range returns the hash suffixes of the given prefix
+func (c *FileChecker) Range(prefix string) ([]string, error) {
+	file, err := os.Open(c.path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := info.Size()
+
+	// find the first line not sorted before the prefix
+	lo, hi := int64(0), size
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		start, line, err := lineAt(file, mid, size)
+		if err != nil {
+			return nil, err
+		}
+		if start >= size || hashOf(line) >= prefix {
+			hi = mid
+		} else {
+			lo = start + 1
+		}
+	}
+
+	start, _, err := lineAt(file, lo, size)
+	if err != nil {
+		return nil, err
+	}
+	var suffixes []string
+	scanner := bufio.NewScanner(io.NewSectionReader(file, start, size-start))
+	for scanner.Scan() {
+		hash := hashOf(scanner.Text())
+		if !strings.HasPrefix(hash, prefix) {
+			break
+		}
+		suffixes = append(suffixes, hash[PrefixLength:])
+	}
+	return suffixes, scanner.Err()
+}
+
+// lineAt returns the first line starting at or after off
+func lineAt(file *os.File, off int64, size int64) (int64, string, error) {
+	start := off
+	if off > 0 {
+		// skip the rest of the line containing off-1
+		reader := bufio.NewReader(io.NewSectionReader(file, off-1, size-off+1))
+		skipped, err := reader.ReadString('\n')
+		if err == io.EOF {
+			return size, "", nil
+		}
+		if err != nil {
+			return 0, "", err
+		}
+		start = off - 1 + int64(len(skipped))
+	}
+	if start >= size {
+		return size, "", nil
+	}
+	line, err := bufio.NewReader(io.NewSectionReader(file, start, size-start)).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return 0, "", err
+	}
+	return start, line, nil
+}
+
+// hashOf strips the count and line ending of a line
+func hashOf(line string) string {
+	hash := strings.TrimRight(line, "\r\n")
+	if i := strings.IndexByte(hash, ':'); i >= 0 {
+		hash = hash[:i]
+	}
+	return hash
+}
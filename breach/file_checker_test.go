@@ -0,0 +1,94 @@
+package breach
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/hhow09/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func sha1Hex(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// writeBreachFile writes the sorted hashes of the passwords and some random ones
+func writeBreachFile(t *testing.T, passwords ...string) string {
+	var hashes []string
+	for _, password := range passwords {
+		hashes = append(hashes, sha1Hex(password))
+	}
+	for i := 0; i < 500; i++ {
+		hashes = append(hashes, sha1Hex(util.RandomString(12)))
+	}
+	sort.Strings(hashes)
+
+	var b strings.Builder
+	for i, hash := range hashes {
+		fmt.Fprintf(&b, "%s:%d\r\n", hash, i+1)
+	}
+	path := filepath.Join(t.TempDir(), "pwned-passwords-sha1-ordered-by-hash.txt")
+	require.NoError(t, os.WriteFile(path, []byte(b.String()), 0o600))
+	return path
+}
+
+func TestFileChecker(t *testing.T) {
+	breached := []string{"password", "123456", "qwerty", util.RandomString(10)}
+	checker, err := NewFileChecker(writeBreachFile(t, breached...))
+	require.NoError(t, err)
+
+	for _, password := range breached {
+		ok, err := checker.Breached(context.Background(), password)
+		require.NoError(t, err)
+		require.True(t, ok, password)
+	}
+	for i := 0; i < 20; i++ {
+		ok, err := checker.Breached(context.Background(), util.RandomString(16))
+		require.NoError(t, err)
+		require.False(t, ok)
+	}
+}
+
+func TestFileCheckerRange(t *testing.T) {
+	// hashes around the first and last line are found as well
+	path := filepath.Join(t.TempDir(), "hashes.txt")
+	content := "00000" + strings.Repeat("A", 35) + ":1\n" +
+		"00000" + strings.Repeat("B", 35) + ":2\n" +
+		"12345" + strings.Repeat("C", 35) + ":3\n" +
+		"FFFFF" + strings.Repeat("D", 35) + ":4"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	checker, err := NewFileChecker(path)
+	require.NoError(t, err)
+
+	suffixes, err := checker.Range("00000")
+	require.NoError(t, err)
+	require.Equal(t, []string{strings.Repeat("A", 35), strings.Repeat("B", 35)}, suffixes)
+
+	suffixes, err = checker.Range("FFFFF")
+	require.NoError(t, err)
+	require.Equal(t, []string{strings.Repeat("D", 35)}, suffixes)
+
+	suffixes, err = checker.Range("12344")
+	require.NoError(t, err)
+	require.Empty(t, suffixes)
+}
+
+func TestNewChecker(t *testing.T) {
+	checker, err := NewChecker(util.Config{})
+	require.NoError(t, err)
+	ok, err := checker.Breached(context.Background(), "password")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	_, err = NewChecker(util.Config{PasswordBreachFile: filepath.Join(t.TempDir(), "missing.txt")})
+	require.Error(t, err)
+}
@@ -0,0 +1,52 @@
+// Command fees charges the monthly maintenance fees of the accounts which aren't charged yet.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	db "github.com/hhow09/simple_bank/db/sqlc"
+	"github.com/hhow09/simple_bank/fee"
+	"github.com/hhow09/simple_bank/money"
+	"github.com/hhow09/simple_bank/statement"
+	"github.com/hhow09/simple_bank/util"
+	_ "github.com/lib/pq"
+	"go.uber.org/fx"
+)
+
+func main() {
+	configPath := flag.String("config", ".", "directory of app.env")
+	month := flag.String("month", "", "month to charge, YYYY-MM, defaults to the last closed month")
+	flag.Parse()
+
+	var count int64
+	app := fx.New(
+		fx.NopLogger,
+		fx.Provide(func() util.ConfigPath {
+			return util.ConfigPath(*configPath)
+		}),
+		fx.Provide(util.LoadConfig),
+		fx.Provide(util.NewClock),
+		db.Module,
+		money.Module,
+		fee.Module,
+		fx.Invoke(func(charger *fee.Charger, clock util.Clock) error {
+			var err error
+			period := statement.LastClosedMonth(clock.Now())
+			if *month != "" {
+				if period, err = time.Parse(statement.MonthFormat, *month); err != nil {
+					return fmt.Errorf("invalid -month: %w", err)
+				}
+			}
+			count, err = charger.ChargeMonth(context.Background(), period)
+			return err
+		}),
+	)
+	if err := app.Err(); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("charged the maintenance fees of %d accounts\n", count)
+}
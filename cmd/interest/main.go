@@ -0,0 +1,77 @@
+// Command interest accrues and posts the interest of the accounts.
+// Without flags it catches up the days accrued since the latest accrual and posts the last
+// accrued month, with -from it accrues the days from -from to -to and with -month it posts a month.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	db "github.com/hhow09/simple_bank/db/sqlc"
+	"github.com/hhow09/simple_bank/interest"
+	"github.com/hhow09/simple_bank/money"
+	"github.com/hhow09/simple_bank/snapshot"
+	"github.com/hhow09/simple_bank/util"
+	_ "github.com/lib/pq"
+	"go.uber.org/fx"
+)
+
+func main() {
+	configPath := flag.String("config", ".", "directory of app.env")
+	from := flag.String("from", "", "first day to accrue, YYYY-MM-DD")
+	to := flag.String("to", "", "last day to accrue, YYYY-MM-DD, defaults to the last closed day")
+	month := flag.String("month", "", "month to post, YYYY-MM")
+	flag.Parse()
+
+	var accrued, posted int64
+	app := fx.New(
+		fx.NopLogger,
+		fx.Provide(func() util.ConfigPath {
+			return util.ConfigPath(*configPath)
+		}),
+		fx.Provide(util.LoadConfig),
+		fx.Provide(util.NewClock),
+		db.Module,
+		money.Module,
+		interest.Module,
+		fx.Invoke(func(accruer *interest.Accruer, clock util.Clock) error {
+			ctx := context.Background()
+			var err error
+			if *from == "" && *month == "" {
+				accrued, posted, err = accruer.CatchUp(ctx)
+				return err
+			}
+			if *from != "" {
+				fromDay, err := time.Parse(snapshot.DateFormat, *from)
+				if err != nil {
+					return fmt.Errorf("invalid -from: %w", err)
+				}
+				toDay := snapshot.LastClosedDay(clock.Now())
+				if *to != "" {
+					if toDay, err = time.Parse(snapshot.DateFormat, *to); err != nil {
+						return fmt.Errorf("invalid -to: %w", err)
+					}
+				}
+				if accrued, err = accruer.AccrueDays(ctx, fromDay, toDay); err != nil {
+					return err
+				}
+			}
+			if *month != "" {
+				period, err := time.Parse(interest.MonthFormat, *month)
+				if err != nil {
+					return fmt.Errorf("invalid -month: %w", err)
+				}
+				posted, err = accruer.PostMonth(ctx, period)
+				return err
+			}
+			return nil
+		}),
+	)
+	if err := app.Err(); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("wrote %d interest accruals, posted the interest of %d accounts\n", accrued, posted)
+}
@@ -0,0 +1,52 @@
+// Command reconcile runs the ledger reconciliation once and prints the discrepancies found.
+// It exits with status 1 when there are discrepancies or the run fails.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	db "github.com/hhow09/simple_bank/db/sqlc"
+	"github.com/hhow09/simple_bank/reconcile"
+	"github.com/hhow09/simple_bank/util"
+	_ "github.com/lib/pq"
+	"go.uber.org/fx"
+)
+
+func main() {
+	configPath := flag.String("config", ".", "directory of app.env")
+	freeze := flag.Bool("freeze", false, "freeze the accounts with discrepancies")
+	flag.Parse()
+
+	var report reconcile.Report
+	app := fx.New(
+		fx.NopLogger,
+		fx.Provide(func() util.ConfigPath {
+			return util.ConfigPath(*configPath)
+		}),
+		fx.Provide(util.LoadConfig),
+		db.Module,
+		reconcile.Module,
+		fx.Invoke(func(reconciler *reconcile.Reconciler) error {
+			var err error
+			report, err = reconciler.Run(context.Background(), reconcile.Options{Freeze: *freeze})
+			return err
+		}),
+	)
+	if err := app.Err(); err != nil {
+		log.Fatal(err)
+	}
+
+	run := report.Run
+	fmt.Printf("run %d: checked %d accounts, %d transfers and %d ledger accounts, found %d discrepancies, froze %d accounts\n",
+		run.ID, run.AccountsChecked, run.TransfersChecked, run.LedgerAccountsChecked, run.Discrepancies, run.FrozenAccounts)
+	for _, discrepancy := range report.Discrepancies {
+		fmt.Printf("%s: %s (expected %d, actual %d)\n", discrepancy.Kind, discrepancy.Details, discrepancy.Expected, discrepancy.Actual)
+	}
+	if run.Discrepancies > 0 {
+		os.Exit(1)
+	}
+}
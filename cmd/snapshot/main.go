@@ -0,0 +1,60 @@
+// Command snapshot takes the daily balance snapshots.
+// Without -from it catches up the days closed since the latest snapshot,
+// with -from it backfills the days from -from to -to.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	db "github.com/hhow09/simple_bank/db/sqlc"
+	"github.com/hhow09/simple_bank/snapshot"
+	"github.com/hhow09/simple_bank/util"
+	_ "github.com/lib/pq"
+	"go.uber.org/fx"
+)
+
+func main() {
+	configPath := flag.String("config", ".", "directory of app.env")
+	from := flag.String("from", "", "first day to backfill, YYYY-MM-DD")
+	to := flag.String("to", "", "last day to backfill, YYYY-MM-DD, defaults to the last closed day")
+	flag.Parse()
+
+	now := time.Now()
+	var count int64
+	app := fx.New(
+		fx.NopLogger,
+		fx.Provide(func() util.ConfigPath {
+			return util.ConfigPath(*configPath)
+		}),
+		fx.Provide(util.LoadConfig),
+		db.Module,
+		snapshot.Module,
+		fx.Invoke(func(snapshotter *snapshot.Snapshotter) error {
+			var err error
+			if *from == "" {
+				count, err = snapshotter.CatchUp(context.Background(), now)
+				return err
+			}
+			fromDay, err := time.Parse(snapshot.DateFormat, *from)
+			if err != nil {
+				return fmt.Errorf("invalid -from: %w", err)
+			}
+			toDay := snapshot.LastClosedDay(now)
+			if *to != "" {
+				if toDay, err = time.Parse(snapshot.DateFormat, *to); err != nil {
+					return fmt.Errorf("invalid -to: %w", err)
+				}
+			}
+			count, err = snapshotter.Backfill(context.Background(), fromDay, toDay, now)
+			return err
+		}),
+	)
+	if err := app.Err(); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("wrote %d balance snapshots\n", count)
+}
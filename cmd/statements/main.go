@@ -0,0 +1,51 @@
+// Command statements generates the monthly pdf statements of the accounts which don't have one yet.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"time"
+
+	db "github.com/hhow09/simple_bank/db/sqlc"
+	"github.com/hhow09/simple_bank/money"
+	"github.com/hhow09/simple_bank/statement"
+	"github.com/hhow09/simple_bank/util"
+	_ "github.com/lib/pq"
+	"go.uber.org/fx"
+)
+
+func main() {
+	configPath := flag.String("config", ".", "directory of app.env")
+	month := flag.String("month", "", "month to generate, YYYY-MM, defaults to the last closed month")
+	flag.Parse()
+
+	now := time.Now()
+	var count int64
+	app := fx.New(
+		fx.NopLogger,
+		fx.Provide(func() util.ConfigPath {
+			return util.ConfigPath(*configPath)
+		}),
+		fx.Provide(util.LoadConfig),
+		db.Module,
+		money.Module,
+		statement.Module,
+		fx.Invoke(func(generator *statement.Generator) error {
+			var err error
+			period := statement.LastClosedMonth(now)
+			if *month != "" {
+				if period, err = time.Parse(statement.MonthFormat, *month); err != nil {
+					return fmt.Errorf("invalid -month: %w", err)
+				}
+			}
+			count, err = generator.GenerateMonth(context.Background(), period, now)
+			return err
+		}),
+	)
+	if err := app.Err(); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("generated %d statements\n", count)
+}
@@ -0,0 +1,17 @@
+package constants
+
+// account member roles
+const (
+	// MemberRoleOwner can view the account, send money and manage the members
+	MemberRoleOwner = "owner"
+	// MemberRoleCoOwner can view the account and send money
+	MemberRoleCoOwner = "co_owner"
+	// MemberRoleViewer can only view the account
+	MemberRoleViewer = "viewer"
+)
+
+// account types
+const (
+	AccountTypeChecking = "checking"
+	AccountTypeSavings  = "savings"
+)
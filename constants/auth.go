@@ -3,5 +3,20 @@ package constants
 const (
 	AuthHeaderKey  = "authorization"
 	AuthTypeBearer = "bearer"
+	AuthTypeAPIKey = "apikey"
 	AuthPayloadKey = "auth_payload"
+	AuthUserKey    = "auth_user"
+	AuthAPIKeyKey  = "auth_api_key"
+)
+
+// api key scopes, access tokens of users have every scope
+const (
+	ScopeAccountsRead    = "accounts:read"
+	ScopeTransfersCreate = "transfers:create"
+)
+
+// user roles
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
 )
@@ -0,0 +1,16 @@
+package constants
+
+// user tiers, fees are waived per tier
+const (
+	TierStandard = "standard"
+	TierPremium  = "premium"
+	TierPrivate  = "private"
+)
+
+// fee kinds
+const (
+	// FeeKindTransfer is charged to the from account of a transfer
+	FeeKindTransfer = "transfer"
+	// FeeKindMaintenance is charged to every account once a month
+	FeeKindMaintenance = "maintenance"
+)
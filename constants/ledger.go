@@ -0,0 +1,24 @@
+package constants
+
+// system accounts of the ledger, one per currency
+const (
+	// LedgerAccountCash is where money paid in comes from and money paid out goes to
+	LedgerAccountCash = "cash"
+	// LedgerAccountFees collects the fees charged to customers
+	LedgerAccountFees = "fees"
+	// LedgerAccountFX balances currency exchanges between the currencies
+	LedgerAccountFX = "fx"
+	// LedgerAccountInterest pays the interest credited to customers
+	LedgerAccountInterest = "interest"
+)
+
+// journal transaction kinds
+const (
+	JournalKindTransfer   = "transfer"
+	JournalKindDeposit    = "deposit"
+	JournalKindWithdrawal = "withdrawal"
+	JournalKindFee        = "fee"
+	JournalKindInterest   = "interest"
+	JournalKindFX         = "fx"
+	JournalKindAdjustment = "adjustment"
+)
@@ -0,0 +1,18 @@
+package constants
+
+// reconciliation run statuses
+const (
+	ReconciliationRunning   = "running"
+	ReconciliationCompleted = "completed"
+	ReconciliationFailed    = "failed"
+)
+
+// kinds of discrepancies found by reconciliation
+const (
+	// DiscrepancyAccountBalance is an account balance which is not the sum of its entries
+	DiscrepancyAccountBalance = "account_balance"
+	// DiscrepancyTransferEntries is a transfer without exactly one matching entry per account
+	DiscrepancyTransferEntries = "transfer_entries"
+	// DiscrepancyLedgerAccountBalance is a system account balance which is not the sum of its postings
+	DiscrepancyLedgerAccountBalance = "ledger_account_balance"
+)
@@ -0,0 +1,3 @@
+ALTER TABLE "users" ADD COLUMN "role" varchar NOT NULL DEFAULT 'user';
+
+COMMENT ON COLUMN "users"."role" IS 'user or admin';
@@ -0,0 +1,14 @@
+CREATE TABLE "login_attempts" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "client_ip" varchar NOT NULL,
+  "success" boolean NOT NULL,
+  "cleared" boolean NOT NULL DEFAULT false,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "login_attempts" ("username", "created_at");
+
+COMMENT ON COLUMN "login_attempts"."username" IS 'not a foreign key, attempts of unknown users are recorded as well';
+
+COMMENT ON COLUMN "login_attempts"."cleared" IS 'failures are cleared by a successful login or an admin unlock';
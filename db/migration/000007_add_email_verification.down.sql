@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "verify_emails";
+ALTER TABLE IF EXISTS "users" DROP COLUMN IF EXISTS "is_email_verified";
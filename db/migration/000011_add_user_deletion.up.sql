@@ -0,0 +1,3 @@
+ALTER TABLE "users" ADD COLUMN "deleted_at" timestamptz;
+
+COMMENT ON COLUMN "users"."deleted_at" IS 'set on the pseudonym which keeps the accounts of a deleted user';
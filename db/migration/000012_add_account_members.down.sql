@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "account_members";
+COMMENT ON COLUMN "accounts"."owner" IS NULL;
+ALTER TABLE "accounts" ADD CONSTRAINT "owner_currency_key" UNIQUE ("owner", "currency");
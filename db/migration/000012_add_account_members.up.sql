@@ -0,0 +1,24 @@
+CREATE TABLE "account_members" (
+  "account_id" bigint NOT NULL,
+  "username" varchar NOT NULL,
+  "role" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("account_id", "username")
+);
+
+ALTER TABLE "account_members" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "account_members" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+CREATE INDEX ON "account_members" ("username");
+
+COMMENT ON COLUMN "account_members"."role" IS 'owner, co_owner or viewer';
+
+COMMENT ON COLUMN "accounts"."owner" IS 'the user who opened the account, access is granted by account_members';
+
+-- the creators of existing accounts are their owners
+INSERT INTO "account_members" ("account_id", "username", "role", "created_at")
+SELECT "id", "owner", 'owner', "created_at" FROM "accounts";
+
+-- users can be members of several accounts in the same currency
+ALTER TABLE "accounts" DROP CONSTRAINT IF EXISTS "owner_currency_key";
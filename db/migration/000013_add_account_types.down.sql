@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "accounts_owner_currency_type_idx";
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "nickname";
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "type";
\ No newline at end of file
@@ -0,0 +1,9 @@
+ALTER TABLE "accounts" ADD COLUMN "type" varchar NOT NULL DEFAULT 'checking';
+ALTER TABLE "accounts" ADD COLUMN "nickname" varchar NOT NULL DEFAULT '';
+
+COMMENT ON COLUMN "accounts"."type" IS 'checking or savings';
+
+COMMENT ON COLUMN "accounts"."nickname" IS 'chosen by the owners, empty when not set';
+
+-- the number of accounts of a type per owner and currency is limited by the configuration
+CREATE INDEX ON "accounts" ("owner", "currency", "type");
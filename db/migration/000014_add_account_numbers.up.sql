@@ -0,0 +1,24 @@
+ALTER TABLE "accounts" ADD COLUMN "number" varchar;
+
+COMMENT ON COLUMN "accounts"."number" IS 'IBAN-style number with mod-97 check digits, used to address transfers';
+
+-- computes the IBAN of the bank code and account digits, letters count as 10 (A) to 35 (Z)
+CREATE FUNCTION pg_temp.iban(country_code text, bban text) RETURNS text AS $$
+DECLARE
+  rearranged text := bban || country_code || '00';
+  digits text := '';
+  c text;
+BEGIN
+  FOREACH c IN ARRAY regexp_split_to_array(rearranged, '') LOOP
+    digits := digits || CASE WHEN c ~ '[A-Z]' THEN (ascii(c) - 55)::text ELSE c END;
+  END LOOP;
+  RETURN country_code || lpad((98 - mod(digits::numeric, 97))::text, 2, '0') || bban;
+END;
+$$ LANGUAGE plpgsql;
+
+-- existing accounts get random numbers of the default scheme
+UPDATE "accounts"
+SET "number" = pg_temp.iban('SB', 'SMPL' || lpad(floor(random() * 1000000000000)::bigint::text, 12, '0'));
+
+ALTER TABLE "accounts" ALTER COLUMN "number" SET NOT NULL;
+ALTER TABLE "accounts" ADD CONSTRAINT "accounts_number_key" UNIQUE ("number");
@@ -0,0 +1,17 @@
+CREATE TABLE "beneficiaries" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "account_id" bigint NOT NULL,
+  "account_number" varchar NOT NULL,
+  "label" varchar NOT NULL,
+  "available_at" timestamptz NOT NULL DEFAULT (now()),
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "beneficiaries" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "beneficiaries" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "beneficiaries" ADD CONSTRAINT "beneficiaries_username_account_id_key" UNIQUE ("username", "account_id");
+
+COMMENT ON COLUMN "beneficiaries"."available_at" IS 'end of the cooling-off period, no transfer to the beneficiary before';
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS "transfers_reference_idx";
+ALTER TABLE IF EXISTS "entries" DROP COLUMN IF EXISTS "reference";
+ALTER TABLE IF EXISTS "entries" DROP COLUMN IF EXISTS "description";
+ALTER TABLE IF EXISTS "transfers" DROP CONSTRAINT IF EXISTS "transfers_metadata_check";
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "metadata";
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "reference";
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "description";
\ No newline at end of file
@@ -0,0 +1,20 @@
+ALTER TABLE "transfers" ADD COLUMN "description" varchar NOT NULL DEFAULT '';
+ALTER TABLE "transfers" ADD COLUMN "reference" varchar NOT NULL DEFAULT '';
+ALTER TABLE "transfers" ADD COLUMN "metadata" jsonb NOT NULL DEFAULT '{}';
+
+ALTER TABLE "transfers" ADD CONSTRAINT "transfers_metadata_check" CHECK (jsonb_typeof("metadata") = 'object');
+
+ALTER TABLE "entries" ADD COLUMN "description" varchar NOT NULL DEFAULT '';
+ALTER TABLE "entries" ADD COLUMN "reference" varchar NOT NULL DEFAULT '';
+
+COMMENT ON COLUMN "transfers"."description" IS 'free text memo of the payment';
+
+COMMENT ON COLUMN "transfers"."reference" IS 'external reference, e.g. an invoice number, empty when not set';
+
+COMMENT ON COLUMN "transfers"."metadata" IS 'string key-value pairs of the client';
+
+COMMENT ON COLUMN "entries"."description" IS 'copied from the transfer';
+
+COMMENT ON COLUMN "entries"."reference" IS 'copied from the transfer';
+
+CREATE INDEX ON "transfers" ("reference") WHERE "reference" <> '';
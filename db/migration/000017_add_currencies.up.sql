@@ -0,0 +1,17 @@
+CREATE TABLE "currencies" (
+  "code" varchar PRIMARY KEY,
+  "exponent" int NOT NULL,
+  "symbol" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "currencies" ADD CONSTRAINT "currencies_exponent_check" CHECK ("exponent" BETWEEN 0 AND 8);
+
+COMMENT ON COLUMN "currencies"."code" IS 'ISO 4217 code';
+
+COMMENT ON COLUMN "currencies"."exponent" IS 'number of decimals of the minor unit';
+
+INSERT INTO "currencies" ("code", "exponent", "symbol") VALUES
+  ('USD', 2, '$'),
+  ('EUR', 2, '€'),
+  ('CAD', 2, 'CA$');
@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS "postings_balanced" ON "postings";
+DROP FUNCTION IF EXISTS check_journal_transaction_balanced();
+DROP TABLE IF EXISTS "postings";
+DROP TABLE IF EXISTS "journal_transactions";
+DROP TABLE IF EXISTS "ledger_accounts";
\ No newline at end of file
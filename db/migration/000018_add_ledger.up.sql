@@ -0,0 +1,83 @@
+CREATE TABLE "ledger_accounts" (
+  "id" bigserial PRIMARY KEY,
+  "code" varchar NOT NULL,
+  "name" varchar NOT NULL,
+  "currency" varchar NOT NULL,
+  "balance" bigint NOT NULL DEFAULT 0,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "journal_transactions" (
+  "id" bigserial PRIMARY KEY,
+  "kind" varchar NOT NULL,
+  "description" varchar NOT NULL DEFAULT '',
+  "reference" varchar NOT NULL DEFAULT '',
+  "transfer_id" bigint,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "postings" (
+  "id" bigserial PRIMARY KEY,
+  "journal_transaction_id" bigint NOT NULL,
+  "account_id" bigint,
+  "ledger_account_id" bigint,
+  "entry_id" bigint,
+  "currency" varchar NOT NULL,
+  "amount" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "ledger_accounts" ADD CONSTRAINT "ledger_accounts_code_currency_key" UNIQUE ("code", "currency");
+
+ALTER TABLE "journal_transactions" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "postings" ADD FOREIGN KEY ("journal_transaction_id") REFERENCES "journal_transactions" ("id");
+
+ALTER TABLE "postings" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "postings" ADD FOREIGN KEY ("ledger_account_id") REFERENCES "ledger_accounts" ("id");
+
+ALTER TABLE "postings" ADD FOREIGN KEY ("entry_id") REFERENCES "entries" ("id");
+
+ALTER TABLE "postings" ADD CONSTRAINT "postings_account_check" CHECK (("account_id" IS NULL) <> ("ledger_account_id" IS NULL));
+
+ALTER TABLE "postings" ADD CONSTRAINT "postings_amount_check" CHECK ("amount" <> 0);
+
+CREATE INDEX ON "postings" ("journal_transaction_id");
+
+CREATE INDEX ON "postings" ("account_id");
+
+CREATE INDEX ON "postings" ("ledger_account_id");
+
+COMMENT ON COLUMN "ledger_accounts"."code" IS 'system account: cash, fees, fx or interest';
+
+COMMENT ON COLUMN "ledger_accounts"."balance" IS 'sum of the postings, cash is negative by the money paid in';
+
+COMMENT ON COLUMN "journal_transactions"."kind" IS 'transfer, deposit, withdrawal, fee, interest, fx or adjustment';
+
+COMMENT ON COLUMN "postings"."account_id" IS 'customer account, or ledger_account_id for a system account';
+
+COMMENT ON COLUMN "postings"."entry_id" IS 'entry of the customer account';
+
+COMMENT ON COLUMN "postings"."amount" IS 'added to the balance of the account, the postings of a journal transaction sum to zero per currency';
+
+-- double entry: checked at commit, once all postings of the transaction are written
+CREATE FUNCTION check_journal_transaction_balanced() RETURNS trigger AS $$
+BEGIN
+  IF EXISTS (
+    SELECT 1 FROM postings
+    WHERE journal_transaction_id = NEW.journal_transaction_id
+    GROUP BY currency
+    HAVING sum(amount) <> 0
+  ) THEN
+    RAISE EXCEPTION 'journal transaction % is not balanced', NEW.journal_transaction_id
+      USING ERRCODE = 'check_violation', CONSTRAINT = 'postings_balanced';
+  END IF;
+  RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER "postings_balanced"
+  AFTER INSERT OR UPDATE ON "postings"
+  DEFERRABLE INITIALLY DEFERRED
+  FOR EACH ROW EXECUTE FUNCTION check_journal_transaction_balanced();
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS "journal_transactions_transfer_id_idx";
+DROP TABLE IF EXISTS "reconciliation_discrepancies";
+DROP TABLE IF EXISTS "reconciliation_runs";
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "frozen_at";
\ No newline at end of file
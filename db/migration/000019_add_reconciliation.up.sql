@@ -0,0 +1,46 @@
+ALTER TABLE "accounts" ADD COLUMN "frozen_at" timestamptz;
+
+COMMENT ON COLUMN "accounts"."frozen_at" IS 'set while the account is frozen, no transfer from or to it';
+
+CREATE TABLE "reconciliation_runs" (
+  "id" bigserial PRIMARY KEY,
+  "status" varchar NOT NULL DEFAULT 'running',
+  "accounts_checked" bigint NOT NULL DEFAULT 0,
+  "transfers_checked" bigint NOT NULL DEFAULT 0,
+  "ledger_accounts_checked" bigint NOT NULL DEFAULT 0,
+  "discrepancies" bigint NOT NULL DEFAULT 0,
+  "frozen_accounts" bigint NOT NULL DEFAULT 0,
+  "error" varchar NOT NULL DEFAULT '',
+  "started_at" timestamptz NOT NULL DEFAULT (now()),
+  "finished_at" timestamptz
+);
+
+CREATE TABLE "reconciliation_discrepancies" (
+  "id" bigserial PRIMARY KEY,
+  "run_id" bigint NOT NULL,
+  "kind" varchar NOT NULL,
+  "account_id" bigint,
+  "transfer_id" bigint,
+  "ledger_account_id" bigint,
+  "expected" bigint NOT NULL,
+  "actual" bigint NOT NULL,
+  "details" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "reconciliation_discrepancies" ADD FOREIGN KEY ("run_id") REFERENCES "reconciliation_runs" ("id") ON DELETE CASCADE;
+
+CREATE INDEX ON "reconciliation_discrepancies" ("run_id");
+
+-- transfers are reconciled with the entries of their journal transaction
+CREATE INDEX ON "journal_transactions" ("transfer_id");
+
+COMMENT ON COLUMN "reconciliation_runs"."status" IS 'running, completed or failed';
+
+COMMENT ON COLUMN "reconciliation_runs"."error" IS 'why the run failed, empty otherwise';
+
+COMMENT ON COLUMN "reconciliation_discrepancies"."kind" IS 'account_balance, transfer_entries or ledger_account_balance';
+
+COMMENT ON COLUMN "reconciliation_discrepancies"."expected" IS 'the value derived from entries or postings';
+
+COMMENT ON COLUMN "reconciliation_discrepancies"."actual" IS 'the value found, e.g. the stored balance';
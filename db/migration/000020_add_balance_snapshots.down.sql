@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS "entries_account_id_created_at_idx";
+DROP TABLE IF EXISTS "account_balance_snapshots";
\ No newline at end of file
@@ -0,0 +1,19 @@
+CREATE TABLE "account_balance_snapshots" (
+  "account_id" bigint NOT NULL,
+  "date" date NOT NULL,
+  "closing_at" timestamptz NOT NULL,
+  "balance" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("account_id", "date")
+);
+
+ALTER TABLE "account_balance_snapshots" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+CREATE INDEX ON "account_balance_snapshots" ("date");
+
+-- historical balances sum the entries of an account within a time range
+CREATE INDEX ON "entries" ("account_id", "created_at");
+
+COMMENT ON COLUMN "account_balance_snapshots"."date" IS 'the day of the closing balance, in UTC';
+
+COMMENT ON COLUMN "account_balance_snapshots"."closing_at" IS 'end of the day, the balance includes the entries created before';
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS "postings_entry_id_idx";
\ No newline at end of file
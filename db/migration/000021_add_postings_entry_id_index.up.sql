@@ -0,0 +1 @@
+CREATE INDEX ON "postings" ("entry_id");
@@ -0,0 +1,20 @@
+CREATE TABLE "account_statements" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "period_start" date NOT NULL,
+  "period_end" date NOT NULL,
+  "opening_balance" bigint NOT NULL,
+  "closing_balance" bigint NOT NULL,
+  "content" bytea NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "account_statements" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "account_statements" ADD CONSTRAINT "account_statements_account_id_period_start_key" UNIQUE ("account_id", "period_start");
+
+COMMENT ON COLUMN "account_statements"."period_start" IS 'first day of the month, in UTC';
+
+COMMENT ON COLUMN "account_statements"."period_end" IS 'last day of the month, in UTC';
+
+COMMENT ON COLUMN "account_statements"."content" IS 'PDF document';
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS "interest_postings";
+DROP TABLE IF EXISTS "interest_accruals";
+DROP TABLE IF EXISTS "interest_rates";
+ALTER TABLE "currencies" DROP COLUMN IF EXISTS "rounding";
\ No newline at end of file
@@ -0,0 +1,66 @@
+ALTER TABLE "currencies" ADD COLUMN "rounding" varchar NOT NULL DEFAULT 'half_even';
+
+ALTER TABLE "currencies" ADD CONSTRAINT "currencies_rounding_check" CHECK ("rounding" IN ('half_even', 'half_up', 'down', 'up'));
+
+COMMENT ON COLUMN "currencies"."rounding" IS 'rounding of computed amounts to minor units: half_even, half_up, down or up';
+
+CREATE TABLE "interest_rates" (
+  "id" bigserial PRIMARY KEY,
+  "account_type" varchar NOT NULL,
+  "currency" varchar NOT NULL,
+  "annual_rate_bps" int NOT NULL,
+  "effective_from" date NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "interest_accruals" (
+  "account_id" bigint NOT NULL,
+  "date" date NOT NULL,
+  "balance" bigint NOT NULL,
+  "annual_rate_bps" int NOT NULL,
+  "amount_micros" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("account_id", "date")
+);
+
+CREATE TABLE "interest_postings" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "month" date NOT NULL,
+  "accrued_micros" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "journal_transaction_id" bigint,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "interest_rates" ADD CONSTRAINT "interest_rates_account_type_currency_effective_from_key" UNIQUE ("account_type", "currency", "effective_from");
+
+ALTER TABLE "interest_rates" ADD CONSTRAINT "interest_rates_annual_rate_bps_check" CHECK ("annual_rate_bps" >= 0);
+
+ALTER TABLE "interest_accruals" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "interest_postings" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "interest_postings" ADD FOREIGN KEY ("journal_transaction_id") REFERENCES "journal_transactions" ("id");
+
+ALTER TABLE "interest_postings" ADD CONSTRAINT "interest_postings_account_id_month_key" UNIQUE ("account_id", "month");
+
+CREATE INDEX ON "interest_accruals" ("date");
+
+COMMENT ON COLUMN "interest_rates"."account_type" IS 'checking or savings';
+
+COMMENT ON COLUMN "interest_rates"."annual_rate_bps" IS 'annual rate in basis points, 1 bps = 0.01%';
+
+COMMENT ON COLUMN "interest_rates"."effective_from" IS 'the rate applies from this day until the next rate of the account type and currency';
+
+COMMENT ON COLUMN "interest_accruals"."date" IS 'the day of the end of day balance, in UTC';
+
+COMMENT ON COLUMN "interest_accruals"."amount_micros" IS 'interest of the day in millionths of the minor unit, not rounded';
+
+COMMENT ON COLUMN "interest_postings"."month" IS 'first day of the month, in UTC';
+
+COMMENT ON COLUMN "interest_postings"."accrued_micros" IS 'sum of the accruals of the month in millionths of the minor unit';
+
+COMMENT ON COLUMN "interest_postings"."amount" IS 'accrued interest rounded with the rounding of the currency';
+
+COMMENT ON COLUMN "interest_postings"."journal_transaction_id" IS 'journal transaction crediting the account, null when the amount rounds to zero';
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "fee_charges";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "tier";
\ No newline at end of file
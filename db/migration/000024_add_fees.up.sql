@@ -0,0 +1,43 @@
+ALTER TABLE "users" ADD COLUMN "tier" varchar NOT NULL DEFAULT 'standard';
+
+ALTER TABLE "users" ADD CONSTRAINT "users_tier_check" CHECK ("tier" IN ('standard', 'premium', 'private'));
+
+COMMENT ON COLUMN "users"."tier" IS 'standard, premium or private, fees are waived per tier';
+
+CREATE TABLE "fee_charges" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "kind" varchar NOT NULL,
+  "transfer_id" bigint,
+  "month" date,
+  "amount" bigint NOT NULL,
+  "waived" boolean NOT NULL DEFAULT false,
+  "journal_transaction_id" bigint,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "fee_charges" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "fee_charges" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "fee_charges" ADD FOREIGN KEY ("journal_transaction_id") REFERENCES "journal_transactions" ("id");
+
+ALTER TABLE "fee_charges" ADD CONSTRAINT "fee_charges_kind_check" CHECK ("kind" IN ('transfer', 'maintenance'));
+
+ALTER TABLE "fee_charges" ADD CONSTRAINT "fee_charges_transfer_id_key" UNIQUE ("transfer_id");
+
+ALTER TABLE "fee_charges" ADD CONSTRAINT "fee_charges_account_id_month_key" UNIQUE ("account_id", "month");
+
+CREATE INDEX ON "fee_charges" ("account_id", "id");
+
+COMMENT ON COLUMN "fee_charges"."kind" IS 'transfer or maintenance';
+
+COMMENT ON COLUMN "fee_charges"."transfer_id" IS 'the transfer of a transfer fee';
+
+COMMENT ON COLUMN "fee_charges"."month" IS 'first day of the month of a maintenance fee, in UTC';
+
+COMMENT ON COLUMN "fee_charges"."amount" IS 'amount taken from the account, 0 when waived';
+
+COMMENT ON COLUMN "fee_charges"."waived" IS 'the fee was waived for the tier of the account owner';
+
+COMMENT ON COLUMN "fee_charges"."journal_transaction_id" IS 'journal transaction crediting the fee system account, null when nothing was taken';
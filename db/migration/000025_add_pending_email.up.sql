@@ -0,0 +1,3 @@
+ALTER TABLE "users" ADD COLUMN "pending_email" varchar NOT NULL DEFAULT '';
+
+COMMENT ON COLUMN "users"."pending_email" IS 'new email waiting for verification, it replaces email once verified, empty when none';
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "balance_snapshot_days";
\ No newline at end of file
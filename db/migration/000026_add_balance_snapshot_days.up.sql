@@ -0,0 +1,11 @@
+CREATE TABLE "balance_snapshot_days" (
+  "date" date PRIMARY KEY,
+  "completed_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON TABLE "balance_snapshot_days" IS 'days whose snapshots are taken of every account';
+
+-- the latest day may still be in progress, the snapshot job takes it again and completes it
+INSERT INTO "balance_snapshot_days" ("date")
+SELECT DISTINCT "date" FROM "account_balance_snapshots"
+WHERE "date" < (SELECT max("date") FROM "account_balance_snapshots");
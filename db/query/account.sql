@@ -2,15 +2,22 @@
 INSERT INTO accounts (
   owner,
   balance,
-  currency
+  currency,
+  type,
+  nickname,
+  number
 ) VALUES (
-  $1, $2, $3
+  $1, $2, $3, $4, $5, $6
 ) RETURNING *;
 
 -- name: GetAccount :one
 SELECT * FROM accounts
 WHERE id = $1 LIMIT 1;
 
+-- name: GetAccountByNumber :one
+SELECT * FROM accounts
+WHERE number = $1 LIMIT 1;
+
 -- name: GetAccountForUpdate :one
 SELECT * FROM accounts
 WHERE id = $1 LIMIT 1
@@ -18,10 +25,23 @@ FOR NO KEY UPDATE;
 
 -- name: ListAccounts :many
 SELECT * FROM accounts
-WHERE owner = $1
+WHERE
+    id IN (SELECT account_id FROM account_members WHERE username = sqlc.arg(username)) AND
+    (sqlc.narg(type)::varchar IS NULL OR type = sqlc.narg(type)) AND
+    (sqlc.narg(nickname)::varchar IS NULL OR nickname ILIKE '%' || sqlc.narg(nickname) || '%')
 ORDER BY id
-LIMIT $2
-OFFSET $3;
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
+
+-- name: CountOwnerAccounts :one
+SELECT count(*) FROM accounts
+WHERE owner = $1 AND currency = $2 AND type = $3;
+
+-- name: UpdateAccountNickname :one
+UPDATE accounts
+SET nickname = sqlc.arg(nickname)
+WHERE id = sqlc.arg(id)
+RETURNING *;
 
 -- name: UpdateAccount :one
 UPDATE accounts
@@ -37,4 +57,32 @@ RETURNING *;
 
 -- name: DeleteAccount :exec
 DELETE FROM accounts
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1;
+
+-- name: ListAllAccounts :many
+SELECT * FROM accounts
+WHERE id IN (SELECT account_id FROM account_members WHERE username = $1)
+ORDER BY id;
+
+-- name: ReassignAccounts :many
+UPDATE accounts
+SET owner = sqlc.arg(new_owner),
+  frozen_at = COALESCE(frozen_at, now())
+WHERE owner = sqlc.arg(owner)
+RETURNING *;
+
+-- name: FreezeAccount :one
+UPDATE accounts
+SET frozen_at = COALESCE(frozen_at, now())
+WHERE id = $1
+RETURNING *;
+
+-- name: UnfreezeAccount :one
+UPDATE accounts
+SET frozen_at = NULL
+WHERE id = $1
+RETURNING *;
+
+-- name: CountFrozenAccounts :one
+SELECT count(*) FROM accounts
+WHERE frozen_at IS NOT NULL;
@@ -0,0 +1,37 @@
+-- name: CreateAccountMember :one
+INSERT INTO account_members (
+  account_id,
+  username,
+  role
+) VALUES (
+  $1, $2, $3
+) RETURNING *;
+
+-- name: GetAccountMember :one
+SELECT * FROM account_members
+WHERE account_id = $1 AND username = $2 LIMIT 1;
+
+-- name: ListAccountMembers :many
+SELECT * FROM account_members
+WHERE account_id = $1
+ORDER BY created_at, username;
+
+-- name: UpdateAccountMemberRole :one
+UPDATE account_members
+SET role = sqlc.arg(role)
+WHERE account_id = sqlc.arg(account_id) AND username = sqlc.arg(username)
+RETURNING *;
+
+-- name: DeleteAccountMember :one
+DELETE FROM account_members
+WHERE account_id = $1 AND username = $2
+RETURNING *;
+
+-- name: ReassignAccountMembers :exec
+UPDATE account_members
+SET username = sqlc.arg(new_username)
+WHERE username = sqlc.arg(username);
+
+-- name: CountAccountOwners :one
+SELECT count(*) FROM account_members
+WHERE account_id = $1 AND role = 'owner';
@@ -0,0 +1,56 @@
+-- name: CreateAccountStatement :one
+INSERT INTO account_statements (
+  account_id,
+  period_start,
+  period_end,
+  opening_balance,
+  closing_balance,
+  content
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+)
+ON CONFLICT (account_id, period_start) DO UPDATE
+SET period_end = EXCLUDED.period_end,
+  opening_balance = EXCLUDED.opening_balance,
+  closing_balance = EXCLUDED.closing_balance,
+  content = EXCLUDED.content,
+  created_at = now()
+RETURNING *;
+
+-- name: GetAccountStatement :one
+SELECT * FROM account_statements
+WHERE account_id = $1 AND period_start = $2
+LIMIT 1;
+
+-- name: ListAccountStatements :many
+SELECT
+  id,
+  account_id,
+  period_start,
+  period_end,
+  opening_balance,
+  closing_balance,
+  octet_length(content)::bigint AS size,
+  created_at
+FROM account_statements
+WHERE account_id = $1
+ORDER BY period_start DESC
+LIMIT $2
+OFFSET $3;
+
+-- name: ListAccountsWithoutStatement :many
+SELECT * FROM accounts
+WHERE id > sqlc.arg(after_id)
+  AND created_at < sqlc.arg(closing_at)
+  AND NOT EXISTS (
+    SELECT 1 FROM account_statements
+    WHERE account_id = accounts.id AND period_start = sqlc.arg(period_start)
+  )
+ORDER BY id
+LIMIT sqlc.arg('limit');
+
+-- name: DeleteOwnerAccountStatements :exec
+DELETE FROM account_statements
+WHERE account_id IN (
+  SELECT id FROM accounts WHERE owner = $1
+);
@@ -0,0 +1,50 @@
+-- name: CreateBalanceSnapshots :many
+INSERT INTO account_balance_snapshots (
+  account_id,
+  date,
+  closing_at,
+  balance
+)
+SELECT
+  id,
+  sqlc.arg(date)::date,
+  sqlc.arg(closing_at)::timestamptz,
+  balance - (
+    SELECT COALESCE(sum(amount), 0) FROM entries
+    WHERE account_id = accounts.id AND created_at >= sqlc.arg(closing_at)::timestamptz
+  )::bigint
+FROM accounts
+WHERE id > sqlc.arg(after_id) AND created_at < sqlc.arg(closing_at)::timestamptz
+ORDER BY id
+LIMIT sqlc.arg('limit')
+ON CONFLICT (account_id, date) DO UPDATE
+SET closing_at = EXCLUDED.closing_at, balance = EXCLUDED.balance, created_at = now()
+RETURNING account_id;
+
+-- name: GetLatestBalanceSnapshot :one
+SELECT * FROM account_balance_snapshots
+WHERE account_id = sqlc.arg(account_id) AND closing_at <= sqlc.arg(at)
+ORDER BY date DESC
+LIMIT 1;
+
+-- name: GetLatestBalanceSnapshotDate :one
+SELECT max(date) AS latest_date FROM account_balance_snapshots;
+
+-- name: CompleteBalanceSnapshotDay :exec
+INSERT INTO balance_snapshot_days (date) VALUES (sqlc.arg(date))
+ON CONFLICT (date) DO UPDATE SET completed_at = now();
+
+-- name: GetLatestCompletedBalanceSnapshotDate :one
+SELECT max(date) AS latest_date FROM balance_snapshot_days;
+
+-- name: SumEntriesBetween :one
+SELECT COALESCE(sum(amount), 0)::bigint AS total FROM entries
+WHERE account_id = sqlc.arg(account_id) AND created_at >= sqlc.arg(from_time) AND created_at <= sqlc.arg(until_time);
+
+-- name: GetAccountBalanceAt :one
+SELECT balance - (
+  SELECT COALESCE(sum(amount), 0) FROM entries
+  WHERE account_id = accounts.id AND created_at > sqlc.arg(at)
+)::bigint AS balance
+FROM accounts
+WHERE id = sqlc.arg(account_id);
@@ -0,0 +1,34 @@
+-- name: CreateBeneficiary :one
+INSERT INTO beneficiaries (
+  username,
+  account_id,
+  account_number,
+  label,
+  available_at
+) VALUES (
+  $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: GetBeneficiary :one
+SELECT * FROM beneficiaries
+WHERE id = $1 AND username = $2 LIMIT 1;
+
+-- name: ListBeneficiaries :many
+SELECT * FROM beneficiaries
+WHERE username = $1
+ORDER BY label, id;
+
+-- name: UpdateBeneficiaryLabel :one
+UPDATE beneficiaries
+SET label = sqlc.arg(label)
+WHERE id = sqlc.arg(id) AND username = sqlc.arg(username)
+RETURNING *;
+
+-- name: DeleteBeneficiary :one
+DELETE FROM beneficiaries
+WHERE id = $1 AND username = $2
+RETURNING *;
+
+-- name: DeleteBeneficiaries :exec
+DELETE FROM beneficiaries
+WHERE username = $1;
@@ -0,0 +1,35 @@
+-- name: CreateFeeCharge :one
+INSERT INTO fee_charges (
+  account_id,
+  kind,
+  transfer_id,
+  month,
+  amount,
+  waived,
+  journal_transaction_id
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7
+) RETURNING *;
+
+-- name: ListFeeCharges :many
+SELECT * FROM fee_charges
+WHERE account_id = $1
+ORDER BY id DESC
+LIMIT $2
+OFFSET $3;
+
+-- name: ListMaintenanceFeeAccounts :many
+SELECT
+  a.id,
+  a.type,
+  a.currency,
+  u.tier
+FROM accounts a
+JOIN users u ON u.username = a.owner
+WHERE a.created_at < sqlc.arg(opened_before) AND a.frozen_at IS NULL AND a.id > sqlc.arg(after_id)
+  AND NOT EXISTS (
+    SELECT 1 FROM fee_charges
+    WHERE account_id = a.id AND month = sqlc.arg(month)
+  )
+ORDER BY a.id
+LIMIT sqlc.arg('limit');
@@ -0,0 +1,99 @@
+-- name: CreateInterestRate :one
+INSERT INTO interest_rates (
+  account_type,
+  currency,
+  annual_rate_bps,
+  effective_from
+) VALUES (
+  $1, $2, $3, $4
+)
+ON CONFLICT (account_type, currency, effective_from) DO UPDATE
+SET annual_rate_bps = EXCLUDED.annual_rate_bps, created_at = now()
+RETURNING *;
+
+-- name: ListInterestRates :many
+SELECT * FROM interest_rates
+ORDER BY account_type, currency, effective_from DESC;
+
+-- name: GetInterestRate :one
+SELECT * FROM interest_rates
+WHERE id = $1
+LIMIT 1;
+
+-- name: DeleteInterestRate :one
+DELETE FROM interest_rates
+WHERE id = $1
+RETURNING *;
+
+-- name: ListInterestAccrualBalances :many
+SELECT
+  s.account_id,
+  a.currency,
+  s.balance,
+  r.annual_rate_bps
+FROM account_balance_snapshots s
+JOIN accounts a ON a.id = s.account_id
+JOIN LATERAL (
+  SELECT annual_rate_bps FROM interest_rates
+  WHERE account_type = a.type AND currency = a.currency AND effective_from <= s.date
+  ORDER BY effective_from DESC
+  LIMIT 1
+) r ON true
+WHERE s.date = sqlc.arg(date) AND s.account_id > sqlc.arg(after_id)
+  AND s.balance > 0 AND r.annual_rate_bps > 0
+ORDER BY s.account_id
+LIMIT sqlc.arg('limit');
+
+-- name: CreateInterestAccrual :exec
+INSERT INTO interest_accruals (
+  account_id,
+  date,
+  balance,
+  annual_rate_bps,
+  amount_micros
+) VALUES (
+  $1, $2, $3, $4, $5
+)
+ON CONFLICT (account_id, date) DO UPDATE
+SET balance = EXCLUDED.balance,
+  annual_rate_bps = EXCLUDED.annual_rate_bps,
+  amount_micros = EXCLUDED.amount_micros,
+  created_at = now();
+
+-- name: GetLatestInterestAccrualDate :one
+SELECT max(date) AS latest_date FROM interest_accruals;
+
+-- name: ListUnpostedInterest :many
+SELECT
+  i.account_id,
+  a.currency,
+  sum(i.amount_micros)::bigint AS accrued_micros
+FROM interest_accruals i
+JOIN accounts a ON a.id = i.account_id
+WHERE i.date >= sqlc.arg(period_start) AND i.date <= sqlc.arg(period_end) AND i.account_id > sqlc.arg(after_id)
+  AND NOT EXISTS (
+    SELECT 1 FROM interest_postings
+    WHERE account_id = i.account_id AND month = sqlc.arg(period_start)
+  )
+GROUP BY i.account_id, a.currency
+ORDER BY i.account_id
+LIMIT sqlc.arg('limit');
+
+-- name: CreateInterestPosting :one
+INSERT INTO interest_postings (
+  account_id,
+  month,
+  accrued_micros,
+  amount,
+  journal_transaction_id
+) VALUES (
+  $1, $2, $3, $4, $5
+)
+RETURNING *;
+
+-- name: ListInterestPostings :many
+SELECT * FROM interest_postings
+WHERE account_id = $1
+ORDER BY month DESC
+LIMIT $2
+OFFSET $3;
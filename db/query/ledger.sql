@@ -0,0 +1,55 @@
+-- name: UpsertLedgerAccount :one
+INSERT INTO ledger_accounts (
+  code,
+  name,
+  currency
+) VALUES (
+  $1, $2, $3
+)
+ON CONFLICT (code, currency) DO UPDATE SET code = EXCLUDED.code
+RETURNING *;
+
+-- name: GetLedgerAccount :one
+SELECT * FROM ledger_accounts
+WHERE id = $1 LIMIT 1;
+
+-- name: ListLedgerAccounts :many
+SELECT * FROM ledger_accounts
+ORDER BY code, currency;
+
+-- name: AddLedgerAccountBalance :one
+UPDATE ledger_accounts
+SET balance = balance + sqlc.arg(amount)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: CreateJournalTransaction :one
+INSERT INTO journal_transactions (
+  kind,
+  description,
+  reference,
+  transfer_id
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: GetJournalTransaction :one
+SELECT * FROM journal_transactions
+WHERE id = $1 LIMIT 1;
+
+-- name: CreatePosting :one
+INSERT INTO postings (
+  journal_transaction_id,
+  account_id,
+  ledger_account_id,
+  entry_id,
+  currency,
+  amount
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+) RETURNING *;
+
+-- name: ListPostings :many
+SELECT * FROM postings
+WHERE journal_transaction_id = $1
+ORDER BY id;
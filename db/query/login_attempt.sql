@@ -0,0 +1,38 @@
+-- name: CreateLoginAttempt :one
+INSERT INTO login_attempts (
+  username,
+  client_ip,
+  success
+) VALUES (
+  $1, $2, $3
+) RETURNING *;
+
+-- name: GetLoginFailures :one
+SELECT
+  count(*) AS failures,
+  COALESCE(max(created_at), '0001-01-01 00:00:00Z')::timestamptz AS last_failed_at
+FROM login_attempts
+WHERE username = sqlc.arg(username)
+  AND success = false
+  AND cleared = false
+  AND created_at > sqlc.arg(since);
+
+-- name: ClearLoginFailures :exec
+UPDATE login_attempts
+SET cleared = true
+WHERE username = $1
+  AND success = false
+  AND cleared = false;
+
+-- name: ListLoginAttempts :many
+SELECT * FROM login_attempts
+WHERE username = $1
+ORDER BY id;
+
+-- name: DeleteLoginAttempts :exec
+DELETE FROM login_attempts
+WHERE username = $1;
+
+-- name: DeleteLoginAttemptsBefore :execrows
+DELETE FROM login_attempts
+WHERE created_at < sqlc.arg(before);
@@ -0,0 +1,27 @@
+-- name: CreateLoginChallenge :one
+INSERT INTO login_challenges (
+  username,
+  token_hash,
+  expires_at
+) VALUES (
+  $1, $2, $3
+) RETURNING *;
+
+-- name: GetLoginChallenge :one
+SELECT * FROM login_challenges
+WHERE token_hash = $1
+  AND used_at IS NULL
+  AND expires_at > now()
+LIMIT 1;
+
+-- name: ConsumeLoginChallenge :one
+UPDATE login_challenges
+SET used_at = now()
+WHERE token_hash = $1
+  AND used_at IS NULL
+  AND expires_at > now()
+RETURNING *;
+
+-- name: DeleteLoginChallenges :exec
+DELETE FROM login_challenges
+WHERE username = $1;
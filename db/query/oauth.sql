@@ -0,0 +1,84 @@
+-- name: ConsumeOAuthAuthorizationCode :one
+UPDATE oauth_authorization_codes
+SET used_at = now()
+WHERE code_hash = $1
+  AND used_at IS NULL
+  AND expires_at > now()
+RETURNING *;
+
+-- name: CreateOAuthAuthorizationCode :one
+INSERT INTO oauth_authorization_codes (
+  code_hash,
+  client_id,
+  username,
+  redirect_uri,
+  scopes,
+  code_challenge,
+  expires_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7
+) RETURNING *;
+
+-- name: CreateOAuthClient :one
+INSERT INTO oauth_clients (
+  id,
+  owner,
+  name,
+  secret_hash,
+  redirect_uris,
+  scopes
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+) RETURNING *;
+
+-- name: DeleteOAuthConsent :one
+DELETE FROM oauth_consents
+WHERE username = $1
+  AND client_id = $2
+RETURNING *;
+
+-- name: GetOAuthClient :one
+SELECT * FROM oauth_clients
+WHERE id = $1 LIMIT 1;
+
+-- name: GetOAuthConsent :one
+SELECT * FROM oauth_consents
+WHERE username = $1
+  AND client_id = $2
+LIMIT 1;
+
+-- name: ListOAuthClients :many
+SELECT * FROM oauth_clients
+WHERE owner = $1
+ORDER BY created_at;
+
+-- name: ListOAuthConsents :many
+SELECT * FROM oauth_consents
+WHERE username = $1
+ORDER BY created_at;
+
+-- name: UpsertOAuthConsent :one
+INSERT INTO oauth_consents (
+  username,
+  client_id,
+  scopes
+) VALUES (
+  $1, $2, $3
+) ON CONFLICT (username, client_id) DO UPDATE
+SET scopes = EXCLUDED.scopes,
+    updated_at = now()
+RETURNING *;
+
+-- name: DeleteOAuthAuthorizationCodes :exec
+DELETE FROM oauth_authorization_codes
+WHERE username = sqlc.arg(username)
+  OR client_id IN (SELECT id FROM oauth_clients WHERE owner = sqlc.arg(username));
+
+-- name: DeleteOAuthClients :exec
+DELETE FROM oauth_clients
+WHERE owner = $1;
+
+-- name: DeleteOAuthConsents :exec
+DELETE FROM oauth_consents
+WHERE username = sqlc.arg(username)
+  OR client_id IN (SELECT id FROM oauth_clients WHERE owner = sqlc.arg(username));
@@ -0,0 +1,21 @@
+-- name: TakeRateLimitToken :one
+INSERT INTO rate_limit_buckets AS b (
+  key,
+  tokens,
+  allowed
+) VALUES (
+  sqlc.arg(key), sqlc.arg(capacity)::float8 - 1, true
+)
+ON CONFLICT (key) DO UPDATE SET
+  tokens = CASE
+    WHEN LEAST(sqlc.arg(capacity)::float8, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at) * sqlc.arg(refill_rate)::float8) >= 1
+    THEN LEAST(sqlc.arg(capacity)::float8, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at) * sqlc.arg(refill_rate)::float8) - 1
+    ELSE LEAST(sqlc.arg(capacity)::float8, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at) * sqlc.arg(refill_rate)::float8)
+  END,
+  allowed = LEAST(sqlc.arg(capacity)::float8, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at) * sqlc.arg(refill_rate)::float8) >= 1,
+  updated_at = now()
+RETURNING tokens, allowed;
+
+-- name: DeleteIdleRateLimitBuckets :execrows
+DELETE FROM rate_limit_buckets
+WHERE updated_at < sqlc.arg(idle_since);
@@ -0,0 +1,107 @@
+-- name: CreateReconciliationRun :one
+INSERT INTO reconciliation_runs DEFAULT VALUES
+RETURNING *;
+
+-- name: FinishReconciliationRun :one
+UPDATE reconciliation_runs
+SET
+  status = sqlc.arg(status),
+  accounts_checked = sqlc.arg(accounts_checked),
+  transfers_checked = sqlc.arg(transfers_checked),
+  ledger_accounts_checked = sqlc.arg(ledger_accounts_checked),
+  discrepancies = sqlc.arg(discrepancies),
+  frozen_accounts = sqlc.arg(frozen_accounts),
+  error = sqlc.arg(error),
+  finished_at = now()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: GetReconciliationRun :one
+SELECT * FROM reconciliation_runs
+WHERE id = $1 LIMIT 1;
+
+-- name: GetLatestReconciliationRun :one
+SELECT * FROM reconciliation_runs
+WHERE finished_at IS NOT NULL
+ORDER BY id DESC
+LIMIT 1;
+
+-- name: ListReconciliationRuns :many
+SELECT * FROM reconciliation_runs
+ORDER BY id DESC
+LIMIT $1
+OFFSET $2;
+
+-- name: CreateReconciliationDiscrepancy :one
+INSERT INTO reconciliation_discrepancies (
+  run_id,
+  kind,
+  account_id,
+  transfer_id,
+  ledger_account_id,
+  expected,
+  actual,
+  details
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8
+) RETURNING *;
+
+-- name: ListReconciliationDiscrepancies :many
+SELECT * FROM reconciliation_discrepancies
+WHERE run_id = $1
+ORDER BY id;
+
+-- name: CountReconciliationDiscrepancies :many
+SELECT kind, count(*) AS count FROM reconciliation_discrepancies
+WHERE run_id = $1
+GROUP BY kind
+ORDER BY kind;
+
+-- name: CheckAccountBalances :many
+SELECT
+  id,
+  balance,
+  (SELECT COALESCE(sum(amount), 0) FROM entries WHERE account_id = accounts.id)::bigint AS entries_total
+FROM accounts
+WHERE id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg('limit');
+
+-- name: CheckTransferEntries :many
+SELECT
+  t.id,
+  t.from_account_id,
+  t.to_account_id,
+  t.amount,
+  (SELECT count(*) FROM journal_transactions j WHERE j.transfer_id = t.id) AS journal_transactions,
+  (
+    SELECT count(*) FROM postings p
+    JOIN journal_transactions j ON j.id = p.journal_transaction_id
+    WHERE j.transfer_id = t.id AND p.entry_id IS NOT NULL
+  ) AS entries,
+  (
+    SELECT COALESCE(sum(e.amount), 0) FROM entries e
+    JOIN postings p ON p.entry_id = e.id
+    JOIN journal_transactions j ON j.id = p.journal_transaction_id
+    WHERE j.transfer_id = t.id AND e.account_id = t.from_account_id
+  )::bigint AS from_total,
+  (
+    SELECT COALESCE(sum(e.amount), 0) FROM entries e
+    JOIN postings p ON p.entry_id = e.id
+    JOIN journal_transactions j ON j.id = p.journal_transaction_id
+    WHERE j.transfer_id = t.id AND e.account_id = t.to_account_id
+  )::bigint AS to_total
+FROM transfers t
+WHERE t.id > sqlc.arg(after_id)
+ORDER BY t.id
+LIMIT sqlc.arg('limit');
+
+-- name: CheckLedgerAccountBalances :many
+SELECT
+  id,
+  balance,
+  (SELECT COALESCE(sum(amount), 0) FROM postings WHERE ledger_account_id = ledger_accounts.id)::bigint AS postings_total
+FROM ledger_accounts
+WHERE id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg('limit');
@@ -0,0 +1,22 @@
+-- name: ListStatementEntries :many
+SELECT
+  e.id,
+  e.amount,
+  e.created_at,
+  e.description,
+  e.reference,
+  COALESCE(j.kind, '')::text AS kind,
+  j.transfer_id,
+  COALESCE(c.number, '')::text AS counterparty_number,
+  COALESCE(u.full_name, '')::text AS counterparty_name
+FROM entries e
+LEFT JOIN postings p ON p.entry_id = e.id
+LEFT JOIN journal_transactions j ON j.id = p.journal_transaction_id
+LEFT JOIN transfers t ON t.id = j.transfer_id
+LEFT JOIN accounts c ON c.id = CASE WHEN t.from_account_id = e.account_id THEN t.to_account_id ELSE t.from_account_id END
+LEFT JOIN users u ON u.username = c.owner
+WHERE e.account_id = sqlc.arg(account_id)
+  AND e.created_at >= sqlc.arg(from_time) AND e.created_at < sqlc.arg(until_time)
+  AND (e.created_at, e.id) > (sqlc.arg(after_created_at)::timestamptz, sqlc.arg(after_id)::bigint)
+ORDER BY e.created_at, e.id
+LIMIT sqlc.arg('limit');
@@ -2,9 +2,12 @@
 INSERT INTO transfers (
   from_account_id,
   to_account_id,
-  amount
+  amount,
+  description,
+  reference,
+  metadata
 ) VALUES (
-  $1, $2, $3
+  $1, $2, $3, $4, $5, $6
 ) RETURNING *;
 
 -- name: GetTransfer :one
@@ -19,3 +22,21 @@ WHERE
 ORDER BY id
 LIMIT $3
 OFFSET $4;
+
+-- name: ListMemberTransfers :many
+SELECT * FROM transfers
+WHERE
+    from_account_id IN (SELECT account_id FROM account_members WHERE username = sqlc.arg(username)) OR
+    to_account_id IN (SELECT account_id FROM account_members WHERE username = sqlc.arg(username))
+ORDER BY id;
+
+-- name: ListTransfersByReference :many
+SELECT * FROM transfers
+WHERE
+    reference = sqlc.arg(reference) AND (
+        from_account_id IN (SELECT account_id FROM account_members WHERE username = sqlc.arg(username)) OR
+        to_account_id IN (SELECT account_id FROM account_members WHERE username = sqlc.arg(username))
+    )
+ORDER BY id
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
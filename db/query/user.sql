@@ -10,4 +10,87 @@ INSERT INTO users (
 
 -- name: GetUser :one
 SELECT * FROM users
-WHERE username = $1 LIMIT 1;
\ No newline at end of file
+WHERE username = $1 LIMIT 1;
+
+-- name: GetUserForUpdate :one
+SELECT * FROM users
+WHERE username = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: GetUserByEmail :one
+SELECT * FROM users
+WHERE email = $1 LIMIT 1;
+
+-- name: UpdateUserPassword :one
+UPDATE users
+SET
+  hashed_password = sqlc.arg(hashed_password),
+  password_changed_at = sqlc.arg(password_changed_at)
+WHERE username = sqlc.arg(username)
+RETURNING *;
+
+-- name: RehashUserPassword :exec
+UPDATE users
+SET hashed_password = sqlc.arg(new_hashed_password)
+WHERE username = sqlc.arg(username)
+  AND hashed_password = sqlc.arg(old_hashed_password);
+
+-- name: SetUserEmailVerified :one
+UPDATE users
+SET
+  email = sqlc.arg(email),
+  is_email_verified = true,
+  pending_email = CASE WHEN email = sqlc.arg(email) THEN pending_email ELSE '' END
+WHERE username = sqlc.arg(username)
+  AND (email = sqlc.arg(email) OR pending_email = sqlc.arg(email))
+RETURNING *;
+
+-- name: SetUserTOTPSecret :one
+UPDATE users
+SET totp_secret = sqlc.arg(totp_secret)
+WHERE username = sqlc.arg(username)
+  AND is_totp_enabled = false
+RETURNING *;
+
+-- name: EnableUserTOTP :one
+UPDATE users
+SET is_totp_enabled = true
+WHERE username = $1
+RETURNING *;
+
+-- name: UseUserTOTPStep :one
+UPDATE users
+SET totp_last_step = sqlc.arg(step)
+WHERE username = sqlc.arg(username)
+  AND totp_last_step < sqlc.arg(step)
+RETURNING *;
+
+-- name: UpdateUser :one
+UPDATE users
+SET
+  full_name = COALESCE(sqlc.narg(full_name), full_name),
+  pending_email = COALESCE(sqlc.narg(pending_email), pending_email)
+WHERE username = sqlc.arg(username)
+RETURNING *;
+
+-- name: CreateUserPseudonym :one
+INSERT INTO users (
+  username,
+  hashed_password,
+  full_name,
+  email,
+  created_at,
+  deleted_at
+) VALUES (
+  $1, '', '', $2, $3, now()
+) RETURNING *;
+
+-- name: DeleteUser :exec
+DELETE FROM users
+WHERE username = $1;
+
+-- name: SetUserTier :one
+UPDATE users
+SET tier = $1
+WHERE username = $2
+RETURNING *;
@@ -5,13 +5,14 @@ package db
 
 import (
 	"context"
+	"database/sql"
 )
 
 const addAccountBalance = `-- name: AddAccountBalance :one
 UPDATE accounts
 SET balance = balance+ $1
 WHERE id = $2
-RETURNING id, owner, balance, currency, created_at
+RETURNING id, owner, balance, currency, created_at, type, nickname, number, frozen_at
 `
 
 type AddAccountBalanceParams struct {
@@ -28,28 +29,75 @@ func (q *Queries) AddAccountBalance(ctx context.Context, arg AddAccountBalancePa
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Type,
+		&i.Nickname,
+		&i.Number,
+		&i.FrozenAt,
 	)
 	return i, err
 }
 
+const countFrozenAccounts = `-- name: CountFrozenAccounts :one
+SELECT count(*) FROM accounts
+WHERE frozen_at IS NOT NULL
+`
+
+func (q *Queries) CountFrozenAccounts(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countFrozenAccounts)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countOwnerAccounts = `-- name: CountOwnerAccounts :one
+SELECT count(*) FROM accounts
+WHERE owner = $1 AND currency = $2 AND type = $3
+`
+
+type CountOwnerAccountsParams struct {
+	Owner    string `json:"owner"`
+	Currency string `json:"currency"`
+	Type     string `json:"type"`
+}
+
+func (q *Queries) CountOwnerAccounts(ctx context.Context, arg CountOwnerAccountsParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countOwnerAccounts, arg.Owner, arg.Currency, arg.Type)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createAccount = `-- name: CreateAccount :one
 INSERT INTO accounts (
   owner,
   balance,
-  currency
+  currency,
+  type,
+  nickname,
+  number
 ) VALUES (
-  $1, $2, $3
-) RETURNING id, owner, balance, currency, created_at
+  $1, $2, $3, $4, $5, $6
+) RETURNING id, owner, balance, currency, created_at, type, nickname, number, frozen_at
 `
 
 type CreateAccountParams struct {
 	Owner    string `json:"owner"`
 	Balance  int64  `json:"balance"`
 	Currency string `json:"currency"`
+	Type     string `json:"type"`
+	Nickname string `json:"nickname"`
+	Number   string `json:"number"`
 }
 
 func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error) {
-	row := q.db.QueryRowContext(ctx, createAccount, arg.Owner, arg.Balance, arg.Currency)
+	row := q.db.QueryRowContext(ctx, createAccount,
+		arg.Owner,
+		arg.Balance,
+		arg.Currency,
+		arg.Type,
+		arg.Nickname,
+		arg.Number,
+	)
 	var i Account
 	err := row.Scan(
 		&i.ID,
@@ -57,6 +105,10 @@ func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (A
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Type,
+		&i.Nickname,
+		&i.Number,
+		&i.FrozenAt,
 	)
 	return i, err
 }
@@ -71,8 +123,32 @@ func (q *Queries) DeleteAccount(ctx context.Context, id int64) error {
 	return err
 }
 
+const freezeAccount = `-- name: FreezeAccount :one
+UPDATE accounts
+SET frozen_at = COALESCE(frozen_at, now())
+WHERE id = $1
+RETURNING id, owner, balance, currency, created_at, type, nickname, number, frozen_at
+`
+
+func (q *Queries) FreezeAccount(ctx context.Context, id int64) (Account, error) {
+	row := q.db.QueryRowContext(ctx, freezeAccount, id)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Type,
+		&i.Nickname,
+		&i.Number,
+		&i.FrozenAt,
+	)
+	return i, err
+}
+
 const getAccount = `-- name: GetAccount :one
-SELECT id, owner, balance, currency, created_at FROM accounts
+SELECT id, owner, balance, currency, created_at, type, nickname, number, frozen_at FROM accounts
 WHERE id = $1 LIMIT 1
 `
 
@@ -85,12 +161,38 @@ func (q *Queries) GetAccount(ctx context.Context, id int64) (Account, error) {
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Type,
+		&i.Nickname,
+		&i.Number,
+		&i.FrozenAt,
+	)
+	return i, err
+}
+
+const getAccountByNumber = `-- name: GetAccountByNumber :one
+SELECT id, owner, balance, currency, created_at, type, nickname, number, frozen_at FROM accounts
+WHERE number = $1 LIMIT 1
+`
+
+func (q *Queries) GetAccountByNumber(ctx context.Context, number string) (Account, error) {
+	row := q.db.QueryRowContext(ctx, getAccountByNumber, number)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Type,
+		&i.Nickname,
+		&i.Number,
+		&i.FrozenAt,
 	)
 	return i, err
 }
 
 const getAccountForUpdate = `-- name: GetAccountForUpdate :one
-SELECT id, owner, balance, currency, created_at FROM accounts
+SELECT id, owner, balance, currency, created_at, type, nickname, number, frozen_at FROM accounts
 WHERE id = $1 LIMIT 1
 FOR NO KEY UPDATE
 `
@@ -104,26 +206,41 @@ func (q *Queries) GetAccountForUpdate(ctx context.Context, id int64) (Account, e
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Type,
+		&i.Nickname,
+		&i.Number,
+		&i.FrozenAt,
 	)
 	return i, err
 }
 
 const listAccounts = `-- name: ListAccounts :many
-SELECT id, owner, balance, currency, created_at FROM accounts
-WHERE owner = $1
+SELECT id, owner, balance, currency, created_at, type, nickname, number, frozen_at FROM accounts
+WHERE
+    id IN (SELECT account_id FROM account_members WHERE username = $1) AND
+    ($2::varchar IS NULL OR type = $2) AND
+    ($3::varchar IS NULL OR nickname ILIKE '%' || $3 || '%')
 ORDER BY id
-LIMIT $2
-OFFSET $3
+LIMIT $4
+OFFSET $5
 `
 
 type ListAccountsParams struct {
-	Owner  string `json:"owner"`
-	Limit  int32  `json:"limit"`
-	Offset int32  `json:"offset"`
+	Username string         `json:"username"`
+	Type     sql.NullString `json:"type"`
+	Nickname sql.NullString `json:"nickname"`
+	Limit    int32          `json:"limit"`
+	Offset   int32          `json:"offset"`
 }
 
 func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error) {
-	rows, err := q.db.QueryContext(ctx, listAccounts, arg.Owner, arg.Limit, arg.Offset)
+	rows, err := q.db.QueryContext(ctx, listAccounts,
+		arg.Username,
+		arg.Type,
+		arg.Nickname,
+		arg.Limit,
+		arg.Offset,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -137,6 +254,10 @@ func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]A
 			&i.Balance,
 			&i.Currency,
 			&i.CreatedAt,
+			&i.Type,
+			&i.Nickname,
+			&i.Number,
+			&i.FrozenAt,
 		); err != nil {
 			return nil, err
 		}
@@ -151,11 +272,120 @@ func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]A
 	return items, nil
 }
 
+const listAllAccounts = `-- name: ListAllAccounts :many
+SELECT id, owner, balance, currency, created_at, type, nickname, number, frozen_at FROM accounts
+WHERE id IN (SELECT account_id FROM account_members WHERE username = $1)
+ORDER BY id
+`
+
+func (q *Queries) ListAllAccounts(ctx context.Context, username string) ([]Account, error) {
+	rows, err := q.db.QueryContext(ctx, listAllAccounts, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Account{}
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Balance,
+			&i.Currency,
+			&i.CreatedAt,
+			&i.Type,
+			&i.Nickname,
+			&i.Number,
+			&i.FrozenAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const reassignAccounts = `-- name: ReassignAccounts :many
+UPDATE accounts
+SET owner = $1,
+  frozen_at = COALESCE(frozen_at, now())
+WHERE owner = $2
+RETURNING id, owner, balance, currency, created_at, type, nickname, number, frozen_at
+`
+
+type ReassignAccountsParams struct {
+	NewOwner string `json:"new_owner"`
+	Owner    string `json:"owner"`
+}
+
+func (q *Queries) ReassignAccounts(ctx context.Context, arg ReassignAccountsParams) ([]Account, error) {
+	rows, err := q.db.QueryContext(ctx, reassignAccounts, arg.NewOwner, arg.Owner)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Account{}
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Balance,
+			&i.Currency,
+			&i.CreatedAt,
+			&i.Type,
+			&i.Nickname,
+			&i.Number,
+			&i.FrozenAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const unfreezeAccount = `-- name: UnfreezeAccount :one
+UPDATE accounts
+SET frozen_at = NULL
+WHERE id = $1
+RETURNING id, owner, balance, currency, created_at, type, nickname, number, frozen_at
+`
+
+func (q *Queries) UnfreezeAccount(ctx context.Context, id int64) (Account, error) {
+	row := q.db.QueryRowContext(ctx, unfreezeAccount, id)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Type,
+		&i.Nickname,
+		&i.Number,
+		&i.FrozenAt,
+	)
+	return i, err
+}
+
 const updateAccount = `-- name: UpdateAccount :one
 UPDATE accounts
 SET balance = $2
 WHERE id = $1
-RETURNING id, owner, balance, currency, created_at
+RETURNING id, owner, balance, currency, created_at, type, nickname, number, frozen_at
 `
 
 type UpdateAccountParams struct {
@@ -172,6 +402,39 @@ func (q *Queries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (A
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Type,
+		&i.Nickname,
+		&i.Number,
+		&i.FrozenAt,
+	)
+	return i, err
+}
+
+const updateAccountNickname = `-- name: UpdateAccountNickname :one
+UPDATE accounts
+SET nickname = $1
+WHERE id = $2
+RETURNING id, owner, balance, currency, created_at, type, nickname, number, frozen_at
+`
+
+type UpdateAccountNicknameParams struct {
+	Nickname string `json:"nickname"`
+	ID       int64  `json:"id"`
+}
+
+func (q *Queries) UpdateAccountNickname(ctx context.Context, arg UpdateAccountNicknameParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, updateAccountNickname, arg.Nickname, arg.ID)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Type,
+		&i.Nickname,
+		&i.Number,
+		&i.FrozenAt,
 	)
 	return i, err
 }
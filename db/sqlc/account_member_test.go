@@ -0,0 +1,107 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/hhow09/simple_bank/constants"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomAccountMember(t *testing.T, account Account, username string, role string) AccountMember {
+	member, err := testQueries.CreateAccountMember(context.Background(), CreateAccountMemberParams{
+		AccountID: account.ID,
+		Username:  username,
+		Role:      role,
+	})
+	require.NoError(t, err)
+	require.Equal(t, account.ID, member.AccountID)
+	require.Equal(t, username, member.Username)
+	require.Equal(t, role, member.Role)
+	require.NotZero(t, member.CreatedAt)
+
+	return member
+}
+
+func TestListAccountMembers(t *testing.T) {
+	account := createRandomAccount(t)
+	coOwner := createRandomAccountMember(t, account, createRandomUser(t).Username, constants.MemberRoleCoOwner)
+
+	members, err := testQueries.ListAccountMembers(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Len(t, members, 2)
+	require.Equal(t, account.Owner, members[0].Username)
+	require.Equal(t, coOwner, members[1])
+}
+
+func TestUpdateAccountMemberRoleTx(t *testing.T) {
+	store := NewStore(testDB)
+	account := createRandomAccount(t)
+	viewer := createRandomAccountMember(t, account, createRandomUser(t).Username, constants.MemberRoleViewer)
+
+	member, err := store.UpdateAccountMemberRoleTx(context.Background(), UpdateAccountMemberRoleParams{
+		Role:      constants.MemberRoleOwner,
+		AccountID: account.ID,
+		Username:  viewer.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, constants.MemberRoleOwner, member.Role)
+
+	// the opener can step down now that there is another owner
+	member, err = store.UpdateAccountMemberRoleTx(context.Background(), UpdateAccountMemberRoleParams{
+		Role:      constants.MemberRoleCoOwner,
+		AccountID: account.ID,
+		Username:  account.Owner,
+	})
+	require.NoError(t, err)
+	require.Equal(t, constants.MemberRoleCoOwner, member.Role)
+
+	owners, err := testQueries.CountAccountOwners(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), owners)
+}
+
+func TestUpdateAccountMemberRoleTxLastOwner(t *testing.T) {
+	store := NewStore(testDB)
+	account := createRandomAccount(t)
+
+	_, err := store.UpdateAccountMemberRoleTx(context.Background(), UpdateAccountMemberRoleParams{
+		Role:      constants.MemberRoleViewer,
+		AccountID: account.ID,
+		Username:  account.Owner,
+	})
+	require.ErrorIs(t, err, ErrLastAccountOwner)
+
+	member, err := testQueries.GetAccountMember(context.Background(), GetAccountMemberParams{
+		AccountID: account.ID,
+		Username:  account.Owner,
+	})
+	require.NoError(t, err)
+	require.Equal(t, constants.MemberRoleOwner, member.Role)
+}
+
+func TestDeleteAccountMemberTx(t *testing.T) {
+	store := NewStore(testDB)
+	account := createRandomAccount(t)
+	viewer := createRandomAccountMember(t, account, createRandomUser(t).Username, constants.MemberRoleViewer)
+
+	deleted, err := store.DeleteAccountMemberTx(context.Background(), DeleteAccountMemberParams{
+		AccountID: account.ID,
+		Username:  viewer.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, viewer, deleted)
+
+	_, err = testQueries.GetAccountMember(context.Background(), GetAccountMemberParams{
+		AccountID: account.ID,
+		Username:  viewer.Username,
+	})
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+
+	_, err = store.DeleteAccountMemberTx(context.Background(), DeleteAccountMemberParams{
+		AccountID: account.ID,
+		Username:  account.Owner,
+	})
+	require.ErrorIs(t, err, ErrLastAccountOwner)
+}
@@ -0,0 +1,68 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountStatements(t *testing.T) {
+	account := createRandomAccount(t)
+	year, month, _ := time.Now().UTC().Date()
+	periodStart := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	periodEnd := periodStart.AddDate(0, 1, -1)
+
+	// the account opened before the end of the month has no statement yet
+	arg := ListAccountsWithoutStatementParams{
+		AfterID:     account.ID - 1,
+		ClosingAt:   periodEnd.AddDate(0, 0, 1),
+		PeriodStart: periodStart,
+		Limit:       1,
+	}
+	accounts, err := testQueries.ListAccountsWithoutStatement(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	require.Equal(t, account.ID, accounts[0].ID)
+
+	statement, err := testQueries.CreateAccountStatement(context.Background(), CreateAccountStatementParams{
+		AccountID:      account.ID,
+		PeriodStart:    periodStart,
+		PeriodEnd:      periodEnd,
+		OpeningBalance: 0,
+		ClosingBalance: account.Balance,
+		Content:        []byte("%PDF-1.4"),
+	})
+	require.NoError(t, err)
+	require.Equal(t, account.ID, statement.AccountID)
+	require.True(t, periodStart.Equal(statement.PeriodStart))
+	require.True(t, periodEnd.Equal(statement.PeriodEnd))
+	require.Equal(t, account.Balance, statement.ClosingBalance)
+
+	// generating the month again replaces the statement
+	replaced, err := testQueries.CreateAccountStatement(context.Background(), CreateAccountStatementParams{
+		AccountID:      account.ID,
+		PeriodStart:    periodStart,
+		PeriodEnd:      periodEnd,
+		ClosingBalance: account.Balance,
+		Content:        []byte("%PDF-1.4 replaced"),
+	})
+	require.NoError(t, err)
+	require.Equal(t, statement.ID, replaced.ID)
+
+	got, err := testQueries.GetAccountStatement(context.Background(), GetAccountStatementParams{AccountID: account.ID, PeriodStart: periodStart})
+	require.NoError(t, err)
+	require.Equal(t, []byte("%PDF-1.4 replaced"), got.Content)
+
+	statements, err := testQueries.ListAccountStatements(context.Background(), ListAccountStatementsParams{AccountID: account.ID, Limit: 5})
+	require.NoError(t, err)
+	require.Len(t, statements, 1)
+	require.Equal(t, int64(len(got.Content)), statements[0].Size)
+
+	accounts, err = testQueries.ListAccountsWithoutStatement(context.Background(), arg)
+	require.NoError(t, err)
+	for _, a := range accounts {
+		require.NotEqual(t, account.ID, a.ID)
+	}
+}
@@ -5,25 +5,52 @@ import (
 	"database/sql"
 	"testing"
 
+	"github.com/hhow09/simple_bank/accountnumber"
+	"github.com/hhow09/simple_bank/constants"
 	"github.com/hhow09/simple_bank/util"
 	"github.com/stretchr/testify/require"
 )
 
+// accountNumbers generates the numbers of random accounts
+var accountNumbers, _ = accountnumber.NewGenerator(util.Config{
+	AccountNumberCountryCode: "SB",
+	AccountNumberBankCode:    "SMPL",
+	AccountNumberDigits:      12,
+})
+
+func randomAccountNumber(t *testing.T) string {
+	number, err := accountNumbers.Generate()
+	require.NoError(t, err)
+	return number
+}
+
 func createRandomAccount(t *testing.T) Account {
+	return createRandomAccountIn(t, util.RandomCurrency())
+}
+
+// createRandomAccountIn creates an account in the currency, money moves only between accounts of the same currency
+func createRandomAccountIn(t *testing.T, currency string) Account {
 	user := createRandomUser(t)
 	args := CreateAccountParams{
 		Owner:    user.Username,
 		Balance:  util.RandomMoney(),
-		Currency: util.RandomCurrency(),
+		Currency: currency,
+		Type:     constants.AccountTypeChecking,
+		Nickname: util.RandomString(8),
+		Number:   randomAccountNumber(t),
 	}
 
-	account, err := testQueries.CreateAccount(context.Background(), args)
+	result, err := NewStore(testDB).CreateAccountTx(context.Background(), CreateAccountTxParams{CreateAccountParams: args})
 	require.NoError(t, err)
+	account := result.Account
 	require.NotEmpty(t, account)
 
 	require.Equal(t, args.Owner, account.Owner)
 	require.Equal(t, args.Balance, account.Balance)
 	require.Equal(t, args.Currency, account.Currency)
+	require.Equal(t, args.Type, account.Type)
+	require.Equal(t, args.Nickname, account.Nickname)
+	require.Equal(t, args.Number, account.Number)
 
 	require.NotZero(t, account.ID)
 	require.NotZero(t, account.CreatedAt)
@@ -85,9 +112,9 @@ func TestListAccounts(t *testing.T) {
 	}
 
 	arg := ListAccountsParams{
-		Owner:  lastAccount.Owner,
-		Limit:  5,
-		Offset: 0,
+		Username: lastAccount.Owner,
+		Limit:    5,
+		Offset:   0,
 	}
 
 	accounts, err := testQueries.ListAccounts(context.Background(), arg)
@@ -99,3 +126,120 @@ func TestListAccounts(t *testing.T) {
 		require.Equal(t, lastAccount.Owner, account.Owner)
 	}
 }
+
+func TestCreateAccountTxOwner(t *testing.T) {
+	account := createRandomAccount(t)
+
+	member, err := testQueries.GetAccountMember(context.Background(), GetAccountMemberParams{
+		AccountID: account.ID,
+		Username:  account.Owner,
+	})
+	require.NoError(t, err)
+	require.Equal(t, constants.MemberRoleOwner, member.Role)
+}
+
+func TestListAccountsShared(t *testing.T) {
+	account := createRandomAccount(t)
+	user := createRandomUser(t)
+	createRandomAccountMember(t, account, user.Username, constants.MemberRoleViewer)
+
+	accounts, err := testQueries.ListAccounts(context.Background(), ListAccountsParams{
+		Username: user.Username,
+		Limit:    5,
+		Offset:   0,
+	})
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	require.Equal(t, account.ID, accounts[0].ID)
+}
+
+func TestCreateAccountTxLimit(t *testing.T) {
+	store := NewStore(testDB)
+	account := createRandomAccount(t)
+	arg := CreateAccountTxParams{
+		CreateAccountParams: CreateAccountParams{
+			Owner:    account.Owner,
+			Currency: account.Currency,
+			Type:     constants.AccountTypeChecking,
+			Number:   randomAccountNumber(t),
+		},
+		MaxPerCurrency: 1,
+	}
+
+	_, err := store.CreateAccountTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrAccountLimitReached)
+
+	// the limit applies per type
+	arg.Type = constants.AccountTypeSavings
+	arg.Number = randomAccountNumber(t)
+	result, err := store.CreateAccountTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, constants.AccountTypeSavings, result.Account.Type)
+}
+
+func TestListAccountsFilter(t *testing.T) {
+	store := NewStore(testDB)
+	checking := createRandomAccount(t)
+	result, err := store.CreateAccountTx(context.Background(), CreateAccountTxParams{
+		CreateAccountParams: CreateAccountParams{
+			Owner:    checking.Owner,
+			Currency: checking.Currency,
+			Type:     constants.AccountTypeSavings,
+			Nickname: "Holiday Savings",
+			Number:   randomAccountNumber(t),
+		},
+	})
+	require.NoError(t, err)
+	savings := result.Account
+
+	accounts, err := testQueries.ListAccounts(context.Background(), ListAccountsParams{
+		Username: checking.Owner,
+		Type:     sql.NullString{String: constants.AccountTypeSavings, Valid: true},
+		Limit:    5,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []Account{savings}, accounts)
+
+	accounts, err = testQueries.ListAccounts(context.Background(), ListAccountsParams{
+		Username: checking.Owner,
+		Nickname: sql.NullString{String: "holiday", Valid: true},
+		Limit:    5,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []Account{savings}, accounts)
+
+	accounts, err = testQueries.ListAccounts(context.Background(), ListAccountsParams{
+		Username: checking.Owner,
+		Limit:    5,
+	})
+	require.NoError(t, err)
+	require.Len(t, accounts, 2)
+}
+
+func TestUpdateAccountNickname(t *testing.T) {
+	account := createRandomAccount(t)
+
+	updated, err := testQueries.UpdateAccountNickname(context.Background(), UpdateAccountNicknameParams{
+		Nickname: "Rent",
+		ID:       account.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "Rent", updated.Nickname)
+	require.Equal(t, account.Balance, updated.Balance)
+}
+
+func TestGetAccountByNumber(t *testing.T) {
+	account := createRandomAccount(t)
+
+	got, err := testQueries.GetAccountByNumber(context.Background(), account.Number)
+	require.NoError(t, err)
+	require.Equal(t, account, got)
+
+	_, err = testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    account.Owner,
+		Currency: account.Currency,
+		Type:     constants.AccountTypeSavings,
+		Number:   account.Number,
+	})
+	require.Error(t, err)
+}
@@ -0,0 +1,101 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/hhow09/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomAPIKey(t *testing.T, user User) ApiKey {
+	_, prefix, secret, err := util.NewAPIKey()
+	require.NoError(t, err)
+
+	arg := CreateAPIKeyParams{
+		Username:   user.Username,
+		Name:       util.RandomOwner(),
+		Prefix:     prefix,
+		SecretHash: util.HashToken(secret),
+		Scopes:     []string{"accounts:read", "transfers:create"},
+		ExpiresAt:  sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true},
+	}
+
+	apiKey, err := testQueries.CreateAPIKey(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, apiKey.ID)
+	require.Equal(t, arg.Username, apiKey.Username)
+	require.Equal(t, arg.Name, apiKey.Name)
+	require.Equal(t, arg.Prefix, apiKey.Prefix)
+	require.Equal(t, arg.SecretHash, apiKey.SecretHash)
+	require.Equal(t, arg.Scopes, apiKey.Scopes)
+	require.WithinDuration(t, arg.ExpiresAt.Time, apiKey.ExpiresAt.Time, time.Second)
+	require.False(t, apiKey.LastUsedAt.Valid)
+	require.False(t, apiKey.RevokedAt.Valid)
+
+	return apiKey
+}
+
+func TestCreateAPIKey(t *testing.T) {
+	createRandomAPIKey(t, createRandomUser(t))
+}
+
+func TestGetAPIKeyByPrefix(t *testing.T) {
+	apiKey1 := createRandomAPIKey(t, createRandomUser(t))
+
+	apiKey2, err := testQueries.GetAPIKeyByPrefix(context.Background(), apiKey1.Prefix)
+	require.NoError(t, err)
+	require.Equal(t, apiKey1.ID, apiKey2.ID)
+	require.Equal(t, apiKey1.SecretHash, apiKey2.SecretHash)
+	require.Equal(t, apiKey1.Scopes, apiKey2.Scopes)
+}
+
+func TestListAPIKeys(t *testing.T) {
+	user := createRandomUser(t)
+	for i := 0; i < 3; i++ {
+		createRandomAPIKey(t, user)
+	}
+	createRandomAPIKey(t, createRandomUser(t))
+
+	apiKeys, err := testQueries.ListAPIKeys(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Len(t, apiKeys, 3)
+	for _, apiKey := range apiKeys {
+		require.Equal(t, user.Username, apiKey.Username)
+	}
+}
+
+func TestRevokeAPIKey(t *testing.T) {
+	user := createRandomUser(t)
+	apiKey := createRandomAPIKey(t, user)
+
+	// keys of other users cannot be revoked
+	_, err := testQueries.RevokeAPIKey(context.Background(), RevokeAPIKeyParams{
+		ID:       apiKey.ID,
+		Username: createRandomUser(t).Username,
+	})
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+
+	arg := RevokeAPIKeyParams{ID: apiKey.ID, Username: user.Username}
+	revoked, err := testQueries.RevokeAPIKey(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, revoked.RevokedAt.Valid)
+
+	// a key can only be revoked once
+	_, err = testQueries.RevokeAPIKey(context.Background(), arg)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+}
+
+func TestTouchAPIKey(t *testing.T) {
+	apiKey := createRandomAPIKey(t, createRandomUser(t))
+
+	err := testQueries.TouchAPIKey(context.Background(), apiKey.ID)
+	require.NoError(t, err)
+
+	touched, err := testQueries.GetAPIKeyByPrefix(context.Background(), apiKey.Prefix)
+	require.NoError(t, err)
+	require.True(t, touched.LastUsedAt.Valid)
+	require.WithinDuration(t, time.Now(), touched.LastUsedAt.Time, time.Minute)
+}
@@ -0,0 +1,78 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBalanceSnapshots(t *testing.T) {
+	account := createRandomAccount(t)
+	year, month, day := time.Now().UTC().Date()
+	date := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	closingAt := date.AddDate(0, 0, 1)
+
+	arg := CreateBalanceSnapshotsParams{Date: date, ClosingAt: closingAt, AfterID: account.ID - 1, Limit: 1}
+	accountIDs, err := testQueries.CreateBalanceSnapshots(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, []int64{account.ID}, accountIDs)
+
+	snapshot, err := testQueries.GetLatestBalanceSnapshot(context.Background(), GetLatestBalanceSnapshotParams{AccountID: account.ID, At: closingAt})
+	require.NoError(t, err)
+	require.Equal(t, account.Balance, snapshot.Balance)
+	require.True(t, date.Equal(snapshot.Date))
+	require.WithinDuration(t, closingAt, snapshot.ClosingAt, time.Second)
+
+	// the snapshot isn't closed yet before its closing time
+	_, err = testQueries.GetLatestBalanceSnapshot(context.Background(), GetLatestBalanceSnapshotParams{AccountID: account.ID, At: closingAt.Add(-time.Second)})
+	require.Error(t, err)
+
+	// taking the day again replaces the snapshot
+	accountIDs, err = testQueries.CreateBalanceSnapshots(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, []int64{account.ID}, accountIDs)
+
+	latest, err := testQueries.GetLatestBalanceSnapshotDate(context.Background())
+	require.NoError(t, err)
+	require.True(t, latest.Valid)
+	require.False(t, latest.Time.Before(date))
+}
+
+func TestAccountBalanceAt(t *testing.T) {
+	account := createRandomAccount(t)
+	before := time.Now()
+
+	_, err := testQueries.CreateEntry(context.Background(), CreateEntryParams{AccountID: account.ID, Amount: 10})
+	require.NoError(t, err)
+	updated, err := testQueries.AddAccountBalance(context.Background(), AddAccountBalanceParams{ID: account.ID, Amount: 10})
+	require.NoError(t, err)
+
+	balance, err := testQueries.GetAccountBalanceAt(context.Background(), GetAccountBalanceAtParams{At: time.Now(), AccountID: account.ID})
+	require.NoError(t, err)
+	require.Equal(t, updated.Balance, balance)
+
+	balance, err = testQueries.GetAccountBalanceAt(context.Background(), GetAccountBalanceAtParams{At: before, AccountID: account.ID})
+	require.NoError(t, err)
+	require.Equal(t, account.Balance, balance)
+
+	total, err := testQueries.SumEntriesBetween(context.Background(), SumEntriesBetweenParams{AccountID: account.ID, FromTime: before, UntilTime: time.Now()})
+	require.NoError(t, err)
+	require.Equal(t, int64(10), total)
+}
+
+func TestCompleteBalanceSnapshotDay(t *testing.T) {
+	year, month, day := time.Now().UTC().Date()
+	date := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+
+	// completing a day again is allowed when it is taken again
+	for i := 0; i < 2; i++ {
+		require.NoError(t, testQueries.CompleteBalanceSnapshotDay(context.Background(), date))
+	}
+
+	latest, err := testQueries.GetLatestCompletedBalanceSnapshotDate(context.Background())
+	require.NoError(t, err)
+	require.True(t, latest.Valid)
+	require.False(t, latest.Time.Before(date))
+}
@@ -0,0 +1,110 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/hhow09/simple_bank/util"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomBeneficiary(t *testing.T, user User, account Account) Beneficiary {
+	arg := CreateBeneficiaryParams{
+		Username:      user.Username,
+		AccountID:     account.ID,
+		AccountNumber: account.Number,
+		Label:         util.RandomOwner(),
+		AvailableAt:   time.Now(),
+	}
+
+	beneficiary, err := testQueries.CreateBeneficiary(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, beneficiary.ID)
+	require.Equal(t, arg.Username, beneficiary.Username)
+	require.Equal(t, arg.AccountID, beneficiary.AccountID)
+	require.Equal(t, arg.AccountNumber, beneficiary.AccountNumber)
+	require.Equal(t, arg.Label, beneficiary.Label)
+	require.WithinDuration(t, arg.AvailableAt, beneficiary.AvailableAt, time.Second)
+	require.NotZero(t, beneficiary.CreatedAt)
+
+	return beneficiary
+}
+
+func TestCreateBeneficiary(t *testing.T) {
+	user := createRandomUser(t)
+	account := createRandomAccount(t)
+	beneficiary := createRandomBeneficiary(t, user, account)
+
+	// an account is saved once per user
+	_, err := testQueries.CreateBeneficiary(context.Background(), CreateBeneficiaryParams{
+		Username:      user.Username,
+		AccountID:     account.ID,
+		AccountNumber: account.Number,
+		Label:         beneficiary.Label,
+		AvailableAt:   time.Now(),
+	})
+	var pqErr *pq.Error
+	require.ErrorAs(t, err, &pqErr)
+	require.Equal(t, "unique_violation", pqErr.Code.Name())
+}
+
+func TestGetBeneficiary(t *testing.T) {
+	user := createRandomUser(t)
+	beneficiary := createRandomBeneficiary(t, user, createRandomAccount(t))
+
+	got, err := testQueries.GetBeneficiary(context.Background(), GetBeneficiaryParams{ID: beneficiary.ID, Username: user.Username})
+	require.NoError(t, err)
+	require.Equal(t, beneficiary, got)
+
+	_, err = testQueries.GetBeneficiary(context.Background(), GetBeneficiaryParams{ID: beneficiary.ID, Username: createRandomUser(t).Username})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestListBeneficiaries(t *testing.T) {
+	user := createRandomUser(t)
+	for i := 0; i < 3; i++ {
+		createRandomBeneficiary(t, user, createRandomAccount(t))
+	}
+
+	beneficiaries, err := testQueries.ListBeneficiaries(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Len(t, beneficiaries, 3)
+	for i, beneficiary := range beneficiaries {
+		require.Equal(t, user.Username, beneficiary.Username)
+		if i > 0 {
+			require.LessOrEqual(t, beneficiaries[i-1].Label, beneficiary.Label)
+		}
+	}
+}
+
+func TestUpdateBeneficiaryLabel(t *testing.T) {
+	user := createRandomUser(t)
+	beneficiary := createRandomBeneficiary(t, user, createRandomAccount(t))
+
+	updated, err := testQueries.UpdateBeneficiaryLabel(context.Background(), UpdateBeneficiaryLabelParams{
+		Label:    "rent",
+		ID:       beneficiary.ID,
+		Username: user.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "rent", updated.Label)
+	require.Equal(t, beneficiary.AccountID, updated.AccountID)
+}
+
+func TestDeleteBeneficiary(t *testing.T) {
+	user := createRandomUser(t)
+	beneficiary := createRandomBeneficiary(t, user, createRandomAccount(t))
+
+	_, err := testQueries.DeleteBeneficiary(context.Background(), DeleteBeneficiaryParams{ID: beneficiary.ID, Username: createRandomUser(t).Username})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	deleted, err := testQueries.DeleteBeneficiary(context.Background(), DeleteBeneficiaryParams{ID: beneficiary.ID, Username: user.Username})
+	require.NoError(t, err)
+	require.Equal(t, beneficiary, deleted)
+
+	_, err = testQueries.GetBeneficiary(context.Background(), GetBeneficiaryParams{ID: beneficiary.ID, Username: user.Username})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
@@ -0,0 +1,22 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hhow09/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListCurrencies(t *testing.T) {
+	currencies, err := testQueries.ListCurrencies(context.Background())
+	require.NoError(t, err)
+
+	codes := make([]string, len(currencies))
+	for i, currency := range currencies {
+		codes[i] = currency.Code
+		require.Equal(t, int32(2), currency.Exponent)
+		require.NotEmpty(t, currency.Symbol)
+	}
+	require.Subset(t, codes, []string{util.USD, util.EUR, util.CAD})
+}
@@ -11,8 +11,10 @@ import (
 
 func createRandomEntry(t *testing.T, account Account) Entry {
 	arg := CreateEntryParams{
-		AccountID: account.ID,
-		Amount:    util.RandomMoney(),
+		AccountID:   account.ID,
+		Amount:      util.RandomMoney(),
+		Description: util.RandomString(20),
+		Reference:   util.RandomString(10),
 	}
 
 	entry, err := testQueries.CreateEntry(context.Background(), arg)
@@ -21,6 +23,8 @@ func createRandomEntry(t *testing.T, account Account) Entry {
 
 	require.Equal(t, arg.AccountID, entry.AccountID)
 	require.Equal(t, arg.Amount, entry.Amount)
+	require.Equal(t, arg.Description, entry.Description)
+	require.Equal(t, arg.Reference, entry.Reference)
 
 	require.NotZero(t, entry.ID)
 	require.NotZero(t, entry.CreatedAt)
@@ -0,0 +1,198 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/hhow09/simple_bank/constants"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransferTxFee(t *testing.T) {
+	store := NewStore(testDB)
+	acc1 := deposit(t, createRandomAccount(t), 1000)
+	acc2 := createRandomAccountIn(t, acc1.Currency)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: acc1.ID,
+		ToAccountID:   acc2.ID,
+		Amount:        10,
+		Reference:     "INV-42",
+		Fee:           2,
+	})
+	require.NoError(t, err)
+	require.Equal(t, acc1.Balance-12, result.FromAccount.Balance)
+	require.Equal(t, acc2.Balance+10, result.ToAccount.Balance)
+	require.Equal(t, int64(-10), result.FromEntry.Amount)
+
+	// the fee has a journal transaction of its own
+	require.NotNil(t, result.Fee)
+	require.Equal(t, constants.FeeKindTransfer, result.Fee.Kind)
+	require.Equal(t, result.Transfer.ID, result.Fee.TransferID.Int64)
+	require.Equal(t, int64(2), result.Fee.Amount)
+	require.NotEqual(t, result.JournalTransactionID, result.Fee.JournalTransactionID.Int64)
+	require.NotNil(t, result.FeeEntry)
+	require.Equal(t, int64(-2), result.FeeEntry.Amount)
+	require.Equal(t, "INV-42", result.FeeEntry.Reference)
+
+	journal, err := testQueries.GetJournalTransaction(context.Background(), result.Fee.JournalTransactionID.Int64)
+	require.NoError(t, err)
+	require.Equal(t, constants.JournalKindFee, journal.Kind)
+	require.False(t, journal.TransferID.Valid)
+
+	charges, err := testQueries.ListFeeCharges(context.Background(), ListFeeChargesParams{AccountID: acc1.ID, Limit: 5})
+	require.NoError(t, err)
+	require.Len(t, charges, 1)
+	require.Equal(t, result.Fee.ID, charges[0].ID)
+}
+
+func TestTransferTxFeeWaived(t *testing.T) {
+	store := NewStore(testDB)
+	acc1 := deposit(t, createRandomAccount(t), 1000)
+	acc2 := createRandomAccountIn(t, acc1.Currency)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: acc1.ID,
+		ToAccountID:   acc2.ID,
+		Amount:        10,
+		FeeWaived:     true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, acc1.Balance-10, result.FromAccount.Balance)
+	require.NotNil(t, result.Fee)
+	require.True(t, result.Fee.Waived)
+	require.Zero(t, result.Fee.Amount)
+	require.False(t, result.Fee.JournalTransactionID.Valid)
+	require.Nil(t, result.FeeEntry)
+
+	// transfers without a fee have no charge
+	result, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: acc1.ID,
+		ToAccountID:   acc2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+	require.Nil(t, result.Fee)
+}
+
+func TestTransferTxFeeInsufficientFunds(t *testing.T) {
+	store := NewStore(testDB)
+	acc1 := deposit(t, createRandomAccount(t), 1000)
+	acc2 := createRandomAccountIn(t, acc1.Currency)
+	// the balance covers the amounts of two transfers but the fees of only one
+	fee := int64(10)
+	amount := acc1.Balance/2 - fee + 1
+
+	n := 5
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		go func() {
+			_, err := store.TransferTx(context.Background(), TransferTxParams{
+				FromAccountID: acc1.ID,
+				ToAccountID:   acc2.ID,
+				Amount:        amount,
+				Fee:           fee,
+			})
+			errs <- err
+		}()
+	}
+
+	succeeded := 0
+	for i := 0; i < n; i++ {
+		err := <-errs
+		if err == nil {
+			succeeded++
+			continue
+		}
+		require.ErrorIs(t, err, ErrInsufficientFunds)
+	}
+	require.Equal(t, 1, succeeded)
+
+	updated, err := store.GetAccount(context.Background(), acc1.ID)
+	require.NoError(t, err)
+	require.Equal(t, acc1.Balance-amount-fee, updated.Balance)
+	charges, err := testQueries.ListFeeCharges(context.Background(), ListFeeChargesParams{AccountID: acc1.ID, Limit: 5})
+	require.NoError(t, err)
+	require.Len(t, charges, 1)
+}
+
+func TestChargeMaintenanceFeeTx(t *testing.T) {
+	store := NewStore(testDB)
+	account := deposit(t, createRandomAccount(t), 1000)
+	month := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	arg := ListMaintenanceFeeAccountsParams{
+		OpenedBefore: time.Now().Add(time.Minute),
+		AfterID:      account.ID - 1,
+		Month:        sql.NullTime{Time: month, Valid: true},
+		Limit:        1,
+	}
+	accounts, err := testQueries.ListMaintenanceFeeAccounts(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	require.Equal(t, account.ID, accounts[0].ID)
+	require.Equal(t, constants.TierStandard, accounts[0].Tier)
+
+	charge := ChargeMaintenanceFeeTxParams{
+		AccountID:   account.ID,
+		Month:       month,
+		Amount:      3,
+		Description: "Maintenance fee",
+	}
+	result, err := store.ChargeMaintenanceFeeTx(context.Background(), charge)
+	require.NoError(t, err)
+	require.Equal(t, account.Balance-3, result.Journal.Accounts[0].Balance)
+	require.Equal(t, constants.JournalKindFee, result.Journal.Transaction.Kind)
+	require.Equal(t, result.Journal.Transaction.ID, result.Charge.JournalTransactionID.Int64)
+	require.Equal(t, month, result.Charge.Month.Time.UTC())
+
+	// a month is charged once per account
+	_, err = store.ChargeMaintenanceFeeTx(context.Background(), charge)
+	require.Error(t, err)
+	updated, err := testQueries.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, account.Balance-3, updated.Balance)
+
+	accounts, err = testQueries.ListMaintenanceFeeAccounts(context.Background(), arg)
+	require.NoError(t, err)
+	for _, row := range accounts {
+		require.NotEqual(t, account.ID, row.ID)
+	}
+}
+
+func TestChargeMaintenanceFeeTxBalance(t *testing.T) {
+	store := NewStore(testDB)
+	account := createRandomAccount(t)
+
+	// the fee never overdraws the account
+	result, err := store.ChargeMaintenanceFeeTx(context.Background(), ChargeMaintenanceFeeTxParams{
+		AccountID: account.ID,
+		Month:     time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		Amount:    account.Balance + 100,
+	})
+	require.NoError(t, err)
+	require.Equal(t, account.Balance, result.Charge.Amount)
+	updated, err := testQueries.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Zero(t, updated.Balance)
+}
+
+func TestSetUserTier(t *testing.T) {
+	user := createRandomUser(t)
+	require.Equal(t, constants.TierStandard, user.Tier)
+
+	updated, err := testQueries.SetUserTier(context.Background(), SetUserTierParams{
+		Tier:     constants.TierPremium,
+		Username: user.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, constants.TierPremium, updated.Tier)
+
+	_, err = testQueries.SetUserTier(context.Background(), SetUserTierParams{
+		Tier:     "gold",
+		Username: user.Username,
+	})
+	require.Error(t, err)
+}
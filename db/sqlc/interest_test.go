@@ -0,0 +1,135 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hhow09/simple_bank/constants"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterest(t *testing.T) {
+	store := NewStore(testDB)
+	account := createRandomAccount(t)
+	deposit, err := store.JournalTx(context.Background(), JournalTxParams{
+		Kind: constants.JournalKindDeposit,
+		Postings: []PostingParams{
+			{AccountID: account.ID, Amount: 1000},
+			{LedgerAccount: constants.LedgerAccountCash, Currency: account.Currency, Amount: -1000},
+		},
+	})
+	require.NoError(t, err)
+	account = deposit.Accounts[0]
+
+	rate, err := testQueries.CreateInterestRate(context.Background(), CreateInterestRateParams{
+		AccountType:   account.Type,
+		Currency:      account.Currency,
+		AnnualRateBps: 365,
+		EffectiveFrom: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(365), rate.AnnualRateBps)
+
+	got, err := testQueries.GetInterestRate(context.Background(), rate.ID)
+	require.NoError(t, err)
+	require.Equal(t, rate.ID, got.ID)
+
+	rates, err := testQueries.ListInterestRates(context.Background())
+	require.NoError(t, err)
+	require.NotEmpty(t, rates)
+
+	// the end of day balance of the account is accrued at the rate of its type and currency
+	year, month, day := time.Now().UTC().Date()
+	date := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	_, err = testQueries.CreateBalanceSnapshots(context.Background(), CreateBalanceSnapshotsParams{
+		Date:      date,
+		ClosingAt: date.AddDate(0, 0, 1),
+		AfterID:   account.ID - 1,
+		Limit:     1,
+	})
+	require.NoError(t, err)
+	balances, err := testQueries.ListInterestAccrualBalances(context.Background(), ListInterestAccrualBalancesParams{
+		Date:    date,
+		AfterID: account.ID - 1,
+		Limit:   1,
+	})
+	require.NoError(t, err)
+	require.Len(t, balances, 1)
+	require.Equal(t, account.ID, balances[0].AccountID)
+	require.Equal(t, account.Balance, balances[0].Balance)
+	require.Equal(t, int32(365), balances[0].AnnualRateBps)
+
+	err = testQueries.CreateInterestAccrual(context.Background(), CreateInterestAccrualParams{
+		AccountID:     account.ID,
+		Date:          date,
+		Balance:       account.Balance,
+		AnnualRateBps: 365,
+		AmountMicros:  2_600_000,
+	})
+	require.NoError(t, err)
+	latest, err := testQueries.GetLatestInterestAccrualDate(context.Background())
+	require.NoError(t, err)
+	require.True(t, latest.Valid)
+
+	periodStart := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	arg := ListUnpostedInterestParams{
+		PeriodStart: periodStart,
+		PeriodEnd:   periodStart.AddDate(0, 1, -1),
+		AfterID:     account.ID - 1,
+		Limit:       1,
+	}
+	unposted, err := testQueries.ListUnpostedInterest(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, unposted, 1)
+	require.Equal(t, account.ID, unposted[0].AccountID)
+	require.Equal(t, int64(2_600_000), unposted[0].AccruedMicros)
+
+	posting := PostInterestTxParams{
+		AccountID:     account.ID,
+		Currency:      account.Currency,
+		Month:         periodStart,
+		AccruedMicros: 2_600_000,
+		Amount:        3,
+		Description:   "Interest",
+	}
+	result, err := store.PostInterestTx(context.Background(), posting)
+	require.NoError(t, err)
+	require.Equal(t, account.Balance+3, result.Journal.Accounts[0].Balance)
+	require.Equal(t, constants.JournalKindInterest, result.Journal.Transaction.Kind)
+	require.Equal(t, result.Journal.Transaction.ID, result.Posting.JournalTransactionID.Int64)
+
+	// a month is posted once per account
+	_, err = store.PostInterestTx(context.Background(), posting)
+	require.Error(t, err)
+	updated, err := testQueries.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, account.Balance+3, updated.Balance)
+
+	unposted, err = testQueries.ListUnpostedInterest(context.Background(), arg)
+	require.NoError(t, err)
+	for _, row := range unposted {
+		require.NotEqual(t, account.ID, row.AccountID)
+	}
+
+	postings, err := testQueries.ListInterestPostings(context.Background(), ListInterestPostingsParams{AccountID: account.ID, Limit: 5})
+	require.NoError(t, err)
+	require.Len(t, postings, 1)
+	require.Equal(t, int64(3), postings[0].Amount)
+}
+
+func TestPostInterestTxZero(t *testing.T) {
+	store := NewStore(testDB)
+	account := createRandomAccount(t)
+
+	// interest rounding to zero is recorded without a journal transaction
+	result, err := store.PostInterestTx(context.Background(), PostInterestTxParams{
+		AccountID:     account.ID,
+		Currency:      account.Currency,
+		Month:         time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		AccruedMicros: 400_000,
+	})
+	require.NoError(t, err)
+	require.False(t, result.Posting.JournalTransactionID.Valid)
+	require.Zero(t, result.Journal.Transaction.ID)
+}
@@ -0,0 +1,83 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hhow09/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomLoginAttempt(t *testing.T, username string, success bool) LoginAttempt {
+	arg := CreateLoginAttemptParams{
+		Username: username,
+		ClientIp: "127.0.0.1",
+		Success:  success,
+	}
+	attempt, err := testQueries.CreateLoginAttempt(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, attempt.ID)
+	require.Equal(t, arg.Username, attempt.Username)
+	require.Equal(t, arg.ClientIp, attempt.ClientIp)
+	require.Equal(t, arg.Success, attempt.Success)
+	require.False(t, attempt.Cleared)
+	require.NotZero(t, attempt.CreatedAt)
+	return attempt
+}
+
+func TestGetLoginFailures(t *testing.T) {
+	// attempts of unknown users are recorded as well
+	username := util.RandomOwner()
+	since := time.Now().Add(-time.Minute)
+
+	var last LoginAttempt
+	for i := 0; i < 3; i++ {
+		last = createRandomLoginAttempt(t, username, false)
+	}
+	createRandomLoginAttempt(t, username, true)
+
+	failures, err := testQueries.GetLoginFailures(context.Background(), GetLoginFailuresParams{Username: username, Since: since})
+	require.NoError(t, err)
+	require.Equal(t, int64(3), failures.Failures)
+	require.WithinDuration(t, last.CreatedAt, failures.LastFailedAt, time.Second)
+
+	// failures before the window are ignored
+	failures, err = testQueries.GetLoginFailures(context.Background(), GetLoginFailuresParams{Username: username, Since: time.Now().Add(time.Minute)})
+	require.NoError(t, err)
+	require.Zero(t, failures.Failures)
+}
+
+func TestClearLoginFailures(t *testing.T) {
+	username := util.RandomOwner()
+	since := time.Now().Add(-time.Minute)
+	for i := 0; i < 3; i++ {
+		createRandomLoginAttempt(t, username, false)
+	}
+
+	err := testQueries.ClearLoginFailures(context.Background(), username)
+	require.NoError(t, err)
+
+	failures, err := testQueries.GetLoginFailures(context.Background(), GetLoginFailuresParams{Username: username, Since: since})
+	require.NoError(t, err)
+	require.Zero(t, failures.Failures)
+	require.True(t, failures.LastFailedAt.Year() == 1)
+}
+
+func TestDeleteLoginAttemptsBefore(t *testing.T) {
+	username := util.RandomOwner()
+	attempt := createRandomLoginAttempt(t, username, false)
+
+	_, err := testQueries.DeleteLoginAttemptsBefore(context.Background(), attempt.CreatedAt)
+	require.NoError(t, err)
+	attempts, err := testQueries.ListLoginAttempts(context.Background(), username)
+	require.NoError(t, err)
+	require.Len(t, attempts, 1)
+
+	deleted, err := testQueries.DeleteLoginAttemptsBefore(context.Background(), attempt.CreatedAt.Add(time.Millisecond))
+	require.NoError(t, err)
+	require.NotZero(t, deleted)
+	attempts, err = testQueries.ListLoginAttempts(context.Background(), username)
+	require.NoError(t, err)
+	require.Empty(t, attempts)
+}
@@ -3,15 +3,101 @@
 package db
 
 import (
+	"database/sql"
+	"encoding/json"
 	"time"
 )
 
 type Account struct {
-	ID        int64     `json:"id"`
+	ID int64 `json:"id"`
+	// the user who opened the account, access is granted by account_members
 	Owner     string    `json:"owner"`
 	Balance   int64     `json:"balance"`
 	Currency  string    `json:"currency"`
 	CreatedAt time.Time `json:"created_at"`
+	// checking or savings
+	Type string `json:"type"`
+	// chosen by the owners, empty when not set
+	Nickname string `json:"nickname"`
+	// IBAN-style number with mod-97 check digits, used to address transfers
+	Number string `json:"number"`
+	// set while the account is frozen, no transfer from or to it
+	FrozenAt sql.NullTime `json:"frozen_at"`
+}
+
+type AccountBalanceSnapshot struct {
+	AccountID int64 `json:"account_id"`
+	// the day of the closing balance, in UTC
+	Date time.Time `json:"date"`
+	// end of the day, the balance includes the entries created before
+	ClosingAt time.Time `json:"closing_at"`
+	Balance   int64     `json:"balance"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type AccountMember struct {
+	AccountID int64  `json:"account_id"`
+	Username  string `json:"username"`
+	// owner, co_owner or viewer
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type AccountStatement struct {
+	ID        int64 `json:"id"`
+	AccountID int64 `json:"account_id"`
+	// first day of the month, in UTC
+	PeriodStart time.Time `json:"period_start"`
+	// last day of the month, in UTC
+	PeriodEnd      time.Time `json:"period_end"`
+	OpeningBalance int64     `json:"opening_balance"`
+	ClosingBalance int64     `json:"closing_balance"`
+	// PDF document
+	Content   []byte    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ApiKey struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	Name     string `json:"name"`
+	// public part of the key used for the lookup
+	Prefix string `json:"prefix"`
+	// sha256 of the secret part of the key
+	SecretHash string       `json:"secret_hash"`
+	Scopes     []string     `json:"scopes"`
+	ExpiresAt  sql.NullTime `json:"expires_at"`
+	LastUsedAt sql.NullTime `json:"last_used_at"`
+	RevokedAt  sql.NullTime `json:"revoked_at"`
+	CreatedAt  time.Time    `json:"created_at"`
+}
+
+// days whose snapshots are taken of every account
+type BalanceSnapshotDay struct {
+	Date        time.Time `json:"date"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+type Beneficiary struct {
+	ID            int64  `json:"id"`
+	Username      string `json:"username"`
+	AccountID     int64  `json:"account_id"`
+	AccountNumber string `json:"account_number"`
+	Label         string `json:"label"`
+	// end of the cooling-off period, no transfer to the beneficiary before
+	AvailableAt time.Time `json:"available_at"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type Currency struct {
+	// ISO 4217 code
+	Code string `json:"code"`
+	// number of decimals of the minor unit
+	Exponent  int32     `json:"exponent"`
+	Symbol    string    `json:"symbol"`
+	CreatedAt time.Time `json:"created_at"`
+	// rounding of computed amounts to minor units: half_even, half_up, down or up
+	Rounding string `json:"rounding"`
 }
 
 type Entry struct {
@@ -20,6 +106,214 @@ type Entry struct {
 	// can be negative or positive
 	Amount    int64     `json:"amount"`
 	CreatedAt time.Time `json:"created_at"`
+	// copied from the transfer
+	Description string `json:"description"`
+	// copied from the transfer
+	Reference string `json:"reference"`
+}
+
+type FeeCharge struct {
+	ID        int64 `json:"id"`
+	AccountID int64 `json:"account_id"`
+	// transfer or maintenance
+	Kind string `json:"kind"`
+	// the transfer of a transfer fee
+	TransferID sql.NullInt64 `json:"transfer_id"`
+	// first day of the month of a maintenance fee, in UTC
+	Month sql.NullTime `json:"month"`
+	// amount taken from the account, 0 when waived
+	Amount int64 `json:"amount"`
+	// the fee was waived for the tier of the account owner
+	Waived bool `json:"waived"`
+	// journal transaction crediting the fee system account, null when nothing was taken
+	JournalTransactionID sql.NullInt64 `json:"journal_transaction_id"`
+	CreatedAt            time.Time     `json:"created_at"`
+}
+
+type InterestAccrual struct {
+	AccountID int64 `json:"account_id"`
+	// the day of the end of day balance, in UTC
+	Date          time.Time `json:"date"`
+	Balance       int64     `json:"balance"`
+	AnnualRateBps int32     `json:"annual_rate_bps"`
+	// interest of the day in millionths of the minor unit, not rounded
+	AmountMicros int64     `json:"amount_micros"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type InterestPosting struct {
+	ID        int64 `json:"id"`
+	AccountID int64 `json:"account_id"`
+	// first day of the month, in UTC
+	Month time.Time `json:"month"`
+	// sum of the accruals of the month in millionths of the minor unit
+	AccruedMicros int64 `json:"accrued_micros"`
+	// accrued interest rounded with the rounding of the currency
+	Amount int64 `json:"amount"`
+	// journal transaction crediting the account, null when the amount rounds to zero
+	JournalTransactionID sql.NullInt64 `json:"journal_transaction_id"`
+	CreatedAt            time.Time     `json:"created_at"`
+}
+
+type InterestRate struct {
+	ID int64 `json:"id"`
+	// checking or savings
+	AccountType string `json:"account_type"`
+	Currency    string `json:"currency"`
+	// annual rate in basis points, 1 bps = 0.01%
+	AnnualRateBps int32 `json:"annual_rate_bps"`
+	// the rate applies from this day until the next rate of the account type and currency
+	EffectiveFrom time.Time `json:"effective_from"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type JournalTransaction struct {
+	ID int64 `json:"id"`
+	// transfer, deposit, withdrawal, fee, interest, fx or adjustment
+	Kind        string        `json:"kind"`
+	Description string        `json:"description"`
+	Reference   string        `json:"reference"`
+	TransferID  sql.NullInt64 `json:"transfer_id"`
+	CreatedAt   time.Time     `json:"created_at"`
+}
+
+type LedgerAccount struct {
+	ID int64 `json:"id"`
+	// system account: cash, fees, fx or interest
+	Code     string `json:"code"`
+	Name     string `json:"name"`
+	Currency string `json:"currency"`
+	// sum of the postings, cash is negative by the money paid in
+	Balance   int64     `json:"balance"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type LoginAttempt struct {
+	ID int64 `json:"id"`
+	// not a foreign key, attempts of unknown users are recorded as well
+	Username string `json:"username"`
+	ClientIp string `json:"client_ip"`
+	Success  bool   `json:"success"`
+	// failures are cleared by a successful login or an admin unlock
+	Cleared   bool      `json:"cleared"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type LoginChallenge struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	// sha256 of the challenge token returned by the password step
+	TokenHash string       `json:"token_hash"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	UsedAt    sql.NullTime `json:"used_at"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+type OauthAuthorizationCode struct {
+	ID int64 `json:"id"`
+	// sha256 of the authorization code
+	CodeHash    string   `json:"code_hash"`
+	ClientID    string   `json:"client_id"`
+	Username    string   `json:"username"`
+	RedirectUri string   `json:"redirect_uri"`
+	Scopes      []string `json:"scopes"`
+	// S256 PKCE challenge
+	CodeChallenge string       `json:"code_challenge"`
+	ExpiresAt     time.Time    `json:"expires_at"`
+	UsedAt        sql.NullTime `json:"used_at"`
+	CreatedAt     time.Time    `json:"created_at"`
+}
+
+type OauthClient struct {
+	ID    string `json:"id"`
+	Owner string `json:"owner"`
+	Name  string `json:"name"`
+	// sha256 of the client secret, empty for public clients
+	SecretHash   string    `json:"secret_hash"`
+	RedirectUris []string  `json:"redirect_uris"`
+	Scopes       []string  `json:"scopes"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type OauthConsent struct {
+	Username string   `json:"username"`
+	ClientID string   `json:"client_id"`
+	Scopes   []string `json:"scopes"`
+	// tokens issued before the consent are not accepted
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type PasswordResetToken struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	// sha256 of the token sent by email
+	TokenHash string       `json:"token_hash"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	UsedAt    sql.NullTime `json:"used_at"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+type Posting struct {
+	ID                   int64 `json:"id"`
+	JournalTransactionID int64 `json:"journal_transaction_id"`
+	// customer account, or ledger_account_id for a system account
+	AccountID       sql.NullInt64 `json:"account_id"`
+	LedgerAccountID sql.NullInt64 `json:"ledger_account_id"`
+	// entry of the customer account
+	EntryID  sql.NullInt64 `json:"entry_id"`
+	Currency string        `json:"currency"`
+	// added to the balance of the account, the postings of a journal transaction sum to zero per currency
+	Amount    int64     `json:"amount"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type RateLimitBucket struct {
+	Key    string  `json:"key"`
+	Tokens float64 `json:"tokens"`
+	// whether the last request took a token
+	Allowed   bool      `json:"allowed"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type ReconciliationDiscrepancy struct {
+	ID    int64 `json:"id"`
+	RunID int64 `json:"run_id"`
+	// account_balance, transfer_entries or ledger_account_balance
+	Kind            string        `json:"kind"`
+	AccountID       sql.NullInt64 `json:"account_id"`
+	TransferID      sql.NullInt64 `json:"transfer_id"`
+	LedgerAccountID sql.NullInt64 `json:"ledger_account_id"`
+	// the value derived from entries or postings
+	Expected int64 `json:"expected"`
+	// the value found, e.g. the stored balance
+	Actual    int64     `json:"actual"`
+	Details   string    `json:"details"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ReconciliationRun struct {
+	ID int64 `json:"id"`
+	// running, completed or failed
+	Status                string `json:"status"`
+	AccountsChecked       int64  `json:"accounts_checked"`
+	TransfersChecked      int64  `json:"transfers_checked"`
+	LedgerAccountsChecked int64  `json:"ledger_accounts_checked"`
+	Discrepancies         int64  `json:"discrepancies"`
+	FrozenAccounts        int64  `json:"frozen_accounts"`
+	// why the run failed, empty otherwise
+	Error      string       `json:"error"`
+	StartedAt  time.Time    `json:"started_at"`
+	FinishedAt sql.NullTime `json:"finished_at"`
+}
+
+type RecoveryCode struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	// sha256 of the recovery code
+	CodeHash  string       `json:"code_hash"`
+	UsedAt    sql.NullTime `json:"used_at"`
+	CreatedAt time.Time    `json:"created_at"`
 }
 
 type Transfer struct {
@@ -29,6 +323,12 @@ type Transfer struct {
 	// must be positive
 	Amount    int64     `json:"amount"`
 	CreatedAt time.Time `json:"created_at"`
+	// free text memo of the payment
+	Description string `json:"description"`
+	// external reference, e.g. an invoice number, empty when not set
+	Reference string `json:"reference"`
+	// string key-value pairs of the client
+	Metadata json.RawMessage `json:"metadata"`
 }
 
 type User struct {
@@ -38,4 +338,30 @@ type User struct {
 	Email             string    `json:"email"`
 	PasswordChangedAt time.Time `json:"password_changed_at"`
 	CreatedAt         time.Time `json:"created_at"`
+	// user or admin
+	Role            string `json:"role"`
+	IsEmailVerified bool   `json:"is_email_verified"`
+	// base32 TOTP secret, pending until is_totp_enabled
+	TotpSecret    string `json:"totp_secret"`
+	IsTotpEnabled bool   `json:"is_totp_enabled"`
+	// last accepted TOTP time step, older or equal steps are replays
+	TotpLastStep int64 `json:"totp_last_step"`
+	// set on the pseudonym which keeps the accounts of a deleted user
+	DeletedAt sql.NullTime `json:"deleted_at"`
+	// standard, premium or private, fees are waived per tier
+	Tier string `json:"tier"`
+	// new email waiting for verification, it replaces email once verified, empty when none
+	PendingEmail string `json:"pending_email"`
+}
+
+type VerifyEmail struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	// the address the token was sent to
+	Email string `json:"email"`
+	// sha256 of the token sent by email
+	TokenHash string       `json:"token_hash"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	UsedAt    sql.NullTime `json:"used_at"`
+	CreatedAt time.Time    `json:"created_at"`
 }
@@ -0,0 +1,121 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/hhow09/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomOAuthClient(t *testing.T, owner User) OauthClient {
+	arg := CreateOAuthClientParams{
+		ID:           util.RandomString(22),
+		Owner:        owner.Username,
+		Name:         util.RandomOwner(),
+		SecretHash:   util.HashToken(util.RandomString(32)),
+		RedirectUris: []string{"https://example.com/callback"},
+		Scopes:       []string{"accounts:read"},
+	}
+
+	client, err := testQueries.CreateOAuthClient(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.ID, client.ID)
+	require.Equal(t, arg.Owner, client.Owner)
+	require.Equal(t, arg.Name, client.Name)
+	require.Equal(t, arg.SecretHash, client.SecretHash)
+	require.Equal(t, arg.RedirectUris, client.RedirectUris)
+	require.Equal(t, arg.Scopes, client.Scopes)
+	require.NotZero(t, client.CreatedAt)
+
+	return client
+}
+
+func TestGetOAuthClient(t *testing.T) {
+	owner := createRandomUser(t)
+	client1 := createRandomOAuthClient(t, owner)
+
+	client2, err := testQueries.GetOAuthClient(context.Background(), client1.ID)
+	require.NoError(t, err)
+	require.Equal(t, client1.ID, client2.ID)
+	require.Equal(t, client1.RedirectUris, client2.RedirectUris)
+
+	clients, err := testQueries.ListOAuthClients(context.Background(), owner.Username)
+	require.NoError(t, err)
+	require.Len(t, clients, 1)
+	require.Equal(t, client1.ID, clients[0].ID)
+}
+
+func TestConsumeOAuthAuthorizationCode(t *testing.T) {
+	user := createRandomUser(t)
+	client := createRandomOAuthClient(t, createRandomUser(t))
+
+	arg := CreateOAuthAuthorizationCodeParams{
+		CodeHash:      util.HashToken(util.RandomString(43)),
+		ClientID:      client.ID,
+		Username:      user.Username,
+		RedirectUri:   client.RedirectUris[0],
+		Scopes:        client.Scopes,
+		CodeChallenge: util.RandomString(43),
+		ExpiresAt:     time.Now().Add(time.Minute),
+	}
+	code, err := testQueries.CreateOAuthAuthorizationCode(context.Background(), arg)
+	require.NoError(t, err)
+	require.False(t, code.UsedAt.Valid)
+
+	consumed, err := testQueries.ConsumeOAuthAuthorizationCode(context.Background(), arg.CodeHash)
+	require.NoError(t, err)
+	require.Equal(t, code.ID, consumed.ID)
+	require.Equal(t, arg.Scopes, consumed.Scopes)
+	require.True(t, consumed.UsedAt.Valid)
+
+	// a code can only be used once
+	_, err = testQueries.ConsumeOAuthAuthorizationCode(context.Background(), arg.CodeHash)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+
+	arg.CodeHash = util.HashToken(util.RandomString(43))
+	arg.ExpiresAt = time.Now().Add(-time.Minute)
+	_, err = testQueries.CreateOAuthAuthorizationCode(context.Background(), arg)
+	require.NoError(t, err)
+
+	_, err = testQueries.ConsumeOAuthAuthorizationCode(context.Background(), arg.CodeHash)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+}
+
+func TestOAuthConsent(t *testing.T) {
+	user := createRandomUser(t)
+	client := createRandomOAuthClient(t, createRandomUser(t))
+
+	arg := UpsertOAuthConsentParams{
+		Username: user.Username,
+		ClientID: client.ID,
+		Scopes:   []string{"accounts:read"},
+	}
+	consent1, err := testQueries.UpsertOAuthConsent(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Scopes, consent1.Scopes)
+
+	arg.Scopes = []string{"accounts:read", "transfers:create"}
+	consent2, err := testQueries.UpsertOAuthConsent(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Scopes, consent2.Scopes)
+	require.Equal(t, consent1.CreatedAt, consent2.CreatedAt)
+	require.True(t, consent2.UpdatedAt.After(consent1.UpdatedAt))
+
+	key := GetOAuthConsentParams{Username: user.Username, ClientID: client.ID}
+	consent3, err := testQueries.GetOAuthConsent(context.Background(), key)
+	require.NoError(t, err)
+	require.Equal(t, consent2.Scopes, consent3.Scopes)
+
+	consents, err := testQueries.ListOAuthConsents(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Len(t, consents, 1)
+
+	_, err = testQueries.DeleteOAuthConsent(context.Background(), DeleteOAuthConsentParams(key))
+	require.NoError(t, err)
+
+	_, err = testQueries.GetOAuthConsent(context.Background(), key)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+}
@@ -0,0 +1,139 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hhow09/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomPasswordResetToken(t *testing.T, user User, expiresAt time.Time) PasswordResetToken {
+	arg := CreatePasswordResetTokenParams{
+		Username:  user.Username,
+		TokenHash: util.HashToken(util.RandomString(32)),
+		ExpiresAt: expiresAt,
+	}
+
+	resetToken, err := testQueries.CreatePasswordResetToken(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, resetToken.ID)
+	require.Equal(t, arg.Username, resetToken.Username)
+	require.Equal(t, arg.TokenHash, resetToken.TokenHash)
+	require.WithinDuration(t, arg.ExpiresAt, resetToken.ExpiresAt, time.Second)
+	require.False(t, resetToken.UsedAt.Valid)
+
+	return resetToken
+}
+
+func TestConsumePasswordResetToken(t *testing.T) {
+	user := createRandomUser(t)
+	resetToken := createRandomPasswordResetToken(t, user, time.Now().Add(time.Minute))
+
+	consumed, err := testQueries.ConsumePasswordResetToken(context.Background(), resetToken.TokenHash)
+	require.NoError(t, err)
+	require.Equal(t, resetToken.ID, consumed.ID)
+	require.True(t, consumed.UsedAt.Valid)
+
+	// a token can only be used once
+	_, err = testQueries.ConsumePasswordResetToken(context.Background(), resetToken.TokenHash)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+}
+
+func TestConsumeExpiredPasswordResetToken(t *testing.T) {
+	user := createRandomUser(t)
+	resetToken := createRandomPasswordResetToken(t, user, time.Now().Add(-time.Minute))
+
+	_, err := testQueries.ConsumePasswordResetToken(context.Background(), resetToken.TokenHash)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+}
+
+func TestResetPasswordTx(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	resetToken := createRandomPasswordResetToken(t, user, time.Now().Add(time.Minute))
+	otherToken := createRandomPasswordResetToken(t, user, time.Now().Add(time.Minute))
+
+	hashedPassword, err := util.HashPassword(util.RandomString(6))
+	require.NoError(t, err)
+
+	arg := ResetPasswordTxParams{
+		TokenHash:         resetToken.TokenHash,
+		HashedPassword:    hashedPassword,
+		PasswordChangedAt: time.Now(),
+	}
+	updated, err := store.ResetPasswordTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, user.Username, updated.Username)
+	require.Equal(t, hashedPassword, updated.HashedPassword)
+	require.WithinDuration(t, arg.PasswordChangedAt, updated.PasswordChangedAt, time.Second)
+
+	_, err = store.ResetPasswordTx(context.Background(), arg)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+
+	// the other reset emails can't be used after the reset
+	arg.TokenHash = otherToken.TokenHash
+	_, err = store.ResetPasswordTx(context.Background(), arg)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+}
+
+func TestChangePasswordTx(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	resetToken := createRandomPasswordResetToken(t, user, time.Now().Add(time.Minute))
+
+	hashedPassword, err := util.HashPassword(util.RandomString(6))
+	require.NoError(t, err)
+
+	arg := UpdateUserPasswordParams{
+		HashedPassword:    hashedPassword,
+		PasswordChangedAt: time.Now(),
+		Username:          user.Username,
+	}
+	updated, err := store.ChangePasswordTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, hashedPassword, updated.HashedPassword)
+
+	// a reset email sent before the change can't be used
+	_, err = store.ResetPasswordTx(context.Background(), ResetPasswordTxParams{
+		TokenHash:         resetToken.TokenHash,
+		HashedPassword:    hashedPassword,
+		PasswordChangedAt: time.Now(),
+	})
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+}
+
+func TestResetPasswordTxRejected(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	resetToken := createRandomPasswordResetToken(t, user, time.Now().Add(time.Minute))
+
+	hashedPassword, err := util.HashPassword(util.RandomString(6))
+	require.NoError(t, err)
+
+	rejected := errors.New("password rejected")
+	arg := ResetPasswordTxParams{
+		TokenHash:         resetToken.TokenHash,
+		HashedPassword:    hashedPassword,
+		PasswordChangedAt: time.Now(),
+		BeforeUpdate: func(owner User) error {
+			require.Equal(t, user.Username, owner.Username)
+			require.Equal(t, user.Email, owner.Email)
+			return rejected
+		},
+	}
+	_, err = store.ResetPasswordTx(context.Background(), arg)
+	require.ErrorIs(t, err, rejected)
+
+	unchanged, err := testQueries.GetUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, user.HashedPassword, unchanged.HashedPassword)
+
+	// the token is still valid after the rollback
+	arg.BeforeUpdate = nil
+	_, err = store.ResetPasswordTx(context.Background(), arg)
+	require.NoError(t, err)
+}
@@ -4,24 +4,147 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"time"
 )
 
 type Querier interface {
 	AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Account, error)
+	AddLedgerAccountBalance(ctx context.Context, arg AddLedgerAccountBalanceParams) (LedgerAccount, error)
+	CheckAccountBalances(ctx context.Context, arg CheckAccountBalancesParams) ([]CheckAccountBalancesRow, error)
+	CheckLedgerAccountBalances(ctx context.Context, arg CheckLedgerAccountBalancesParams) ([]CheckLedgerAccountBalancesRow, error)
+	CheckTransferEntries(ctx context.Context, arg CheckTransferEntriesParams) ([]CheckTransferEntriesRow, error)
+	ClearLoginFailures(ctx context.Context, username string) error
+	CompleteBalanceSnapshotDay(ctx context.Context, date time.Time) error
+	ConsumeLoginChallenge(ctx context.Context, tokenHash string) (LoginChallenge, error)
+	ConsumeOAuthAuthorizationCode(ctx context.Context, codeHash string) (OauthAuthorizationCode, error)
+	ConsumePasswordResetToken(ctx context.Context, tokenHash string) (PasswordResetToken, error)
+	ConsumeVerifyEmail(ctx context.Context, tokenHash string) (VerifyEmail, error)
+	CountAccountOwners(ctx context.Context, accountID int64) (int64, error)
+	CountFrozenAccounts(ctx context.Context) (int64, error)
+	CountOwnerAccounts(ctx context.Context, arg CountOwnerAccountsParams) (int64, error)
+	CountReconciliationDiscrepancies(ctx context.Context, runID int64) ([]CountReconciliationDiscrepanciesRow, error)
+	CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (ApiKey, error)
 	CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error)
+	CreateAccountMember(ctx context.Context, arg CreateAccountMemberParams) (AccountMember, error)
+	CreateAccountStatement(ctx context.Context, arg CreateAccountStatementParams) (AccountStatement, error)
+	CreateBalanceSnapshots(ctx context.Context, arg CreateBalanceSnapshotsParams) ([]int64, error)
+	CreateBeneficiary(ctx context.Context, arg CreateBeneficiaryParams) (Beneficiary, error)
 	CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error)
+	CreateFeeCharge(ctx context.Context, arg CreateFeeChargeParams) (FeeCharge, error)
+	CreateInterestAccrual(ctx context.Context, arg CreateInterestAccrualParams) error
+	CreateInterestPosting(ctx context.Context, arg CreateInterestPostingParams) (InterestPosting, error)
+	CreateInterestRate(ctx context.Context, arg CreateInterestRateParams) (InterestRate, error)
+	CreateJournalTransaction(ctx context.Context, arg CreateJournalTransactionParams) (JournalTransaction, error)
+	CreateLoginAttempt(ctx context.Context, arg CreateLoginAttemptParams) (LoginAttempt, error)
+	CreateLoginChallenge(ctx context.Context, arg CreateLoginChallengeParams) (LoginChallenge, error)
+	CreateOAuthAuthorizationCode(ctx context.Context, arg CreateOAuthAuthorizationCodeParams) (OauthAuthorizationCode, error)
+	CreateOAuthClient(ctx context.Context, arg CreateOAuthClientParams) (OauthClient, error)
+	CreatePasswordResetToken(ctx context.Context, arg CreatePasswordResetTokenParams) (PasswordResetToken, error)
+	CreatePosting(ctx context.Context, arg CreatePostingParams) (Posting, error)
+	CreateReconciliationDiscrepancy(ctx context.Context, arg CreateReconciliationDiscrepancyParams) (ReconciliationDiscrepancy, error)
+	CreateReconciliationRun(ctx context.Context) (ReconciliationRun, error)
+	CreateRecoveryCode(ctx context.Context, arg CreateRecoveryCodeParams) (RecoveryCode, error)
 	CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	CreateUserPseudonym(ctx context.Context, arg CreateUserPseudonymParams) (User, error)
+	CreateVerifyEmail(ctx context.Context, arg CreateVerifyEmailParams) (VerifyEmail, error)
+	DeleteAPIKeys(ctx context.Context, username string) error
 	DeleteAccount(ctx context.Context, id int64) error
+	DeleteAccountMember(ctx context.Context, arg DeleteAccountMemberParams) (AccountMember, error)
+	DeleteBeneficiaries(ctx context.Context, username string) error
+	DeleteBeneficiary(ctx context.Context, arg DeleteBeneficiaryParams) (Beneficiary, error)
+	DeleteIdleRateLimitBuckets(ctx context.Context, idleSince time.Time) (int64, error)
+	DeleteInterestRate(ctx context.Context, id int64) (InterestRate, error)
+	DeleteLoginAttempts(ctx context.Context, username string) error
+	DeleteLoginAttemptsBefore(ctx context.Context, before time.Time) (int64, error)
+	DeleteLoginChallenges(ctx context.Context, username string) error
+	DeleteOAuthAuthorizationCodes(ctx context.Context, username string) error
+	DeleteOAuthClients(ctx context.Context, owner string) error
+	DeleteOAuthConsent(ctx context.Context, arg DeleteOAuthConsentParams) (OauthConsent, error)
+	DeleteOAuthConsents(ctx context.Context, username string) error
+	DeleteOwnerAccountStatements(ctx context.Context, owner string) error
+	DeletePasswordResetTokens(ctx context.Context, username string) error
+	DeleteRecoveryCodes(ctx context.Context, username string) error
+	DeleteUser(ctx context.Context, username string) error
+	DeleteVerifyEmails(ctx context.Context, username string) error
+	EnableUserTOTP(ctx context.Context, username string) (User, error)
+	FinishReconciliationRun(ctx context.Context, arg FinishReconciliationRunParams) (ReconciliationRun, error)
+	FreezeAccount(ctx context.Context, id int64) (Account, error)
+	GetAPIKeyByPrefix(ctx context.Context, prefix string) (ApiKey, error)
 	GetAccount(ctx context.Context, id int64) (Account, error)
+	GetAccountBalanceAt(ctx context.Context, arg GetAccountBalanceAtParams) (int64, error)
+	GetAccountByNumber(ctx context.Context, number string) (Account, error)
 	GetAccountForUpdate(ctx context.Context, id int64) (Account, error)
+	GetAccountMember(ctx context.Context, arg GetAccountMemberParams) (AccountMember, error)
+	GetAccountStatement(ctx context.Context, arg GetAccountStatementParams) (AccountStatement, error)
+	GetBeneficiary(ctx context.Context, arg GetBeneficiaryParams) (Beneficiary, error)
 	GetEntry(ctx context.Context, id int64) (Entry, error)
+	GetInterestRate(ctx context.Context, id int64) (InterestRate, error)
+	GetJournalTransaction(ctx context.Context, id int64) (JournalTransaction, error)
+	GetLatestBalanceSnapshot(ctx context.Context, arg GetLatestBalanceSnapshotParams) (AccountBalanceSnapshot, error)
+	GetLatestBalanceSnapshotDate(ctx context.Context) (sql.NullTime, error)
+	GetLatestCompletedBalanceSnapshotDate(ctx context.Context) (sql.NullTime, error)
+	GetLatestInterestAccrualDate(ctx context.Context) (sql.NullTime, error)
+	GetLatestReconciliationRun(ctx context.Context) (ReconciliationRun, error)
+	GetLedgerAccount(ctx context.Context, id int64) (LedgerAccount, error)
+	GetLoginChallenge(ctx context.Context, tokenHash string) (LoginChallenge, error)
+	GetLoginFailures(ctx context.Context, arg GetLoginFailuresParams) (GetLoginFailuresRow, error)
+	GetOAuthClient(ctx context.Context, id string) (OauthClient, error)
+	GetOAuthConsent(ctx context.Context, arg GetOAuthConsentParams) (OauthConsent, error)
+	GetReconciliationRun(ctx context.Context, id int64) (ReconciliationRun, error)
 	GetTransfer(ctx context.Context, id int64) (Transfer, error)
 	GetUser(ctx context.Context, username string) (User, error)
+	GetUserByEmail(ctx context.Context, email string) (User, error)
+	GetUserForUpdate(ctx context.Context, username string) (User, error)
+	ListAPIKeys(ctx context.Context, username string) ([]ApiKey, error)
+	ListAccountMembers(ctx context.Context, accountID int64) ([]AccountMember, error)
+	ListAccountStatements(ctx context.Context, arg ListAccountStatementsParams) ([]ListAccountStatementsRow, error)
 	ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error)
+	ListAccountsWithoutStatement(ctx context.Context, arg ListAccountsWithoutStatementParams) ([]Account, error)
+	ListAllAccounts(ctx context.Context, username string) ([]Account, error)
+	ListBeneficiaries(ctx context.Context, username string) ([]Beneficiary, error)
+	ListCurrencies(ctx context.Context) ([]Currency, error)
 	ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error)
+	ListFeeCharges(ctx context.Context, arg ListFeeChargesParams) ([]FeeCharge, error)
+	ListInterestAccrualBalances(ctx context.Context, arg ListInterestAccrualBalancesParams) ([]ListInterestAccrualBalancesRow, error)
+	ListInterestPostings(ctx context.Context, arg ListInterestPostingsParams) ([]InterestPosting, error)
+	ListInterestRates(ctx context.Context) ([]InterestRate, error)
+	ListLedgerAccounts(ctx context.Context) ([]LedgerAccount, error)
+	ListLoginAttempts(ctx context.Context, username string) ([]LoginAttempt, error)
+	ListMaintenanceFeeAccounts(ctx context.Context, arg ListMaintenanceFeeAccountsParams) ([]ListMaintenanceFeeAccountsRow, error)
+	ListMemberEntries(ctx context.Context, username string) ([]Entry, error)
+	ListMemberTransfers(ctx context.Context, username string) ([]Transfer, error)
+	ListOAuthClients(ctx context.Context, owner string) ([]OauthClient, error)
+	ListOAuthConsents(ctx context.Context, username string) ([]OauthConsent, error)
+	ListPostings(ctx context.Context, journalTransactionID int64) ([]Posting, error)
+	ListReconciliationDiscrepancies(ctx context.Context, runID int64) ([]ReconciliationDiscrepancy, error)
+	ListReconciliationRuns(ctx context.Context, arg ListReconciliationRunsParams) ([]ReconciliationRun, error)
+	ListStatementEntries(ctx context.Context, arg ListStatementEntriesParams) ([]ListStatementEntriesRow, error)
 	ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error)
+	ListTransfersByReference(ctx context.Context, arg ListTransfersByReferenceParams) ([]Transfer, error)
+	ListUnpostedInterest(ctx context.Context, arg ListUnpostedInterestParams) ([]ListUnpostedInterestRow, error)
+	ReassignAccountMembers(ctx context.Context, arg ReassignAccountMembersParams) error
+	ReassignAccounts(ctx context.Context, arg ReassignAccountsParams) ([]Account, error)
+	RehashUserPassword(ctx context.Context, arg RehashUserPasswordParams) error
+	RevokeAPIKey(ctx context.Context, arg RevokeAPIKeyParams) (ApiKey, error)
+	SetUserEmailVerified(ctx context.Context, arg SetUserEmailVerifiedParams) (User, error)
+	SetUserTOTPSecret(ctx context.Context, arg SetUserTOTPSecretParams) (User, error)
+	SetUserTier(ctx context.Context, arg SetUserTierParams) (User, error)
+	SumEntriesBetween(ctx context.Context, arg SumEntriesBetweenParams) (int64, error)
+	TakeRateLimitToken(ctx context.Context, arg TakeRateLimitTokenParams) (TakeRateLimitTokenRow, error)
+	TouchAPIKey(ctx context.Context, id int64) error
+	UnfreezeAccount(ctx context.Context, id int64) (Account, error)
 	UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error)
+	UpdateAccountMemberRole(ctx context.Context, arg UpdateAccountMemberRoleParams) (AccountMember, error)
+	UpdateAccountNickname(ctx context.Context, arg UpdateAccountNicknameParams) (Account, error)
+	UpdateBeneficiaryLabel(ctx context.Context, arg UpdateBeneficiaryLabelParams) (Beneficiary, error)
+	UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error)
+	UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (User, error)
+	UpsertLedgerAccount(ctx context.Context, arg UpsertLedgerAccountParams) (LedgerAccount, error)
+	UpsertOAuthConsent(ctx context.Context, arg UpsertOAuthConsentParams) (OauthConsent, error)
+	UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeParams) (RecoveryCode, error)
+	UseUserTOTPStep(ctx context.Context, arg UseUserTOTPStepParams) (User, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -0,0 +1,56 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hhow09/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTakeRateLimitToken(t *testing.T) {
+	arg := TakeRateLimitTokenParams{
+		Key:        util.RandomString(12),
+		Capacity:   2,
+		RefillRate: 0.0001,
+	}
+
+	for i := 0; i < 2; i++ {
+		row, err := testQueries.TakeRateLimitToken(context.Background(), arg)
+		require.NoError(t, err)
+		require.True(t, row.Allowed)
+		require.True(t, row.Tokens >= 0)
+	}
+
+	row, err := testQueries.TakeRateLimitToken(context.Background(), arg)
+	require.NoError(t, err)
+	require.False(t, row.Allowed)
+	require.True(t, row.Tokens < 1)
+}
+
+func TestDeleteIdleRateLimitBuckets(t *testing.T) {
+	arg := TakeRateLimitTokenParams{
+		Key:        util.RandomString(12),
+		Capacity:   1,
+		RefillRate: 0.0001,
+	}
+	row, err := testQueries.TakeRateLimitToken(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, row.Allowed)
+
+	// buckets used since are kept
+	_, err = testQueries.DeleteIdleRateLimitBuckets(context.Background(), time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	row, err = testQueries.TakeRateLimitToken(context.Background(), arg)
+	require.NoError(t, err)
+	require.False(t, row.Allowed)
+
+	// a deleted bucket starts full again
+	deleted, err := testQueries.DeleteIdleRateLimitBuckets(context.Background(), time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	require.NotZero(t, deleted)
+	row, err = testQueries.TakeRateLimitToken(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, row.Allowed)
+}
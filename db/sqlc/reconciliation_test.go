@@ -0,0 +1,157 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/hhow09/simple_bank/constants"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckAccountBalances(t *testing.T) {
+	// random accounts are created with a balance but without entries
+	account := createRandomAccount(t)
+	arg := CheckAccountBalancesParams{AfterID: account.ID - 1, Limit: 1}
+
+	rows, err := testQueries.CheckAccountBalances(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, CheckAccountBalancesRow{ID: account.ID, Balance: account.Balance, EntriesTotal: 0}, rows[0])
+
+	_, err = testQueries.CreateEntry(context.Background(), CreateEntryParams{AccountID: account.ID, Amount: account.Balance})
+	require.NoError(t, err)
+
+	rows, err = testQueries.CheckAccountBalances(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, rows[0].Balance, rows[0].EntriesTotal)
+}
+
+func TestCheckTransferEntries(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := deposit(t, createRandomAccount(t), 1000)
+	account2 := createRandomAccountIn(t, account1.Currency)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	rows, err := testQueries.CheckTransferEntries(context.Background(), CheckTransferEntriesParams{AfterID: result.Transfer.ID - 1, Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, CheckTransferEntriesRow{
+		ID:                  result.Transfer.ID,
+		FromAccountID:       account1.ID,
+		ToAccountID:         account2.ID,
+		Amount:              10,
+		JournalTransactions: 1,
+		Entries:             2,
+		FromTotal:           -10,
+		ToTotal:             10,
+	}, rows[0])
+}
+
+func TestCheckLedgerAccountBalances(t *testing.T) {
+	store := NewStore(testDB)
+	account := createRandomAccount(t)
+
+	result, err := store.JournalTx(context.Background(), JournalTxParams{
+		Kind: constants.JournalKindDeposit,
+		Postings: []PostingParams{
+			{AccountID: account.ID, Amount: 100},
+			{LedgerAccount: constants.LedgerAccountCash, Currency: account.Currency, Amount: -100},
+		},
+	})
+	require.NoError(t, err)
+	cashID := result.Postings[1].LedgerAccountID.Int64
+
+	rows, err := testQueries.CheckLedgerAccountBalances(context.Background(), CheckLedgerAccountBalancesParams{AfterID: cashID - 1, Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, cashID, rows[0].ID)
+	require.Equal(t, rows[0].Balance, rows[0].PostingsTotal)
+}
+
+func TestReconciliationRun(t *testing.T) {
+	run, err := testQueries.CreateReconciliationRun(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, constants.ReconciliationRunning, run.Status)
+	require.False(t, run.FinishedAt.Valid)
+
+	account := createRandomAccount(t)
+	discrepancy, err := testQueries.CreateReconciliationDiscrepancy(context.Background(), CreateReconciliationDiscrepancyParams{
+		RunID:     run.ID,
+		Kind:      constants.DiscrepancyAccountBalance,
+		AccountID: sql.NullInt64{Int64: account.ID, Valid: true},
+		Expected:  0,
+		Actual:    account.Balance,
+		Details:   "balance is not the sum of the entries",
+	})
+	require.NoError(t, err)
+	require.Equal(t, run.ID, discrepancy.RunID)
+
+	finished, err := testQueries.FinishReconciliationRun(context.Background(), FinishReconciliationRunParams{
+		ID:              run.ID,
+		Status:          constants.ReconciliationCompleted,
+		AccountsChecked: 1,
+		Discrepancies:   1,
+	})
+	require.NoError(t, err)
+	require.Equal(t, constants.ReconciliationCompleted, finished.Status)
+	require.True(t, finished.FinishedAt.Valid)
+
+	latest, err := testQueries.GetLatestReconciliationRun(context.Background())
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, latest.ID, run.ID)
+
+	discrepancies, err := testQueries.ListReconciliationDiscrepancies(context.Background(), run.ID)
+	require.NoError(t, err)
+	require.Equal(t, []ReconciliationDiscrepancy{discrepancy}, discrepancies)
+
+	counts, err := testQueries.CountReconciliationDiscrepancies(context.Background(), run.ID)
+	require.NoError(t, err)
+	require.Equal(t, []CountReconciliationDiscrepanciesRow{{Kind: constants.DiscrepancyAccountBalance, Count: 1}}, counts)
+}
+
+func TestTransferTxFrozenAccount(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := deposit(t, createRandomAccount(t), 1000)
+	account2 := createRandomAccountIn(t, account1.Currency)
+
+	frozen, err := testQueries.FreezeAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+	require.True(t, frozen.FrozenAt.Valid)
+
+	// freezing again keeps the time it was frozen at
+	again, err := testQueries.FreezeAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+	require.Equal(t, frozen.FrozenAt, again.FrozenAt)
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.True(t, errors.Is(err, ErrAccountFrozen))
+
+	// the transfer was rolled back
+	updated, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updated.Balance)
+
+	unfrozen, err := testQueries.UnfreezeAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+	require.False(t, unfrozen.FrozenAt.Valid)
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+}
@@ -0,0 +1,64 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hhow09/simple_bank/constants"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListStatementEntries(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccountIn(t, account1.Currency)
+	from := time.Now().Add(-time.Minute)
+	funded, err := store.JournalTx(context.Background(), JournalTxParams{
+		Kind: constants.JournalKindDeposit,
+		Postings: []PostingParams{
+			{AccountID: account1.ID, Amount: 1000},
+			{LedgerAccount: constants.LedgerAccountCash, Currency: account1.Currency, Amount: -1000},
+		},
+	})
+	require.NoError(t, err)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+		Description:   "rent",
+	})
+	require.NoError(t, err)
+
+	// the page starts after the deposit
+	arg := ListStatementEntriesParams{
+		AccountID:      account1.ID,
+		FromTime:       from,
+		UntilTime:      time.Now().Add(time.Minute),
+		AfterCreatedAt: funded.Entries[0].CreatedAt,
+		AfterID:        funded.Entries[0].ID,
+		Limit:          5,
+	}
+	entries, err := testQueries.ListStatementEntries(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	entry := entries[0]
+	require.Equal(t, result.FromEntry.ID, entry.ID)
+	require.Equal(t, int64(-10), entry.Amount)
+	require.Equal(t, "rent", entry.Description)
+	require.Equal(t, constants.JournalKindTransfer, entry.Kind)
+	require.Equal(t, result.Transfer.ID, entry.TransferID.Int64)
+	require.Equal(t, account2.Number, entry.CounterpartyNumber)
+
+	owner, err := testQueries.GetUser(context.Background(), account2.Owner)
+	require.NoError(t, err)
+	require.Equal(t, owner.FullName, entry.CounterpartyName)
+
+	// the next page starts after the last entry
+	arg.AfterCreatedAt, arg.AfterID = entry.CreatedAt, entry.ID
+	entries, err = testQueries.ListStatementEntries(context.Background(), arg)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
@@ -3,8 +3,11 @@ package db
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 
+	"github.com/hhow09/simple_bank/constants"
 	"github.com/hhow09/simple_bank/util"
 	"go.uber.org/fx"
 )
@@ -12,6 +15,19 @@ import (
 type Store interface {
 	Querier
 	TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error)
+	JournalTx(ctx context.Context, arg JournalTxParams) (JournalTxResult, error)
+	PostInterestTx(ctx context.Context, arg PostInterestTxParams) (PostInterestTxResult, error)
+	ChargeMaintenanceFeeTx(ctx context.Context, arg ChargeMaintenanceFeeTxParams) (ChargeFeeTxResult, error)
+	ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) (User, error)
+	ChangePasswordTx(ctx context.Context, arg UpdateUserPasswordParams) (User, error)
+	CreateUserTx(ctx context.Context, arg CreateUserTxParams) (CreateUserTxResult, error)
+	VerifyEmailTx(ctx context.Context, tokenHash string) (User, error)
+	EnableTOTPTx(ctx context.Context, arg EnableTOTPTxParams) (User, error)
+	UpdateUserTx(ctx context.Context, arg UpdateUserTxParams) (UpdateUserTxResult, error)
+	DeleteUserTx(ctx context.Context, arg DeleteUserTxParams) (DeleteUserTxResult, error)
+	CreateAccountTx(ctx context.Context, arg CreateAccountTxParams) (CreateAccountTxResult, error)
+	UpdateAccountMemberRoleTx(ctx context.Context, arg UpdateAccountMemberRoleParams) (AccountMember, error)
+	DeleteAccountMemberTx(ctx context.Context, arg DeleteAccountMemberParams) (AccountMember, error)
 }
 
 // SQLStore provides all funcs to execute queries and transactions
@@ -59,10 +75,27 @@ func (store *SQLStore) execTx(ctx context.Context, fn func(*Queries) error) erro
 	return tx.Commit()
 }
 
+// ErrAccountFrozen is returned for transfers from or to a frozen account
+var ErrAccountFrozen = errors.New("account is frozen")
+
+// ErrInsufficientFunds is returned for transfers which would overdraw the from account
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
+// ErrSameAccount is returned for transfers from an account to itself
+var ErrSameAccount = errors.New("from and to account are the same")
+
 type TransferTxParams struct {
-	FromAccountID int64 `json:"from_account_id"`
-	ToAccountID   int64 `json:"to_account_id"`
-	Amount        int64 `json:"amount"`
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	Amount        int64  `json:"amount"`
+	Description   string `json:"description"`
+	Reference     string `json:"reference"`
+	// Metadata is a JSON object, empty when nil
+	Metadata json.RawMessage `json:"metadata"`
+	// Fee is taken from the from account on top of the amount,
+	// FeeWaived records a fee of the schedule which was waived
+	Fee       int64 `json:"fee"`
+	FeeWaived bool  `json:"fee_waived"`
 }
 
 type TransferTxResult struct {
@@ -71,14 +104,27 @@ type TransferTxResult struct {
 	ToAccount   Account  `json:"to_account"`
 	FromEntry   Entry    `json:"from_entry"`
 	ToEntry     Entry    `json:"to_entry"`
+	// JournalTransactionID is the journal transaction of the postings of the transfer
+	JournalTransactionID int64 `json:"journal_transaction_id"`
+	// Fee is null when the transfer has no fee, FeeEntry when nothing was taken
+	Fee      *FeeCharge `json:"fee"`
+	FeeEntry *Entry     `json:"fee_entry"`
 }
 
+// TransferTx records the transfer and moves the money with a journal transaction,
+// transfers from or to a frozen account and transfers which overdraw the from account are rolled back.
+// Transfers from an account to itself return ErrSameAccount.
+// The fee is taken from the from account with a journal transaction of its own in the same db transaction,
+// so the journal transaction of the transfer only moves the amount.
 func (store *SQLStore) TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error) {
-	// 1. create transfer record
-	// 2. create Entry of from account
-	// 3. create Entry of to account
-	// 4. update account
 	var result TransferTxResult
+	if arg.FromAccountID == arg.ToAccountID {
+		return result, fmt.Errorf("%w: account [%d]", ErrSameAccount, arg.FromAccountID)
+	}
+	metadata := arg.Metadata
+	if len(metadata) == 0 {
+		metadata = json.RawMessage("{}")
+	}
 
 	err := store.execTx(ctx, func(q *Queries) error {
 		var err error
@@ -86,61 +132,68 @@ func (store *SQLStore) TransferTx(ctx context.Context, arg TransferTxParams) (Tr
 			FromAccountID: arg.FromAccountID,
 			ToAccountID:   arg.ToAccountID,
 			Amount:        arg.Amount,
+			Description:   arg.Description,
+			Reference:     arg.Reference,
+			Metadata:      metadata,
 		})
 		if err != nil {
 			return err
 		}
-		result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.FromAccountID,
-			Amount:    -arg.Amount,
+
+		journal, err := postJournal(ctx, q, JournalTxParams{
+			Kind:        constants.JournalKindTransfer,
+			Description: arg.Description,
+			Reference:   arg.Reference,
+			TransferID:  sql.NullInt64{Int64: result.Transfer.ID, Valid: true},
+			Postings: []PostingParams{
+				{AccountID: arg.FromAccountID, Amount: -arg.Amount},
+				{AccountID: arg.ToAccountID, Amount: arg.Amount},
+			},
 		})
 		if err != nil {
 			return err
 		}
+		result.JournalTransactionID = journal.Transaction.ID
+		result.FromEntry, result.ToEntry = journal.Entries[0], journal.Entries[1]
+		result.FromAccount, result.ToAccount = journal.Accounts[0], journal.Accounts[1]
+		// the accounts are locked by the journal, so they can't be frozen concurrently
+		for _, account := range journal.Accounts {
+			if account.FrozenAt.Valid {
+				return fmt.Errorf("%w: account [%d]", ErrAccountFrozen, account.ID)
+			}
+		}
+		// the balance is checked under the lock, concurrent transfers can't overdraw the account
+		// and it must still cover the fee
+		if result.FromAccount.Balance < arg.Fee {
+			return fmt.Errorf("%w: account [%d]", ErrInsufficientFunds, result.FromAccount.ID)
+		}
 
-		result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.ToAccountID,
-			Amount:    arg.Amount,
+		if arg.Fee == 0 && !arg.FeeWaived {
+			return nil
+		}
+		fee, err := chargeFee(ctx, q, chargeFeeParams{
+			Account:     result.FromAccount,
+			Kind:        constants.FeeKindTransfer,
+			TransferID:  sql.NullInt64{Int64: result.Transfer.ID, Valid: true},
+			Amount:      arg.Fee,
+			Waived:      arg.FeeWaived,
+			Description: "Transfer fee",
+			Reference:   arg.Reference,
 		})
 		if err != nil {
 			return err
 		}
-
-		// update accounts' balance
-		if arg.FromAccountID < arg.ToAccountID {
-			result.FromAccount, result.ToAccount, err = addMoney(ctx, q, arg.FromAccountID, -arg.Amount, arg.ToAccountID, arg.Amount)
-		} else {
-			result.ToAccount, result.FromAccount, err = addMoney(ctx, q, arg.ToAccountID, arg.Amount, arg.FromAccountID, -arg.Amount)
+		result.Fee = &fee.Charge
+		if len(fee.Journal.Entries) > 0 {
+			result.FeeEntry = &fee.Journal.Entries[0]
+			result.FromAccount = fee.Journal.Accounts[0]
 		}
-		return err
+		return nil
 	})
 
 	return result, err
 }
 
-func addMoney(
-	ctx context.Context,
-	q *Queries,
-	accountID1 int64,
-	amount1 int64,
-	accountID2 int64,
-	amount2 int64,
-) (account1 Account, account2 Account, err error) {
-	account1, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
-		ID:     accountID1,
-		Amount: amount1,
-	})
-	if err != nil {
-		return
-	}
-
-	account2, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
-		ID:     accountID2,
-		Amount: amount2,
-	})
-	return
-}
-
 var Module = fx.Options(
 	fx.Provide(openSQL),
 	fx.Provide(NewStore),
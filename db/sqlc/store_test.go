@@ -2,17 +2,19 @@ package db
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"testing"
 
+	"github.com/hhow09/simple_bank/constants"
 	"github.com/stretchr/testify/require"
 )
 
 func TestTransferTx(t *testing.T) {
 	store := NewStore(testDB)
 
-	acc1 := createRandomAccount(t)
-	acc2 := createRandomAccount(t)
+	acc1 := deposit(t, createRandomAccount(t), 1000)
+	acc2 := createRandomAccountIn(t, acc1.Currency)
 	fmt.Println(">> before:", acc1.Balance, acc2.Balance)
 
 	//run n concurrent transfer transaction
@@ -117,8 +119,8 @@ func TestTransferTxDeadlock(t *testing.T) {
 	// 5 goroutine from acc2 -> acc1
 	store := NewStore(testDB)
 
-	account1 := createRandomAccount(t)
-	account2 := createRandomAccount(t)
+	account1 := deposit(t, createRandomAccount(t), 1000)
+	account2 := deposit(t, createRandomAccountIn(t, account1.Currency), 1000)
 	fmt.Println(">> before:", account1.Balance, account2.Balance)
 
 	n := 10
@@ -161,3 +163,103 @@ func TestTransferTxDeadlock(t *testing.T) {
 	require.Equal(t, account1.Balance, updatedAccount1.Balance)
 	require.Equal(t, account2.Balance, updatedAccount2.Balance)
 }
+
+func TestTransferTxInsufficientFunds(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := deposit(t, createRandomAccount(t), 1000)
+	account2 := createRandomAccountIn(t, account1.Currency)
+	// the balance only covers one of the concurrent transfers
+	amount := account1.Balance/2 + 1
+
+	n := 5
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		go func() {
+			_, err := store.TransferTx(context.Background(), TransferTxParams{
+				FromAccountID: account1.ID,
+				ToAccountID:   account2.ID,
+				Amount:        amount,
+			})
+			errs <- err
+		}()
+	}
+
+	succeeded := 0
+	for i := 0; i < n; i++ {
+		err := <-errs
+		if err == nil {
+			succeeded++
+			continue
+		}
+		require.ErrorIs(t, err, ErrInsufficientFunds)
+	}
+	require.Equal(t, 1, succeeded)
+
+	updated, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-amount, updated.Balance)
+	require.GreaterOrEqual(t, updated.Balance, int64(0))
+}
+
+func TestTransferTxSameAccount(t *testing.T) {
+	store := NewStore(testDB)
+	account := deposit(t, createRandomAccount(t), 1000)
+
+	_, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account.ID,
+		ToAccountID:   account.ID,
+		Amount:        10,
+	})
+	require.ErrorIs(t, err, ErrSameAccount)
+
+	unchanged, err := store.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, account.Balance, unchanged.Balance)
+}
+
+func TestTransferTxMemo(t *testing.T) {
+	store := NewStore(testDB)
+	acc1 := deposit(t, createRandomAccount(t), 1000)
+	acc2 := createRandomAccountIn(t, acc1.Currency)
+
+	arg := TransferTxParams{
+		FromAccountID: acc1.ID,
+		ToAccountID:   acc2.ID,
+		Amount:        10,
+		Description:   "March rent",
+		Reference:     "INV-2023-03",
+		Metadata:      json.RawMessage(`{"invoice":"INV-2023-03"}`),
+	}
+	result, err := store.TransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Description, result.Transfer.Description)
+	require.Equal(t, arg.Reference, result.Transfer.Reference)
+	require.JSONEq(t, string(arg.Metadata), string(result.Transfer.Metadata))
+	for _, entry := range []Entry{result.FromEntry, result.ToEntry} {
+		require.Equal(t, arg.Description, entry.Description)
+		require.Equal(t, arg.Reference, entry.Reference)
+	}
+
+	// the money moved with a balanced journal transaction
+	journal, err := store.GetJournalTransaction(context.Background(), result.JournalTransactionID)
+	require.NoError(t, err)
+	require.Equal(t, constants.JournalKindTransfer, journal.Kind)
+	require.Equal(t, result.Transfer.ID, journal.TransferID.Int64)
+	postings, err := store.ListPostings(context.Background(), journal.ID)
+	require.NoError(t, err)
+	require.Len(t, postings, 2)
+	require.Equal(t, result.FromEntry.ID, postings[0].EntryID.Int64)
+	require.Equal(t, -arg.Amount, postings[0].Amount)
+	require.Equal(t, result.ToEntry.ID, postings[1].EntryID.Int64)
+	require.Equal(t, arg.Amount, postings[1].Amount)
+
+	// metadata defaults to an empty object
+	result, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: acc1.ID,
+		ToAccountID:   acc2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+	require.JSONEq(t, `{}`, string(result.Transfer.Metadata))
+	require.Empty(t, result.Transfer.Reference)
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 	"time"
 
@@ -14,6 +15,9 @@ func createRandomTransfer(t *testing.T, account1, account2 Account) Transfer {
 		FromAccountID: account1.ID,
 		ToAccountID:   account2.ID,
 		Amount:        util.RandomMoney(),
+		Description:   util.RandomString(20),
+		Reference:     util.RandomString(10),
+		Metadata:      json.RawMessage(`{"order_id": "42"}`),
 	}
 
 	transfer, err := testQueries.CreateTransfer(context.Background(), arg)
@@ -23,6 +27,9 @@ func createRandomTransfer(t *testing.T, account1, account2 Account) Transfer {
 	require.Equal(t, arg.FromAccountID, transfer.FromAccountID)
 	require.Equal(t, arg.ToAccountID, transfer.ToAccountID)
 	require.Equal(t, arg.Amount, transfer.Amount)
+	require.Equal(t, arg.Description, transfer.Description)
+	require.Equal(t, arg.Reference, transfer.Reference)
+	require.JSONEq(t, string(arg.Metadata), string(transfer.Metadata))
 
 	require.NotZero(t, transfer.ID)
 	require.NotZero(t, transfer.CreatedAt)
@@ -77,3 +84,32 @@ func TestListTransfer(t *testing.T) {
 		require.True(t, transfer.FromAccountID == account1.ID || transfer.ToAccountID == account1.ID)
 	}
 }
+
+func TestListTransfersByReference(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	transfer := createRandomTransfer(t, account1, account2)
+	createRandomTransfer(t, account1, account2)
+
+	for _, username := range []string{account1.Owner, account2.Owner} {
+		transfers, err := testQueries.ListTransfersByReference(context.Background(), ListTransfersByReferenceParams{
+			Reference: transfer.Reference,
+			Username:  username,
+			Limit:     5,
+			Offset:    0,
+		})
+		require.NoError(t, err)
+		require.Len(t, transfers, 1)
+		require.Equal(t, transfer.ID, transfers[0].ID)
+	}
+
+	// transfers of accounts the user is not a member of are not found
+	transfers, err := testQueries.ListTransfersByReference(context.Background(), ListTransfersByReferenceParams{
+		Reference: transfer.Reference,
+		Username:  createRandomUser(t).Username,
+		Limit:     5,
+		Offset:    0,
+	})
+	require.NoError(t, err)
+	require.Empty(t, transfers)
+}
@@ -0,0 +1,80 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/hhow09/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnableTOTPTx(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+
+	pending, err := store.SetUserTOTPSecret(context.Background(), SetUserTOTPSecretParams{
+		TotpSecret: "JBSWY3DPEHPK3PXP",
+		Username:   user.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "JBSWY3DPEHPK3PXP", pending.TotpSecret)
+	require.False(t, pending.IsTotpEnabled)
+
+	codeHash := util.HashToken(util.RandomString(10))
+	arg := EnableTOTPTxParams{
+		Username:           user.Username,
+		Step:               100,
+		RecoveryCodeHashes: []string{codeHash, util.HashToken(util.RandomString(10))},
+	}
+	enabled, err := store.EnableTOTPTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, enabled.IsTotpEnabled)
+
+	// the step of the confirmation code cannot be replayed
+	_, err = store.UseUserTOTPStep(context.Background(), UseUserTOTPStepParams{Step: 100, Username: user.Username})
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+	updated, err := store.UseUserTOTPStep(context.Background(), UseUserTOTPStepParams{Step: 101, Username: user.Username})
+	require.NoError(t, err)
+	require.Equal(t, int64(101), updated.TotpLastStep)
+
+	// the secret cannot be replaced once enabled
+	_, err = store.SetUserTOTPSecret(context.Background(), SetUserTOTPSecretParams{
+		TotpSecret: "KRSXG5CTMVRXEZLU",
+		Username:   user.Username,
+	})
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+
+	// recovery codes are single use
+	recoveryCode, err := store.UseRecoveryCode(context.Background(), UseRecoveryCodeParams{Username: user.Username, CodeHash: codeHash})
+	require.NoError(t, err)
+	require.True(t, recoveryCode.UsedAt.Valid)
+	_, err = store.UseRecoveryCode(context.Background(), UseRecoveryCodeParams{Username: user.Username, CodeHash: codeHash})
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+}
+
+func TestLoginChallenge(t *testing.T) {
+	user := createRandomUser(t)
+	arg := CreateLoginChallengeParams{
+		Username:  user.Username,
+		TokenHash: util.HashToken(util.RandomString(32)),
+		ExpiresAt: time.Now().Add(time.Minute),
+	}
+	challenge, err := testQueries.CreateLoginChallenge(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Username, challenge.Username)
+
+	got, err := testQueries.GetLoginChallenge(context.Background(), arg.TokenHash)
+	require.NoError(t, err)
+	require.Equal(t, challenge.ID, got.ID)
+
+	consumed, err := testQueries.ConsumeLoginChallenge(context.Background(), arg.TokenHash)
+	require.NoError(t, err)
+	require.True(t, consumed.UsedAt.Valid)
+
+	_, err = testQueries.GetLoginChallenge(context.Background(), arg.TokenHash)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+	_, err = testQueries.ConsumeLoginChallenge(context.Background(), arg.TokenHash)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+}
@@ -0,0 +1,69 @@
+package db
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrLastAccountOwner is returned when a change would leave an account without owner
+var ErrLastAccountOwner = errors.New("account must keep at least one owner")
+
+// UpdateAccountMemberRoleTx changes the role of a member.
+// It returns ErrLastAccountOwner when the last owner is demoted.
+func (store *SQLStore) UpdateAccountMemberRoleTx(ctx context.Context, arg UpdateAccountMemberRoleParams) (AccountMember, error) {
+	var member AccountMember
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		err := lockAccountMembers(ctx, q, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		member, err = q.UpdateAccountMemberRole(ctx, arg)
+		if err != nil {
+			return err
+		}
+		return requireAccountOwner(ctx, q, arg.AccountID)
+	})
+
+	return member, err
+}
+
+// DeleteAccountMemberTx removes a member from an account.
+// It returns ErrLastAccountOwner when the last owner is removed.
+func (store *SQLStore) DeleteAccountMemberTx(ctx context.Context, arg DeleteAccountMemberParams) (AccountMember, error) {
+	var member AccountMember
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		err := lockAccountMembers(ctx, q, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		member, err = q.DeleteAccountMember(ctx, arg)
+		if err != nil {
+			return err
+		}
+		return requireAccountOwner(ctx, q, arg.AccountID)
+	})
+
+	return member, err
+}
+
+// lockAccountMembers serializes membership changes of an account through its row lock,
+// so two owners can't demote each other at the same time
+func lockAccountMembers(ctx context.Context, q *Queries, accountID int64) error {
+	_, err := q.GetAccountForUpdate(ctx, accountID)
+	return err
+}
+
+func requireAccountOwner(ctx context.Context, q *Queries, accountID int64) error {
+	owners, err := q.CountAccountOwners(ctx, accountID)
+	if err != nil {
+		return err
+	}
+	if owners == 0 {
+		return ErrLastAccountOwner
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package db
+
+import (
+	"context"
+)
+
+// ChangePasswordTx sets the new password of a user and deletes the password reset tokens
+// of the user, so a reset email sent before can't take over the account.
+func (store *SQLStore) ChangePasswordTx(ctx context.Context, arg UpdateUserPasswordParams) (User, error) {
+	var user User
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		user, err = q.UpdateUserPassword(ctx, arg)
+		if err != nil {
+			return err
+		}
+		return q.DeletePasswordResetTokens(ctx, arg.Username)
+	})
+
+	return user, err
+}
@@ -0,0 +1,66 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/hhow09/simple_bank/constants"
+)
+
+// ErrAccountLimitReached is returned by CreateAccountTx when the owner already has the maximum
+// number of accounts of the type in the currency
+var ErrAccountLimitReached = errors.New("maximum number of accounts of this type in this currency reached")
+
+type CreateAccountTxParams struct {
+	CreateAccountParams
+	// MaxPerCurrency limits the accounts of the type and currency opened by the owner, 0 for no limit
+	MaxPerCurrency int64 `json:"max_per_currency"`
+}
+
+type CreateAccountTxResult struct {
+	Account Account       `json:"account"`
+	Member  AccountMember `json:"member"`
+}
+
+// CreateAccountTx creates an account and makes its owner the first member.
+// It returns ErrAccountLimitReached when the owner can't open another account of the type and currency.
+func (store *SQLStore) CreateAccountTx(ctx context.Context, arg CreateAccountTxParams) (CreateAccountTxResult, error) {
+	var result CreateAccountTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		if arg.MaxPerCurrency > 0 {
+			// the lock on the owner serializes concurrent creations, so the count stays accurate
+			_, err := q.GetUserForUpdate(ctx, arg.Owner)
+			if err != nil {
+				return err
+			}
+
+			count, err := q.CountOwnerAccounts(ctx, CountOwnerAccountsParams{
+				Owner:    arg.Owner,
+				Currency: arg.Currency,
+				Type:     arg.Type,
+			})
+			if err != nil {
+				return err
+			}
+			if count >= arg.MaxPerCurrency {
+				return ErrAccountLimitReached
+			}
+		}
+
+		var err error
+		result.Account, err = q.CreateAccount(ctx, arg.CreateAccountParams)
+		if err != nil {
+			return err
+		}
+
+		result.Member, err = q.CreateAccountMember(ctx, CreateAccountMemberParams{
+			AccountID: result.Account.ID,
+			Username:  result.Account.Owner,
+			Role:      constants.MemberRoleOwner,
+		})
+		return err
+	})
+
+	return result, err
+}
@@ -0,0 +1,50 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+type CreateUserTxParams struct {
+	CreateUserParams
+	// VerifyEmailTokenHash is stored with the new user, the plain token is sent by email
+	VerifyEmailTokenHash string    `json:"verify_email_token_hash"`
+	VerifyEmailExpiresAt time.Time `json:"verify_email_expires_at"`
+	// AfterCreate runs inside the transaction, an error rolls back the new user
+	AfterCreate func(user User) error `json:"-"`
+}
+
+type CreateUserTxResult struct {
+	User        User        `json:"user"`
+	VerifyEmail VerifyEmail `json:"verify_email"`
+}
+
+// CreateUserTx creates a user together with its email verification token.
+func (store *SQLStore) CreateUserTx(ctx context.Context, arg CreateUserTxParams) (CreateUserTxResult, error) {
+	var result CreateUserTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		result.User, err = q.CreateUser(ctx, arg.CreateUserParams)
+		if err != nil {
+			return err
+		}
+
+		result.VerifyEmail, err = q.CreateVerifyEmail(ctx, CreateVerifyEmailParams{
+			Username:  result.User.Username,
+			Email:     result.User.Email,
+			TokenHash: arg.VerifyEmailTokenHash,
+			ExpiresAt: arg.VerifyEmailExpiresAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		if arg.AfterCreate != nil {
+			return arg.AfterCreate(result.User)
+		}
+		return nil
+	})
+
+	return result, err
+}
@@ -0,0 +1,98 @@
+package db
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrAccountsNotEmpty is returned by DeleteUserTx when an account of the user still holds money
+var ErrAccountsNotEmpty = errors.New("accounts of the user are not empty")
+
+// pseudonymEmailDomain is reserved (RFC 2606), so pseudonyms never receive emails
+const pseudonymEmailDomain = "deleted.invalid"
+
+type DeleteUserTxParams struct {
+	Username string `json:"username"`
+	// Pseudonym replaces the username as owner of the accounts
+	Pseudonym string `json:"pseudonym"`
+}
+
+type DeleteUserTxResult struct {
+	Pseudonym User      `json:"pseudonym"`
+	Accounts  []Account `json:"accounts"`
+}
+
+// DeleteUserTx erases the personal data of a user. Accounts are kept for the
+// entries and transfers which reference them, but they are reassigned to a
+// pseudonym without password, name or email, as are the memberships in accounts shared
+// with other users. The reassigned accounts are frozen, so no money can be sent to them. The monthly statements of the accounts are deleted, they show the name of the owner.
+// Everything else of the user is deleted.
+// It returns ErrAccountsNotEmpty when an account has a balance.
+func (store *SQLStore) DeleteUserTx(ctx context.Context, arg DeleteUserTxParams) (DeleteUserTxResult, error) {
+	var result DeleteUserTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		user, err := q.GetUser(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		result.Pseudonym, err = q.CreateUserPseudonym(ctx, CreateUserPseudonymParams{
+			Username:  arg.Pseudonym,
+			Email:     arg.Pseudonym + "@" + pseudonymEmailDomain,
+			CreatedAt: user.CreatedAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		// the update locks the accounts, so no transfer can change the balances before the commit
+		result.Accounts, err = q.ReassignAccounts(ctx, ReassignAccountsParams{
+			NewOwner: arg.Pseudonym,
+			Owner:    arg.Username,
+		})
+		if err != nil {
+			return err
+		}
+		for _, account := range result.Accounts {
+			if account.Balance != 0 {
+				return ErrAccountsNotEmpty
+			}
+		}
+		err = q.DeleteOwnerAccountStatements(ctx, arg.Pseudonym)
+		if err != nil {
+			return err
+		}
+
+		err = q.ReassignAccountMembers(ctx, ReassignAccountMembersParams{
+			NewUsername: arg.Pseudonym,
+			Username:    arg.Username,
+		})
+		if err != nil {
+			return err
+		}
+
+		deletes := []func(context.Context, string) error{
+			q.DeleteLoginAttempts,
+			q.DeleteLoginChallenges,
+			q.DeleteRecoveryCodes,
+			q.DeletePasswordResetTokens,
+			q.DeleteVerifyEmails,
+			q.DeleteAPIKeys,
+			q.DeleteBeneficiaries,
+			// codes and consents of the clients of the user go before the clients
+			q.DeleteOAuthAuthorizationCodes,
+			q.DeleteOAuthConsents,
+			q.DeleteOAuthClients,
+			q.DeleteUser,
+		}
+		for _, deleteAll := range deletes {
+			if err := deleteAll(ctx, arg.Username); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	return result, err
+}
@@ -0,0 +1,157 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/hhow09/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteUserTx(t *testing.T) {
+	store := NewStore(testDB)
+	account := createRandomAccount(t)
+	other := createRandomAccountIn(t, account.Currency)
+	user, err := testQueries.GetUser(context.Background(), account.Owner)
+	require.NoError(t, err)
+
+	// move all the money out, the ledger of the account is kept after the deletion
+	transfer, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account.ID,
+		ToAccountID:   other.ID,
+		Amount:        account.Balance,
+	})
+	require.NoError(t, err)
+	require.Zero(t, transfer.FromAccount.Balance)
+
+	periodStart := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, statementAccount := range []Account{account, other} {
+		owner, err := testQueries.GetUser(context.Background(), statementAccount.Owner)
+		require.NoError(t, err)
+		_, err = testQueries.CreateAccountStatement(context.Background(), CreateAccountStatementParams{
+			AccountID:   statementAccount.ID,
+			PeriodStart: periodStart,
+			PeriodEnd:   periodStart.AddDate(0, 1, -1),
+			Content:     []byte("%PDF-1.4 " + owner.FullName),
+		})
+		require.NoError(t, err)
+	}
+	createRandomAPIKey(t, user)
+	createRandomLoginAttempt(t, user.Username, true)
+	createRandomPasswordResetToken(t, user, time.Now().Add(time.Minute))
+	createRandomVerifyEmail(t, user, time.Now().Add(time.Minute))
+	createRandomBeneficiary(t, user, other)
+	client := createRandomOAuthClient(t, user)
+	_, err = testQueries.UpsertOAuthConsent(context.Background(), UpsertOAuthConsentParams{
+		Username: createRandomUser(t).Username,
+		ClientID: client.ID,
+		Scopes:   client.Scopes,
+	})
+	require.NoError(t, err)
+
+	pseudonym := "deleted" + util.RandomString(12)
+	result, err := store.DeleteUserTx(context.Background(), DeleteUserTxParams{
+		Username:  user.Username,
+		Pseudonym: pseudonym,
+	})
+	require.NoError(t, err)
+	require.Equal(t, pseudonym, result.Pseudonym.Username)
+	require.Empty(t, result.Pseudonym.FullName)
+	require.Empty(t, result.Pseudonym.HashedPassword)
+	require.NotContains(t, result.Pseudonym.Email, user.Email)
+	require.True(t, result.Pseudonym.DeletedAt.Valid)
+	require.WithinDuration(t, user.CreatedAt, result.Pseudonym.CreatedAt, time.Second)
+	require.Len(t, result.Accounts, 1)
+
+	_, err = testQueries.GetUser(context.Background(), user.Username)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+	_, err = testQueries.GetUserByEmail(context.Background(), user.Email)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+	_, err = testQueries.GetOAuthClient(context.Background(), client.ID)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+
+	apiKeys, err := testQueries.ListAPIKeys(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Empty(t, apiKeys)
+	attempts, err := testQueries.ListLoginAttempts(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Empty(t, attempts)
+	beneficiaries, err := testQueries.ListBeneficiaries(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Empty(t, beneficiaries)
+
+	// accounts, memberships, entries and transfers belong to the pseudonym
+	kept, err := testQueries.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, pseudonym, kept.Owner)
+	require.True(t, kept.FrozenAt.Valid)
+	members, err := testQueries.ListAccountMembers(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Len(t, members, 1)
+	require.Equal(t, pseudonym, members[0].Username)
+	entries, err := testQueries.ListMemberEntries(context.Background(), pseudonym)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	transfers, err := testQueries.ListMemberTransfers(context.Background(), pseudonym)
+	require.NoError(t, err)
+	require.Len(t, transfers, 1)
+	require.Equal(t, transfer.Transfer.ID, transfers[0].ID)
+
+	// no statement shows the name of the deleted user, the statements of other owners are kept
+	statements, err := testQueries.ListAccountStatements(context.Background(), ListAccountStatementsParams{AccountID: account.ID, Limit: 5})
+	require.NoError(t, err)
+	require.Empty(t, statements)
+	_, err = testQueries.GetAccountStatement(context.Background(), GetAccountStatementParams{AccountID: account.ID, PeriodStart: periodStart})
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+	_, err = testQueries.GetAccountStatement(context.Background(), GetAccountStatementParams{AccountID: other.ID, PeriodStart: periodStart})
+	require.NoError(t, err)
+}
+
+func TestDeleteUserTxFreezesAccounts(t *testing.T) {
+	store := NewStore(testDB)
+	account := createRandomAccount(t)
+	account, err := testQueries.UpdateAccount(context.Background(), UpdateAccountParams{ID: account.ID, Balance: 0})
+	require.NoError(t, err)
+	sender := deposit(t, createRandomAccountIn(t, account.Currency), 1000)
+
+	result, err := store.DeleteUserTx(context.Background(), DeleteUserTxParams{
+		Username:  account.Owner,
+		Pseudonym: "deleted" + util.RandomString(12),
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Accounts, 1)
+	require.True(t, result.Accounts[0].FrozenAt.Valid)
+
+	// the accounts of the erased user can't receive money
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: sender.ID,
+		ToAccountID:   account.ID,
+		Amount:        10,
+	})
+	require.ErrorIs(t, err, ErrAccountFrozen)
+	updated, err := testQueries.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Zero(t, updated.Balance)
+}
+
+func TestDeleteUserTxAccountsNotEmpty(t *testing.T) {
+	store := NewStore(testDB)
+	account := createRandomAccount(t)
+	account, err := testQueries.UpdateAccount(context.Background(), UpdateAccountParams{ID: account.ID, Balance: 1})
+	require.NoError(t, err)
+
+	_, err = store.DeleteUserTx(context.Background(), DeleteUserTxParams{
+		Username:  account.Owner,
+		Pseudonym: "deleted" + util.RandomString(12),
+	})
+	require.ErrorIs(t, err, ErrAccountsNotEmpty)
+
+	user, err := testQueries.GetUser(context.Background(), account.Owner)
+	require.NoError(t, err)
+	require.False(t, user.DeletedAt.Valid)
+	kept, err := testQueries.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, user.Username, kept.Owner)
+}
@@ -0,0 +1,50 @@
+package db
+
+import (
+	"context"
+)
+
+type EnableTOTPTxParams struct {
+	Username           string   `json:"username"`
+	Step               int64    `json:"step"`
+	RecoveryCodeHashes []string `json:"recovery_code_hashes"`
+}
+
+// EnableTOTPTx turns on two-factor authentication with the confirmed code
+// and replaces the recovery codes of the user.
+// It returns sql.ErrNoRows when the time step of the code was already used.
+func (store *SQLStore) EnableTOTPTx(ctx context.Context, arg EnableTOTPTxParams) (User, error) {
+	var user User
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		_, err := q.UseUserTOTPStep(ctx, UseUserTOTPStepParams{
+			Step:     arg.Step,
+			Username: arg.Username,
+		})
+		if err != nil {
+			return err
+		}
+
+		user, err = q.EnableUserTOTP(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		err = q.DeleteRecoveryCodes(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+		for _, codeHash := range arg.RecoveryCodeHashes {
+			_, err = q.CreateRecoveryCode(ctx, CreateRecoveryCodeParams{
+				Username: arg.Username,
+				CodeHash: codeHash,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	return user, err
+}
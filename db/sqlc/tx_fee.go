@@ -0,0 +1,102 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/hhow09/simple_bank/constants"
+)
+
+type ChargeMaintenanceFeeTxParams struct {
+	AccountID int64     `json:"account_id"`
+	Month     time.Time `json:"month"`
+	// Amount is the fee of the schedule, 0 when Waived
+	Amount      int64  `json:"amount"`
+	Waived      bool   `json:"waived"`
+	Description string `json:"description"`
+	Reference   string `json:"reference"`
+}
+
+type ChargeFeeTxResult struct {
+	Charge FeeCharge `json:"charge"`
+	// Journal is empty when nothing was taken
+	Journal JournalTxResult `json:"journal"`
+}
+
+// ChargeMaintenanceFeeTx takes the maintenance fee of a month from an account to the fee system account
+// and records the charge, so a month is charged once per account: a second charge of the month
+// fails on the unique key and rolls back its journal transaction.
+// The fee is capped at the balance of the account, so it never overdraws it.
+func (store *SQLStore) ChargeMaintenanceFeeTx(ctx context.Context, arg ChargeMaintenanceFeeTxParams) (ChargeFeeTxResult, error) {
+	var result ChargeFeeTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		account, err := q.GetAccountForUpdate(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+		amount := arg.Amount
+		if amount > account.Balance {
+			amount = account.Balance
+		}
+		if amount < 0 {
+			amount = 0
+		}
+		result, err = chargeFee(ctx, q, chargeFeeParams{
+			Account:     account,
+			Kind:        constants.FeeKindMaintenance,
+			Month:       sql.NullTime{Time: arg.Month, Valid: true},
+			Amount:      amount,
+			Waived:      arg.Waived,
+			Description: arg.Description,
+			Reference:   arg.Reference,
+		})
+		return err
+	})
+
+	return result, err
+}
+
+type chargeFeeParams struct {
+	Account     Account
+	Kind        string
+	TransferID  sql.NullInt64
+	Month       sql.NullTime
+	Amount      int64
+	Waived      bool
+	Description string
+	Reference   string
+}
+
+// chargeFee takes a fee from an account to the fee system account within a db transaction
+// and records the charge, zero amounts are only recorded
+func chargeFee(ctx context.Context, q *Queries, arg chargeFeeParams) (ChargeFeeTxResult, error) {
+	var result ChargeFeeTxResult
+	var err error
+	params := CreateFeeChargeParams{
+		AccountID:  arg.Account.ID,
+		Kind:       arg.Kind,
+		TransferID: arg.TransferID,
+		Month:      arg.Month,
+		Amount:     arg.Amount,
+		Waived:     arg.Waived,
+	}
+	if arg.Amount != 0 {
+		result.Journal, err = postJournal(ctx, q, JournalTxParams{
+			Kind:        constants.JournalKindFee,
+			Description: arg.Description,
+			Reference:   arg.Reference,
+			Postings: []PostingParams{
+				{AccountID: arg.Account.ID, Currency: arg.Account.Currency, Amount: -arg.Amount},
+				{LedgerAccount: constants.LedgerAccountFees, Currency: arg.Account.Currency, Amount: arg.Amount},
+			},
+		})
+		if err != nil {
+			return result, err
+		}
+		params.JournalTransactionID = sql.NullInt64{Int64: result.Journal.Transaction.ID, Valid: true}
+	}
+	result.Charge, err = q.CreateFeeCharge(ctx, params)
+	return result, err
+}
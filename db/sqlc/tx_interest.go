@@ -0,0 +1,62 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/hhow09/simple_bank/constants"
+)
+
+type PostInterestTxParams struct {
+	AccountID int64     `json:"account_id"`
+	Currency  string    `json:"currency"`
+	Month     time.Time `json:"month"`
+	// AccruedMicros is the exact interest of the month, Amount is it rounded to minor units
+	AccruedMicros int64  `json:"accrued_micros"`
+	Amount        int64  `json:"amount"`
+	Description   string `json:"description"`
+	Reference     string `json:"reference"`
+}
+
+type PostInterestTxResult struct {
+	Posting InterestPosting `json:"posting"`
+	// Journal is empty when the amount rounds to zero
+	Journal JournalTxResult `json:"journal"`
+}
+
+// PostInterestTx credits the interest of a month to an account from the interest system account
+// and records the posting, so a month is posted once per account: a second posting of the month
+// fails on the unique key and rolls back its journal transaction.
+func (store *SQLStore) PostInterestTx(ctx context.Context, arg PostInterestTxParams) (PostInterestTxResult, error) {
+	var result PostInterestTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		params := CreateInterestPostingParams{
+			AccountID:     arg.AccountID,
+			Month:         arg.Month,
+			AccruedMicros: arg.AccruedMicros,
+			Amount:        arg.Amount,
+		}
+		if arg.Amount != 0 {
+			result.Journal, err = postJournal(ctx, q, JournalTxParams{
+				Kind:        constants.JournalKindInterest,
+				Description: arg.Description,
+				Reference:   arg.Reference,
+				Postings: []PostingParams{
+					{AccountID: arg.AccountID, Currency: arg.Currency, Amount: arg.Amount},
+					{LedgerAccount: constants.LedgerAccountInterest, Currency: arg.Currency, Amount: -arg.Amount},
+				},
+			})
+			if err != nil {
+				return err
+			}
+			params.JournalTransactionID = sql.NullInt64{Int64: result.Journal.Transaction.ID, Valid: true}
+		}
+		result.Posting, err = q.CreateInterestPosting(ctx, params)
+		return err
+	})
+
+	return result, err
+}
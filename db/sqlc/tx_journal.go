@@ -0,0 +1,205 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/hhow09/simple_bank/constants"
+)
+
+var (
+	// ErrUnbalancedJournal is returned when the postings of a journal transaction don't sum to zero per currency
+	ErrUnbalancedJournal = errors.New("journal transaction is not balanced")
+	// ErrInvalidPosting is returned for postings without exactly one account or with a zero amount
+	ErrInvalidPosting = errors.New("posting is invalid")
+	// ErrPostingCurrencyMismatch is returned when a posting is not in the currency of its account
+	ErrPostingCurrencyMismatch = errors.New("posting currency mismatch")
+)
+
+// ledgerAccountNames are the names of the system accounts in the chart of accounts
+var ledgerAccountNames = map[string]string{
+	constants.LedgerAccountCash:     "Cash",
+	constants.LedgerAccountFees:     "Fee income",
+	constants.LedgerAccountFX:       "Foreign exchange",
+	constants.LedgerAccountInterest: "Interest expense",
+}
+
+// PostingParams adds Amount to the balance of a customer account (AccountID)
+// or of a system account (LedgerAccount), negative amounts take money out
+type PostingParams struct {
+	AccountID     int64  `json:"account_id"`
+	LedgerAccount string `json:"ledger_account"`
+	// Currency is required for system accounts, customer accounts post in their currency
+	Currency string `json:"currency"`
+	Amount   int64  `json:"amount"`
+}
+
+type JournalTxParams struct {
+	Kind        string          `json:"kind"`
+	Description string          `json:"description"`
+	Reference   string          `json:"reference"`
+	TransferID  sql.NullInt64   `json:"transfer_id"`
+	Postings    []PostingParams `json:"postings"`
+}
+
+type JournalTxResult struct {
+	Transaction JournalTransaction `json:"transaction"`
+	// Postings, Entries and Accounts are in the order of the posting params,
+	// Entries and Accounts are empty for postings of system accounts
+	Postings []Posting `json:"postings"`
+	Entries  []Entry   `json:"entries"`
+	Accounts []Account `json:"accounts"`
+}
+
+// JournalTx records a journal transaction and applies its postings to the balances
+func (store *SQLStore) JournalTx(ctx context.Context, arg JournalTxParams) (JournalTxResult, error) {
+	var result JournalTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		result, err = postJournal(ctx, q, arg)
+		return err
+	})
+
+	return result, err
+}
+
+// postJournal writes a journal transaction within a db transaction.
+// Customer accounts are locked in the order of their ids, then system accounts
+// in the order of their codes, so concurrent journal transactions can't deadlock.
+func postJournal(ctx context.Context, q *Queries, arg JournalTxParams) (JournalTxResult, error) {
+	result := JournalTxResult{
+		Postings: make([]Posting, len(arg.Postings)),
+		Entries:  make([]Entry, len(arg.Postings)),
+		Accounts: make([]Account, len(arg.Postings)),
+	}
+	if len(arg.Postings) < 2 {
+		return result, fmt.Errorf("%w: a journal transaction needs at least 2 postings", ErrInvalidPosting)
+	}
+	for _, posting := range arg.Postings {
+		if (posting.AccountID == 0) == (posting.LedgerAccount == "") || posting.Amount == 0 {
+			return result, ErrInvalidPosting
+		}
+		if posting.LedgerAccount != "" {
+			if _, ok := ledgerAccountNames[posting.LedgerAccount]; !ok || posting.Currency == "" {
+				return result, fmt.Errorf("%w: unknown system account %q in %q", ErrInvalidPosting, posting.LedgerAccount, posting.Currency)
+			}
+		}
+	}
+
+	// lock the customer accounts and find the currency of their postings
+	order := make([]int, len(arg.Postings))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return arg.Postings[order[i]].AccountID < arg.Postings[order[j]].AccountID
+	})
+	currencies := make([]string, len(arg.Postings))
+	ledgerAccounts := make([]LedgerAccount, len(arg.Postings))
+	for _, i := range order {
+		posting := arg.Postings[i]
+		currencies[i] = posting.Currency
+		if posting.AccountID == 0 {
+			continue
+		}
+		account, err := q.GetAccountForUpdate(ctx, posting.AccountID)
+		if err != nil {
+			return result, err
+		}
+		if posting.Currency != "" && posting.Currency != account.Currency {
+			return result, fmt.Errorf("%w: account [%d] is in %s, not %s", ErrPostingCurrencyMismatch, account.ID, account.Currency, posting.Currency)
+		}
+		currencies[i] = account.Currency
+	}
+
+	sums := map[string]int64{}
+	for i, posting := range arg.Postings {
+		sums[currencies[i]] += posting.Amount
+	}
+	for currency, sum := range sums {
+		if sum != 0 {
+			return result, fmt.Errorf("%w: postings in %s sum to %d", ErrUnbalancedJournal, currency, sum)
+		}
+	}
+
+	var err error
+	result.Transaction, err = q.CreateJournalTransaction(ctx, CreateJournalTransactionParams{
+		Kind:        arg.Kind,
+		Description: arg.Description,
+		Reference:   arg.Reference,
+		TransferID:  arg.TransferID,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	// system accounts are created on their first posting,
+	// the upsert locks them in the order of their code and currency
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := arg.Postings[order[i]], arg.Postings[order[j]]
+		if a.LedgerAccount != b.LedgerAccount {
+			return a.LedgerAccount < b.LedgerAccount
+		}
+		return a.Currency < b.Currency
+	})
+	for _, i := range order {
+		posting := arg.Postings[i]
+		if posting.LedgerAccount == "" {
+			continue
+		}
+		ledgerAccounts[i], err = q.UpsertLedgerAccount(ctx, UpsertLedgerAccountParams{
+			Code:     posting.LedgerAccount,
+			Name:     ledgerAccountNames[posting.LedgerAccount],
+			Currency: posting.Currency,
+		})
+		if err != nil {
+			return result, err
+		}
+	}
+
+	for i, posting := range arg.Postings {
+		params := CreatePostingParams{
+			JournalTransactionID: result.Transaction.ID,
+			Currency:             currencies[i],
+			Amount:               posting.Amount,
+		}
+		if posting.AccountID != 0 {
+			result.Entries[i], err = q.CreateEntry(ctx, CreateEntryParams{
+				AccountID:   posting.AccountID,
+				Amount:      posting.Amount,
+				Description: arg.Description,
+				Reference:   arg.Reference,
+			})
+			if err != nil {
+				return result, err
+			}
+			result.Accounts[i], err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
+				ID:     posting.AccountID,
+				Amount: posting.Amount,
+			})
+			if err != nil {
+				return result, err
+			}
+			params.AccountID = sql.NullInt64{Int64: posting.AccountID, Valid: true}
+			params.EntryID = sql.NullInt64{Int64: result.Entries[i].ID, Valid: true}
+		} else {
+			_, err = q.AddLedgerAccountBalance(ctx, AddLedgerAccountBalanceParams{
+				ID:     ledgerAccounts[i].ID,
+				Amount: posting.Amount,
+			})
+			if err != nil {
+				return result, err
+			}
+			params.LedgerAccountID = sql.NullInt64{Int64: ledgerAccounts[i].ID, Valid: true}
+		}
+		result.Postings[i], err = q.CreatePosting(ctx, params)
+		if err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,201 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/hhow09/simple_bank/constants"
+	"github.com/hhow09/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+// deposit credits cash to the account, so it can fund the transfers of a test
+func deposit(t *testing.T, account Account, amount int64) Account {
+	result, err := NewStore(testDB).JournalTx(context.Background(), JournalTxParams{
+		Kind: constants.JournalKindDeposit,
+		Postings: []PostingParams{
+			{AccountID: account.ID, Amount: amount},
+			{LedgerAccount: constants.LedgerAccountCash, Currency: account.Currency, Amount: -amount},
+		},
+	})
+	require.NoError(t, err)
+	return result.Accounts[0]
+}
+
+func TestJournalTxDeposit(t *testing.T) {
+	store := NewStore(testDB)
+	account := createRandomAccount(t)
+
+	result, err := store.JournalTx(context.Background(), JournalTxParams{
+		Kind:        constants.JournalKindDeposit,
+		Description: "cash deposit",
+		Postings: []PostingParams{
+			{AccountID: account.ID, Amount: 100},
+			{LedgerAccount: constants.LedgerAccountCash, Currency: account.Currency, Amount: -100},
+		},
+	})
+	require.NoError(t, err)
+	require.NotZero(t, result.Transaction.ID)
+	require.Equal(t, constants.JournalKindDeposit, result.Transaction.Kind)
+	require.False(t, result.Transaction.TransferID.Valid)
+
+	require.Equal(t, account.Balance+100, result.Accounts[0].Balance)
+	require.Equal(t, account.ID, result.Entries[0].AccountID)
+	require.Equal(t, int64(100), result.Entries[0].Amount)
+	require.Equal(t, "cash deposit", result.Entries[0].Description)
+	require.Equal(t, result.Entries[0].ID, result.Postings[0].EntryID.Int64)
+	require.Zero(t, result.Entries[1].ID)
+
+	postings, err := store.ListPostings(context.Background(), result.Transaction.ID)
+	require.NoError(t, err)
+	require.Len(t, postings, 2)
+	var sum int64
+	for _, posting := range postings {
+		require.Equal(t, account.Currency, posting.Currency)
+		sum += posting.Amount
+	}
+	require.Zero(t, sum)
+
+	cash, err := store.GetLedgerAccount(context.Background(), postings[1].LedgerAccountID.Int64)
+	require.NoError(t, err)
+	require.Equal(t, constants.LedgerAccountCash, cash.Code)
+	require.Equal(t, account.Currency, cash.Currency)
+}
+
+func TestJournalTxRejected(t *testing.T) {
+	store := NewStore(testDB)
+	account := createRandomAccount(t)
+	other := createRandomAccountIn(t, util.EUR)
+	if account.Currency == util.EUR {
+		other = createRandomAccountIn(t, util.USD)
+	}
+
+	testCases := []struct {
+		name     string
+		postings []PostingParams
+		err      error
+	}{
+		{
+			name: "Unbalanced",
+			postings: []PostingParams{
+				{AccountID: account.ID, Amount: 100},
+				{LedgerAccount: constants.LedgerAccountCash, Currency: account.Currency, Amount: -99},
+			},
+			err: ErrUnbalancedJournal,
+		},
+		{
+			name: "UnbalancedPerCurrency",
+			postings: []PostingParams{
+				{AccountID: account.ID, Amount: -100},
+				{AccountID: other.ID, Amount: 100},
+			},
+			err: ErrUnbalancedJournal,
+		},
+		{
+			name: "CurrencyMismatch",
+			postings: []PostingParams{
+				{AccountID: account.ID, Currency: other.Currency, Amount: 100},
+				{LedgerAccount: constants.LedgerAccountCash, Currency: other.Currency, Amount: -100},
+			},
+			err: ErrPostingCurrencyMismatch,
+		},
+		{
+			name: "BothAccounts",
+			postings: []PostingParams{
+				{AccountID: account.ID, LedgerAccount: constants.LedgerAccountCash, Currency: account.Currency, Amount: 100},
+				{LedgerAccount: constants.LedgerAccountCash, Currency: account.Currency, Amount: -100},
+			},
+			err: ErrInvalidPosting,
+		},
+		{
+			name: "UnknownLedgerAccount",
+			postings: []PostingParams{
+				{AccountID: account.ID, Amount: 100},
+				{LedgerAccount: "bonus", Currency: account.Currency, Amount: -100},
+			},
+			err: ErrInvalidPosting,
+		},
+		{
+			name:     "SinglePosting",
+			postings: []PostingParams{{AccountID: account.ID, Amount: 100}},
+			err:      ErrInvalidPosting,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := store.JournalTx(context.Background(), JournalTxParams{
+				Kind:     constants.JournalKindAdjustment,
+				Postings: tc.postings,
+			})
+			require.ErrorIs(t, err, tc.err)
+		})
+	}
+
+	// nothing was written
+	unchanged, err := store.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, account.Balance, unchanged.Balance)
+}
+
+func TestJournalTransactionBalancedConstraint(t *testing.T) {
+	store := NewStore(testDB).(*SQLStore)
+	account := createRandomAccount(t)
+
+	// postings written around the store are checked by the database at commit
+	err := store.execTx(context.Background(), func(q *Queries) error {
+		transaction, err := q.CreateJournalTransaction(context.Background(), CreateJournalTransactionParams{
+			Kind: constants.JournalKindAdjustment,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = q.CreatePosting(context.Background(), CreatePostingParams{
+			JournalTransactionID: transaction.ID,
+			AccountID:            sql.NullInt64{Int64: account.ID, Valid: true},
+			Currency:             account.Currency,
+			Amount:               100,
+		})
+		return err
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "is not balanced")
+}
+
+func TestJournalTxConcurrentFees(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccountIn(t, account1.Currency)
+
+	// postings in opposite orders must not deadlock
+	n := 10
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		postings := []PostingParams{
+			{AccountID: account1.ID, Amount: -2},
+			{AccountID: account2.ID, Amount: -1},
+			{LedgerAccount: constants.LedgerAccountFees, Currency: account1.Currency, Amount: 2},
+			{LedgerAccount: constants.LedgerAccountInterest, Currency: account1.Currency, Amount: 1},
+		}
+		if i%2 == 1 {
+			postings[0], postings[1], postings[2], postings[3] = postings[3], postings[2], postings[1], postings[0]
+		}
+		go func() {
+			_, err := store.JournalTx(context.Background(), JournalTxParams{
+				Kind:     constants.JournalKindFee,
+				Postings: postings,
+			})
+			errs <- err
+		}()
+	}
+	for i := 0; i < n; i++ {
+		require.NoError(t, <-errs)
+	}
+
+	updated1, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-int64(2*n), updated1.Balance)
+	updated2, err := store.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+	require.Equal(t, account2.Balance-int64(n), updated2.Balance)
+}
@@ -0,0 +1,52 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+type ResetPasswordTxParams struct {
+	TokenHash         string    `json:"token_hash"`
+	HashedPassword    string    `json:"hashed_password"`
+	PasswordChangedAt time.Time `json:"password_changed_at"`
+	// BeforeUpdate runs inside the transaction with the owner of the token,
+	// an error rolls back the consumed token
+	BeforeUpdate func(user User) error `json:"-"`
+}
+
+// ResetPasswordTx consumes a reset token and sets the new password.
+// The token can only be used once, it returns sql.ErrNoRows
+// when the token is unknown, expired or already used.
+// The other reset tokens of the user are deleted with it.
+func (store *SQLStore) ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) (User, error) {
+	var user User
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		resetToken, err := q.ConsumePasswordResetToken(ctx, arg.TokenHash)
+		if err != nil {
+			return err
+		}
+
+		if arg.BeforeUpdate != nil {
+			owner, err := q.GetUser(ctx, resetToken.Username)
+			if err != nil {
+				return err
+			}
+			if err := arg.BeforeUpdate(owner); err != nil {
+				return err
+			}
+		}
+
+		user, err = q.UpdateUserPassword(ctx, UpdateUserPasswordParams{
+			HashedPassword:    arg.HashedPassword,
+			PasswordChangedAt: arg.PasswordChangedAt,
+			Username:          resetToken.Username,
+		})
+		if err != nil {
+			return err
+		}
+		return q.DeletePasswordResetTokens(ctx, resetToken.Username)
+	})
+
+	return user, err
+}
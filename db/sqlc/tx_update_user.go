@@ -0,0 +1,54 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+type UpdateUserTxParams struct {
+	UpdateUserParams
+	// VerifyEmailTokenHash is stored with a new pending email, the plain token is sent to it
+	VerifyEmailTokenHash string    `json:"verify_email_token_hash"`
+	VerifyEmailExpiresAt time.Time `json:"verify_email_expires_at"`
+	// AfterUpdate runs inside the transaction, an error rolls back the update
+	AfterUpdate func(user User) error `json:"-"`
+}
+
+type UpdateUserTxResult struct {
+	User User `json:"user"`
+	// VerifyEmail is only set with a new pending email
+	VerifyEmail VerifyEmail `json:"verify_email"`
+}
+
+// UpdateUserTx updates the profile of a user. A new email is kept pending and only
+// replaces the email when the verification token created with it is used.
+func (store *SQLStore) UpdateUserTx(ctx context.Context, arg UpdateUserTxParams) (UpdateUserTxResult, error) {
+	var result UpdateUserTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		result.User, err = q.UpdateUser(ctx, arg.UpdateUserParams)
+		if err != nil {
+			return err
+		}
+
+		if arg.PendingEmail.Valid {
+			result.VerifyEmail, err = q.CreateVerifyEmail(ctx, CreateVerifyEmailParams{
+				Username:  result.User.Username,
+				Email:     result.User.PendingEmail,
+				TokenHash: arg.VerifyEmailTokenHash,
+				ExpiresAt: arg.VerifyEmailExpiresAt,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if arg.AfterUpdate != nil {
+			return arg.AfterUpdate(result.User)
+		}
+		return nil
+	})
+
+	return result, err
+}
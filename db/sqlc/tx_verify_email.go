@@ -0,0 +1,28 @@
+package db
+
+import (
+	"context"
+)
+
+// VerifyEmailTx consumes a verification token and marks the email of its user as verified,
+// a verified pending email replaces the email.
+// It returns sql.ErrNoRows when the token is unknown, expired, already used
+// or was sent to an address the user no longer has or no longer waits for.
+func (store *SQLStore) VerifyEmailTx(ctx context.Context, tokenHash string) (User, error) {
+	var user User
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		verifyEmail, err := q.ConsumeVerifyEmail(ctx, tokenHash)
+		if err != nil {
+			return err
+		}
+
+		user, err = q.SetUserEmailVerified(ctx, SetUserEmailVerifiedParams{
+			Username: verifyEmail.Username,
+			Email:    verifyEmail.Email,
+		})
+		return err
+	})
+
+	return user, err
+}
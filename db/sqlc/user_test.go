@@ -2,6 +2,8 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"testing"
 	"time"
 
@@ -29,6 +31,9 @@ func createRandomUser(t *testing.T) User {
 	require.Equal(t, arg.Email, user.Email)
 	require.True(t, user.PasswordChangedAt.IsZero())
 	require.NotZero(t, user.CreatedAt)
+	require.Equal(t, "user", user.Role)
+	require.False(t, user.IsEmailVerified)
+	require.False(t, user.IsTotpEnabled)
 
 	return user
 }
@@ -50,3 +55,161 @@ func TestGetUser(t *testing.T) {
 	require.WithinDuration(t, user1.PasswordChangedAt, user2.PasswordChangedAt, time.Second)
 	require.WithinDuration(t, user1.CreatedAt, user2.CreatedAt, time.Second)
 }
+
+func TestGetUserByEmail(t *testing.T) {
+	user1 := createRandomUser(t)
+	user2, err := testQueries.GetUserByEmail(context.Background(), user1.Email)
+	require.NoError(t, err)
+	require.Equal(t, user1.Username, user2.Username)
+}
+
+func TestUpdateUserPassword(t *testing.T) {
+	user := createRandomUser(t)
+	hashedPassword, err := util.HashPassword(util.RandomString(6))
+	require.NoError(t, err)
+
+	arg := UpdateUserPasswordParams{
+		HashedPassword:    hashedPassword,
+		PasswordChangedAt: time.Now(),
+		Username:          user.Username,
+	}
+	updated, err := testQueries.UpdateUserPassword(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, hashedPassword, updated.HashedPassword)
+	require.WithinDuration(t, arg.PasswordChangedAt, updated.PasswordChangedAt, time.Second)
+}
+
+func TestRehashUserPassword(t *testing.T) {
+	user := createRandomUser(t)
+	hashedPassword, err := util.HashPassword(util.RandomString(6))
+	require.NoError(t, err)
+
+	// the hash is only replaced if it was not changed in the meantime
+	err = testQueries.RehashUserPassword(context.Background(), RehashUserPasswordParams{
+		NewHashedPassword: hashedPassword,
+		Username:          user.Username,
+		OldHashedPassword: "outdated",
+	})
+	require.NoError(t, err)
+
+	unchanged, err := testQueries.GetUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, user.HashedPassword, unchanged.HashedPassword)
+
+	err = testQueries.RehashUserPassword(context.Background(), RehashUserPasswordParams{
+		NewHashedPassword: hashedPassword,
+		Username:          user.Username,
+		OldHashedPassword: user.HashedPassword,
+	})
+	require.NoError(t, err)
+
+	rehashed, err := testQueries.GetUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, hashedPassword, rehashed.HashedPassword)
+	// a rehash does not revoke tokens
+	require.Equal(t, user.PasswordChangedAt, rehashed.PasswordChangedAt)
+}
+
+func TestUpdateUserFullName(t *testing.T) {
+	user := createRandomUser(t)
+	fullName := util.RandomOwner()
+
+	updated, err := testQueries.UpdateUser(context.Background(), UpdateUserParams{
+		FullName: sql.NullString{String: fullName, Valid: true},
+		Username: user.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, fullName, updated.FullName)
+	require.Equal(t, user.Email, updated.Email)
+	require.Equal(t, user.IsEmailVerified, updated.IsEmailVerified)
+}
+
+func TestUpdateUserTx(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	user, err := testQueries.SetUserEmailVerified(context.Background(), SetUserEmailVerifiedParams{
+		Username: user.Username,
+		Email:    user.Email,
+	})
+	require.NoError(t, err)
+	require.True(t, user.IsEmailVerified)
+
+	arg := UpdateUserTxParams{
+		UpdateUserParams: UpdateUserParams{
+			PendingEmail: sql.NullString{String: util.RandomEmail(), Valid: true},
+			Username:     user.Username,
+		},
+		VerifyEmailTokenHash: util.HashToken(util.RandomString(32)),
+		VerifyEmailExpiresAt: time.Now().Add(time.Minute),
+	}
+	result, err := store.UpdateUserTx(context.Background(), arg)
+	require.NoError(t, err)
+	// the current email stays in use until the new one is verified
+	require.Equal(t, user.Email, result.User.Email)
+	require.True(t, result.User.IsEmailVerified)
+	require.Equal(t, arg.PendingEmail.String, result.User.PendingEmail)
+	require.Equal(t, user.FullName, result.User.FullName)
+	require.Equal(t, arg.PendingEmail.String, result.VerifyEmail.Email)
+	require.Equal(t, arg.VerifyEmailTokenHash, result.VerifyEmail.TokenHash)
+
+	verified, err := store.VerifyEmailTx(context.Background(), arg.VerifyEmailTokenHash)
+	require.NoError(t, err)
+	require.Equal(t, arg.PendingEmail.String, verified.Email)
+	require.Empty(t, verified.PendingEmail)
+	require.True(t, verified.IsEmailVerified)
+}
+
+func TestUpdateUserTxReplacedPendingEmail(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+
+	first := UpdateUserTxParams{
+		UpdateUserParams: UpdateUserParams{
+			PendingEmail: sql.NullString{String: util.RandomEmail(), Valid: true},
+			Username:     user.Username,
+		},
+		VerifyEmailTokenHash: util.HashToken(util.RandomString(32)),
+		VerifyEmailExpiresAt: time.Now().Add(time.Minute),
+	}
+	_, err := store.UpdateUserTx(context.Background(), first)
+	require.NoError(t, err)
+
+	second := first
+	second.PendingEmail = sql.NullString{String: util.RandomEmail(), Valid: true}
+	second.VerifyEmailTokenHash = util.HashToken(util.RandomString(32))
+	_, err = store.UpdateUserTx(context.Background(), second)
+	require.NoError(t, err)
+
+	// the token of the replaced pending email no longer verifies it
+	_, err = store.VerifyEmailTx(context.Background(), first.VerifyEmailTokenHash)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	unchanged, err := testQueries.GetUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, user.Email, unchanged.Email)
+	require.Equal(t, second.PendingEmail.String, unchanged.PendingEmail)
+}
+
+func TestUpdateUserTxRollback(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+
+	sendErr := errors.New("cannot send email")
+	_, err := store.UpdateUserTx(context.Background(), UpdateUserTxParams{
+		UpdateUserParams: UpdateUserParams{
+			PendingEmail: sql.NullString{String: util.RandomEmail(), Valid: true},
+			Username:     user.Username,
+		},
+		VerifyEmailTokenHash: util.HashToken(util.RandomString(32)),
+		VerifyEmailExpiresAt: time.Now().Add(time.Minute),
+		AfterUpdate: func(user User) error {
+			return sendErr
+		},
+	})
+	require.ErrorIs(t, err, sendErr)
+
+	unchanged, err := testQueries.GetUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, user.Email, unchanged.Email)
+	require.Empty(t, unchanged.PendingEmail)
+}
@@ -0,0 +1,103 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/hhow09/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomVerifyEmail(t *testing.T, user User, expiresAt time.Time) VerifyEmail {
+	arg := CreateVerifyEmailParams{
+		Username:  user.Username,
+		Email:     user.Email,
+		TokenHash: util.HashToken(util.RandomString(32)),
+		ExpiresAt: expiresAt,
+	}
+
+	verifyEmail, err := testQueries.CreateVerifyEmail(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, verifyEmail.ID)
+	require.Equal(t, arg.Username, verifyEmail.Username)
+	require.Equal(t, arg.Email, verifyEmail.Email)
+	require.Equal(t, arg.TokenHash, verifyEmail.TokenHash)
+	require.False(t, verifyEmail.UsedAt.Valid)
+
+	return verifyEmail
+}
+
+func TestVerifyEmailTx(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	verifyEmail := createRandomVerifyEmail(t, user, time.Now().Add(time.Minute))
+
+	verified, err := store.VerifyEmailTx(context.Background(), verifyEmail.TokenHash)
+	require.NoError(t, err)
+	require.Equal(t, user.Username, verified.Username)
+	require.True(t, verified.IsEmailVerified)
+
+	// a token can only be used once
+	_, err = store.VerifyEmailTx(context.Background(), verifyEmail.TokenHash)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+}
+
+func TestVerifyEmailTxExpired(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	verifyEmail := createRandomVerifyEmail(t, user, time.Now().Add(-time.Minute))
+
+	_, err := store.VerifyEmailTx(context.Background(), verifyEmail.TokenHash)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+}
+
+func TestCreateUserTx(t *testing.T) {
+	store := NewStore(testDB)
+	hashedPassword, err := util.HashPassword(util.RandomString(6))
+	require.NoError(t, err)
+
+	arg := CreateUserTxParams{
+		CreateUserParams: CreateUserParams{
+			Username:       util.RandomOwner(),
+			HashedPassword: hashedPassword,
+			FullName:       util.RandomOwner(),
+			Email:          util.RandomEmail(),
+		},
+		VerifyEmailTokenHash: util.HashToken(util.RandomString(32)),
+		VerifyEmailExpiresAt: time.Now().Add(time.Minute),
+	}
+	result, err := store.CreateUserTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Username, result.User.Username)
+	require.False(t, result.User.IsEmailVerified)
+	require.Equal(t, arg.Username, result.VerifyEmail.Username)
+	require.Equal(t, arg.Email, result.VerifyEmail.Email)
+	require.Equal(t, arg.VerifyEmailTokenHash, result.VerifyEmail.TokenHash)
+}
+
+func TestCreateUserTxRollback(t *testing.T) {
+	store := NewStore(testDB)
+	hashedPassword, err := util.HashPassword(util.RandomString(6))
+	require.NoError(t, err)
+
+	arg := CreateUserTxParams{
+		CreateUserParams: CreateUserParams{
+			Username:       util.RandomOwner(),
+			HashedPassword: hashedPassword,
+			FullName:       util.RandomOwner(),
+			Email:          util.RandomEmail(),
+		},
+		VerifyEmailTokenHash: util.HashToken(util.RandomString(32)),
+		VerifyEmailExpiresAt: time.Now().Add(time.Minute),
+		AfterCreate: func(user User) error {
+			return sql.ErrConnDone
+		},
+	}
+	_, err = store.CreateUserTx(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrConnDone)
+
+	_, err = store.GetUser(context.Background(), arg.Username)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+}
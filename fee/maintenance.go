@@ -0,0 +1,151 @@
+package fee
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hhow09/simple_bank/constants"
+	db "github.com/hhow09/simple_bank/db/sqlc"
+	"github.com/hhow09/simple_bank/statement"
+	"github.com/hhow09/simple_bank/util"
+	"go.uber.org/fx"
+)
+
+const batchSize = 500
+
+// Charger charges the monthly maintenance fees of the accounts
+type Charger struct {
+	store    db.Store
+	schedule *Schedule
+	clock    util.Clock
+	interval time.Duration
+}
+
+func NewCharger(config util.Config, store db.Store, schedule *Schedule, clock util.Clock) *Charger {
+	return &Charger{
+		store:    store,
+		schedule: schedule,
+		clock:    clock,
+		interval: config.MaintenanceFeeInterval,
+	}
+}
+
+// ChargeMonth charges the maintenance fee of the month to the accounts opened by its end which
+// aren't charged yet, frozen accounts are skipped. Waived fees are recorded so the account isn't charged
+// later, accounts without a fee of their type and currency are skipped.
+// An account which fails to be charged is logged and the others are still charged, it is charged
+// again by the next run. The error then reports the number of failures and the first one.
+// It returns the number of accounts charged, including waived ones.
+func (c *Charger) ChargeMonth(ctx context.Context, month time.Time) (int64, error) {
+	start := statement.Month(month)
+	if start.After(statement.LastClosedMonth(c.clock.Now())) {
+		return 0, fmt.Errorf("the maintenance fees of %s can't be charged before the month is closed", start.Format(statement.MonthFormat))
+	}
+	arg := db.ListMaintenanceFeeAccountsParams{
+		OpenedBefore: start.AddDate(0, 1, 0),
+		Month:        sql.NullTime{Time: start, Valid: true},
+		Limit:        batchSize,
+	}
+	var count, failures int64
+	var firstErr error
+	for {
+		accounts, err := c.store.ListMaintenanceFeeAccounts(ctx, arg)
+		if err != nil {
+			return count, fmt.Errorf("failed to list the accounts of %s: %w", start.Format(statement.MonthFormat), err)
+		}
+		for _, account := range accounts {
+			quote := c.schedule.Apply(c.schedule.MaintenanceFee(account.Type, account.Currency), account.Tier, constants.FeeKindMaintenance)
+			if quote.Fee == 0 && !quote.Waived {
+				continue
+			}
+			_, err := c.store.ChargeMaintenanceFeeTx(ctx, db.ChargeMaintenanceFeeTxParams{
+				AccountID:   account.ID,
+				Month:       start,
+				Amount:      quote.Fee,
+				Waived:      quote.Waived,
+				Description: "Maintenance fee " + start.Format(statement.MonthFormat),
+				Reference:   "FEE-" + start.Format(statement.MonthFormat),
+			})
+			if err != nil {
+				if ctx.Err() != nil {
+					return count, ctx.Err()
+				}
+				err = fmt.Errorf("failed to charge the maintenance fee of account [%d]: %w", account.ID, err)
+				log.Println("maintenance fees:", err)
+				if firstErr == nil {
+					firstErr = err
+				}
+				failures++
+				continue
+			}
+			count++
+		}
+		if len(accounts) < batchSize {
+			if failures > 0 {
+				return count, fmt.Errorf("%d maintenance fees of %s failed, first: %w", failures, start.Format(statement.MonthFormat), firstErr)
+			}
+			return count, nil
+		}
+		arg.AfterID = accounts[len(accounts)-1].ID
+	}
+}
+
+// CatchUp charges the maintenance fees of the last closed month, nothing without maintenance fees
+func (c *Charger) CatchUp(ctx context.Context) (int64, error) {
+	if !c.schedule.HasMaintenanceFees() {
+		return 0, nil
+	}
+	return c.ChargeMonth(ctx, statement.LastClosedMonth(c.clock.Now()))
+}
+
+// start catches up once at start and then every interval until ctx is done
+func (c *Charger) start(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		if _, err := c.CatchUp(ctx); err != nil {
+			log.Println("maintenance fees:", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// registerJob charges the maintenance fees in the background when MAINTENANCE_FEE_INTERVAL is set
+func registerJob(lc fx.Lifecycle, c *Charger) {
+	if c.interval <= 0 {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go func() {
+				defer close(done)
+				c.start(ctx)
+			}()
+			return nil
+		},
+		OnStop: func(stopCtx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+				return nil
+			case <-stopCtx.Done():
+				return stopCtx.Err()
+			}
+		},
+	})
+}
+
+var Module = fx.Options(
+	fx.Provide(NewSchedule),
+	fx.Provide(NewCharger),
+	fx.Invoke(registerJob),
+)
@@ -0,0 +1,121 @@
+package fee
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/hhow09/simple_bank/constants"
+	mockdb "github.com/hhow09/simple_bank/db/mock"
+	db "github.com/hhow09/simple_bank/db/sqlc"
+	"github.com/hhow09/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCharger(t *testing.T, store db.Store, now time.Time) *Charger {
+	schedule, err := NewSchedule(util.Config{
+		FeeMaintenance: "checking:USD:2.50,savings:USD:0,checking:JPY:300",
+		FeeWaivers:     "premium:maintenance",
+	}, newTestRegistry(t))
+	require.NoError(t, err)
+	return NewCharger(util.Config{}, store, schedule, util.NewFixedClock(now))
+}
+
+func TestChargeMonth(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	february := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().ListMaintenanceFeeAccounts(gomock.Any(), db.ListMaintenanceFeeAccountsParams{
+		OpenedBefore: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		Month:        sql.NullTime{Time: february, Valid: true},
+		Limit:        batchSize,
+	}).Times(1).Return([]db.ListMaintenanceFeeAccountsRow{
+		{ID: 1, Type: constants.AccountTypeChecking, Currency: "USD", Tier: constants.TierStandard},
+		{ID: 2, Type: constants.AccountTypeSavings, Currency: "USD", Tier: constants.TierStandard},
+		{ID: 3, Type: constants.AccountTypeChecking, Currency: "USD", Tier: constants.TierPremium},
+		{ID: 4, Type: constants.AccountTypeChecking, Currency: "JPY", Tier: constants.TierPrivate},
+		{ID: 5, Type: constants.AccountTypeChecking, Currency: "EUR", Tier: constants.TierStandard},
+	}, nil)
+	charged := map[int64]db.ChargeMaintenanceFeeTxParams{}
+	store.EXPECT().ChargeMaintenanceFeeTx(gomock.Any(), gomock.Any()).Times(3).
+		DoAndReturn(func(_ context.Context, arg db.ChargeMaintenanceFeeTxParams) (db.ChargeFeeTxResult, error) {
+			require.Equal(t, february, arg.Month)
+			require.Equal(t, "Maintenance fee 2024-02", arg.Description)
+			require.Equal(t, "FEE-2024-02", arg.Reference)
+			charged[arg.AccountID] = arg
+			return db.ChargeFeeTxResult{Charge: db.FeeCharge{AccountID: arg.AccountID, Amount: arg.Amount}}, nil
+		})
+
+	charger := newTestCharger(t, store, time.Date(2024, 3, 1, 1, 0, 0, 0, time.UTC))
+	count, err := charger.ChargeMonth(context.Background(), february.AddDate(0, 0, 14))
+	require.NoError(t, err)
+	require.Equal(t, int64(3), count)
+	// accounts without a fee of their type and currency are skipped, waived fees are recorded
+	require.Len(t, charged, 3)
+	require.Equal(t, int64(250), charged[1].Amount)
+	require.Zero(t, charged[3].Amount)
+	require.True(t, charged[3].Waived)
+	require.Equal(t, int64(300), charged[4].Amount)
+	require.False(t, charged[4].Waived)
+}
+
+func TestChargeMonthContinuesAfterFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	february := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().ListMaintenanceFeeAccounts(gomock.Any(), gomock.Any()).Times(1).Return([]db.ListMaintenanceFeeAccountsRow{
+		{ID: 1, Type: constants.AccountTypeChecking, Currency: "USD", Tier: constants.TierStandard},
+		{ID: 2, Type: constants.AccountTypeChecking, Currency: "USD", Tier: constants.TierStandard},
+		{ID: 3, Type: constants.AccountTypeChecking, Currency: "USD", Tier: constants.TierStandard},
+	}, nil)
+	var charged []int64
+	store.EXPECT().ChargeMaintenanceFeeTx(gomock.Any(), gomock.Any()).Times(3).
+		DoAndReturn(func(_ context.Context, arg db.ChargeMaintenanceFeeTxParams) (db.ChargeFeeTxResult, error) {
+			if arg.AccountID == 1 {
+				return db.ChargeFeeTxResult{}, db.ErrInsufficientFunds
+			}
+			charged = append(charged, arg.AccountID)
+			return db.ChargeFeeTxResult{}, nil
+		})
+
+	charger := newTestCharger(t, store, time.Date(2024, 3, 1, 1, 0, 0, 0, time.UTC))
+	count, err := charger.ChargeMonth(context.Background(), february)
+	// the other accounts are still charged, the failed one is left for the next run
+	require.ErrorIs(t, err, db.ErrInsufficientFunds)
+	require.ErrorContains(t, err, "account [1]")
+	require.Equal(t, int64(2), count)
+	require.Equal(t, []int64{2, 3}, charged)
+}
+
+func TestChargeMonthNotClosed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().ListMaintenanceFeeAccounts(gomock.Any(), gomock.Any()).Times(0)
+
+	charger := newTestCharger(t, store, time.Date(2024, 2, 29, 23, 0, 0, 0, time.UTC))
+	_, err := charger.ChargeMonth(context.Background(), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC))
+	require.Error(t, err)
+}
+
+func TestCatchUpWithoutMaintenanceFees(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().ListMaintenanceFeeAccounts(gomock.Any(), gomock.Any()).Times(0)
+
+	schedule, err := NewSchedule(util.Config{}, newTestRegistry(t))
+	require.NoError(t, err)
+	charger := NewCharger(util.Config{}, store, schedule, util.NewFixedClock(time.Date(2024, 3, 1, 6, 0, 0, 0, time.UTC)))
+	count, err := charger.CatchUp(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, count)
+}
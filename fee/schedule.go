@@ -0,0 +1,263 @@
+// Package fee computes the fees of the fee schedule, waived per tier of the account owner,
+// and charges the monthly maintenance fees of the accounts to the fee system account.
+package fee
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/hhow09/simple_bank/constants"
+	"github.com/hhow09/simple_bank/money"
+	"github.com/hhow09/simple_bank/util"
+)
+
+// BasisPoints is the number of basis points of a fee of 100%
+const BasisPoints = 10_000
+
+// TransferFee is the fee of a transfer in a currency: Flat plus Bps of the amount, bounded by Min and Max
+type TransferFee struct {
+	// Flat is in minor units
+	Flat int64
+	// Bps is the percentage of the amount in basis points, 10 for 0.1%
+	Bps int64
+	// Min and Max bound the fee in minor units, no upper bound when Max is 0
+	Min int64
+	Max int64
+}
+
+// Compute returns the fee of an amount, the percentage is rounded with the rounding of the currency
+func (f TransferFee) Compute(amount int64, currency money.Currency) (int64, error) {
+	percentage, err := currency.Round(new(big.Rat).SetFrac(
+		new(big.Int).Mul(big.NewInt(amount), big.NewInt(f.Bps)),
+		big.NewInt(BasisPoints),
+	))
+	if err != nil {
+		return 0, err
+	}
+	fee, err := money.Add(f.Flat, percentage)
+	if err != nil {
+		return 0, err
+	}
+	if fee < f.Min {
+		fee = f.Min
+	}
+	if f.Max > 0 && fee > f.Max {
+		fee = f.Max
+	}
+	return fee, nil
+}
+
+// Quote is a fee after waivers, Fee is 0 when it is Waived
+type Quote struct {
+	Fee    int64
+	Waived bool
+}
+
+// Schedule is the fee schedule of FEE_TRANSFER, FEE_MAINTENANCE and FEE_WAIVERS
+type Schedule struct {
+	currencies *money.Registry
+	// transfer fees by currency
+	transfer map[string]TransferFee
+	// maintenance fees by account type and currency
+	maintenance map[[2]string]int64
+	// waived fee kinds by tier
+	waivers map[[2]string]bool
+}
+
+// NewSchedule parses the fee schedule of the config, amounts are decimals of the currencies
+func NewSchedule(config util.Config, currencies *money.Registry) (*Schedule, error) {
+	s := &Schedule{
+		currencies:  currencies,
+		transfer:    map[string]TransferFee{},
+		maintenance: map[[2]string]int64{},
+		waivers:     map[[2]string]bool{},
+	}
+	for _, field := range fields(config.FeeTransfer) {
+		if err := s.parseTransferFee(field); err != nil {
+			return nil, fmt.Errorf("invalid transfer fee %q: %w", field, err)
+		}
+	}
+	for _, field := range fields(config.FeeMaintenance) {
+		if err := s.parseMaintenanceFee(field); err != nil {
+			return nil, fmt.Errorf("invalid maintenance fee %q: %w", field, err)
+		}
+	}
+	for _, field := range fields(config.FeeWaivers) {
+		if err := s.parseWaiver(field); err != nil {
+			return nil, fmt.Errorf("invalid fee waiver %q: %w", field, err)
+		}
+	}
+	return s, nil
+}
+
+// fields splits a comma separated list and drops the empty fields
+func fields(s string) []string {
+	var fields []string
+	for _, field := range strings.Split(s, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// parseTransferFee parses <currency>:<fee>[:<min>-<max>] where the fee is <amount>, <percent>% or <amount>+<percent>%,
+// e.g. USD:0.25+0.1%:0.50-5.00, either bound may be empty
+func (s *Schedule) parseTransferFee(field string) error {
+	parts := strings.Split(field, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return fmt.Errorf("must be <currency>:<fee>[:<min>-<max>]")
+	}
+	currency, ok := s.currencies.Lookup(parts[0])
+	if !ok {
+		return fmt.Errorf("currency %s is not supported", parts[0])
+	}
+	var fee TransferFee
+	flat, percent, hasPercent := strings.Cut(parts[1], "+")
+	if !hasPercent && strings.HasSuffix(flat, "%") {
+		flat, percent, hasPercent = "", flat, true
+	}
+	if flat != "" {
+		if err := parseAmount(currency, flat, &fee.Flat); err != nil {
+			return err
+		}
+	}
+	if hasPercent {
+		bps, err := money.Parse(strings.TrimSuffix(percent, "%"), 2)
+		if err != nil || !strings.HasSuffix(percent, "%") || bps < 0 || bps > BasisPoints {
+			return fmt.Errorf("percentage %q must be between 0%% and 100%% with at most 2 decimals", percent)
+		}
+		fee.Bps = bps
+	}
+	if len(parts) == 3 {
+		lower, upper, ok := strings.Cut(parts[2], "-")
+		if !ok {
+			return fmt.Errorf("bounds %q must be <min>-<max>", parts[2])
+		}
+		if lower != "" {
+			if err := parseAmount(currency, lower, &fee.Min); err != nil {
+				return err
+			}
+		}
+		if upper != "" {
+			if err := parseAmount(currency, upper, &fee.Max); err != nil {
+				return err
+			}
+			if fee.Max < fee.Min {
+				return fmt.Errorf("maximum %s is below the minimum", upper)
+			}
+		}
+	}
+	if _, ok := s.transfer[currency.Code]; ok {
+		return fmt.Errorf("duplicate currency %s", currency.Code)
+	}
+	s.transfer[currency.Code] = fee
+	return nil
+}
+
+// parseMaintenanceFee parses <account_type>:<currency>:<amount>, e.g. checking:USD:2.50
+func (s *Schedule) parseMaintenanceFee(field string) error {
+	parts := strings.Split(field, ":")
+	if len(parts) != 3 {
+		return fmt.Errorf("must be <account_type>:<currency>:<amount>")
+	}
+	if parts[0] != constants.AccountTypeChecking && parts[0] != constants.AccountTypeSavings {
+		return fmt.Errorf("account type %q is not checking or savings", parts[0])
+	}
+	currency, ok := s.currencies.Lookup(parts[1])
+	if !ok {
+		return fmt.Errorf("currency %s is not supported", parts[1])
+	}
+	var amount int64
+	if err := parseAmount(currency, parts[2], &amount); err != nil {
+		return err
+	}
+	key := [2]string{parts[0], currency.Code}
+	if _, ok := s.maintenance[key]; ok {
+		return fmt.Errorf("duplicate account type %s in %s", parts[0], currency.Code)
+	}
+	s.maintenance[key] = amount
+	return nil
+}
+
+// parseWaiver parses <tier>:<kind>, e.g. premium:maintenance
+func (s *Schedule) parseWaiver(field string) error {
+	tier, kind, ok := strings.Cut(field, ":")
+	if !ok {
+		return fmt.Errorf("must be <tier>:<kind>")
+	}
+	switch tier {
+	case constants.TierStandard, constants.TierPremium, constants.TierPrivate:
+	default:
+		return fmt.Errorf("tier %q is not standard, premium or private", tier)
+	}
+	if kind != constants.FeeKindTransfer && kind != constants.FeeKindMaintenance {
+		return fmt.Errorf("kind %q is not transfer or maintenance", kind)
+	}
+	s.waivers[[2]string{tier, kind}] = true
+	return nil
+}
+
+// parseAmount parses a non-negative decimal of the currency
+func parseAmount(currency money.Currency, s string, minor *int64) error {
+	amount, err := currency.Parse(s)
+	if err != nil {
+		return fmt.Errorf("amount %q: %w", s, err)
+	}
+	if amount < 0 {
+		return fmt.Errorf("amount %q must not be negative", s)
+	}
+	*minor = amount
+	return nil
+}
+
+// TransferFee returns the fee of a transfer of amount in the currency before waivers,
+// 0 for currencies without a transfer fee
+func (s *Schedule) TransferFee(code string, amount int64) (int64, error) {
+	fee, ok := s.transfer[code]
+	if !ok {
+		return 0, nil
+	}
+	currency, _ := s.currencies.Lookup(code)
+	return fee.Compute(amount, currency)
+}
+
+// MaintenanceFee returns the monthly fee of an account type in the currency before waivers
+func (s *Schedule) MaintenanceFee(accountType, currency string) int64 {
+	return s.maintenance[[2]string{accountType, currency}]
+}
+
+// HasMaintenanceFees reports whether any account is charged a maintenance fee
+func (s *Schedule) HasMaintenanceFees() bool {
+	for _, amount := range s.maintenance {
+		if amount > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// IsWaived reports whether fees of the kind are waived for the tier
+func (s *Schedule) IsWaived(tier, kind string) bool {
+	return s.waivers[[2]string{tier, kind}]
+}
+
+// HasWaivers reports whether fees of the kind are waived for any tier,
+// the tier of the account owner only needs to be looked up then
+func (s *Schedule) HasWaivers(kind string) bool {
+	for waiver := range s.waivers {
+		if waiver[1] == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply waives a fee of the kind for the tier, fees of 0 are not waived
+func (s *Schedule) Apply(fee int64, tier, kind string) Quote {
+	if fee > 0 && s.IsWaived(tier, kind) {
+		return Quote{Waived: true}
+	}
+	return Quote{Fee: fee}
+}
@@ -0,0 +1,114 @@
+package fee
+
+import (
+	"testing"
+
+	"github.com/hhow09/simple_bank/constants"
+	"github.com/hhow09/simple_bank/money"
+	"github.com/hhow09/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRegistry(t *testing.T) *money.Registry {
+	currencies, err := money.NewRegistry(
+		money.Currency{Code: "USD", Exponent: 2, Symbol: "$"},
+		money.Currency{Code: "EUR", Exponent: 2, Symbol: "€", Rounding: money.RoundUp},
+		money.Currency{Code: "JPY", Exponent: 0, Symbol: "¥"},
+	)
+	require.NoError(t, err)
+	return currencies
+}
+
+func TestNewSchedule(t *testing.T) {
+	schedule, err := NewSchedule(util.Config{
+		FeeTransfer:    "USD:0.25+0.1%:0.50-5.00, EUR:1%:1.00-, JPY:100",
+		FeeMaintenance: "checking:USD:2.50,savings:USD:0",
+		FeeWaivers:     "premium:maintenance,private:transfer,",
+	}, newTestRegistry(t))
+	require.NoError(t, err)
+	require.Equal(t, TransferFee{Flat: 25, Bps: 10, Min: 50, Max: 500}, schedule.transfer["USD"])
+	require.Equal(t, TransferFee{Bps: 100, Min: 100}, schedule.transfer["EUR"])
+	require.Equal(t, TransferFee{Flat: 100}, schedule.transfer["JPY"])
+	require.Equal(t, int64(250), schedule.MaintenanceFee(constants.AccountTypeChecking, "USD"))
+	require.Zero(t, schedule.MaintenanceFee(constants.AccountTypeSavings, "USD"))
+	require.Zero(t, schedule.MaintenanceFee(constants.AccountTypeChecking, "EUR"))
+	require.True(t, schedule.HasMaintenanceFees())
+	require.True(t, schedule.IsWaived(constants.TierPremium, constants.FeeKindMaintenance))
+	require.False(t, schedule.IsWaived(constants.TierPremium, constants.FeeKindTransfer))
+	require.True(t, schedule.HasWaivers(constants.FeeKindTransfer))
+
+	schedule, err = NewSchedule(util.Config{}, newTestRegistry(t))
+	require.NoError(t, err)
+	require.False(t, schedule.HasMaintenanceFees())
+	require.False(t, schedule.HasWaivers(constants.FeeKindTransfer))
+	fee, err := schedule.TransferFee("USD", 1000)
+	require.NoError(t, err)
+	require.Zero(t, fee)
+
+	for name, config := range map[string]util.Config{
+		"TransferFormat":      {FeeTransfer: "USD"},
+		"TransferCurrency":    {FeeTransfer: "GBP:1.00"},
+		"TransferDecimals":    {FeeTransfer: "USD:0.001"},
+		"TransferNegative":    {FeeTransfer: "USD:-1.00"},
+		"TransferPercentage":  {FeeTransfer: "USD:101%"},
+		"TransferPercent":     {FeeTransfer: "USD:1.00+1"},
+		"TransferBounds":      {FeeTransfer: "USD:1%:5.00"},
+		"TransferMaxBelowMin": {FeeTransfer: "USD:1%:5.00-1.00"},
+		"TransferDuplicate":   {FeeTransfer: "USD:1.00,USD:2.00"},
+		"MaintenanceFormat":   {FeeMaintenance: "checking:USD"},
+		"MaintenanceType":     {FeeMaintenance: "loan:USD:1.00"},
+		"MaintenanceCurrency": {FeeMaintenance: "checking:GBP:1.00"},
+		"MaintenanceAmount":   {FeeMaintenance: "checking:USD:one"},
+		"WaiverFormat":        {FeeWaivers: "premium"},
+		"WaiverTier":          {FeeWaivers: "gold:transfer"},
+		"WaiverKind":          {FeeWaivers: "premium:interest"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewSchedule(config, newTestRegistry(t))
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestTransferFee(t *testing.T) {
+	schedule, err := NewSchedule(util.Config{
+		FeeTransfer: "USD:0.25+0.1%:0.50-5.00,EUR:0.15%",
+	}, newTestRegistry(t))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		currency string
+		amount   int64
+		fee      int64
+	}{
+		// the minimum applies up to 250.00
+		{"USD", 1000, 50},
+		{"USD", 25_000, 50},
+		{"USD", 100_000, 125},
+		// 0.1% of 1205.00 is 120.5 cents, rounded half to even
+		{"USD", 120_500, 145},
+		{"USD", 121_500, 147},
+		// the maximum applies from 4750.00
+		{"USD", 1_000_000, 500},
+		// EUR is rounded up
+		{"EUR", 1001, 2},
+		{"EUR", 100_000, 150},
+		{"JPY", 100_000, 0},
+	}
+	for _, tc := range testCases {
+		fee, err := schedule.TransferFee(tc.currency, tc.amount)
+		require.NoError(t, err)
+		require.Equal(t, tc.fee, fee, "%s %d", tc.currency, tc.amount)
+	}
+}
+
+func TestApply(t *testing.T) {
+	schedule, err := NewSchedule(util.Config{FeeWaivers: "private:transfer"}, newTestRegistry(t))
+	require.NoError(t, err)
+
+	require.Equal(t, Quote{Fee: 50}, schedule.Apply(50, constants.TierStandard, constants.FeeKindTransfer))
+	require.Equal(t, Quote{Waived: true}, schedule.Apply(50, constants.TierPrivate, constants.FeeKindTransfer))
+	require.Equal(t, Quote{Fee: 50}, schedule.Apply(50, constants.TierPrivate, constants.FeeKindMaintenance))
+	// nothing is waived without a fee
+	require.Equal(t, Quote{}, schedule.Apply(0, constants.TierPrivate, constants.FeeKindTransfer))
+}
@@ -0,0 +1,261 @@
+// Package interest accrues interest daily on the end of day balances of the accounts
+// and posts it monthly to the accounts from the interest system account.
+package interest
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"time"
+
+	db "github.com/hhow09/simple_bank/db/sqlc"
+	"github.com/hhow09/simple_bank/money"
+	"github.com/hhow09/simple_bank/snapshot"
+	"github.com/hhow09/simple_bank/util"
+	"go.uber.org/fx"
+)
+
+const (
+	batchSize = 500
+	// MicrosPerMinor is the precision of accruals, they are kept in millionths of the minor unit
+	MicrosPerMinor = 1_000_000
+	// MicrosDigits is the number of decimals of the minor unit in accruals
+	MicrosDigits = 6
+	// BasisPoints is the number of basis points of a rate of 100%
+	BasisPoints = 10_000
+	// MonthFormat is the format of interest months
+	MonthFormat = "2006-01"
+)
+
+// Accruer accrues and posts the interest of the accounts
+type Accruer struct {
+	store      db.Store
+	currencies *money.Registry
+	clock      util.Clock
+	interval   time.Duration
+}
+
+func NewAccruer(config util.Config, store db.Store, currencies *money.Registry, clock util.Clock) *Accruer {
+	return &Accruer{
+		store:      store,
+		currencies: currencies,
+		clock:      clock,
+		interval:   config.InterestInterval,
+	}
+}
+
+// Month returns the first day of the month of t in UTC
+func Month(t time.Time) time.Time {
+	year, month, _ := t.UTC().Date()
+	return time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// daysInYear returns 365 or 366 for leap years
+func daysInYear(year int) int64 {
+	start := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+	return int64(start.AddDate(1, 0, 0).Sub(start) / (24 * time.Hour))
+}
+
+// DailyMicros returns the interest of the day on the balance in millionths of the minor unit.
+// The day count is actual/actual: the annual rate is divided by the number of days of the year of the day.
+// The result is truncated, the monthly sum is rounded when it is posted.
+// It returns an error when the interest doesn't fit in an int64.
+func DailyMicros(balance int64, annualRateBps int32, day time.Time) (int64, error) {
+	n := big.NewInt(balance)
+	n.Mul(n, big.NewInt(int64(annualRateBps)))
+	n.Mul(n, big.NewInt(MicrosPerMinor))
+	n.Quo(n, big.NewInt(BasisPoints*daysInYear(day.UTC().Year())))
+	if !n.IsInt64() {
+		return 0, fmt.Errorf("the daily interest of %d at %d bps overflows", balance, annualRateBps)
+	}
+	return n.Int64(), nil
+}
+
+// AccrueDay accrues the interest of the day on the end of day balance of every account with a positive
+// balance and an interest rate for its type and currency, accruals of the day are replaced.
+// The balances are read from the snapshots of the day, so it must be taken first.
+// It returns the number of accruals written.
+func (a *Accruer) AccrueDay(ctx context.Context, day time.Time) (int64, error) {
+	day = snapshot.Day(day)
+	arg := db.ListInterestAccrualBalancesParams{
+		Date:  day,
+		Limit: batchSize,
+	}
+	var count int64
+	for {
+		balances, err := a.store.ListInterestAccrualBalances(ctx, arg)
+		if err != nil {
+			return count, fmt.Errorf("failed to list the balances of %s: %w", day.Format(snapshot.DateFormat), err)
+		}
+		for _, balance := range balances {
+			amount, err := DailyMicros(balance.Balance, balance.AnnualRateBps, day)
+			if err != nil {
+				return count, fmt.Errorf("failed to accrue the interest of account [%d]: %w", balance.AccountID, err)
+			}
+			err = a.store.CreateInterestAccrual(ctx, db.CreateInterestAccrualParams{
+				AccountID:     balance.AccountID,
+				Date:          day,
+				Balance:       balance.Balance,
+				AnnualRateBps: balance.AnnualRateBps,
+				AmountMicros:  amount,
+			})
+			if err != nil {
+				return count, fmt.Errorf("failed to accrue the interest of account [%d]: %w", balance.AccountID, err)
+			}
+			count++
+		}
+		if len(balances) < batchSize {
+			return count, nil
+		}
+		arg.AfterID = balances[len(balances)-1].AccountID
+	}
+}
+
+// AccrueDays accrues the days from from to to, both included, which must be closed
+func (a *Accruer) AccrueDays(ctx context.Context, from, to time.Time) (int64, error) {
+	from, to = snapshot.Day(from), snapshot.Day(to)
+	if last := snapshot.LastClosedDay(a.clock.Now()); to.After(last) {
+		return 0, fmt.Errorf("the interest of %s can't be accrued before the day is closed", to.Format(snapshot.DateFormat))
+	}
+	var count int64
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		n, err := a.AccrueDay(ctx, day)
+		count += n
+		if err != nil {
+			return count, err
+		}
+	}
+	return count, nil
+}
+
+// PostMonth credits the interest accrued over the month to the accounts which don't have it yet.
+// The monthly sum is rounded with the rounding of the currency, the remainder is not carried over.
+// It returns the number of accounts posted, including those whose interest rounds to zero.
+func (a *Accruer) PostMonth(ctx context.Context, month time.Time) (int64, error) {
+	start := Month(month)
+	end := start.AddDate(0, 1, -1)
+	if end.After(snapshot.LastClosedDay(a.clock.Now())) {
+		return 0, fmt.Errorf("the interest of %s can't be posted before the month is closed", start.Format(MonthFormat))
+	}
+	arg := db.ListUnpostedInterestParams{
+		PeriodStart: start,
+		PeriodEnd:   end,
+		Limit:       batchSize,
+	}
+	var count int64
+	for {
+		accruals, err := a.store.ListUnpostedInterest(ctx, arg)
+		if err != nil {
+			return count, fmt.Errorf("failed to list the interest of %s: %w", start.Format(MonthFormat), err)
+		}
+		for _, accrual := range accruals {
+			currency, ok := a.currencies.Lookup(accrual.Currency)
+			if !ok {
+				currency = money.Currency{Code: accrual.Currency, Rounding: money.RoundHalfEven}
+			}
+			amount, err := currency.Round(big.NewRat(accrual.AccruedMicros, MicrosPerMinor))
+			if err != nil {
+				return count, err
+			}
+			_, err = a.store.PostInterestTx(ctx, db.PostInterestTxParams{
+				AccountID:     accrual.AccountID,
+				Currency:      accrual.Currency,
+				Month:         start,
+				AccruedMicros: accrual.AccruedMicros,
+				Amount:        amount,
+				Description:   "Interest " + start.Format(MonthFormat),
+				Reference:     "INT-" + start.Format(MonthFormat),
+			})
+			if err != nil {
+				return count, fmt.Errorf("failed to post the interest of account [%d]: %w", accrual.AccountID, err)
+			}
+			count++
+		}
+		if len(accruals) < batchSize {
+			return count, nil
+		}
+		arg.AfterID = accruals[len(accruals)-1].AccountID
+	}
+}
+
+// CatchUp accrues the days since the latest accrual up to the latest completed balance snapshot,
+// days whose snapshot is still being taken are left for the next run. The latest accrued day is
+// accrued again in case it was interrupted. Without any accrual only the latest completed
+// snapshot is accrued and older days must be accrued with AccrueDays.
+// Then it posts the latest month whose days are all accrued.
+func (a *Accruer) CatchUp(ctx context.Context) (accrued int64, posted int64, err error) {
+	latestSnapshot, err := a.store.GetLatestCompletedBalanceSnapshotDate(ctx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get the latest snapshot: %w", err)
+	}
+	if !latestSnapshot.Valid {
+		return 0, 0, nil
+	}
+	to := snapshot.Day(latestSnapshot.Time)
+	if last := snapshot.LastClosedDay(a.clock.Now()); to.After(last) {
+		to = last
+	}
+	from := to
+	latestAccrual, err := a.store.GetLatestInterestAccrualDate(ctx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get the latest accrual: %w", err)
+	}
+	if latestAccrual.Valid && snapshot.Day(latestAccrual.Time).Before(from) {
+		from = snapshot.Day(latestAccrual.Time)
+	}
+	if accrued, err = a.AccrueDays(ctx, from, to); err != nil {
+		return accrued, 0, err
+	}
+	// the month of to is only posted once its last day is accrued
+	posted, err = a.PostMonth(ctx, Month(to.AddDate(0, 0, 1)).AddDate(0, -1, 0))
+	return accrued, posted, err
+}
+
+// start catches up once at start and then every interval until ctx is done
+func (a *Accruer) start(ctx context.Context) {
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+	for {
+		if _, _, err := a.CatchUp(ctx); err != nil {
+			log.Println("interest:", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// registerJob accrues and posts interest in the background when INTEREST_INTERVAL is set
+func registerJob(lc fx.Lifecycle, a *Accruer) {
+	if a.interval <= 0 {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go func() {
+				defer close(done)
+				a.start(ctx)
+			}()
+			return nil
+		},
+		OnStop: func(stopCtx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+				return nil
+			case <-stopCtx.Done():
+				return stopCtx.Err()
+			}
+		},
+	})
+}
+
+var Module = fx.Options(
+	fx.Provide(NewAccruer),
+	fx.Invoke(registerJob),
+)
@@ -0,0 +1,237 @@
+package interest
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/hhow09/simple_bank/db/mock"
+	db "github.com/hhow09/simple_bank/db/sqlc"
+	"github.com/hhow09/simple_bank/money"
+	"github.com/hhow09/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestAccruer(t *testing.T, store db.Store, now time.Time) (*Accruer, *util.FixedClock) {
+	currencies, err := money.NewRegistry(
+		money.Currency{Code: "USD", Exponent: 2, Symbol: "$"},
+		money.Currency{Code: "JPY", Exponent: 0, Symbol: "¥", Rounding: money.RoundDown},
+	)
+	require.NoError(t, err)
+	clock := util.NewFixedClock(now)
+	return NewAccruer(util.Config{}, store, currencies, clock), clock
+}
+
+func TestDailyMicros(t *testing.T) {
+	testCases := []struct {
+		name    string
+		balance int64
+		rateBps int32
+		day     time.Time
+		micros  int64
+	}{
+		// 3.65% of 1000.00 is 36.50 a year, 0.10 a day in a year of 365 days
+		{name: "Year", balance: 100_000, rateBps: 365, day: time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC), micros: 10_000_000},
+		// leap years have 366 days, the result is truncated
+		{name: "LeapYear", balance: 100_000, rateBps: 365, day: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), micros: 9_972_677},
+		{name: "ZeroRate", balance: 100_000, rateBps: 0, day: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), micros: 0},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			micros, err := DailyMicros(tc.balance, tc.rateBps, tc.day)
+			require.NoError(t, err)
+			require.Equal(t, tc.micros, micros)
+		})
+	}
+
+	// the interest of huge balances at huge rates doesn't fit in micros
+	_, err := DailyMicros(math.MaxInt64, math.MaxInt32, time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC))
+	require.Error(t, err)
+}
+
+func TestAccrueDay(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	day := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().ListInterestAccrualBalances(gomock.Any(), db.ListInterestAccrualBalancesParams{Date: day, Limit: batchSize}).
+		Times(1).
+		Return([]db.ListInterestAccrualBalancesRow{
+			{AccountID: 1, Currency: "USD", Balance: 100_000, AnnualRateBps: 365},
+			{AccountID: 2, Currency: "JPY", Balance: 50_000, AnnualRateBps: 100},
+		}, nil)
+	store.EXPECT().CreateInterestAccrual(gomock.Any(), db.CreateInterestAccrualParams{
+		AccountID:     1,
+		Date:          day,
+		Balance:       100_000,
+		AnnualRateBps: 365,
+		AmountMicros:  10_000_000,
+	}).Times(1).Return(nil)
+	store.EXPECT().CreateInterestAccrual(gomock.Any(), db.CreateInterestAccrualParams{
+		AccountID:     2,
+		Date:          day,
+		Balance:       50_000,
+		AnnualRateBps: 100,
+		AmountMicros:  1_369_863,
+	}).Times(1).Return(nil)
+
+	accruer, _ := newTestAccruer(t, store, time.Date(2023, 6, 2, 1, 0, 0, 0, time.UTC))
+	count, err := accruer.AccrueDays(context.Background(), day.Add(3*time.Hour), day)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), count)
+}
+
+func TestAccrueDayOverflow(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	day := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().ListInterestAccrualBalances(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return([]db.ListInterestAccrualBalancesRow{
+			{AccountID: 1, Currency: "USD", Balance: math.MaxInt64, AnnualRateBps: math.MaxInt32},
+		}, nil)
+	store.EXPECT().CreateInterestAccrual(gomock.Any(), gomock.Any()).Times(0)
+
+	accruer, _ := newTestAccruer(t, store, time.Date(2023, 6, 2, 1, 0, 0, 0, time.UTC))
+	_, err := accruer.AccrueDay(context.Background(), day)
+	require.ErrorContains(t, err, "account [1]")
+}
+
+func TestAccrueDaysNotClosed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().ListInterestAccrualBalances(gomock.Any(), gomock.Any()).Times(0)
+
+	now := time.Date(2023, 6, 2, 0, 5, 0, 0, time.UTC)
+	accruer, clock := newTestAccruer(t, store, now)
+	_, err := accruer.AccrueDays(context.Background(), now.AddDate(0, 0, -1), now.AddDate(0, 0, -1))
+	require.Error(t, err)
+
+	// the day is closed once it is settled
+	clock.Add(10 * time.Minute)
+	store.EXPECT().ListInterestAccrualBalances(gomock.Any(), gomock.Any()).Times(1).Return(nil, nil)
+	_, err = accruer.AccrueDays(context.Background(), now.AddDate(0, 0, -1), now.AddDate(0, 0, -1))
+	require.NoError(t, err)
+}
+
+func TestPostMonth(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	february := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().ListUnpostedInterest(gomock.Any(), db.ListUnpostedInterestParams{
+		PeriodStart: february,
+		PeriodEnd:   time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+		Limit:       batchSize,
+	}).Times(1).Return([]db.ListUnpostedInterestRow{
+		{AccountID: 1, Currency: "USD", AccruedMicros: 2_500_000},
+		{AccountID: 2, Currency: "USD", AccruedMicros: 3_500_000},
+		{AccountID: 3, Currency: "JPY", AccruedMicros: 1_900_000},
+		{AccountID: 4, Currency: "USD", AccruedMicros: 400_000},
+	}, nil)
+	posted := map[int64]int64{}
+	store.EXPECT().PostInterestTx(gomock.Any(), gomock.Any()).Times(4).
+		DoAndReturn(func(_ context.Context, arg db.PostInterestTxParams) (db.PostInterestTxResult, error) {
+			require.Equal(t, february, arg.Month)
+			require.Equal(t, "Interest 2024-02", arg.Description)
+			require.Equal(t, "INT-2024-02", arg.Reference)
+			posted[arg.AccountID] = arg.Amount
+			return db.PostInterestTxResult{Posting: db.InterestPosting{AccountID: arg.AccountID, Amount: arg.Amount}}, nil
+		})
+
+	accruer, _ := newTestAccruer(t, store, time.Date(2024, 3, 1, 1, 0, 0, 0, time.UTC))
+	count, err := accruer.PostMonth(context.Background(), february.AddDate(0, 0, 14))
+	require.NoError(t, err)
+	require.Equal(t, int64(4), count)
+	// USD is rounded half to even, JPY down
+	require.Equal(t, map[int64]int64{1: 2, 2: 4, 3: 1, 4: 0}, posted)
+}
+
+func TestPostMonthNotClosed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().ListUnpostedInterest(gomock.Any(), gomock.Any()).Times(0)
+
+	accruer, _ := newTestAccruer(t, store, time.Date(2024, 2, 29, 23, 0, 0, 0, time.UTC))
+	_, err := accruer.PostMonth(context.Background(), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC))
+	require.Error(t, err)
+}
+
+func TestCatchUp(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetLatestCompletedBalanceSnapshotDate(gomock.Any()).Times(1).
+		Return(sql.NullTime{Time: time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC), Valid: true}, nil)
+	store.EXPECT().GetLatestInterestAccrualDate(gomock.Any()).Times(1).
+		Return(sql.NullTime{Time: time.Date(2024, 2, 27, 0, 0, 0, 0, time.UTC), Valid: true}, nil)
+	// the latest accrued day is accrued again
+	for _, day := range []int{27, 28, 29} {
+		arg := db.ListInterestAccrualBalancesParams{Date: time.Date(2024, 2, day, 0, 0, 0, 0, time.UTC), Limit: batchSize}
+		store.EXPECT().ListInterestAccrualBalances(gomock.Any(), arg).Times(1).Return(nil, nil)
+	}
+	store.EXPECT().ListUnpostedInterest(gomock.Any(), gomock.Any()).Times(1).
+		DoAndReturn(func(_ context.Context, arg db.ListUnpostedInterestParams) ([]db.ListUnpostedInterestRow, error) {
+			require.Equal(t, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), arg.PeriodStart)
+			return nil, nil
+		})
+
+	accruer, _ := newTestAccruer(t, store, time.Date(2024, 3, 1, 6, 0, 0, 0, time.UTC))
+	accrued, posted, err := accruer.CatchUp(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, accrued)
+	require.Zero(t, posted)
+}
+
+func TestCatchUpIncompleteSnapshot(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	// the snapshot of the last day of February is still being taken
+	store.EXPECT().GetLatestCompletedBalanceSnapshotDate(gomock.Any()).Times(1).
+		Return(sql.NullTime{Time: time.Date(2024, 2, 28, 0, 0, 0, 0, time.UTC), Valid: true}, nil)
+	store.EXPECT().GetLatestInterestAccrualDate(gomock.Any()).Times(1).
+		Return(sql.NullTime{Time: time.Date(2024, 2, 28, 0, 0, 0, 0, time.UTC), Valid: true}, nil)
+	store.EXPECT().ListInterestAccrualBalances(gomock.Any(), gomock.Any()).Times(1).
+		DoAndReturn(func(_ context.Context, arg db.ListInterestAccrualBalancesParams) ([]db.ListInterestAccrualBalancesRow, error) {
+			require.Equal(t, time.Date(2024, 2, 28, 0, 0, 0, 0, time.UTC), arg.Date)
+			return nil, nil
+		})
+	// February isn't posted before its last day is accrued
+	store.EXPECT().ListUnpostedInterest(gomock.Any(), gomock.Any()).Times(1).
+		DoAndReturn(func(_ context.Context, arg db.ListUnpostedInterestParams) ([]db.ListUnpostedInterestRow, error) {
+			require.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), arg.PeriodStart)
+			return nil, nil
+		})
+
+	accruer, _ := newTestAccruer(t, store, time.Date(2024, 3, 1, 6, 0, 0, 0, time.UTC))
+	_, _, err := accruer.CatchUp(context.Background())
+	require.NoError(t, err)
+}
+
+func TestCatchUpWithoutSnapshot(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetLatestCompletedBalanceSnapshotDate(gomock.Any()).Times(1).Return(sql.NullTime{}, nil)
+	store.EXPECT().ListInterestAccrualBalances(gomock.Any(), gomock.Any()).Times(0)
+
+	accruer, _ := newTestAccruer(t, store, time.Date(2024, 3, 1, 6, 0, 0, 0, time.UTC))
+	_, _, err := accruer.CatchUp(context.Background())
+	require.NoError(t, err)
+}
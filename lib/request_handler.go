@@ -1,7 +1,10 @@
 package lib
 
 import (
+	"strings"
+
 	"github.com/gin-gonic/gin"
+	"github.com/hhow09/simple_bank/util"
 )
 
 // RequestHandler function
@@ -9,8 +12,19 @@ type RequestHandler struct {
 	Gin *gin.Engine
 }
 
-// NewRequestHandler creates a new request handler
-func NewRequestHandler() RequestHandler {
+// NewRequestHandler creates a new request handler,
+// the client IP is only read from X-Forwarded-For of the TRUSTED_PROXIES
+func NewRequestHandler(config util.Config) (RequestHandler, error) {
 	engine := gin.New()
-	return RequestHandler{Gin: engine}
+	var proxies []string
+	for _, proxy := range strings.Split(config.TrustedProxies, ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	// nil trusts no proxy, so the client IP is the IP of the peer
+	if err := engine.SetTrustedProxies(proxies); err != nil {
+		return RequestHandler{}, err
+	}
+	return RequestHandler{Gin: engine}, nil
 }
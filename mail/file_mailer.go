@@ -0,0 +1,82 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileMailer writes every email as an .eml file into an outbox directory,
+// it is a stand-in for local development and tests
+type FileMailer struct {
+	mu   sync.Mutex
+	from string
+	dir  string
+	seq  int
+}
+
+func NewFileMailer(from string, dir string) (*FileMailer, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("mail outbox dir is not configured")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create mail outbox: %w", err)
+	}
+	return &FileMailer{from: from, dir: dir}, nil
+}
+
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.seq++
+	name := fmt.Sprintf("%d-%06d.eml", time.Now().UnixNano(), m.seq)
+	content := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\n\r\n%s\r\n", m.from, msg.To, msg.Subject, msg.Body)
+	return os.WriteFile(filepath.Join(m.dir, name), []byte(content), 0o600)
+}
+
+// Messages reads back the emails sent to the given address, oldest first
+func (m *FileMailer) Messages(to string) ([]Message, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	files, err := filepath.Glob(filepath.Join(m.dir, "*.eml"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	messages := []Message{}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		msg := parseMessage(string(data))
+		if msg.To == to {
+			messages = append(messages, msg)
+		}
+	}
+	return messages, nil
+}
+
+func parseMessage(content string) Message {
+	var msg Message
+	header, body, _ := strings.Cut(content, "\r\n\r\n")
+	for _, line := range strings.Split(header, "\r\n") {
+		key, value, _ := strings.Cut(line, ": ")
+		switch key {
+		case "To":
+			msg.To = value
+		case "Subject":
+			msg.Subject = value
+		}
+	}
+	msg.Body = strings.TrimSuffix(body, "\r\n")
+	return msg
+}
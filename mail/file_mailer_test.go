@@ -0,0 +1,34 @@
+package mail
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hhow09/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileMailer(t *testing.T) {
+	mailer, err := NewFileMailer("no-reply@simplebank.dev", t.TempDir())
+	require.NoError(t, err)
+
+	to := util.RandomEmail()
+	for _, subject := range []string{"first", "second"} {
+		err = mailer.Send(context.Background(), Message{To: to, Subject: subject, Body: "token: abc"})
+		require.NoError(t, err)
+	}
+	err = mailer.Send(context.Background(), Message{To: util.RandomEmail(), Subject: "other"})
+	require.NoError(t, err)
+
+	messages, err := mailer.Messages(to)
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	require.Equal(t, "first", messages[0].Subject)
+	require.Equal(t, "second", messages[1].Subject)
+	require.Equal(t, "token: abc", messages[1].Body)
+}
+
+func TestNewFileMailerWithoutDir(t *testing.T) {
+	_, err := NewFileMailer("no-reply@simplebank.dev", "")
+	require.Error(t, err)
+}
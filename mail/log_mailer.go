@@ -0,0 +1,20 @@
+package mail
+
+import (
+	"context"
+	"log"
+)
+
+// LogMailer prints emails to the log, it is a stand-in for local development
+type LogMailer struct {
+	from string
+}
+
+func NewLogMailer(from string) *LogMailer {
+	return &LogMailer{from: from}
+}
+
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	log.Printf("mail from %s to %s: %s\n%s", m.from, msg.To, msg.Subject, msg.Body)
+	return nil
+}
@@ -0,0 +1,41 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hhow09/simple_bank/util"
+	"go.uber.org/fx"
+)
+
+const (
+	MailerLog  = "log"
+	MailerFile = "file"
+)
+
+// Message is a plain text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers emails to users
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// NewMailer creates the mailer selected by MAILER
+func NewMailer(config util.Config) (Mailer, error) {
+	switch config.Mailer {
+	case "", MailerLog:
+		return NewLogMailer(config.MailFrom), nil
+	case MailerFile:
+		return NewFileMailer(config.MailFrom, config.MailOutboxDir)
+	}
+	return nil, fmt.Errorf("unsupported mailer %q", config.Mailer)
+}
+
+var Module = fx.Options(
+	fx.Provide(NewMailer),
+)
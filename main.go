@@ -1,9 +1,19 @@
 package main
 
 import (
+	"github.com/hhow09/simple_bank/accountnumber"
 	"github.com/hhow09/simple_bank/api"
+	"github.com/hhow09/simple_bank/breach"
 	db "github.com/hhow09/simple_bank/db/sqlc"
+	"github.com/hhow09/simple_bank/fee"
+	"github.com/hhow09/simple_bank/interest"
 	"github.com/hhow09/simple_bank/lib"
+	"github.com/hhow09/simple_bank/mail"
+	"github.com/hhow09/simple_bank/money"
+	"github.com/hhow09/simple_bank/ratelimit"
+	"github.com/hhow09/simple_bank/reconcile"
+	"github.com/hhow09/simple_bank/snapshot"
+	"github.com/hhow09/simple_bank/statement"
 	"github.com/hhow09/simple_bank/token"
 	"github.com/hhow09/simple_bank/util"
 	_ "github.com/lib/pq"
@@ -16,9 +26,20 @@ func main() {
 			return "."
 		}),
 		fx.Provide(util.LoadConfig),
+		fx.Provide(util.NewClock),
 		token.Module,
 		db.Module,
 		lib.Module,
+		ratelimit.Module,
+		mail.Module,
+		breach.Module,
+		accountnumber.Module,
+		money.Module,
+		reconcile.Module,
+		snapshot.Module,
+		statement.Module,
+		interest.Module,
+		fee.Module,
 		api.Module,
 	).Run()
 }
@@ -0,0 +1,195 @@
+package money
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	db "github.com/hhow09/simple_bank/db/sqlc"
+	"github.com/hhow09/simple_bank/util"
+	"go.uber.org/fx"
+)
+
+const (
+	SourceConfig   = "config"
+	SourcePostgres = "postgres"
+
+	// MaxExponent keeps amounts of a billion major units within int64
+	MaxExponent = 8
+)
+
+// Currency is an ISO 4217 currency
+type Currency struct {
+	Code string `json:"code"`
+	// Exponent is the number of decimals of the minor unit, e.g. 2 for cents
+	Exponent int    `json:"exponent"`
+	Symbol   string `json:"symbol"`
+	// Rounding is how computed amounts such as interest are rounded to minor units
+	Rounding Rounding `json:"rounding"`
+}
+
+// Format returns an amount of minor units as a decimal string of major units
+func (c Currency) Format(minor int64) string {
+	return Format(minor, c.Exponent)
+}
+
+// Display returns an amount of minor units for people, e.g. $12.34
+func (c Currency) Display(minor int64) string {
+	s := c.Format(minor)
+	if strings.HasPrefix(s, "-") {
+		return "-" + c.Symbol + s[1:]
+	}
+	return c.Symbol + s
+}
+
+// Round rounds a fractional amount of minor units with the rounding of the currency
+func (c Currency) Round(x *big.Rat) (int64, error) {
+	return c.Rounding.Round(x)
+}
+
+// Parse converts a decimal string of major units to minor units
+func (c Currency) Parse(s string) (int64, error) {
+	return Parse(s, c.Exponent)
+}
+
+func (c Currency) validate() error {
+	if len(c.Code) != 3 || !isLetters(c.Code) {
+		return fmt.Errorf("currency code %q must be 3 upper case letters", c.Code)
+	}
+	if c.Exponent < 0 || c.Exponent > MaxExponent {
+		return fmt.Errorf("currency %s exponent %d is not between 0 and %d", c.Code, c.Exponent, MaxExponent)
+	}
+	if err := c.Rounding.Validate(); err != nil {
+		return fmt.Errorf("currency %s: %w", c.Code, err)
+	}
+	return nil
+}
+
+// Registry holds the supported currencies
+type Registry struct {
+	currencies map[string]Currency
+}
+
+// NewRegistry creates a registry of the given currencies
+func NewRegistry(currencies ...Currency) (*Registry, error) {
+	r := &Registry{currencies: make(map[string]Currency, len(currencies))}
+	for _, currency := range currencies {
+		if currency.Rounding == "" {
+			currency.Rounding = RoundHalfEven
+		}
+		if err := currency.validate(); err != nil {
+			return nil, err
+		}
+		if _, ok := r.currencies[currency.Code]; ok {
+			return nil, fmt.Errorf("currency %s is defined twice", currency.Code)
+		}
+		r.currencies[currency.Code] = currency
+	}
+	if len(r.currencies) == 0 {
+		return nil, fmt.Errorf("no currency is supported")
+	}
+	return r, nil
+}
+
+// LoadRegistry creates the registry of CURRENCIES, or of the currencies table
+// with CURRENCY_SOURCE=postgres
+func LoadRegistry(config util.Config, store db.Store) (*Registry, error) {
+	switch config.CurrencySource {
+	case "", SourceConfig:
+		currencies, err := ParseCurrencies(config.Currencies)
+		if err != nil {
+			return nil, err
+		}
+		return NewRegistry(currencies...)
+	case SourcePostgres:
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		rows, err := store.ListCurrencies(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load currencies: %w", err)
+		}
+		currencies := make([]Currency, len(rows))
+		for i, row := range rows {
+			currencies[i] = Currency{Code: row.Code, Exponent: int(row.Exponent), Symbol: row.Symbol, Rounding: Rounding(row.Rounding)}
+		}
+		return NewRegistry(currencies...)
+	}
+	return nil, fmt.Errorf("unsupported currency source %q", config.CurrencySource)
+}
+
+// ParseCurrencies parses a comma separated list of <code>:<exponent>:<symbol>[:<rounding>],
+// e.g. USD:2:$,JPY:0:¥:down
+func ParseCurrencies(s string) ([]Currency, error) {
+	var currencies []Currency
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		parts := strings.SplitN(field, ":", 4)
+		if len(parts) < 3 {
+			return nil, fmt.Errorf("currency %q is not in the form <code>:<exponent>:<symbol>[:<rounding>]", field)
+		}
+		exponent, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("currency %q has an invalid exponent: %w", field, err)
+		}
+		currency := Currency{Code: parts[0], Exponent: exponent, Symbol: parts[2]}
+		if len(parts) == 4 {
+			currency.Rounding = Rounding(parts[3])
+		}
+		currencies = append(currencies, currency)
+	}
+	return currencies, nil
+}
+
+// Lookup returns the currency of the code
+func (r *Registry) Lookup(code string) (Currency, bool) {
+	currency, ok := r.currencies[code]
+	return currency, ok
+}
+
+// IsSupported reports whether the code is a currency of the registry
+func (r *Registry) IsSupported(code string) bool {
+	_, ok := r.currencies[code]
+	return ok
+}
+
+// Currencies returns the supported currencies ordered by code
+func (r *Registry) Currencies() []Currency {
+	currencies := make([]Currency, 0, len(r.currencies))
+	for _, currency := range r.currencies {
+		currencies = append(currencies, currency)
+	}
+	sort.Slice(currencies, func(i, j int) bool {
+		return currencies[i].Code < currencies[j].Code
+	})
+	return currencies
+}
+
+// Format returns an amount of minor units of the currency as a decimal string,
+// amounts of unknown currencies are formatted as minor units
+func (r *Registry) Format(code string, minor int64) string {
+	currency, ok := r.currencies[code]
+	if !ok {
+		return Format(minor, 0)
+	}
+	return currency.Format(minor)
+}
+
+func isLetters(s string) bool {
+	for _, c := range s {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+var Module = fx.Options(
+	fx.Provide(LoadRegistry),
+)
@@ -0,0 +1,84 @@
+package money
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/hhow09/simple_bank/db/mock"
+	db "github.com/hhow09/simple_bank/db/sqlc"
+	"github.com/hhow09/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCurrencies(t *testing.T) {
+	currencies, err := ParseCurrencies("USD:2:$, JPY:0:¥:down,")
+	require.NoError(t, err)
+	require.Equal(t, []Currency{
+		{Code: "USD", Exponent: 2, Symbol: "$"},
+		{Code: "JPY", Exponent: 0, Symbol: "¥", Rounding: RoundDown},
+	}, currencies)
+
+	for _, s := range []string{"USD", "USD:2", "USD:two:$"} {
+		_, err := ParseCurrencies(s)
+		require.Error(t, err, s)
+	}
+}
+
+func TestNewRegistry(t *testing.T) {
+	registry, err := NewRegistry(
+		Currency{Code: "USD", Exponent: 2, Symbol: "$"},
+		Currency{Code: "JPY", Exponent: 0, Symbol: "¥"},
+	)
+	require.NoError(t, err)
+	require.True(t, registry.IsSupported("JPY"))
+	require.False(t, registry.IsSupported("EUR"))
+	require.Equal(t, []string{"JPY", "USD"}, []string{registry.Currencies()[0].Code, registry.Currencies()[1].Code})
+	require.Equal(t, "12.34", registry.Format("USD", 1234))
+	require.Equal(t, "1234", registry.Format("JPY", 1234))
+	require.Equal(t, "1234", registry.Format("EUR", 1234))
+
+	usd, ok := registry.Lookup("USD")
+	require.True(t, ok)
+	require.Equal(t, "$12.34", usd.Display(1234))
+	require.Equal(t, "-$0.05", usd.Display(-5))
+	require.Equal(t, RoundHalfEven, usd.Rounding)
+
+	for name, currencies := range map[string][]Currency{
+		"Empty":     nil,
+		"Code":      {{Code: "usd", Exponent: 2}},
+		"Exponent":  {{Code: "USD", Exponent: 9}},
+		"Duplicate": {{Code: "USD", Exponent: 2}, {Code: "USD", Exponent: 0}},
+		"Rounding":  {{Code: "USD", Exponent: 2, Rounding: "ceiling"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewRegistry(currencies...)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestLoadRegistry(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	registry, err := LoadRegistry(util.Config{CurrencySource: SourceConfig, Currencies: "EUR:2:€"}, store)
+	require.NoError(t, err)
+	require.True(t, registry.IsSupported("EUR"))
+
+	store.EXPECT().ListCurrencies(gomock.Any()).Times(1).Return([]db.Currency{{Code: "KWD", Exponent: 3, Symbol: "KD", Rounding: "half_up"}}, nil)
+	registry, err = LoadRegistry(util.Config{CurrencySource: SourcePostgres}, store)
+	require.NoError(t, err)
+	kwd, ok := registry.Lookup("KWD")
+	require.True(t, ok)
+	require.Equal(t, "1.500", kwd.Format(1500))
+	require.Equal(t, RoundHalfUp, kwd.Rounding)
+
+	store.EXPECT().ListCurrencies(gomock.Any()).Times(1).Return(nil, errors.New("connection refused"))
+	_, err = LoadRegistry(util.Config{CurrencySource: SourcePostgres}, store)
+	require.Error(t, err)
+
+	_, err = LoadRegistry(util.Config{CurrencySource: "redis"}, store)
+	require.Error(t, err)
+}
@@ -0,0 +1,149 @@
+// Package money handles amounts in the minor unit of their currency,
+// e.g. cents: decimal strings of API payloads are parsed to and formatted
+// from minor units with the exponent of the currency, and arithmetic on
+// amounts fails instead of overflowing.
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrOverflow = errors.New("amount overflows")
+	ErrSyntax   = errors.New("amount is not a decimal number")
+	ErrDecimals = errors.New("amount has more decimals than the currency")
+)
+
+// Add returns a + b
+func Add(a, b int64) (int64, error) {
+	if (b > 0 && a > math.MaxInt64-b) || (b < 0 && a < math.MinInt64-b) {
+		return 0, ErrOverflow
+	}
+	return a + b, nil
+}
+
+// Sub returns a - b
+func Sub(a, b int64) (int64, error) {
+	if (b < 0 && a > math.MaxInt64+b) || (b > 0 && a < math.MinInt64+b) {
+		return 0, ErrOverflow
+	}
+	return a - b, nil
+}
+
+// Mul returns a * b
+func Mul(a, b int64) (int64, error) {
+	if a == 0 || b == 0 {
+		return 0, nil
+	}
+	c := a * b
+	if (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) || c/b != a {
+		return 0, ErrOverflow
+	}
+	return c, nil
+}
+
+// Parse converts a decimal string of major units such as "12.34" to minor units.
+// At most exponent decimals are accepted, no exponents or digit separators.
+func Parse(s string, exponent int) (int64, error) {
+	negative := strings.HasPrefix(s, "-")
+	digits := strings.TrimPrefix(s, "-")
+	whole, fraction, hasPoint := strings.Cut(digits, ".")
+	if whole == "" || !isDigits(whole) || (hasPoint && (fraction == "" || !isDigits(fraction))) {
+		return 0, ErrSyntax
+	}
+	if len(fraction) > exponent {
+		return 0, ErrDecimals
+	}
+
+	// the digits are parsed as one integer of minor units
+	minor, err := strconv.ParseInt(whole+fraction+strings.Repeat("0", exponent-len(fraction)), 10, 64)
+	if err != nil {
+		return 0, ErrOverflow
+	}
+	if negative {
+		minor = -minor
+	}
+	return minor, nil
+}
+
+// Format returns the minor units as a decimal string of major units, e.g. 1234 as "12.34"
+func Format(minor int64, exponent int) string {
+	s := strconv.FormatUint(abs(minor), 10)
+	if exponent > 0 {
+		if len(s) <= exponent {
+			s = strings.Repeat("0", exponent-len(s)+1) + s
+		}
+		s = s[:len(s)-exponent] + "." + s[len(s)-exponent:]
+	}
+	if minor < 0 {
+		s = "-" + s
+	}
+	return s
+}
+
+// abs returns the absolute value, math.MinInt64 included
+func abs(n int64) uint64 {
+	if n < 0 {
+		return uint64(-(n + 1)) + 1
+	}
+	return uint64(n)
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Amount is an amount of an API payload, either a JSON integer of minor units
+// such as 1234 or a JSON string of major units such as "12.34".
+// The currency of the payload decides what a decimal string is worth.
+type Amount struct {
+	minor   int64
+	decimal string
+}
+
+// MinorAmount returns an amount of minor units
+func MinorAmount(minor int64) Amount {
+	return Amount{minor: minor}
+}
+
+// DecimalAmount returns an amount of major units
+func DecimalAmount(decimal string) Amount {
+	return Amount{decimal: decimal}
+}
+
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		a.minor = 0
+		return json.Unmarshal(data, &a.decimal)
+	}
+	a.decimal = ""
+	if err := json.Unmarshal(data, &a.minor); err != nil {
+		return fmt.Errorf("amount must be an integer of minor units or a decimal string: %w", err)
+	}
+	return nil
+}
+
+func (a Amount) MarshalJSON() ([]byte, error) {
+	if a.decimal != "" {
+		return json.Marshal(a.decimal)
+	}
+	return json.Marshal(a.minor)
+}
+
+// Minor returns the amount in minor units of the currency
+func (a Amount) Minor(currency Currency) (int64, error) {
+	if a.decimal != "" {
+		return Parse(a.decimal, currency.Exponent)
+	}
+	return a.minor, nil
+}
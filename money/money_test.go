@@ -0,0 +1,123 @@
+package money
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestArithmetic(t *testing.T) {
+	sum, err := Add(40, 2)
+	require.NoError(t, err)
+	require.Equal(t, int64(42), sum)
+	diff, err := Sub(40, 42)
+	require.NoError(t, err)
+	require.Equal(t, int64(-2), diff)
+	product, err := Mul(-6, 7)
+	require.NoError(t, err)
+	require.Equal(t, int64(-42), product)
+
+	for name, fn := range map[string]func() (int64, error){
+		"AddMax":    func() (int64, error) { return Add(math.MaxInt64, 1) },
+		"AddMin":    func() (int64, error) { return Add(math.MinInt64, -1) },
+		"SubMax":    func() (int64, error) { return Sub(math.MaxInt64, -1) },
+		"SubMin":    func() (int64, error) { return Sub(math.MinInt64, 1) },
+		"Mul":       func() (int64, error) { return Mul(math.MaxInt64/2, 3) },
+		"MulMinNeg": func() (int64, error) { return Mul(math.MinInt64, -1) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := fn()
+			require.ErrorIs(t, err, ErrOverflow)
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		s        string
+		exponent int
+		minor    int64
+		err      error
+	}{
+		{"12.34", 2, 1234, nil},
+		{"12.3", 2, 1230, nil},
+		{"12", 2, 1200, nil},
+		{"0.01", 2, 1, nil},
+		{"-0.5", 2, -50, nil},
+		{"1500", 0, 1500, nil},
+		{"1.234", 3, 1234, nil},
+		{"12.345", 2, 0, ErrDecimals},
+		{"12.5", 0, 0, ErrDecimals},
+		{"", 2, 0, ErrSyntax},
+		{"12.", 2, 0, ErrSyntax},
+		{".5", 2, 0, ErrSyntax},
+		{"1,000.00", 2, 0, ErrSyntax},
+		{"1e3", 2, 0, ErrSyntax},
+		{"+1", 2, 0, ErrSyntax},
+		{"92233720368547758.08", 2, 0, ErrOverflow},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.s, func(t *testing.T) {
+			minor, err := Parse(tc.s, tc.exponent)
+			require.ErrorIs(t, err, tc.err)
+			require.Equal(t, tc.minor, minor)
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	testCases := []struct {
+		minor    int64
+		exponent int
+		s        string
+	}{
+		{1234, 2, "12.34"},
+		{5, 2, "0.05"},
+		{0, 2, "0.00"},
+		{-1234, 2, "-12.34"},
+		{-5, 3, "-0.005"},
+		{1500, 0, "1500"},
+		{math.MinInt64, 2, "-92233720368547758.08"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.s, func(t *testing.T) {
+			require.Equal(t, tc.s, Format(tc.minor, tc.exponent))
+			if tc.minor != math.MinInt64 {
+				minor, err := Parse(tc.s, tc.exponent)
+				require.NoError(t, err)
+				require.Equal(t, tc.minor, minor)
+			}
+		})
+	}
+}
+
+func TestAmountJSON(t *testing.T) {
+	usd := Currency{Code: "USD", Exponent: 2, Symbol: "$"}
+
+	var amount Amount
+	require.NoError(t, json.Unmarshal([]byte(`1234`), &amount))
+	minor, err := amount.Minor(usd)
+	require.NoError(t, err)
+	require.Equal(t, int64(1234), minor)
+
+	require.NoError(t, json.Unmarshal([]byte(`"12.34"`), &amount))
+	minor, err = amount.Minor(usd)
+	require.NoError(t, err)
+	require.Equal(t, int64(1234), minor)
+
+	require.NoError(t, json.Unmarshal([]byte(`"12.345"`), &amount))
+	_, err = amount.Minor(usd)
+	require.ErrorIs(t, err, ErrDecimals)
+
+	// minor units are integers
+	require.Error(t, json.Unmarshal([]byte(`12.34`), &amount))
+
+	data, err := json.Marshal(DecimalAmount("12.34"))
+	require.NoError(t, err)
+	require.JSONEq(t, `"12.34"`, string(data))
+	data, err = json.Marshal(MinorAmount(1234))
+	require.NoError(t, err)
+	require.JSONEq(t, `1234`, string(data))
+}
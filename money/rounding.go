@@ -0,0 +1,58 @@
+package money
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// Rounding is how a fractional amount of minor units is rounded to a whole one
+type Rounding string
+
+const (
+	// RoundHalfEven rounds to the nearest minor unit and halves to the even one, the default
+	RoundHalfEven Rounding = "half_even"
+	// RoundHalfUp rounds to the nearest minor unit and halves away from zero
+	RoundHalfUp Rounding = "half_up"
+	// RoundDown rounds towards zero
+	RoundDown Rounding = "down"
+	// RoundUp rounds away from zero
+	RoundUp Rounding = "up"
+)
+
+// Validate returns an error for unknown rounding rules
+func (r Rounding) Validate() error {
+	switch r {
+	case RoundHalfEven, RoundHalfUp, RoundDown, RoundUp:
+		return nil
+	}
+	return fmt.Errorf("rounding %q is not one of half_even, half_up, down or up", r)
+}
+
+// Round rounds an amount of minor units to a whole one.
+// It returns ErrOverflow when the result doesn't fit in int64.
+func (r Rounding) Round(x *big.Rat) (int64, error) {
+	quo, rem := new(big.Int).QuoRem(x.Num(), x.Denom(), new(big.Int))
+	if rem.Sign() != 0 {
+		// quo is truncated towards zero, away moves it one unit away from zero
+		away := false
+		switch r {
+		case RoundUp:
+			away = true
+		case RoundHalfUp, RoundHalfEven, "":
+			twice := new(big.Int).Abs(rem)
+			switch twice.Lsh(twice, 1).Cmp(x.Denom()) {
+			case 1:
+				away = true
+			case 0:
+				away = r == RoundHalfUp || quo.Bit(0) == 1
+			}
+		}
+		if away {
+			quo.Add(quo, big.NewInt(int64(x.Sign())))
+		}
+	}
+	if !quo.IsInt64() {
+		return 0, ErrOverflow
+	}
+	return quo.Int64(), nil
+}
@@ -0,0 +1,52 @@
+package money
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRound(t *testing.T) {
+	testCases := []struct {
+		x        *big.Rat
+		halfEven int64
+		halfUp   int64
+		down     int64
+		up       int64
+	}{
+		{big.NewRat(5, 2), 2, 3, 2, 3},
+		{big.NewRat(7, 2), 4, 4, 3, 4},
+		{big.NewRat(-5, 2), -2, -3, -2, -3},
+		{big.NewRat(-7, 2), -4, -4, -3, -4},
+		{big.NewRat(2_400_001, 1_000_000), 2, 2, 2, 3},
+		{big.NewRat(2_600_000, 1_000_000), 3, 3, 2, 3},
+		{big.NewRat(-2_600_000, 1_000_000), -3, -3, -2, -3},
+		{big.NewRat(3, 1), 3, 3, 3, 3},
+		{big.NewRat(0, 1), 0, 0, 0, 0},
+	}
+	for _, tc := range testCases {
+		for rounding, expected := range map[Rounding]int64{
+			RoundHalfEven: tc.halfEven,
+			RoundHalfUp:   tc.halfUp,
+			RoundDown:     tc.down,
+			RoundUp:       tc.up,
+		} {
+			actual, err := rounding.Round(tc.x)
+			require.NoError(t, err)
+			require.Equal(t, expected, actual, "%s %s", rounding, tc.x)
+		}
+	}
+
+	// without a rounding amounts are rounded half to even
+	actual, err := Rounding("").Round(big.NewRat(5, 2))
+	require.NoError(t, err)
+	require.Equal(t, int64(2), actual)
+
+	_, err = RoundUp.Round(new(big.Rat).SetFrac(new(big.Int).Add(big.NewInt(math.MaxInt64), big.NewInt(1)), big.NewInt(1)))
+	require.ErrorIs(t, err, ErrOverflow)
+
+	require.NoError(t, RoundDown.Validate())
+	require.Error(t, Rounding("ceiling").Validate())
+}
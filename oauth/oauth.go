@@ -0,0 +1,98 @@
+// Package oauth implements the protocol helpers of the OAuth 2.0 server:
+// scope strings (RFC 6749) and proof key for code exchange (RFC 7636).
+package oauth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"strings"
+)
+
+const (
+	GrantTypeAuthorizationCode = "authorization_code"
+	GrantTypeClientCredentials = "client_credentials"
+	ResponseTypeCode           = "code"
+	TokenTypeBearer            = "Bearer"
+	// CodeChallengeMethodS256 is the only supported PKCE method, plain is not accepted
+	CodeChallengeMethodS256 = "S256"
+)
+
+// ParseScope splits a space-delimited scope string, dropping duplicates
+func ParseScope(scope string) []string {
+	scopes := []string{}
+	for _, s := range strings.Fields(scope) {
+		if !Contains(scopes, s) {
+			scopes = append(scopes, s)
+		}
+	}
+	return scopes
+}
+
+// FormatScope joins scopes into a space-delimited scope string
+func FormatScope(scopes []string) string {
+	return strings.Join(scopes, " ")
+}
+
+// Contains reports whether scope is one of scopes
+func Contains(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Subset reports whether every scope of requested is one of allowed
+func Subset(requested []string, allowed []string) bool {
+	for _, s := range requested {
+		if !Contains(allowed, s) {
+			return false
+		}
+	}
+	return true
+}
+
+// Union returns the scopes of a followed by those of b which are not in a
+func Union(a []string, b []string) []string {
+	scopes := append([]string{}, a...)
+	for _, s := range b {
+		if !Contains(scopes, s) {
+			scopes = append(scopes, s)
+		}
+	}
+	return scopes
+}
+
+// CodeChallenge derives the S256 code challenge of a code verifier
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// ValidVerifier reports whether a code verifier has the length and
+// characters required by RFC 7636
+func ValidVerifier(verifier string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	for _, c := range verifier {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '.', c == '_', c == '~':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// VerifyCodeChallenge checks a code verifier against the S256 challenge
+// sent with the authorization request
+func VerifyCodeChallenge(verifier string, challenge string) bool {
+	if !ValidVerifier(verifier) {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(CodeChallenge(verifier)), []byte(challenge)) == 1
+}
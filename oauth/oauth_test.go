@@ -0,0 +1,44 @@
+package oauth
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseScope(t *testing.T) {
+	require.Equal(t, []string{}, ParseScope(""))
+	require.Equal(t, []string{"a", "b"}, ParseScope(" a  b a "))
+	require.Equal(t, "a b", FormatScope(ParseScope("a b")))
+}
+
+func TestSubset(t *testing.T) {
+	require.True(t, Subset([]string{}, []string{"a"}))
+	require.True(t, Subset([]string{"a"}, []string{"a", "b"}))
+	require.False(t, Subset([]string{"a", "c"}, []string{"a", "b"}))
+}
+
+func TestUnion(t *testing.T) {
+	a := []string{"a"}
+	require.Equal(t, []string{"a", "b"}, Union(a, []string{"b", "a"}))
+	require.Equal(t, []string{"a"}, a)
+}
+
+func TestCodeChallenge(t *testing.T) {
+	// example of RFC 7636 appendix B
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	challenge := "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+
+	require.Equal(t, challenge, CodeChallenge(verifier))
+	require.True(t, VerifyCodeChallenge(verifier, challenge))
+	require.False(t, VerifyCodeChallenge(verifier, CodeChallenge("x"+verifier[1:])))
+}
+
+func TestValidVerifier(t *testing.T) {
+	require.True(t, ValidVerifier(strings.Repeat("a", 43)))
+	require.True(t, ValidVerifier(strings.Repeat("-._~", 32)))
+	require.False(t, ValidVerifier(strings.Repeat("a", 42)))
+	require.False(t, ValidVerifier(strings.Repeat("a", 129)))
+	require.False(t, ValidVerifier(strings.Repeat("a", 42)+"+"))
+}
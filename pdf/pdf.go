@@ -0,0 +1,183 @@
+// Package pdf writes simple PDF 1.4 documents with text and lines in the standard Helvetica fonts,
+// which every reader has so no font is embedded.
+package pdf
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// A4 page size in points
+const (
+	A4Width  = 595
+	A4Height = 842
+)
+
+// Font is one of the standard fonts
+type Font int
+
+const (
+	Helvetica Font = iota
+	HelveticaBold
+)
+
+var fontNames = []string{"Helvetica", "Helvetica-Bold"}
+
+// Document is a PDF document, pages are kept in memory until it is written
+type Document struct {
+	width  float64
+	height float64
+	pages  []*Page
+	title  string
+}
+
+// New creates an empty document with pages of the size in points
+func New(width, height float64) *Document {
+	return &Document{width: width, height: height}
+}
+
+// SetTitle sets the title shown by readers
+func (d *Document) SetTitle(title string) {
+	d.title = title
+}
+
+// AddPage adds a page at the end of the document
+func (d *Document) AddPage() *Page {
+	page := &Page{}
+	d.pages = append(d.pages, page)
+	return page
+}
+
+// Pages returns the pages of the document
+func (d *Document) Pages() []*Page {
+	return d.pages
+}
+
+// Page is a page of the document, the origin is its bottom left corner
+type Page struct {
+	content bytes.Buffer
+}
+
+// Text draws s with its baseline starting at x, y
+func (p *Page) Text(x, y float64, font Font, size float64, s string) {
+	fmt.Fprintf(&p.content, "BT /F%d %s Tf %s %s Td %s Tj ET\n", font+1, number(size), number(x), number(y), literal(s))
+}
+
+// TextRight draws s with its baseline ending at x, y
+func (p *Page) TextRight(x, y float64, font Font, size float64, s string) {
+	p.Text(x-TextWidth(font, size, s), y, font, size, s)
+}
+
+// Line draws a line of the width from x1, y1 to x2, y2
+func (p *Page) Line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(&p.content, "%s w %s %s m %s %s l S\n", number(width), number(x1), number(y1), number(x2), number(y2))
+}
+
+// Write writes the document to w
+func (d *Document) Write(w io.Writer) error {
+	out := &writer{w: bufio.NewWriter(w)}
+	out.printf("%%PDF-1.4\n%%\xe2\xe3\xcf\xd3\n")
+
+	// objects: catalog, pages, fonts, info, then a page and its content per page
+	fontID := 3
+	infoID := fontID + len(fontNames)
+	firstPageID := infoID + 1
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPageID+2*i)
+	}
+
+	out.object(1, "<< /Type /Catalog /Pages 2 0 R >>")
+	out.object(2, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", join(kids), len(d.pages)))
+	fonts := ""
+	for i, name := range fontNames {
+		out.object(fontID+i, fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", name))
+		fonts += fmt.Sprintf(" /F%d %d 0 R", i+1, fontID+i)
+	}
+	out.object(infoID, fmt.Sprintf("<< /Title %s /Producer (simple_bank) >>", literal(d.title)))
+	for i, page := range d.pages {
+		pageID := firstPageID + 2*i
+		out.object(pageID, fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font <<%s >> >> /Contents %d 0 R >>",
+			number(d.width), number(d.height), fonts, pageID+1))
+		out.stream(pageID+1, page.content.Bytes())
+	}
+
+	xref := out.offset
+	out.printf("xref\n0 %d\n0000000000 65535 f \n", len(out.offsets)+1)
+	for _, offset := range out.offsets {
+		out.printf("%010d 00000 n \n", offset)
+	}
+	out.printf("trailer\n<< /Size %d /Root 1 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(out.offsets)+1, infoID, xref)
+	if out.err != nil {
+		return out.err
+	}
+	return out.w.Flush()
+}
+
+// writer keeps the offsets of the objects for the cross-reference table
+type writer struct {
+	w       *bufio.Writer
+	offset  int
+	offsets []int
+	err     error
+}
+
+func (w *writer) printf(format string, args ...interface{}) {
+	if w.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(w.w, format, args...)
+	w.offset += n
+	w.err = err
+}
+
+// object writes the object id, objects must be written in the order of their ids
+func (w *writer) object(id int, body string) {
+	w.offsets = append(w.offsets, w.offset)
+	w.printf("%d 0 obj\n%s\nendobj\n", id, body)
+}
+
+func (w *writer) stream(id int, data []byte) {
+	w.offsets = append(w.offsets, w.offset)
+	w.printf("%d 0 obj\n<< /Length %d >>\nstream\n%s\nendstream\nendobj\n", id, len(data), data)
+}
+
+func join(items []string) string {
+	var buf bytes.Buffer
+	for i, item := range items {
+		if i > 0 {
+			buf.WriteByte(' ')
+		}
+		buf.WriteString(item)
+	}
+	return buf.String()
+}
+
+func number(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// literal returns s as a PDF string in WinAnsiEncoding,
+// characters outside of Latin-1 are replaced by a question mark
+func literal(s string) string {
+	var buf bytes.Buffer
+	buf.WriteByte('(')
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			buf.WriteByte('\\')
+			buf.WriteRune(r)
+		case r >= 32 && r < 127:
+			buf.WriteRune(r)
+		case r >= 160 && r <= 255:
+			fmt.Fprintf(&buf, "\\%03o", r)
+		default:
+			buf.WriteByte('?')
+		}
+	}
+	buf.WriteByte(')')
+	return buf.String()
+}
@@ -0,0 +1,59 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrite(t *testing.T) {
+	doc := New(A4Width, A4Height)
+	doc.SetTitle("Statement")
+	first := doc.AddPage()
+	first.Text(50, 800, HelveticaBold, 18, "Account (statement)")
+	first.Line(50, 790, 545, 790, 0.5)
+	second := doc.AddPage()
+	second.TextRight(545, 800, Helvetica, 10, "12.34")
+
+	var buf bytes.Buffer
+	require.NoError(t, doc.Write(&buf))
+	out := buf.String()
+
+	require.True(t, strings.HasPrefix(out, "%PDF-1.4\n"))
+	require.True(t, strings.HasSuffix(out, "%%EOF\n"))
+	require.Contains(t, out, "/Count 2")
+	require.Contains(t, out, "/BaseFont /Helvetica-Bold")
+	require.Contains(t, out, `BT /F2 18 Tf 50 800 Td (Account \(statement\)) Tj ET`)
+	require.Contains(t, out, "0.5 w 50 790 m 545 790 l S")
+	require.Contains(t, out, "BT /F1 10 Tf 519.98 800 Td (12.34) Tj ET")
+
+	// the cross-reference table points at the objects
+	xref := regexp.MustCompile(`startxref\n(\d+)\n`).FindStringSubmatch(out)
+	require.Len(t, xref, 2)
+	offset, err := strconv.Atoi(xref[1])
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(out[offset:], "xref\n0 10\n"))
+	entries := strings.Split(out[offset:], "\n")[3:12]
+	for i, entry := range entries {
+		objectOffset, err := strconv.Atoi(entry[:10])
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(out[objectOffset:], fmt.Sprintf("%d 0 obj\n", i+1)))
+	}
+}
+
+func TestLiteral(t *testing.T) {
+	require.Equal(t, `(a\\b)`, literal(`a\b`))
+	require.Equal(t, `(caf\351)`, literal("café"))
+	require.Equal(t, "(?)", literal("€"))
+}
+
+func TestTextWidth(t *testing.T) {
+	require.InDelta(t, 25.02, TextWidth(Helvetica, 10, "12.34"), 1e-9)
+	require.Equal(t, TextWidth(Helvetica, 10, "1"), TextWidth(HelveticaBold, 10, "1"))
+	require.Greater(t, TextWidth(HelveticaBold, 10, "m"), TextWidth(Helvetica, 10, "m"))
+}
@@ -0,0 +1,72 @@
+package ratelimit
+
+import (
+	"container/list"
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// defaultMaxBuckets is the number of buckets kept in memory, a client IP or user takes one bucket per route
+const defaultMaxBuckets = 100_000
+
+type bucket struct {
+	key       string
+	tokens    float64
+	updatedAt time.Time
+}
+
+// MemoryBackend keeps buckets in process memory.
+// It is only suitable for a single replica.
+// At most maxBuckets are kept, the least recently used bucket is evicted for a new one.
+// The evicted bucket is usually full again, otherwise its client gets a full bucket early.
+type MemoryBackend struct {
+	mu         sync.Mutex
+	maxBuckets int
+	// recent orders the buckets from the most to the least recently used
+	recent  *list.List
+	buckets map[string]*list.Element
+	now     func() time.Time
+}
+
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{
+		maxBuckets: defaultMaxBuckets,
+		recent:     list.New(),
+		buckets:    make(map[string]*list.Element),
+		now:        time.Now,
+	}
+}
+
+func (m *MemoryBackend) Take(ctx context.Context, key string, policy Policy) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	elem, ok := m.buckets[key]
+	if ok {
+		m.recent.MoveToFront(elem)
+	} else {
+		if m.recent.Len() >= m.maxBuckets {
+			oldest := m.recent.Back()
+			m.recent.Remove(oldest)
+			delete(m.buckets, oldest.Value.(*bucket).key)
+		}
+		elem = m.recent.PushFront(&bucket{key: key, tokens: float64(policy.Limit), updatedAt: now})
+		m.buckets[key] = elem
+	}
+	b := elem.Value.(*bucket)
+	b.tokens = refill(b.tokens, now.Sub(b.updatedAt), policy)
+	b.updatedAt = now
+
+	if b.tokens < 1 {
+		return Result{Allowed: false, RetryAfter: policy.retryAfter(b.tokens)}, nil
+	}
+	b.tokens--
+	return Result{Allowed: true, Remaining: int(b.tokens)}, nil
+}
+
+func refill(tokens float64, elapsed time.Duration, policy Policy) float64 {
+	return math.Min(float64(policy.Limit), tokens+elapsed.Seconds()*policy.refillRate())
+}
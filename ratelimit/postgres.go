@@ -0,0 +1,32 @@
+package ratelimit
+
+import (
+	"context"
+
+	db "github.com/hhow09/simple_bank/db/sqlc"
+)
+
+// PostgresBackend keeps buckets in the rate_limit_buckets table,
+// so that limits are shared by all replicas.
+type PostgresBackend struct {
+	store db.Store
+}
+
+func NewPostgresBackend(store db.Store) *PostgresBackend {
+	return &PostgresBackend{store: store}
+}
+
+func (p *PostgresBackend) Take(ctx context.Context, key string, policy Policy) (Result, error) {
+	row, err := p.store.TakeRateLimitToken(ctx, db.TakeRateLimitTokenParams{
+		Key:        key,
+		Capacity:   float64(policy.Limit),
+		RefillRate: policy.refillRate(),
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	if !row.Allowed {
+		return Result{Allowed: false, RetryAfter: policy.retryAfter(row.Tokens)}, nil
+	}
+	return Result{Allowed: true, Remaining: int(row.Tokens)}, nil
+}
@@ -0,0 +1,103 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	db "github.com/hhow09/simple_bank/db/sqlc"
+	"github.com/hhow09/simple_bank/util"
+	"go.uber.org/fx"
+)
+
+const (
+	BackendMemory   = "memory"
+	BackendPostgres = "postgres"
+)
+
+// Policy is a token bucket which holds up to Limit tokens
+// and is refilled with Limit tokens every Period.
+type Policy struct {
+	Limit  int
+	Period time.Duration
+}
+
+// ParsePolicy parses a policy in the form of "<limit>/<period>", e.g. "5/1m".
+// An empty string returns a disabled policy.
+func ParsePolicy(s string) (Policy, error) {
+	if strings.TrimSpace(s) == "" {
+		return Policy{}, nil
+	}
+	parts := strings.SplitN(s, "/", 2)
+	if len(parts) != 2 {
+		return Policy{}, fmt.Errorf("invalid rate limit policy %q: must be <limit>/<period>", s)
+	}
+	limit, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil || limit <= 0 {
+		return Policy{}, fmt.Errorf("invalid rate limit policy %q: limit must be a positive integer", s)
+	}
+	period, err := time.ParseDuration(strings.TrimSpace(parts[1]))
+	if err != nil || period <= 0 {
+		return Policy{}, fmt.Errorf("invalid rate limit policy %q: period must be a positive duration", s)
+	}
+	return Policy{Limit: limit, Period: period}, nil
+}
+
+// Enabled reports whether requests should be limited by the policy
+func (p Policy) Enabled() bool {
+	return p.Limit > 0 && p.Period > 0
+}
+
+// refillRate returns the number of tokens added per second
+func (p Policy) refillRate() float64 {
+	return float64(p.Limit) / p.Period.Seconds()
+}
+
+// retryAfter returns how long it takes until the bucket holds one token again
+func (p Policy) retryAfter(tokens float64) time.Duration {
+	if tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - tokens) / p.refillRate() * float64(time.Second))
+}
+
+// IdleAfter returns how long after its last request a bucket of the configured policies is full again,
+// it can be deleted then. Invalid policies are ignored, they fail the rate limit middleware.
+func IdleAfter(config util.Config) time.Duration {
+	var idle time.Duration
+	for _, s := range []string{config.RateLimitLogin, config.RateLimitTransfer} {
+		if policy, err := ParsePolicy(s); err == nil && policy.Period > idle {
+			idle = policy.Period
+		}
+	}
+	return idle
+}
+
+// Result is the outcome of taking a token from a bucket
+type Result struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+// Backend stores token buckets
+type Backend interface {
+	Take(ctx context.Context, key string, policy Policy) (Result, error)
+}
+
+// NewBackend creates the backend selected by RATE_LIMIT_BACKEND
+func NewBackend(config util.Config, store db.Store) (Backend, error) {
+	switch config.RateLimitBackend {
+	case "", BackendMemory:
+		return NewMemoryBackend(), nil
+	case BackendPostgres:
+		return NewPostgresBackend(store), nil
+	}
+	return nil, fmt.Errorf("unsupported rate limit backend %q", config.RateLimitBackend)
+}
+
+var Module = fx.Options(
+	fx.Provide(NewBackend),
+)
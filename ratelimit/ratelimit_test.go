@@ -0,0 +1,111 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hhow09/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePolicy(t *testing.T) {
+	policy, err := ParsePolicy("5/1m")
+	require.NoError(t, err)
+	require.Equal(t, Policy{Limit: 5, Period: time.Minute}, policy)
+	require.True(t, policy.Enabled())
+
+	policy, err = ParsePolicy("")
+	require.NoError(t, err)
+	require.False(t, policy.Enabled())
+
+	for _, invalid := range []string{"5", "0/1m", "-1/1m", "five/1m", "5/0s", "5/minute"} {
+		_, err = ParsePolicy(invalid)
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestMemoryBackend(t *testing.T) {
+	now := time.Now()
+	backend := NewMemoryBackend()
+	backend.now = func() time.Time { return now }
+
+	policy := Policy{Limit: 3, Period: 3 * time.Second}
+	ctx := context.Background()
+
+	for i := 2; i >= 0; i-- {
+		result, err := backend.Take(ctx, "key", policy)
+		require.NoError(t, err)
+		require.True(t, result.Allowed)
+		require.Equal(t, i, result.Remaining)
+	}
+
+	result, err := backend.Take(ctx, "key", policy)
+	require.NoError(t, err)
+	require.False(t, result.Allowed)
+	require.Equal(t, time.Second, result.RetryAfter)
+
+	// other keys have their own bucket
+	result, err = backend.Take(ctx, "other", policy)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+
+	// one token is refilled every second
+	now = now.Add(time.Second)
+	result, err = backend.Take(ctx, "key", policy)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+
+	result, err = backend.Take(ctx, "key", policy)
+	require.NoError(t, err)
+	require.False(t, result.Allowed)
+
+	// the bucket never holds more than the limit
+	now = now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		result, err = backend.Take(ctx, "key", policy)
+		require.NoError(t, err)
+		require.True(t, result.Allowed)
+	}
+	result, err = backend.Take(ctx, "key", policy)
+	require.NoError(t, err)
+	require.False(t, result.Allowed)
+}
+
+func TestMemoryBackendMaxBuckets(t *testing.T) {
+	backend := NewMemoryBackend()
+	backend.maxBuckets = 2
+
+	policy := Policy{Limit: 1, Period: time.Hour}
+	ctx := context.Background()
+
+	for _, key := range []string{"a", "b"} {
+		result, err := backend.Take(ctx, key, policy)
+		require.NoError(t, err)
+		require.True(t, result.Allowed)
+	}
+	// a is used more recently than b
+	result, err := backend.Take(ctx, "a", policy)
+	require.NoError(t, err)
+	require.False(t, result.Allowed)
+
+	// the least recently used bucket is evicted for a new one
+	result, err = backend.Take(ctx, "c", policy)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+	require.Len(t, backend.buckets, 2)
+	require.Equal(t, 2, backend.recent.Len())
+
+	result, err = backend.Take(ctx, "a", policy)
+	require.NoError(t, err)
+	require.False(t, result.Allowed)
+	result, err = backend.Take(ctx, "b", policy)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+}
+
+func TestIdleAfter(t *testing.T) {
+	require.Equal(t, 10*time.Minute, IdleAfter(util.Config{RateLimitLogin: "5/1m", RateLimitTransfer: "30/10m"}))
+	require.Equal(t, time.Minute, IdleAfter(util.Config{RateLimitLogin: "5/1m", RateLimitTransfer: "invalid"}))
+	require.Zero(t, IdleAfter(util.Config{}))
+}
@@ -0,0 +1,77 @@
+package reconcile
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/hhow09/simple_bank/constants"
+	db "github.com/hhow09/simple_bank/db/sqlc"
+)
+
+// MetricsContentType is the content type of the Prometheus text format
+const MetricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// discrepancyKinds are always exported, so that alerts see a 0 instead of a missing series
+var discrepancyKinds = []string{
+	constants.DiscrepancyAccountBalance,
+	constants.DiscrepancyLedgerAccountBalance,
+	constants.DiscrepancyTransferEntries,
+}
+
+// WriteMetrics writes the results of the last finished run and the number of frozen accounts
+// in the Prometheus text format. They are read from the database, so every replica reports
+// the same values whichever replica ran the reconciliation.
+func WriteMetrics(ctx context.Context, w io.Writer, store db.Store) error {
+	frozen, err := store.CountFrozenAccounts(ctx)
+	if err != nil {
+		return err
+	}
+	writeMetric(w, "reconciliation_frozen_accounts", "Number of frozen accounts.", frozen)
+
+	run, err := store.GetLatestReconciliationRun(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	counts, err := store.CountReconciliationDiscrepancies(ctx, run.ID)
+	if err != nil {
+		return err
+	}
+	byKind := map[string]int64{}
+	for _, count := range counts {
+		byKind[count.Kind] = count.Count
+	}
+
+	var success int64
+	if run.Status == constants.ReconciliationCompleted {
+		success = 1
+	}
+	writeMetric(w, "reconciliation_last_run_success", "Whether the last reconciliation run completed.", success)
+	writeMetric(w, "reconciliation_last_run_timestamp_seconds", "Start time of the last reconciliation run.", run.StartedAt.Unix())
+	writeMetric(w, "reconciliation_last_run_duration_seconds", "Duration of the last reconciliation run.", run.FinishedAt.Time.Sub(run.StartedAt).Seconds())
+
+	fmt.Fprintln(w, "# HELP reconciliation_last_run_checked Rows checked by the last reconciliation run.")
+	fmt.Fprintln(w, "# TYPE reconciliation_last_run_checked gauge")
+	fmt.Fprintf(w, "reconciliation_last_run_checked{type=\"accounts\"} %d\n", run.AccountsChecked)
+	fmt.Fprintf(w, "reconciliation_last_run_checked{type=\"ledger_accounts\"} %d\n", run.LedgerAccountsChecked)
+	fmt.Fprintf(w, "reconciliation_last_run_checked{type=\"transfers\"} %d\n", run.TransfersChecked)
+
+	fmt.Fprintln(w, "# HELP reconciliation_last_run_discrepancies Discrepancies found by the last reconciliation run.")
+	fmt.Fprintln(w, "# TYPE reconciliation_last_run_discrepancies gauge")
+	for _, kind := range discrepancyKinds {
+		fmt.Fprintf(w, "reconciliation_last_run_discrepancies{kind=%q} %d\n", kind, byKind[kind])
+	}
+	return nil
+}
+
+// writeMetric writes a gauge without labels
+func writeMetric(w io.Writer, name, help string, value interface{}) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s gauge\n", name)
+	fmt.Fprintf(w, "%s %v\n", name, value)
+}
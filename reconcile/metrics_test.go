@@ -0,0 +1,64 @@
+package reconcile
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/hhow09/simple_bank/constants"
+	mockdb "github.com/hhow09/simple_bank/db/mock"
+	db "github.com/hhow09/simple_bank/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	startedAt := time.Unix(1700000000, 0)
+	run := db.ReconciliationRun{
+		ID:                    4,
+		Status:                constants.ReconciliationCompleted,
+		AccountsChecked:       10,
+		TransfersChecked:      20,
+		LedgerAccountsChecked: 3,
+		Discrepancies:         2,
+		StartedAt:             startedAt,
+		FinishedAt:            sql.NullTime{Time: startedAt.Add(1500 * time.Millisecond), Valid: true},
+	}
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().CountFrozenAccounts(gomock.Any()).Times(1).Return(int64(1), nil)
+	store.EXPECT().GetLatestReconciliationRun(gomock.Any()).Times(1).Return(run, nil)
+	store.EXPECT().CountReconciliationDiscrepancies(gomock.Any(), run.ID).Times(1).Return([]db.CountReconciliationDiscrepanciesRow{
+		{Kind: constants.DiscrepancyAccountBalance, Count: 2},
+	}, nil)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteMetrics(context.Background(), &buf, store))
+	metrics := buf.String()
+	require.Contains(t, metrics, "# TYPE reconciliation_frozen_accounts gauge\nreconciliation_frozen_accounts 1\n")
+	require.Contains(t, metrics, "reconciliation_last_run_success 1\n")
+	require.Contains(t, metrics, "reconciliation_last_run_timestamp_seconds 1700000000\n")
+	require.Contains(t, metrics, "reconciliation_last_run_duration_seconds 1.5\n")
+	require.Contains(t, metrics, "reconciliation_last_run_checked{type=\"transfers\"} 20\n")
+	require.Contains(t, metrics, "reconciliation_last_run_discrepancies{kind=\"account_balance\"} 2\n")
+	require.Contains(t, metrics, "reconciliation_last_run_discrepancies{kind=\"transfer_entries\"} 0\n")
+}
+
+func TestWriteMetricsWithoutRun(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().CountFrozenAccounts(gomock.Any()).Times(1).Return(int64(0), nil)
+	store.EXPECT().GetLatestReconciliationRun(gomock.Any()).Times(1).Return(db.ReconciliationRun{}, sql.ErrNoRows)
+	store.EXPECT().CountReconciliationDiscrepancies(gomock.Any(), gomock.Any()).Times(0)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteMetrics(context.Background(), &buf, store))
+	require.Contains(t, buf.String(), "reconciliation_frozen_accounts 0\n")
+	require.NotContains(t, buf.String(), "reconciliation_last_run")
+}
@@ -0,0 +1,340 @@
+package reconcile
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hhow09/simple_bank/constants"
+	db "github.com/hhow09/simple_bank/db/sqlc"
+	"github.com/hhow09/simple_bank/util"
+	"go.uber.org/fx"
+)
+
+const defaultBatchSize = 500
+
+// ErrRunning is returned when a run is started while another one is in progress
+var ErrRunning = errors.New("a reconciliation is already running")
+
+// Reconciler checks the invariants of the ledger:
+// account balances are the sum of their entries, every transfer has exactly
+// one matching entry per account and system account balances are the sum of their postings.
+type Reconciler struct {
+	store     db.Store
+	batchSize int32
+	interval  time.Duration
+	freeze    bool
+	running   sync.Mutex
+}
+
+// Options of a run
+type Options struct {
+	// Freeze freezes the accounts with discrepancies
+	Freeze bool `json:"freeze"`
+}
+
+// Report is a finished run with the discrepancies it found
+type Report struct {
+	Run           db.ReconciliationRun           `json:"run"`
+	Discrepancies []db.ReconciliationDiscrepancy `json:"discrepancies"`
+}
+
+func NewReconciler(config util.Config, store db.Store) *Reconciler {
+	batchSize := config.ReconciliationBatchSize
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+	return &Reconciler{
+		store:     store,
+		batchSize: batchSize,
+		interval:  config.ReconciliationInterval,
+		freeze:    config.ReconciliationFreezeAccounts,
+	}
+}
+
+// Run scans the ledger in batches and records the discrepancies found.
+// Only one run at a time is allowed per reconciler.
+func (r *Reconciler) Run(ctx context.Context, opts Options) (Report, error) {
+	if !r.running.TryLock() {
+		return Report{}, ErrRunning
+	}
+	defer r.running.Unlock()
+
+	run, err := r.store.CreateReconciliationRun(ctx)
+	if err != nil {
+		return Report{}, fmt.Errorf("failed to create reconciliation run: %w", err)
+	}
+	s := &scan{store: r.store, batchSize: r.batchSize, run: run}
+
+	err = s.check(ctx)
+	if err == nil && opts.Freeze {
+		err = s.freeze(ctx)
+	}
+	finish := db.FinishReconciliationRunParams{
+		ID:                    run.ID,
+		Status:                constants.ReconciliationCompleted,
+		AccountsChecked:       s.accounts,
+		TransfersChecked:      s.transfers,
+		LedgerAccountsChecked: s.ledgerAccounts,
+		Discrepancies:         int64(len(s.discrepancies)),
+		FrozenAccounts:        s.frozen,
+	}
+	if err != nil {
+		finish.Status = constants.ReconciliationFailed
+		finish.Error = err.Error()
+	}
+
+	report := Report{Discrepancies: s.discrepancies}
+	report.Run, err = r.store.FinishReconciliationRun(ctx, finish)
+	if err != nil {
+		return report, fmt.Errorf("failed to finish reconciliation run: %w", err)
+	}
+	if report.Run.Status == constants.ReconciliationFailed {
+		return report, fmt.Errorf("reconciliation run [%d] failed: %s", run.ID, report.Run.Error)
+	}
+	return report, nil
+}
+
+// scan is the state of a single run
+type scan struct {
+	store     db.Store
+	batchSize int32
+	run       db.ReconciliationRun
+
+	accounts       int64
+	transfers      int64
+	ledgerAccounts int64
+	frozen         int64
+	discrepancies  []db.ReconciliationDiscrepancy
+}
+
+func (s *scan) check(ctx context.Context) error {
+	if err := s.checkAccounts(ctx); err != nil {
+		return fmt.Errorf("failed to check accounts: %w", err)
+	}
+	if err := s.checkTransfers(ctx); err != nil {
+		return fmt.Errorf("failed to check transfers: %w", err)
+	}
+	if err := s.checkLedgerAccounts(ctx); err != nil {
+		return fmt.Errorf("failed to check ledger accounts: %w", err)
+	}
+	return nil
+}
+
+func (s *scan) checkAccounts(ctx context.Context) error {
+	var afterID int64
+	for {
+		rows, err := s.store.CheckAccountBalances(ctx, db.CheckAccountBalancesParams{AfterID: afterID, Limit: s.batchSize})
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			s.accounts++
+			if row.Balance == row.EntriesTotal {
+				continue
+			}
+			err := s.report(ctx, db.CreateReconciliationDiscrepancyParams{
+				Kind:      constants.DiscrepancyAccountBalance,
+				AccountID: validID(row.ID),
+				Expected:  row.EntriesTotal,
+				Actual:    row.Balance,
+				Details:   fmt.Sprintf("account [%d] balance %d is not the sum of its entries %d", row.ID, row.Balance, row.EntriesTotal),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if len(rows) < int(s.batchSize) {
+			return nil
+		}
+		afterID = rows[len(rows)-1].ID
+	}
+}
+
+func (s *scan) checkTransfers(ctx context.Context) error {
+	var afterID int64
+	for {
+		rows, err := s.store.CheckTransferEntries(ctx, db.CheckTransferEntriesParams{AfterID: afterID, Limit: s.batchSize})
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			s.transfers++
+			if err := s.checkTransfer(ctx, row); err != nil {
+				return err
+			}
+		}
+		if len(rows) < int(s.batchSize) {
+			return nil
+		}
+		afterID = rows[len(rows)-1].ID
+	}
+}
+
+func (s *scan) checkTransfer(ctx context.Context, row db.CheckTransferEntriesRow) error {
+	var found []db.CreateReconciliationDiscrepancyParams
+	if row.JournalTransactions != 1 {
+		found = append(found, db.CreateReconciliationDiscrepancyParams{
+			Expected: 1,
+			Actual:   row.JournalTransactions,
+			Details:  fmt.Sprintf("transfer [%d] has %d journal transactions", row.ID, row.JournalTransactions),
+		})
+	}
+	// without a journal transaction there are no entries to compare with
+	if row.JournalTransactions > 0 {
+		if row.Entries != 2 {
+			found = append(found, db.CreateReconciliationDiscrepancyParams{
+				Expected: 2,
+				Actual:   row.Entries,
+				Details:  fmt.Sprintf("transfer [%d] has %d entries", row.ID, row.Entries),
+			})
+		}
+		if row.FromTotal != -row.Amount {
+			found = append(found, db.CreateReconciliationDiscrepancyParams{
+				AccountID: validID(row.FromAccountID),
+				Expected:  -row.Amount,
+				Actual:    row.FromTotal,
+				Details:   fmt.Sprintf("entries of transfer [%d] on account [%d] sum to %d", row.ID, row.FromAccountID, row.FromTotal),
+			})
+		}
+		if row.ToTotal != row.Amount {
+			found = append(found, db.CreateReconciliationDiscrepancyParams{
+				AccountID: validID(row.ToAccountID),
+				Expected:  row.Amount,
+				Actual:    row.ToTotal,
+				Details:   fmt.Sprintf("entries of transfer [%d] on account [%d] sum to %d", row.ID, row.ToAccountID, row.ToTotal),
+			})
+		}
+	}
+	for _, arg := range found {
+		arg.Kind = constants.DiscrepancyTransferEntries
+		arg.TransferID = validID(row.ID)
+		if err := s.report(ctx, arg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *scan) checkLedgerAccounts(ctx context.Context) error {
+	var afterID int64
+	for {
+		rows, err := s.store.CheckLedgerAccountBalances(ctx, db.CheckLedgerAccountBalancesParams{AfterID: afterID, Limit: s.batchSize})
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			s.ledgerAccounts++
+			if row.Balance == row.PostingsTotal {
+				continue
+			}
+			err := s.report(ctx, db.CreateReconciliationDiscrepancyParams{
+				Kind:            constants.DiscrepancyLedgerAccountBalance,
+				LedgerAccountID: validID(row.ID),
+				Expected:        row.PostingsTotal,
+				Actual:          row.Balance,
+				Details:         fmt.Sprintf("ledger account [%d] balance %d is not the sum of its postings %d", row.ID, row.Balance, row.PostingsTotal),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if len(rows) < int(s.batchSize) {
+			return nil
+		}
+		afterID = rows[len(rows)-1].ID
+	}
+}
+
+// report records a discrepancy of the run
+func (s *scan) report(ctx context.Context, arg db.CreateReconciliationDiscrepancyParams) error {
+	arg.RunID = s.run.ID
+	discrepancy, err := s.store.CreateReconciliationDiscrepancy(ctx, arg)
+	if err != nil {
+		return err
+	}
+	s.discrepancies = append(s.discrepancies, discrepancy)
+	return nil
+}
+
+// freeze freezes the customer accounts of the discrepancies found
+func (s *scan) freeze(ctx context.Context) error {
+	seen := map[int64]bool{}
+	var ids []int64
+	for _, discrepancy := range s.discrepancies {
+		if discrepancy.AccountID.Valid && !seen[discrepancy.AccountID.Int64] {
+			seen[discrepancy.AccountID.Int64] = true
+			ids = append(ids, discrepancy.AccountID.Int64)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		if _, err := s.store.FreezeAccount(ctx, id); err != nil {
+			return fmt.Errorf("failed to freeze account [%d]: %w", id, err)
+		}
+		s.frozen++
+	}
+	return nil
+}
+
+func validID(id int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: id, Valid: true}
+}
+
+// start runs the reconciliation every interval until ctx is done
+func (r *Reconciler) start(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report, err := r.Run(ctx, Options{Freeze: r.freeze})
+			if err != nil {
+				log.Println("reconciliation:", err)
+				continue
+			}
+			if report.Run.Discrepancies > 0 {
+				log.Printf("reconciliation: run [%d] found %d discrepancies, froze %d accounts", report.Run.ID, report.Run.Discrepancies, report.Run.FrozenAccounts)
+			}
+		}
+	}
+}
+
+// registerJob runs the reconciliation in the background when RECONCILIATION_INTERVAL is set
+func registerJob(lc fx.Lifecycle, r *Reconciler) {
+	if r.interval <= 0 {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go func() {
+				defer close(done)
+				r.start(ctx)
+			}()
+			return nil
+		},
+		OnStop: func(stopCtx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+				return nil
+			case <-stopCtx.Done():
+				return stopCtx.Err()
+			}
+		},
+	})
+}
+
+var Module = fx.Options(
+	fx.Provide(NewReconciler),
+	fx.Invoke(registerJob),
+)
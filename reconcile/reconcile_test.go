@@ -0,0 +1,194 @@
+package reconcile
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/hhow09/simple_bank/constants"
+	mockdb "github.com/hhow09/simple_bank/db/mock"
+	db "github.com/hhow09/simple_bank/db/sqlc"
+	"github.com/hhow09/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+// finishRun returns the finished run like FinishReconciliationRun does
+func finishRun(ctx context.Context, arg db.FinishReconciliationRunParams) (db.ReconciliationRun, error) {
+	return db.ReconciliationRun{
+		ID:                    arg.ID,
+		Status:                arg.Status,
+		AccountsChecked:       arg.AccountsChecked,
+		TransfersChecked:      arg.TransfersChecked,
+		LedgerAccountsChecked: arg.LedgerAccountsChecked,
+		Discrepancies:         arg.Discrepancies,
+		FrozenAccounts:        arg.FrozenAccounts,
+		Error:                 arg.Error,
+	}, nil
+}
+
+// createDiscrepancy returns the discrepancy like CreateReconciliationDiscrepancy does
+func createDiscrepancy(ctx context.Context, arg db.CreateReconciliationDiscrepancyParams) (db.ReconciliationDiscrepancy, error) {
+	return db.ReconciliationDiscrepancy{
+		RunID:           arg.RunID,
+		Kind:            arg.Kind,
+		AccountID:       arg.AccountID,
+		TransferID:      arg.TransferID,
+		LedgerAccountID: arg.LedgerAccountID,
+		Expected:        arg.Expected,
+		Actual:          arg.Actual,
+		Details:         arg.Details,
+	}, nil
+}
+
+func TestRunClean(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	run := db.ReconciliationRun{ID: 1, Status: constants.ReconciliationRunning}
+	store.EXPECT().CreateReconciliationRun(gomock.Any()).Times(1).Return(run, nil)
+	// full batches are followed by the next batch, a short batch ends the scan
+	gomock.InOrder(
+		store.EXPECT().CheckAccountBalances(gomock.Any(), db.CheckAccountBalancesParams{AfterID: 0, Limit: 2}).Times(1).Return([]db.CheckAccountBalancesRow{
+			{ID: 1, Balance: 10, EntriesTotal: 10},
+			{ID: 3, Balance: 0, EntriesTotal: 0},
+		}, nil),
+		store.EXPECT().CheckAccountBalances(gomock.Any(), db.CheckAccountBalancesParams{AfterID: 3, Limit: 2}).Times(1).Return([]db.CheckAccountBalancesRow{
+			{ID: 4, Balance: -5, EntriesTotal: -5},
+		}, nil),
+	)
+	store.EXPECT().CheckTransferEntries(gomock.Any(), db.CheckTransferEntriesParams{AfterID: 0, Limit: 2}).Times(1).Return([]db.CheckTransferEntriesRow{
+		{ID: 1, FromAccountID: 1, ToAccountID: 4, Amount: 5, JournalTransactions: 1, Entries: 2, FromTotal: -5, ToTotal: 5},
+	}, nil)
+	store.EXPECT().CheckLedgerAccountBalances(gomock.Any(), db.CheckLedgerAccountBalancesParams{AfterID: 0, Limit: 2}).Times(1).Return([]db.CheckLedgerAccountBalancesRow{}, nil)
+	store.EXPECT().CreateReconciliationDiscrepancy(gomock.Any(), gomock.Any()).Times(0)
+	store.EXPECT().FreezeAccount(gomock.Any(), gomock.Any()).Times(0)
+	store.EXPECT().FinishReconciliationRun(gomock.Any(), db.FinishReconciliationRunParams{
+		ID:               run.ID,
+		Status:           constants.ReconciliationCompleted,
+		AccountsChecked:  3,
+		TransfersChecked: 1,
+	}).Times(1).DoAndReturn(finishRun)
+
+	reconciler := NewReconciler(util.Config{ReconciliationBatchSize: 2}, store)
+	report, err := reconciler.Run(context.Background(), Options{Freeze: true})
+	require.NoError(t, err)
+	require.Equal(t, constants.ReconciliationCompleted, report.Run.Status)
+	require.Empty(t, report.Discrepancies)
+}
+
+func TestRunDiscrepancies(t *testing.T) {
+	for _, freeze := range []bool{false, true} {
+		ctrl := gomock.NewController(t)
+		store := mockdb.NewMockStore(ctrl)
+
+		run := db.ReconciliationRun{ID: 2, Status: constants.ReconciliationRunning}
+		store.EXPECT().CreateReconciliationRun(gomock.Any()).Times(1).Return(run, nil)
+		store.EXPECT().CheckAccountBalances(gomock.Any(), gomock.Any()).Times(1).Return([]db.CheckAccountBalancesRow{
+			{ID: 1, Balance: 10, EntriesTotal: 10},
+			{ID: 2, Balance: 15, EntriesTotal: 5},
+		}, nil)
+		store.EXPECT().CheckTransferEntries(gomock.Any(), gomock.Any()).Times(1).Return([]db.CheckTransferEntriesRow{
+			// created before the ledger, only reported once
+			{ID: 1, FromAccountID: 1, ToAccountID: 3, Amount: 5},
+			// the entry on account 3 was lost
+			{ID: 2, FromAccountID: 2, ToAccountID: 3, Amount: 5, JournalTransactions: 1, Entries: 1, FromTotal: -5},
+		}, nil)
+		store.EXPECT().CheckLedgerAccountBalances(gomock.Any(), gomock.Any()).Times(1).Return([]db.CheckLedgerAccountBalancesRow{
+			{ID: 1, Balance: -20, PostingsTotal: -10},
+		}, nil)
+		store.EXPECT().CreateReconciliationDiscrepancy(gomock.Any(), gomock.Any()).Times(5).DoAndReturn(createDiscrepancy)
+		if freeze {
+			gomock.InOrder(
+				store.EXPECT().FreezeAccount(gomock.Any(), int64(2)).Times(1).Return(db.Account{ID: 2}, nil),
+				store.EXPECT().FreezeAccount(gomock.Any(), int64(3)).Times(1).Return(db.Account{ID: 3}, nil),
+			)
+		} else {
+			store.EXPECT().FreezeAccount(gomock.Any(), gomock.Any()).Times(0)
+		}
+		store.EXPECT().FinishReconciliationRun(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(finishRun)
+
+		reconciler := NewReconciler(util.Config{}, store)
+		report, err := reconciler.Run(context.Background(), Options{Freeze: freeze})
+		require.NoError(t, err)
+		require.Equal(t, constants.ReconciliationCompleted, report.Run.Status)
+		require.Equal(t, int64(2), report.Run.AccountsChecked)
+		require.Equal(t, int64(2), report.Run.TransfersChecked)
+		require.Equal(t, int64(1), report.Run.LedgerAccountsChecked)
+		require.Equal(t, int64(5), report.Run.Discrepancies)
+		if freeze {
+			require.Equal(t, int64(2), report.Run.FrozenAccounts)
+		} else {
+			require.Zero(t, report.Run.FrozenAccounts)
+		}
+
+		require.Len(t, report.Discrepancies, 5)
+		for _, discrepancy := range report.Discrepancies {
+			require.Equal(t, run.ID, discrepancy.RunID)
+			require.NotEmpty(t, discrepancy.Details)
+		}
+
+		balance := report.Discrepancies[0]
+		require.Equal(t, constants.DiscrepancyAccountBalance, balance.Kind)
+		require.Equal(t, sql.NullInt64{Int64: 2, Valid: true}, balance.AccountID)
+		require.Equal(t, int64(5), balance.Expected)
+		require.Equal(t, int64(15), balance.Actual)
+
+		unposted := report.Discrepancies[1]
+		require.Equal(t, constants.DiscrepancyTransferEntries, unposted.Kind)
+		require.Equal(t, sql.NullInt64{Int64: 1, Valid: true}, unposted.TransferID)
+		require.False(t, unposted.AccountID.Valid)
+		require.Equal(t, int64(1), unposted.Expected)
+		require.Zero(t, unposted.Actual)
+
+		entries := report.Discrepancies[2]
+		require.Equal(t, sql.NullInt64{Int64: 2, Valid: true}, entries.TransferID)
+		require.Equal(t, int64(2), entries.Expected)
+		require.Equal(t, int64(1), entries.Actual)
+
+		lost := report.Discrepancies[3]
+		require.Equal(t, sql.NullInt64{Int64: 3, Valid: true}, lost.AccountID)
+		require.Equal(t, int64(5), lost.Expected)
+		require.Zero(t, lost.Actual)
+
+		ledger := report.Discrepancies[4]
+		require.Equal(t, constants.DiscrepancyLedgerAccountBalance, ledger.Kind)
+		require.Equal(t, sql.NullInt64{Int64: 1, Valid: true}, ledger.LedgerAccountID)
+
+		ctrl.Finish()
+	}
+}
+
+func TestRunFailed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().CreateReconciliationRun(gomock.Any()).Times(1).Return(db.ReconciliationRun{ID: 3}, nil)
+	store.EXPECT().CheckAccountBalances(gomock.Any(), gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+	store.EXPECT().CheckTransferEntries(gomock.Any(), gomock.Any()).Times(0)
+	store.EXPECT().FinishReconciliationRun(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(finishRun)
+
+	reconciler := NewReconciler(util.Config{}, store)
+	report, err := reconciler.Run(context.Background(), Options{})
+	require.Error(t, err)
+	require.Equal(t, constants.ReconciliationFailed, report.Run.Status)
+	require.Contains(t, report.Run.Error, sql.ErrConnDone.Error())
+}
+
+func TestRunAlreadyRunning(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().CreateReconciliationRun(gomock.Any()).Times(0)
+
+	reconciler := NewReconciler(util.Config{}, store)
+	reconciler.running.Lock()
+	defer reconciler.running.Unlock()
+
+	_, err := reconciler.Run(context.Background(), Options{})
+	require.True(t, errors.Is(err, ErrRunning))
+}
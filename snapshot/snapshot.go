@@ -0,0 +1,231 @@
+package snapshot
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	db "github.com/hhow09/simple_bank/db/sqlc"
+	"github.com/hhow09/simple_bank/ratelimit"
+	"github.com/hhow09/simple_bank/util"
+	"go.uber.org/fx"
+)
+
+const (
+	defaultBatchSize = 500
+	// settleDelay is how long after the end of a day its snapshot is taken,
+	// so that transactions still running at midnight have committed their entries
+	settleDelay = 10 * time.Minute
+	// DateFormat is the format of snapshot dates
+	DateFormat = "2006-01-02"
+)
+
+// Snapshotter writes the daily closing balances of the accounts to account_balance_snapshots.
+// Its job also prunes the rows which are only needed for a while.
+type Snapshotter struct {
+	store     db.Store
+	batchSize int32
+	interval  time.Duration
+	// rate limit buckets idle for bucketIdle are full again, 0 to keep them
+	bucketIdle time.Duration
+	// login attempts are kept for loginAttemptRetention, 0 to keep them
+	loginAttemptRetention time.Duration
+}
+
+func NewSnapshotter(config util.Config, store db.Store) *Snapshotter {
+	batchSize := config.BalanceSnapshotBatchSize
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+	retention := config.LoginAttemptRetention
+	if retention > 0 && retention < config.LoginFailureWindow {
+		// the lockout counts the failures of the window
+		retention = config.LoginFailureWindow
+	}
+	return &Snapshotter{
+		store:                 store,
+		batchSize:             batchSize,
+		interval:              config.BalanceSnapshotInterval,
+		bucketIdle:            ratelimit.IdleAfter(config),
+		loginAttemptRetention: retention,
+	}
+}
+
+// Day returns the day of t in UTC
+func Day(t time.Time) time.Time {
+	year, month, day := t.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// ClosingAt returns the end of the day, the closing balance includes the entries created before
+func ClosingAt(day time.Time) time.Time {
+	return Day(day).AddDate(0, 0, 1)
+}
+
+// LastClosedDay returns the latest day whose snapshot can be taken at now
+func LastClosedDay(now time.Time) time.Time {
+	return Day(now.Add(-settleDelay)).AddDate(0, 0, -1)
+}
+
+// TakeDay writes the closing balance of day of every account opened before its end,
+// existing snapshots of the day are replaced. The day is marked complete once every
+// account is written. It returns the number of snapshots written.
+func (s *Snapshotter) TakeDay(ctx context.Context, day time.Time) (int64, error) {
+	day = Day(day)
+	arg := db.CreateBalanceSnapshotsParams{
+		Date:      day,
+		ClosingAt: ClosingAt(day),
+		Limit:     s.batchSize,
+	}
+	var count int64
+	for {
+		accountIDs, err := s.store.CreateBalanceSnapshots(ctx, arg)
+		if err != nil {
+			return count, fmt.Errorf("failed to snapshot %s: %w", day.Format(DateFormat), err)
+		}
+		count += int64(len(accountIDs))
+		if len(accountIDs) < int(s.batchSize) {
+			if err := s.store.CompleteBalanceSnapshotDay(ctx, day); err != nil {
+				return count, fmt.Errorf("failed to complete the snapshot of %s: %w", day.Format(DateFormat), err)
+			}
+			return count, nil
+		}
+		for _, id := range accountIDs {
+			if id > arg.AfterID {
+				arg.AfterID = id
+			}
+		}
+	}
+}
+
+// Backfill takes the snapshots of the days from from to to, both included
+func (s *Snapshotter) Backfill(ctx context.Context, from, to time.Time, now time.Time) (int64, error) {
+	from, to = Day(from), Day(to)
+	if last := LastClosedDay(now); to.After(last) {
+		return 0, fmt.Errorf("the snapshot of %s can't be taken before %s", to.Format(DateFormat), ClosingAt(to).Add(settleDelay).Format(time.RFC3339))
+	}
+	var count int64
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		n, err := s.TakeDay(ctx, day)
+		count += n
+		if err != nil {
+			return count, err
+		}
+	}
+	return count, nil
+}
+
+// CatchUp takes the snapshots of the days closed since the latest snapshot.
+// The latest day is taken again in case it was interrupted, without any
+// snapshot only the last closed day is taken and older days must be backfilled.
+func (s *Snapshotter) CatchUp(ctx context.Context, now time.Time) (int64, error) {
+	from := LastClosedDay(now)
+	latest, err := s.store.GetLatestBalanceSnapshotDate(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get the latest snapshot: %w", err)
+	}
+	if latest.Valid && Day(latest.Time).Before(from) {
+		from = Day(latest.Time)
+	}
+	return s.Backfill(ctx, from, LastClosedDay(now), now)
+}
+
+// Prune deletes the rate limit buckets of the Postgres backend which are full again
+// and the login attempts older than the retention
+func (s *Snapshotter) Prune(ctx context.Context, now time.Time) error {
+	if s.bucketIdle > 0 {
+		if _, err := s.store.DeleteIdleRateLimitBuckets(ctx, now.Add(-s.bucketIdle)); err != nil {
+			return fmt.Errorf("failed to delete idle rate limit buckets: %w", err)
+		}
+	}
+	if s.loginAttemptRetention > 0 {
+		if _, err := s.store.DeleteLoginAttemptsBefore(ctx, now.Add(-s.loginAttemptRetention)); err != nil {
+			return fmt.Errorf("failed to delete old login attempts: %w", err)
+		}
+	}
+	return nil
+}
+
+// BalanceAt returns the balance of the account at a point in time, including the entries created at.
+// It starts from the latest snapshot closed by then and adds the entries created since.
+// Without such a snapshot it subtracts the entries created after at from the current balance,
+// the snapshot used is nil then.
+func BalanceAt(ctx context.Context, store db.Store, accountID int64, at time.Time) (int64, *db.AccountBalanceSnapshot, error) {
+	snapshot, err := store.GetLatestBalanceSnapshot(ctx, db.GetLatestBalanceSnapshotParams{
+		AccountID: accountID,
+		At:        at,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		balance, err := store.GetAccountBalanceAt(ctx, db.GetAccountBalanceAtParams{
+			At:        at,
+			AccountID: accountID,
+		})
+		return balance, nil, err
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+	total, err := store.SumEntriesBetween(ctx, db.SumEntriesBetweenParams{
+		AccountID: accountID,
+		FromTime:  snapshot.ClosingAt,
+		UntilTime: at,
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	return snapshot.Balance + total, &snapshot, nil
+}
+
+// start catches up once at start and then every interval until ctx is done
+func (s *Snapshotter) start(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.CatchUp(ctx, time.Now()); err != nil {
+			log.Println("balance snapshots:", err)
+		}
+		if err := s.Prune(ctx, time.Now()); err != nil {
+			log.Println("balance snapshots:", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// registerJob takes the daily snapshots and prunes in the background when BALANCE_SNAPSHOT_INTERVAL is set
+func registerJob(lc fx.Lifecycle, s *Snapshotter) {
+	if s.interval <= 0 {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go func() {
+				defer close(done)
+				s.start(ctx)
+			}()
+			return nil
+		},
+		OnStop: func(stopCtx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+				return nil
+			case <-stopCtx.Done():
+				return stopCtx.Err()
+			}
+		},
+	})
+}
+
+var Module = fx.Options(
+	fx.Provide(NewSnapshotter),
+	fx.Invoke(registerJob),
+)
@@ -0,0 +1,192 @@
+package snapshot
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/hhow09/simple_bank/db/mock"
+	db "github.com/hhow09/simple_bank/db/sqlc"
+	"github.com/hhow09/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestDays(t *testing.T) {
+	taipei := time.FixedZone("UTC+8", 8*60*60)
+	require.Equal(t, date(2024, 2, 29), Day(time.Date(2024, 3, 1, 7, 0, 0, 0, taipei)))
+	require.Equal(t, date(2024, 3, 1), ClosingAt(time.Date(2024, 2, 29, 23, 59, 0, 0, time.UTC)))
+
+	// the day is only closed once the settle delay has passed
+	require.Equal(t, date(2024, 2, 28), LastClosedDay(time.Date(2024, 3, 1, 0, 5, 0, 0, time.UTC)))
+	require.Equal(t, date(2024, 2, 29), LastClosedDay(time.Date(2024, 3, 1, 0, 15, 0, 0, time.UTC)))
+}
+
+func TestTakeDay(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	day := date(2024, 2, 29)
+	arg := db.CreateBalanceSnapshotsParams{Date: day, ClosingAt: date(2024, 3, 1), Limit: 2}
+	// full batches are followed by the next batch, a short batch ends the day
+	gomock.InOrder(
+		store.EXPECT().CreateBalanceSnapshots(gomock.Any(), arg).Times(1).Return([]int64{1, 3}, nil),
+		store.EXPECT().CreateBalanceSnapshots(gomock.Any(), db.CreateBalanceSnapshotsParams{Date: day, ClosingAt: arg.ClosingAt, AfterID: 3, Limit: 2}).Times(1).Return([]int64{4}, nil),
+		store.EXPECT().CompleteBalanceSnapshotDay(gomock.Any(), day).Times(1).Return(nil),
+	)
+
+	snapshotter := NewSnapshotter(util.Config{BalanceSnapshotBatchSize: 2}, store)
+	count, err := snapshotter.TakeDay(context.Background(), day.Add(15*time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, int64(3), count)
+}
+
+func TestTakeDayInterrupted(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	day := date(2024, 2, 29)
+	gomock.InOrder(
+		store.EXPECT().CreateBalanceSnapshots(gomock.Any(), gomock.Any()).Times(1).Return([]int64{1, 3}, nil),
+		store.EXPECT().CreateBalanceSnapshots(gomock.Any(), gomock.Any()).Times(1).Return(nil, sql.ErrConnDone),
+	)
+	// a day with missing accounts isn't complete
+	store.EXPECT().CompleteBalanceSnapshotDay(gomock.Any(), gomock.Any()).Times(0)
+
+	snapshotter := NewSnapshotter(util.Config{BalanceSnapshotBatchSize: 2}, store)
+	count, err := snapshotter.TakeDay(context.Background(), day)
+	require.ErrorIs(t, err, sql.ErrConnDone)
+	require.Equal(t, int64(2), count)
+}
+
+func TestBackfill(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	gomock.InOrder(
+		store.EXPECT().CreateBalanceSnapshots(gomock.Any(), db.CreateBalanceSnapshotsParams{Date: date(2024, 2, 28), ClosingAt: date(2024, 2, 29), Limit: defaultBatchSize}).Times(1).Return([]int64{1}, nil),
+		store.EXPECT().CompleteBalanceSnapshotDay(gomock.Any(), date(2024, 2, 28)).Times(1).Return(nil),
+		store.EXPECT().CreateBalanceSnapshots(gomock.Any(), db.CreateBalanceSnapshotsParams{Date: date(2024, 2, 29), ClosingAt: date(2024, 3, 1), Limit: defaultBatchSize}).Times(1).Return([]int64{1, 2}, nil),
+		store.EXPECT().CompleteBalanceSnapshotDay(gomock.Any(), date(2024, 2, 29)).Times(1).Return(nil),
+	)
+
+	snapshotter := NewSnapshotter(util.Config{}, store)
+	now := time.Date(2024, 3, 1, 1, 0, 0, 0, time.UTC)
+	count, err := snapshotter.Backfill(context.Background(), date(2024, 2, 28), date(2024, 2, 29), now)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), count)
+
+	// the current day isn't closed yet
+	_, err = snapshotter.Backfill(context.Background(), date(2024, 2, 28), date(2024, 3, 1), now)
+	require.Error(t, err)
+}
+
+func TestCatchUp(t *testing.T) {
+	now := time.Date(2024, 3, 1, 1, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name   string
+		latest sql.NullTime
+		days   []time.Time
+	}{
+		{
+			name: "No Snapshots",
+			days: []time.Time{date(2024, 2, 29)},
+		},
+		{
+			name:   "Behind",
+			latest: sql.NullTime{Time: date(2024, 2, 27), Valid: true},
+			days:   []time.Time{date(2024, 2, 27), date(2024, 2, 28), date(2024, 2, 29)},
+		},
+		{
+			name:   "Up To Date",
+			latest: sql.NullTime{Time: date(2024, 2, 29), Valid: true},
+			days:   []time.Time{date(2024, 2, 29)},
+		},
+	}
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetLatestBalanceSnapshotDate(gomock.Any()).Times(1).Return(tc.latest, nil)
+			var days []time.Time
+			store.EXPECT().CreateBalanceSnapshots(gomock.Any(), gomock.Any()).Times(len(tc.days)).
+				DoAndReturn(func(_ context.Context, arg db.CreateBalanceSnapshotsParams) ([]int64, error) {
+					days = append(days, arg.Date)
+					return []int64{1}, nil
+				})
+			store.EXPECT().CompleteBalanceSnapshotDay(gomock.Any(), gomock.Any()).Times(len(tc.days)).Return(nil)
+
+			snapshotter := NewSnapshotter(util.Config{}, store)
+			count, err := snapshotter.CatchUp(context.Background(), now)
+			require.NoError(t, err)
+			require.Equal(t, int64(len(tc.days)), count)
+			require.Equal(t, tc.days, days)
+		})
+	}
+}
+
+func TestBalanceAt(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	snapshot := db.AccountBalanceSnapshot{AccountID: 1, Date: date(2024, 2, 29), ClosingAt: date(2024, 3, 1), Balance: 100}
+	store.EXPECT().GetLatestBalanceSnapshot(gomock.Any(), db.GetLatestBalanceSnapshotParams{AccountID: 1, At: at}).Times(1).Return(snapshot, nil)
+	store.EXPECT().SumEntriesBetween(gomock.Any(), db.SumEntriesBetweenParams{AccountID: 1, FromTime: snapshot.ClosingAt, UntilTime: at}).Times(1).Return(int64(25), nil)
+
+	balance, used, err := BalanceAt(context.Background(), store, 1, at)
+	require.NoError(t, err)
+	require.Equal(t, int64(125), balance)
+	require.Equal(t, &snapshot, used)
+
+	store.EXPECT().GetLatestBalanceSnapshot(gomock.Any(), db.GetLatestBalanceSnapshotParams{AccountID: 2, At: at}).Times(1).Return(db.AccountBalanceSnapshot{}, sql.ErrNoRows)
+	store.EXPECT().GetAccountBalanceAt(gomock.Any(), db.GetAccountBalanceAtParams{At: at, AccountID: 2}).Times(1).Return(int64(40), nil)
+
+	balance, used, err = BalanceAt(context.Background(), store, 2, at)
+	require.NoError(t, err)
+	require.Equal(t, int64(40), balance)
+	require.Nil(t, used)
+}
+
+func TestPrune(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Date(2024, 3, 1, 1, 0, 0, 0, time.UTC)
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().DeleteIdleRateLimitBuckets(gomock.Any(), now.Add(-10*time.Minute)).Times(1).Return(int64(2), nil)
+	// login attempts are kept at least for the failure window of the lockout
+	store.EXPECT().DeleteLoginAttemptsBefore(gomock.Any(), now.Add(-time.Hour)).Times(1).Return(int64(3), nil)
+
+	snapshotter := NewSnapshotter(util.Config{
+		RateLimitLogin:        "5/1m",
+		RateLimitTransfer:     "30/10m",
+		LoginFailureWindow:    time.Hour,
+		LoginAttemptRetention: time.Minute,
+	}, store)
+	require.NoError(t, snapshotter.Prune(context.Background(), now))
+}
+
+func TestPruneDisabled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().DeleteIdleRateLimitBuckets(gomock.Any(), gomock.Any()).Times(0)
+	store.EXPECT().DeleteLoginAttemptsBefore(gomock.Any(), gomock.Any()).Times(0)
+
+	snapshotter := NewSnapshotter(util.Config{}, store)
+	require.NoError(t, snapshotter.Prune(context.Background(), time.Now()))
+}
@@ -0,0 +1,204 @@
+package statement
+
+import (
+	"encoding/xml"
+	"io"
+	"strconv"
+	"time"
+
+	db "github.com/hhow09/simple_bank/db/sqlc"
+	"github.com/hhow09/simple_bank/snapshot"
+)
+
+// camtNamespace is the namespace of camt.053.001.02
+const camtNamespace = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.02"
+
+// camtDateTime is the ISODateTime format in UTC
+const camtDateTime = "2006-01-02T15:04:05Z"
+
+const (
+	camtCredit = "CRDT"
+	camtDebit  = "DBIT"
+	// camtNotProvided is the end to end id of entries without a reference
+	camtNotProvided = "NOTPROVIDED"
+	// camtRemittanceLength is the maximum length of the unstructured remittance information
+	camtRemittanceLength = 140
+)
+
+type camtGroupHeader struct {
+	MessageID string `xml:"MsgId"`
+	CreatedAt string `xml:"CreDtTm"`
+}
+
+type camtPeriod struct {
+	From string `xml:"FrDtTm"`
+	To   string `xml:"ToDtTm"`
+}
+
+type camtAccountID struct {
+	ID string `xml:"Othr>Id"`
+}
+
+type camtAccount struct {
+	ID       camtAccountID `xml:"Id"`
+	Currency string        `xml:"Ccy"`
+	Owner    *camtParty    `xml:"Ownr,omitempty"`
+}
+
+type camtAmount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type camtBalance struct {
+	Type      string     `xml:"Tp>CdOrPrtry>Cd"`
+	Amount    camtAmount `xml:"Amt"`
+	Indicator string     `xml:"CdtDbtInd"`
+	Date      string     `xml:"Dt>Dt"`
+}
+
+type camtParty struct {
+	Name string `xml:"Nm"`
+}
+
+type camtRelatedParties struct {
+	Debtor          *camtParty     `xml:"Dbtr,omitempty"`
+	DebtorAccount   *camtAccountID `xml:"DbtrAcct>Id,omitempty"`
+	Creditor        *camtParty     `xml:"Cdtr,omitempty"`
+	CreditorAccount *camtAccountID `xml:"CdtrAcct>Id,omitempty"`
+}
+
+type camtTransaction struct {
+	EndToEndID     string              `xml:"Refs>EndToEndId"`
+	RelatedParties *camtRelatedParties `xml:"RltdPties,omitempty"`
+	Remittance     *camtRemittance     `xml:"RmtInf,omitempty"`
+}
+
+type camtRemittance struct {
+	Unstructured string `xml:"Ustrd"`
+}
+
+type camtEntry struct {
+	Reference   string          `xml:"NtryRef"`
+	Amount      camtAmount      `xml:"Amt"`
+	Indicator   string          `xml:"CdtDbtInd"`
+	Status      string          `xml:"Sts"`
+	BookingDate string          `xml:"BookgDt>DtTm"`
+	ValueDate   string          `xml:"ValDt>DtTm"`
+	Code        string          `xml:"BkTxCd>Prtry>Cd"`
+	Transaction camtTransaction `xml:"NtryDtls>TxDtls"`
+}
+
+// camtEncoder writes an ISO 20022 camt.053 document with a single statement
+type camtEncoder struct {
+	w *xmlWriter
+}
+
+func newCAMTEncoder(w io.Writer) *camtEncoder {
+	return &camtEncoder{w: newXMLWriter(w)}
+}
+
+func (e *camtEncoder) begin(s *Statement) error {
+	w := e.w
+	w.token(xml.ProcInst{Target: "xml", Inst: []byte(`version="1.0" encoding="UTF-8"`)})
+	w.token(xml.CharData("\n"))
+	w.token(xml.StartElement{Name: xml.Name{Space: camtNamespace, Local: "Document"}})
+	w.start("BkToCstmrStmt")
+	w.element("GrpHdr", camtGroupHeader{
+		MessageID: s.ID(),
+		CreatedAt: s.CreatedAt.Format(camtDateTime),
+	})
+	w.start("Stmt")
+	w.element("Id", s.ID())
+	w.element("CreDtTm", s.CreatedAt.Format(camtDateTime))
+	w.element("FrToDt", camtPeriod{
+		From: s.From.Format(camtDateTime),
+		To:   s.Until.Format(camtDateTime),
+	})
+	account := camtAccount{
+		ID:       camtAccountID{ID: s.Account.Number},
+		Currency: s.Currency.Code,
+	}
+	if s.Holder != "" {
+		account.Owner = &camtParty{Name: s.Holder}
+	}
+	w.element("Acct", account)
+	w.element("Bal", e.balance(s, "OPBD", s.OpeningBalance, s.From))
+	w.element("Bal", e.balance(s, "CLBD", s.ClosingBalance, s.To))
+	return w.err
+}
+
+func (e *camtEncoder) entry(s *Statement, entry db.ListStatementEntriesRow) error {
+	bookedAt := entry.CreatedAt.UTC().Format(camtDateTime)
+	ntry := camtEntry{
+		Reference:   strconv.FormatInt(entry.ID, 10),
+		Amount:      e.amount(s, entry.Amount),
+		Indicator:   camtIndicator(entry.Amount),
+		Status:      "BOOK",
+		BookingDate: bookedAt,
+		ValueDate:   bookedAt,
+		Code:        entry.Kind,
+		Transaction: camtTransaction{
+			EndToEndID: entry.Reference,
+		},
+	}
+	if ntry.Code == "" {
+		ntry.Code = "entry"
+	}
+	if ntry.Transaction.EndToEndID == "" {
+		ntry.Transaction.EndToEndID = camtNotProvided
+	}
+	if entry.Description != "" {
+		ntry.Transaction.Remittance = &camtRemittance{Unstructured: truncate(entry.Description, camtRemittanceLength)}
+	}
+	if entry.CounterpartyNumber != "" {
+		party := &camtParty{Name: entry.CounterpartyName}
+		account := &camtAccountID{ID: entry.CounterpartyNumber}
+		// the counterparty of a credit paid it, of a debit was paid
+		if entry.Amount >= 0 {
+			ntry.Transaction.RelatedParties = &camtRelatedParties{Debtor: party, DebtorAccount: account}
+		} else {
+			ntry.Transaction.RelatedParties = &camtRelatedParties{Creditor: party, CreditorAccount: account}
+		}
+	}
+	e.w.element("Ntry", ntry)
+	return e.w.err
+}
+
+func (e *camtEncoder) end(s *Statement) error {
+	w := e.w
+	w.end("Stmt")
+	w.end("BkToCstmrStmt")
+	w.token(xml.EndElement{Name: xml.Name{Space: camtNamespace, Local: "Document"}})
+	w.token(xml.CharData("\n"))
+	return w.err
+}
+
+func (e *camtEncoder) flush() error {
+	return e.w.flush()
+}
+
+func (e *camtEncoder) balance(s *Statement, code string, balance int64, date time.Time) camtBalance {
+	return camtBalance{
+		Type:      code,
+		Amount:    e.amount(s, balance),
+		Indicator: camtIndicator(balance),
+		Date:      date.Format(snapshot.DateFormat),
+	}
+}
+
+// amount returns the absolute amount, the sign is given by the credit debit indicator
+func (e *camtEncoder) amount(s *Statement, amount int64) camtAmount {
+	value := s.Currency.Format(amount)
+	if amount < 0 {
+		value = value[1:]
+	}
+	return camtAmount{Currency: s.Currency.Code, Value: value}
+}
+
+func camtIndicator(amount int64) string {
+	if amount < 0 {
+		return camtDebit
+	}
+	return camtCredit
+}
@@ -0,0 +1,73 @@
+package statement
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	db "github.com/hhow09/simple_bank/db/sqlc"
+)
+
+const (
+	csvOpeningBalance = "opening_balance"
+	csvClosingBalance = "closing_balance"
+)
+
+var csvHeader = []string{"date", "type", "id", "description", "reference", "counterparty_name", "counterparty_account", "amount", "balance"}
+
+// csvEncoder writes one row per entry with the running balance,
+// between an opening and a closing balance row
+type csvEncoder struct {
+	w       *csv.Writer
+	balance int64
+}
+
+func newCSVEncoder(w io.Writer) *csvEncoder {
+	return &csvEncoder{w: csv.NewWriter(w)}
+}
+
+func (e *csvEncoder) begin(s *Statement) error {
+	e.balance = s.OpeningBalance
+	if err := e.w.Write(csvHeader); err != nil {
+		return err
+	}
+	return e.w.Write(e.balanceRow(s, s.From, csvOpeningBalance, s.OpeningBalance))
+}
+
+func (e *csvEncoder) entry(s *Statement, entry db.ListStatementEntriesRow) error {
+	e.balance += entry.Amount
+	return e.w.Write([]string{
+		entry.CreatedAt.UTC().Format(time.RFC3339),
+		entry.Kind,
+		strconv.FormatInt(entry.ID, 10),
+		csvText(entry.Description),
+		csvText(entry.Reference),
+		csvText(entry.CounterpartyName),
+		entry.CounterpartyNumber,
+		s.Currency.Format(entry.Amount),
+		s.Currency.Format(e.balance),
+	})
+}
+
+func (e *csvEncoder) end(s *Statement) error {
+	return e.w.Write(e.balanceRow(s, s.Until, csvClosingBalance, s.ClosingBalance))
+}
+
+func (e *csvEncoder) flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvEncoder) balanceRow(s *Statement, at time.Time, kind string, balance int64) []string {
+	return []string{at.Format(time.RFC3339), kind, "", "", "", "", "", "", s.Currency.Format(balance)}
+}
+
+// csvText keeps spreadsheets from running text as a formula
+func csvText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
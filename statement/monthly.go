@@ -0,0 +1,160 @@
+package statement
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	db "github.com/hhow09/simple_bank/db/sqlc"
+	"github.com/hhow09/simple_bank/money"
+	"github.com/hhow09/simple_bank/snapshot"
+	"github.com/hhow09/simple_bank/util"
+	"go.uber.org/fx"
+)
+
+// MonthFormat is the format of statement months
+const MonthFormat = "2006-01"
+
+// Generator stores the monthly pdf statements of the accounts
+type Generator struct {
+	store      db.Store
+	currencies *money.Registry
+	bankID     string
+	interval   time.Duration
+}
+
+func NewGenerator(config util.Config, store db.Store, currencies *money.Registry) *Generator {
+	return &Generator{
+		store:      store,
+		currencies: currencies,
+		bankID:     config.AccountNumberBankCode,
+		interval:   config.MonthlyStatementInterval,
+	}
+}
+
+// Month returns the first day of the month of t in UTC
+func Month(t time.Time) time.Time {
+	year, month, _ := t.UTC().Date()
+	return time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// LastClosedMonth returns the latest month whose statements can be generated at now
+func LastClosedMonth(now time.Time) time.Time {
+	return Month(snapshot.LastClosedDay(now).AddDate(0, 0, 1)).AddDate(0, -1, 0)
+}
+
+// GenerateMonth generates the statements of the accounts opened by the end of the month
+// which don't have one yet. It returns the number of statements generated.
+func (g *Generator) GenerateMonth(ctx context.Context, month time.Time, now time.Time) (int64, error) {
+	start := Month(month)
+	end := start.AddDate(0, 1, -1)
+	if start.After(LastClosedMonth(now)) {
+		return 0, fmt.Errorf("the statements of %s can't be generated before the month is closed", start.Format(MonthFormat))
+	}
+	arg := db.ListAccountsWithoutStatementParams{
+		ClosingAt:   snapshot.ClosingAt(end),
+		PeriodStart: start,
+		Limit:       batchSize,
+	}
+	var count int64
+	for {
+		accounts, err := g.store.ListAccountsWithoutStatement(ctx, arg)
+		if err != nil {
+			return count, fmt.Errorf("failed to list the accounts: %w", err)
+		}
+		for _, account := range accounts {
+			if _, err := g.Generate(ctx, account, start, now); err != nil {
+				return count, fmt.Errorf("failed to generate the statement of account [%d]: %w", account.ID, err)
+			}
+			count++
+		}
+		if len(accounts) < batchSize {
+			return count, nil
+		}
+		arg.AfterID = accounts[len(accounts)-1].ID
+	}
+}
+
+// Generate renders and stores the statement of the account for the month, replacing an existing one
+func (g *Generator) Generate(ctx context.Context, account db.Account, month time.Time, now time.Time) (db.AccountStatement, error) {
+	start := Month(month)
+	end := start.AddDate(0, 1, -1)
+	owner, err := g.store.GetUser(ctx, account.Owner)
+	if err != nil {
+		return db.AccountStatement{}, err
+	}
+	currency, ok := g.currencies.Lookup(account.Currency)
+	if !ok {
+		currency = money.Currency{Code: account.Currency}
+	}
+	st, err := New(ctx, g.store, account, currency, start, end, now)
+	if err != nil {
+		return db.AccountStatement{}, err
+	}
+	st.Holder = owner.FullName
+	st.BankID = g.bankID
+
+	var buf bytes.Buffer
+	if err := st.Write(ctx, g.store, &buf, FormatPDF); err != nil {
+		return db.AccountStatement{}, err
+	}
+	return g.store.CreateAccountStatement(ctx, db.CreateAccountStatementParams{
+		AccountID:      account.ID,
+		PeriodStart:    start,
+		PeriodEnd:      end,
+		OpeningBalance: st.OpeningBalance,
+		ClosingBalance: st.ClosingBalance,
+		Content:        buf.Bytes(),
+	})
+}
+
+// start generates the statements of the last closed month once at start and then every interval until ctx is done
+func (g *Generator) start(ctx context.Context) {
+	ticker := time.NewTicker(g.interval)
+	defer ticker.Stop()
+	for {
+		now := time.Now()
+		if _, err := g.GenerateMonth(ctx, LastClosedMonth(now), now); err != nil {
+			log.Println("monthly statements:", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// registerJob generates the monthly statements in the background when MONTHLY_STATEMENT_INTERVAL is set
+func registerJob(lc fx.Lifecycle, g *Generator) {
+	if g.interval <= 0 {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go func() {
+				defer close(done)
+				g.start(ctx)
+			}()
+			return nil
+		},
+		OnStop: func(stopCtx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+				return nil
+			case <-stopCtx.Done():
+				return stopCtx.Err()
+			}
+		},
+	})
+}
+
+var Module = fx.Options(
+	fx.Provide(NewGenerator),
+	fx.Invoke(registerJob),
+)
@@ -0,0 +1,80 @@
+package statement
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/hhow09/simple_bank/db/mock"
+	db "github.com/hhow09/simple_bank/db/sqlc"
+	"github.com/hhow09/simple_bank/money"
+	"github.com/hhow09/simple_bank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLastClosedMonth(t *testing.T) {
+	february := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	// the last day of the month is only closed once its snapshot can be taken
+	require.Equal(t, february.AddDate(0, -1, 0), LastClosedMonth(time.Date(2024, 3, 1, 0, 5, 0, 0, time.UTC)))
+	require.Equal(t, february, LastClosedMonth(time.Date(2024, 3, 1, 0, 15, 0, 0, time.UTC)))
+	require.Equal(t, february, LastClosedMonth(time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC)))
+}
+
+func TestGenerateMonth(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Date(2024, 3, 2, 9, 30, 0, 0, time.UTC)
+	february := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	account := db.Account{ID: 7, Owner: "jane", Number: "SB12SMPL000000000007", Currency: "USD"}
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().ListAccountsWithoutStatement(gomock.Any(), db.ListAccountsWithoutStatementParams{
+		ClosingAt:   time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		PeriodStart: february,
+		Limit:       batchSize,
+	}).Times(1).Return([]db.Account{account}, nil)
+	store.EXPECT().GetUser(gomock.Any(), account.Owner).Times(1).Return(db.User{Username: account.Owner, FullName: "Jane Doe"}, nil)
+	store.EXPECT().GetLatestBalanceSnapshot(gomock.Any(), gomock.Any()).Times(2).Return(db.AccountBalanceSnapshot{}, sql.ErrNoRows)
+	gomock.InOrder(
+		store.EXPECT().GetAccountBalanceAt(gomock.Any(), gomock.Any()).Times(1).Return(int64(1000), nil),
+		store.EXPECT().GetAccountBalanceAt(gomock.Any(), gomock.Any()).Times(1).Return(int64(850), nil),
+	)
+	store.EXPECT().ListStatementEntries(gomock.Any(), gomock.Any()).Times(1).Return(testEntries(), nil)
+	store.EXPECT().CreateAccountStatement(gomock.Any(), gomock.Any()).Times(1).
+		DoAndReturn(func(_ context.Context, arg db.CreateAccountStatementParams) (db.AccountStatement, error) {
+			require.Equal(t, account.ID, arg.AccountID)
+			require.Equal(t, february, arg.PeriodStart)
+			require.Equal(t, time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC), arg.PeriodEnd)
+			require.Equal(t, int64(1000), arg.OpeningBalance)
+			require.Equal(t, int64(850), arg.ClosingBalance)
+			require.True(t, bytes.HasPrefix(arg.Content, []byte("%PDF-")))
+			require.True(t, bytes.Contains(arg.Content, []byte("(Jane Doe)")))
+			return db.AccountStatement{ID: 1, AccountID: arg.AccountID, PeriodStart: arg.PeriodStart}, nil
+		})
+
+	currencies, err := money.NewRegistry(usd)
+	require.NoError(t, err)
+	generator := NewGenerator(util.Config{AccountNumberBankCode: "SMPL"}, store, currencies)
+	count, err := generator.GenerateMonth(context.Background(), february.AddDate(0, 0, 10), now)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), count)
+}
+
+func TestGenerateMonthNotClosed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().ListAccountsWithoutStatement(gomock.Any(), gomock.Any()).Times(0)
+
+	currencies, err := money.NewRegistry(usd)
+	require.NoError(t, err)
+	generator := NewGenerator(util.Config{}, store, currencies)
+	now := time.Date(2024, 3, 20, 0, 0, 0, 0, time.UTC)
+	_, err = generator.GenerateMonth(context.Background(), now, now)
+	require.Error(t, err)
+}
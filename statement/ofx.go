@@ -0,0 +1,152 @@
+package statement
+
+import (
+	"encoding/xml"
+	"io"
+	"strconv"
+
+	"github.com/hhow09/simple_bank/constants"
+	db "github.com/hhow09/simple_bank/db/sqlc"
+)
+
+// ofxTime is the OFX datetime format in UTC
+const ofxTime = "20060102150405.000[0:GMT]"
+
+// ofxNameLength is the maximum length of the payee name
+const ofxNameLength = 32
+
+type ofxStatus struct {
+	Code     int    `xml:"CODE"`
+	Severity string `xml:"SEVERITY"`
+}
+
+type ofxSignon struct {
+	Status     ofxStatus `xml:"SONRS>STATUS"`
+	ServerTime string    `xml:"SONRS>DTSERVER"`
+	Language   string    `xml:"SONRS>LANGUAGE"`
+}
+
+type ofxAccount struct {
+	BankID    string `xml:"BANKID"`
+	AccountID string `xml:"ACCTID"`
+	Type      string `xml:"ACCTTYPE"`
+}
+
+type ofxTransaction struct {
+	Type      string `xml:"TRNTYPE"`
+	Posted    string `xml:"DTPOSTED"`
+	Amount    string `xml:"TRNAMT"`
+	ID        string `xml:"FITID"`
+	Reference string `xml:"REFNUM,omitempty"`
+	Name      string `xml:"NAME,omitempty"`
+	Memo      string `xml:"MEMO,omitempty"`
+}
+
+type ofxBalance struct {
+	Amount string `xml:"BALAMT"`
+	AsOf   string `xml:"DTASOF"`
+}
+
+// ofxEncoder writes an OFX 2.2 bank statement response.
+// OFX has no opening balance, only the closing balance is written as the ledger balance.
+type ofxEncoder struct {
+	w *xmlWriter
+}
+
+func newOFXEncoder(w io.Writer) *ofxEncoder {
+	return &ofxEncoder{w: newXMLWriter(w)}
+}
+
+func (e *ofxEncoder) begin(s *Statement) error {
+	w := e.w
+	w.token(xml.ProcInst{Target: "xml", Inst: []byte(`version="1.0" encoding="UTF-8" standalone="no"`)})
+	w.token(xml.CharData("\n"))
+	w.token(xml.ProcInst{Target: "OFX", Inst: []byte(`OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"`)})
+	w.token(xml.CharData("\n"))
+	w.start("OFX")
+	w.element("SIGNONMSGSRSV1", ofxSignon{
+		Status:     ofxStatus{Severity: "INFO"},
+		ServerTime: s.CreatedAt.Format(ofxTime),
+		Language:   "ENG",
+	})
+	w.start("BANKMSGSRSV1")
+	w.start("STMTTRNRS")
+	w.element("TRNUID", s.ID())
+	w.element("STATUS", ofxStatus{Severity: "INFO"})
+	w.start("STMTRS")
+	w.element("CURDEF", s.Currency.Code)
+	w.element("BANKACCTFROM", ofxAccount{
+		BankID:    s.BankID,
+		AccountID: s.Account.Number,
+		Type:      ofxAccountType(s.Account.Type),
+	})
+	w.start("BANKTRANLIST")
+	w.element("DTSTART", s.From.Format(ofxTime))
+	w.element("DTEND", s.Until.Format(ofxTime))
+	return w.err
+}
+
+func (e *ofxEncoder) entry(s *Statement, entry db.ListStatementEntriesRow) error {
+	e.w.element("STMTTRN", ofxTransaction{
+		Type:      ofxTransactionType(entry),
+		Posted:    entry.CreatedAt.UTC().Format(ofxTime),
+		Amount:    s.Currency.Format(entry.Amount),
+		ID:        strconv.FormatInt(entry.ID, 10),
+		Reference: entry.Reference,
+		Name:      truncate(entry.CounterpartyName, ofxNameLength),
+		Memo:      entry.Description,
+	})
+	return e.w.err
+}
+
+func (e *ofxEncoder) end(s *Statement) error {
+	w := e.w
+	w.end("BANKTRANLIST")
+	w.element("LEDGERBAL", ofxBalance{
+		Amount: s.Currency.Format(s.ClosingBalance),
+		AsOf:   s.Until.Format(ofxTime),
+	})
+	w.end("STMTRS")
+	w.end("STMTTRNRS")
+	w.end("BANKMSGSRSV1")
+	w.end("OFX")
+	w.token(xml.CharData("\n"))
+	return w.err
+}
+
+func (e *ofxEncoder) flush() error {
+	return e.w.flush()
+}
+
+func ofxAccountType(accountType string) string {
+	if accountType == constants.AccountTypeSavings {
+		return "SAVINGS"
+	}
+	return "CHECKING"
+}
+
+func ofxTransactionType(entry db.ListStatementEntriesRow) string {
+	switch entry.Kind {
+	case constants.JournalKindTransfer:
+		return "XFER"
+	case constants.JournalKindDeposit:
+		return "DEP"
+	case constants.JournalKindFee:
+		return "FEE"
+	case constants.JournalKindInterest:
+		return "INT"
+	}
+	if entry.Amount < 0 {
+		return "DEBIT"
+	}
+	return "CREDIT"
+}
+
+// truncate cuts s to at most n runes
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
@@ -0,0 +1,166 @@
+package statement
+
+import (
+	"fmt"
+	"io"
+
+	db "github.com/hhow09/simple_bank/db/sqlc"
+	"github.com/hhow09/simple_bank/pdf"
+	"github.com/hhow09/simple_bank/snapshot"
+)
+
+// layout of the pdf statement in points
+const (
+	pdfMargin     = 50
+	pdfTop        = pdf.A4Height - pdfMargin
+	pdfBottom     = 80
+	pdfRight      = pdf.A4Width - pdfMargin
+	pdfRowHeight  = 14
+	pdfFontSize   = 9
+	pdfDateX      = pdfMargin
+	pdfDetailsX   = 110
+	pdfReferenceX = 330
+	pdfAmountX    = 470
+	pdfBalanceX   = pdfRight
+	// pdfDetailsLength and pdfReferenceLength keep the columns apart
+	pdfDetailsLength   = 42
+	pdfReferenceLength = 20
+)
+
+// pdfEncoder renders a printable statement, the document is written once it is complete
+type pdfEncoder struct {
+	w       io.Writer
+	doc     *pdf.Document
+	page    *pdf.Page
+	y       float64
+	balance int64
+	credits int64
+	debits  int64
+	entries int
+}
+
+func newPDFEncoder(w io.Writer) *pdfEncoder {
+	return &pdfEncoder{w: w, doc: pdf.New(pdf.A4Width, pdf.A4Height)}
+}
+
+func (e *pdfEncoder) begin(s *Statement) error {
+	e.balance = s.OpeningBalance
+	e.doc.SetTitle(fmt.Sprintf("Statement %s %s - %s", s.Account.Number, s.From.Format(snapshot.DateFormat), s.To.Format(snapshot.DateFormat)))
+	e.page = e.doc.AddPage()
+	page := e.page
+
+	page.Text(pdfMargin, pdfTop, pdf.HelveticaBold, 18, "Account statement")
+	page.TextRight(pdfRight, pdfTop, pdf.Helvetica, 10, fmt.Sprintf("%s to %s", s.From.Format(snapshot.DateFormat), s.To.Format(snapshot.DateFormat)))
+	y := float64(pdfTop - 30)
+	page.Text(pdfMargin, y, pdf.HelveticaBold, 11, s.Holder)
+	details := [][2]string{
+		{"Account number", s.Account.Number},
+		{"Account type", s.Account.Type},
+		{"Currency", s.Currency.Code},
+		{"Created", s.CreatedAt.Format("2006-01-02 15:04 UTC")},
+	}
+	for _, detail := range details {
+		y -= pdfRowHeight
+		page.Text(pdfMargin, y, pdf.Helvetica, pdfFontSize, detail[0])
+		page.Text(pdfDetailsX+40, y, pdf.Helvetica, pdfFontSize, detail[1])
+	}
+
+	y -= 2 * pdfRowHeight
+	page.Text(pdfMargin, y, pdf.HelveticaBold, 10, "Opening balance")
+	page.TextRight(pdfBalanceX, y, pdf.HelveticaBold, 10, s.Currency.Format(s.OpeningBalance))
+	y -= pdfRowHeight
+	page.Text(pdfMargin, y, pdf.HelveticaBold, 10, "Closing balance")
+	page.TextRight(pdfBalanceX, y, pdf.HelveticaBold, 10, s.Currency.Format(s.ClosingBalance))
+
+	e.y = y - 2*pdfRowHeight
+	e.tableHeader()
+	return nil
+}
+
+func (e *pdfEncoder) tableHeader() {
+	page := e.page
+	page.Text(pdfDateX, e.y, pdf.HelveticaBold, pdfFontSize, "Date")
+	page.Text(pdfDetailsX, e.y, pdf.HelveticaBold, pdfFontSize, "Details")
+	page.Text(pdfReferenceX, e.y, pdf.HelveticaBold, pdfFontSize, "Reference")
+	page.TextRight(pdfAmountX, e.y, pdf.HelveticaBold, pdfFontSize, "Amount")
+	page.TextRight(pdfBalanceX, e.y, pdf.HelveticaBold, pdfFontSize, "Balance")
+	page.Line(pdfMargin, e.y-4, pdfRight, e.y-4, 0.5)
+	e.y -= pdfRowHeight + 2
+}
+
+// row moves to the next row, on a new page when the page is full
+func (e *pdfEncoder) row() float64 {
+	if e.y < pdfBottom {
+		e.page = e.doc.AddPage()
+		e.y = pdfTop
+		e.tableHeader()
+	}
+	y := e.y
+	e.y -= pdfRowHeight
+	return y
+}
+
+func (e *pdfEncoder) entry(s *Statement, entry db.ListStatementEntriesRow) error {
+	e.entries++
+	e.balance += entry.Amount
+	if entry.Amount < 0 {
+		e.debits += entry.Amount
+	} else {
+		e.credits += entry.Amount
+	}
+
+	details := entry.Description
+	if entry.CounterpartyName != "" {
+		if details == "" {
+			details = entry.CounterpartyName
+		} else {
+			details = entry.CounterpartyName + ": " + details
+		}
+	}
+	if details == "" {
+		details = entry.Kind
+	}
+
+	y := e.row()
+	e.page.Text(pdfDateX, y, pdf.Helvetica, pdfFontSize, entry.CreatedAt.UTC().Format(snapshot.DateFormat))
+	e.page.Text(pdfDetailsX, y, pdf.Helvetica, pdfFontSize, truncate(details, pdfDetailsLength))
+	e.page.Text(pdfReferenceX, y, pdf.Helvetica, pdfFontSize, truncate(entry.Reference, pdfReferenceLength))
+	e.page.TextRight(pdfAmountX, y, pdf.Helvetica, pdfFontSize, s.Currency.Format(entry.Amount))
+	e.page.TextRight(pdfBalanceX, y, pdf.Helvetica, pdfFontSize, s.Currency.Format(e.balance))
+	return nil
+}
+
+func (e *pdfEncoder) end(s *Statement) error {
+	if e.entries == 0 {
+		y := e.row()
+		e.page.Text(pdfDetailsX, y, pdf.Helvetica, pdfFontSize, "No transactions in this period")
+	}
+	y := e.row()
+	e.page.Line(pdfMargin, y+pdfRowHeight-4, pdfRight, y+pdfRowHeight-4, 0.5)
+	totals := []struct {
+		label  string
+		amount int64
+	}{
+		{"Total credits", e.credits},
+		{"Total debits", e.debits},
+		{"Closing balance", s.ClosingBalance},
+	}
+	for i, total := range totals {
+		if i > 0 {
+			y = e.row()
+		}
+		e.page.Text(pdfDetailsX, y, pdf.HelveticaBold, pdfFontSize, total.label)
+		e.page.TextRight(pdfBalanceX, y, pdf.HelveticaBold, pdfFontSize, s.Currency.Format(total.amount))
+	}
+
+	pages := e.doc.Pages()
+	for i, page := range pages {
+		page.Text(pdfMargin, pdfMargin-10, pdf.Helvetica, 8, s.Account.Number)
+		page.TextRight(pdfRight, pdfMargin-10, pdf.Helvetica, 8, fmt.Sprintf("Page %d of %d", i+1, len(pages)))
+	}
+	return e.doc.Write(e.w)
+}
+
+func (e *pdfEncoder) flush() error {
+	return nil
+}
@@ -0,0 +1,177 @@
+package statement
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	db "github.com/hhow09/simple_bank/db/sqlc"
+	"github.com/hhow09/simple_bank/money"
+	"github.com/hhow09/simple_bank/snapshot"
+)
+
+// Format is the file format of a statement
+type Format string
+
+const (
+	FormatCSV Format = "csv"
+	FormatOFX Format = "ofx"
+	// FormatCAMT053 is the ISO 20022 bank to customer statement, camt.053.001.02
+	FormatCAMT053 Format = "camt053"
+	// FormatPDF is the printable statement, it is rendered in memory
+	FormatPDF Format = "pdf"
+)
+
+// batchSize is the number of entries read and written at a time
+const batchSize = 500
+
+// Formats are the supported statement formats
+var Formats = []Format{FormatCSV, FormatOFX, FormatCAMT053, FormatPDF}
+
+// ContentType returns the media type of the format
+func (f Format) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatOFX:
+		return "application/x-ofx"
+	case FormatPDF:
+		return "application/pdf"
+	default:
+		return "application/xml; charset=utf-8"
+	}
+}
+
+// Extension returns the file extension of the format
+func (f Format) Extension() string {
+	if f == FormatCAMT053 {
+		return "xml"
+	}
+	return string(f)
+}
+
+// Statement is the activity of an account over the days from From to To, both included.
+// Days are in UTC like the balance snapshots.
+type Statement struct {
+	Account  db.Account
+	Currency money.Currency
+	// Holder is the full name of the account owner
+	Holder string
+	// BankID identifies the bank in the formats that need it
+	BankID string
+	From   time.Time
+	To     time.Time
+	// Until is the end of the statement, the end of To or when it was created for a day not closed yet
+	Until          time.Time
+	OpeningBalance int64
+	ClosingBalance int64
+	CreatedAt      time.Time
+}
+
+// New computes the opening and closing balances of the statement
+func New(ctx context.Context, store db.Store, account db.Account, currency money.Currency, from, to, now time.Time) (Statement, error) {
+	// timestamps are stored in microseconds, the balance before t is the balance at t - 1µs
+	now = now.UTC().Truncate(time.Microsecond)
+	from, to = snapshot.Day(from), snapshot.Day(to)
+	until := snapshot.ClosingAt(to)
+	if until.After(now) {
+		until = now
+	}
+	opening, _, err := snapshot.BalanceAt(ctx, store, account.ID, from.Add(-time.Microsecond))
+	if err != nil {
+		return Statement{}, fmt.Errorf("failed to get the opening balance: %w", err)
+	}
+	closing, _, err := snapshot.BalanceAt(ctx, store, account.ID, until.Add(-time.Microsecond))
+	if err != nil {
+		return Statement{}, fmt.Errorf("failed to get the closing balance: %w", err)
+	}
+	return Statement{
+		Account:        account,
+		Currency:       currency,
+		From:           from,
+		To:             to,
+		Until:          until,
+		OpeningBalance: opening,
+		ClosingBalance: closing,
+		CreatedAt:      now,
+	}, nil
+}
+
+// ID identifies the statement in the formats that need it
+func (s *Statement) ID() string {
+	return fmt.Sprintf("%d-%s-%s", s.Account.ID, s.From.Format("20060102"), s.To.Format("20060102"))
+}
+
+// Filename returns the name of the statement file in the format
+func (s *Statement) Filename(format Format) string {
+	return fmt.Sprintf("statement-%s-%s-%s.%s", s.Account.Number, s.From.Format(snapshot.DateFormat), s.To.Format(snapshot.DateFormat), format.Extension())
+}
+
+// encoder writes a statement in a format as it is read
+type encoder interface {
+	begin(s *Statement) error
+	entry(s *Statement, entry db.ListStatementEntriesRow) error
+	end(s *Statement) error
+	flush() error
+}
+
+func newEncoder(format Format, w io.Writer) (encoder, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVEncoder(w), nil
+	case FormatOFX:
+		return newOFXEncoder(w), nil
+	case FormatCAMT053:
+		return newCAMTEncoder(w), nil
+	case FormatPDF:
+		return newPDFEncoder(w), nil
+	}
+	return nil, fmt.Errorf("unsupported statement format %q", format)
+}
+
+// Write streams the statement to w in the format, the entries are read in batches
+// and w is flushed after each batch when it is an http.Flusher
+func (s *Statement) Write(ctx context.Context, store db.Store, w io.Writer, format Format) error {
+	enc, err := newEncoder(format, w)
+	if err != nil {
+		return err
+	}
+	if err := enc.begin(s); err != nil {
+		return err
+	}
+	arg := db.ListStatementEntriesParams{
+		AccountID:      s.Account.ID,
+		FromTime:       s.From,
+		UntilTime:      s.Until,
+		AfterCreatedAt: s.From,
+		Limit:          batchSize,
+	}
+	for {
+		entries, err := store.ListStatementEntries(ctx, arg)
+		if err != nil {
+			return fmt.Errorf("failed to list the entries: %w", err)
+		}
+		for _, entry := range entries {
+			if err := enc.entry(s, entry); err != nil {
+				return err
+			}
+		}
+		if err := enc.flush(); err != nil {
+			return err
+		}
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+		if len(entries) < batchSize {
+			break
+		}
+		last := entries[len(entries)-1]
+		arg.AfterCreatedAt, arg.AfterID = last.CreatedAt, last.ID
+	}
+	if err := enc.end(s); err != nil {
+		return err
+	}
+	return enc.flush()
+}
@@ -0,0 +1,252 @@
+package statement
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/hhow09/simple_bank/constants"
+	mockdb "github.com/hhow09/simple_bank/db/mock"
+	db "github.com/hhow09/simple_bank/db/sqlc"
+	"github.com/hhow09/simple_bank/money"
+	"github.com/stretchr/testify/require"
+)
+
+var usd = money.Currency{Code: "USD", Exponent: 2, Symbol: "$"}
+
+func testStatement() Statement {
+	return Statement{
+		Account:        db.Account{ID: 7, Number: "SB12SMPL000000000007", Currency: "USD", Type: constants.AccountTypeChecking},
+		Currency:       usd,
+		Holder:         "Jane Doe",
+		BankID:         "SMPL",
+		From:           time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		To:             time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+		Until:          time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		OpeningBalance: 1000,
+		ClosingBalance: 850,
+		CreatedAt:      time.Date(2024, 3, 2, 9, 30, 0, 0, time.UTC),
+	}
+}
+
+func testEntries() []db.ListStatementEntriesRow {
+	return []db.ListStatementEntriesRow{
+		{
+			ID:                 11,
+			Amount:             -250,
+			CreatedAt:          time.Date(2024, 2, 3, 10, 0, 0, 0, time.UTC),
+			Description:        "=rent",
+			Reference:          "INV-1",
+			Kind:               constants.JournalKindTransfer,
+			TransferID:         sql.NullInt64{Int64: 4, Valid: true},
+			CounterpartyNumber: "SB34SMPL000000000009",
+			CounterpartyName:   "John Roe",
+		},
+		{
+			ID:        12,
+			Amount:    100,
+			CreatedAt: time.Date(2024, 2, 10, 10, 0, 0, 0, time.UTC),
+			Kind:      constants.JournalKindDeposit,
+		},
+	}
+}
+
+func TestNew(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	account := db.Account{ID: 7, Currency: "USD"}
+	now := time.Date(2024, 2, 20, 12, 0, 0, 0, time.UTC)
+	from := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetLatestBalanceSnapshot(gomock.Any(), gomock.Any()).Times(2).Return(db.AccountBalanceSnapshot{}, sql.ErrNoRows)
+	// the opening balance is the balance before the first day, the statement of a day not closed yet ends now
+	store.EXPECT().GetAccountBalanceAt(gomock.Any(), db.GetAccountBalanceAtParams{At: from.Add(-time.Microsecond), AccountID: account.ID}).Times(1).Return(int64(1000), nil)
+	store.EXPECT().GetAccountBalanceAt(gomock.Any(), db.GetAccountBalanceAtParams{At: now.Add(-time.Microsecond), AccountID: account.ID}).Times(1).Return(int64(900), nil)
+
+	statement, err := New(context.Background(), store, account, usd, from.Add(5*time.Hour), time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC), now)
+	require.NoError(t, err)
+	require.Equal(t, from, statement.From)
+	require.Equal(t, now, statement.Until)
+	require.Equal(t, int64(1000), statement.OpeningBalance)
+	require.Equal(t, int64(900), statement.ClosingBalance)
+}
+
+func writeStatement(t *testing.T, format Format, batches ...[]db.ListStatementEntriesRow) string {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	statement := testStatement()
+	store := mockdb.NewMockStore(ctrl)
+	calls := make([]*gomock.Call, len(batches))
+	for i, batch := range batches {
+		calls[i] = store.EXPECT().ListStatementEntries(gomock.Any(), gomock.Any()).Times(1).Return(batch, nil)
+	}
+	gomock.InOrder(calls...)
+
+	var buf bytes.Buffer
+	require.NoError(t, statement.Write(context.Background(), store, &buf, format))
+	return buf.String()
+}
+
+func TestWriteCSV(t *testing.T) {
+	records, err := csv.NewReader(bytes.NewBufferString(writeStatement(t, FormatCSV, testEntries()))).ReadAll()
+	require.NoError(t, err)
+	require.Equal(t, [][]string{
+		csvHeader,
+		{"2024-02-01T00:00:00Z", "opening_balance", "", "", "", "", "", "", "10.00"},
+		{"2024-02-03T10:00:00Z", "transfer", "11", "'=rent", "INV-1", "John Roe", "SB34SMPL000000000009", "-2.50", "7.50"},
+		{"2024-02-10T10:00:00Z", "deposit", "12", "", "", "", "", "1.00", "8.50"},
+		{"2024-03-01T00:00:00Z", "closing_balance", "", "", "", "", "", "", "8.50"},
+	}, records)
+}
+
+func TestWriteOFX(t *testing.T) {
+	ofx := writeStatement(t, FormatOFX, testEntries())
+	require.Contains(t, ofx, `<?OFX OFXHEADER="200" VERSION="220"`)
+
+	var doc struct {
+		Currency string `xml:"BANKMSGSRSV1>STMTTRNRS>STMTRS>CURDEF"`
+		Account  string `xml:"BANKMSGSRSV1>STMTTRNRS>STMTRS>BANKACCTFROM>ACCTID"`
+		Start    string `xml:"BANKMSGSRSV1>STMTTRNRS>STMTRS>BANKTRANLIST>DTSTART"`
+		Entries  []struct {
+			Type   string `xml:"TRNTYPE"`
+			Amount string `xml:"TRNAMT"`
+			ID     string `xml:"FITID"`
+			Name   string `xml:"NAME"`
+		} `xml:"BANKMSGSRSV1>STMTTRNRS>STMTRS>BANKTRANLIST>STMTTRN"`
+		Balance string `xml:"BANKMSGSRSV1>STMTTRNRS>STMTRS>LEDGERBAL>BALAMT"`
+	}
+	require.NoError(t, xml.Unmarshal([]byte(ofx), &doc))
+	require.Equal(t, "USD", doc.Currency)
+	require.Equal(t, "SB12SMPL000000000007", doc.Account)
+	require.Equal(t, "20240201000000.000[0:GMT]", doc.Start)
+	require.Len(t, doc.Entries, 2)
+	require.Equal(t, "XFER", doc.Entries[0].Type)
+	require.Equal(t, "-2.50", doc.Entries[0].Amount)
+	require.Equal(t, "11", doc.Entries[0].ID)
+	require.Equal(t, "John Roe", doc.Entries[0].Name)
+	require.Equal(t, "DEP", doc.Entries[1].Type)
+	require.Equal(t, "8.50", doc.Balance)
+}
+
+func TestWriteCAMT053(t *testing.T) {
+	camt := writeStatement(t, FormatCAMT053, testEntries())
+
+	var doc struct {
+		XMLName  xml.Name
+		Balances []struct {
+			Code      string `xml:"Tp>CdOrPrtry>Cd"`
+			Amount    string `xml:"Amt"`
+			Indicator string `xml:"CdtDbtInd"`
+			Date      string `xml:"Dt>Dt"`
+		} `xml:"BkToCstmrStmt>Stmt>Bal"`
+		Entries []struct {
+			Amount    camtAmount `xml:"Amt"`
+			Indicator string     `xml:"CdtDbtInd"`
+			Code      string     `xml:"BkTxCd>Prtry>Cd"`
+			EndToEnd  string     `xml:"NtryDtls>TxDtls>Refs>EndToEndId"`
+			Creditor  string     `xml:"NtryDtls>TxDtls>RltdPties>Cdtr>Nm"`
+			Account   string     `xml:"NtryDtls>TxDtls>RltdPties>CdtrAcct>Id>Othr>Id"`
+			Remitted  string     `xml:"NtryDtls>TxDtls>RmtInf>Ustrd"`
+		} `xml:"BkToCstmrStmt>Stmt>Ntry"`
+	}
+	require.NoError(t, xml.Unmarshal([]byte(camt), &doc))
+	require.Equal(t, xml.Name{Space: camtNamespace, Local: "Document"}, doc.XMLName)
+
+	require.Len(t, doc.Balances, 2)
+	require.Equal(t, "OPBD", doc.Balances[0].Code)
+	require.Equal(t, "10.00", doc.Balances[0].Amount)
+	require.Equal(t, "2024-02-01", doc.Balances[0].Date)
+	require.Equal(t, "CLBD", doc.Balances[1].Code)
+	require.Equal(t, "8.50", doc.Balances[1].Amount)
+	require.Equal(t, "2024-02-29", doc.Balances[1].Date)
+
+	require.Len(t, doc.Entries, 2)
+	debit := doc.Entries[0]
+	require.Equal(t, camtAmount{Currency: "USD", Value: "2.50"}, debit.Amount)
+	require.Equal(t, camtDebit, debit.Indicator)
+	require.Equal(t, "transfer", debit.Code)
+	require.Equal(t, "INV-1", debit.EndToEnd)
+	require.Equal(t, "John Roe", debit.Creditor)
+	require.Equal(t, "SB34SMPL000000000009", debit.Account)
+	require.Equal(t, "=rent", debit.Remitted)
+
+	credit := doc.Entries[1]
+	require.Equal(t, camtCredit, credit.Indicator)
+	require.Equal(t, camtNotProvided, credit.EndToEnd)
+	require.Empty(t, credit.Creditor)
+}
+
+func TestWriteBatches(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	statement := testStatement()
+	full := make([]db.ListStatementEntriesRow, batchSize)
+	for i := range full {
+		full[i] = db.ListStatementEntriesRow{ID: int64(i + 1), Amount: 1, CreatedAt: statement.From.Add(time.Duration(i) * time.Second)}
+	}
+	last := full[batchSize-1]
+	store := mockdb.NewMockStore(ctrl)
+	// a full batch is followed by the entries after its last one
+	gomock.InOrder(
+		store.EXPECT().ListStatementEntries(gomock.Any(), db.ListStatementEntriesParams{
+			AccountID:      statement.Account.ID,
+			FromTime:       statement.From,
+			UntilTime:      statement.Until,
+			AfterCreatedAt: statement.From,
+			Limit:          batchSize,
+		}).Times(1).Return(full, nil),
+		store.EXPECT().ListStatementEntries(gomock.Any(), db.ListStatementEntriesParams{
+			AccountID:      statement.Account.ID,
+			FromTime:       statement.From,
+			UntilTime:      statement.Until,
+			AfterCreatedAt: last.CreatedAt,
+			AfterID:        last.ID,
+			Limit:          batchSize,
+		}).Times(1).Return([]db.ListStatementEntriesRow{}, nil),
+	)
+
+	var buf bytes.Buffer
+	require.NoError(t, statement.Write(context.Background(), store, &buf, FormatCSV))
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, batchSize+3)
+}
+
+func TestWriteUnsupportedFormat(t *testing.T) {
+	statement := testStatement()
+	var buf bytes.Buffer
+	require.Error(t, statement.Write(context.Background(), nil, &buf, Format("qif")))
+	require.Zero(t, buf.Len())
+}
+
+func TestWritePDF(t *testing.T) {
+	pdf := writeStatement(t, FormatPDF, testEntries())
+	require.True(t, strings.HasPrefix(pdf, "%PDF-1.4\n"))
+	require.True(t, strings.HasSuffix(pdf, "%%EOF\n"))
+	require.Contains(t, pdf, "(Account statement)")
+	require.Contains(t, pdf, "(Jane Doe)")
+	require.Contains(t, pdf, "(John Roe: =rent)")
+	require.Contains(t, pdf, "(-2.50)")
+	require.Contains(t, pdf, "(8.50)")
+	require.Contains(t, pdf, "(Page 1 of 1)")
+}
+
+func TestWritePDFPages(t *testing.T) {
+	statement := testStatement()
+	entries := make([]db.ListStatementEntriesRow, 120)
+	for i := range entries {
+		entries[i] = db.ListStatementEntriesRow{ID: int64(i + 1), Amount: 1, CreatedAt: statement.From, Kind: constants.JournalKindDeposit}
+	}
+	pdf := writeStatement(t, FormatPDF, entries)
+	require.Contains(t, pdf, "/Count 3")
+	require.Contains(t, pdf, "(Page 3 of 3)")
+}
@@ -31,6 +31,15 @@ func (maker *JWTMaker) CreateToken(username string, duration time.Duration) (str
 	return jwtToken.SignedString([]byte(maker.secretKey))
 }
 
+func (maker *JWTMaker) CreateScopedToken(username string, clientID string, scopes []string, duration time.Duration) (string, error) {
+	payload, err := NewScopedPayload(username, clientID, scopes, duration)
+	if err != nil {
+		return "", err
+	}
+	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, payload)
+	return jwtToken.SignedString([]byte(maker.secretKey))
+}
+
 func (maker *JWTMaker) VerifyToken(token string) (*Payload, error) {
 	keyFunc := func(token *jwt.Token) (interface{}, error) {
 		// convert token.Method since we here uses SigningMethodHS256
@@ -8,6 +8,9 @@ import (
 
 type Maker interface {
 	CreateToken(username string, duration time.Duration) (string, error)
+	// CreateScopedToken creates a token delegated to an OAuth client,
+	// it only grants the given scopes
+	CreateScopedToken(username string, clientID string, scopes []string, duration time.Duration) (string, error)
 	VerifyToken(token string) (*Payload, error)
 }
 
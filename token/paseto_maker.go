@@ -37,6 +37,14 @@ func (maker *PasetoMaker) CreateToken(username string, duration time.Duration) (
 	return maker.paseto.Encrypt(maker.symmetricKey, payload, nil)
 }
 
+func (maker *PasetoMaker) CreateScopedToken(username string, clientID string, scopes []string, duration time.Duration) (string, error) {
+	payload, err := NewScopedPayload(username, clientID, scopes, duration)
+	if err != nil {
+		return "", err
+	}
+	return maker.paseto.Encrypt(maker.symmetricKey, payload, nil)
+}
+
 func (maker *PasetoMaker) VerifyToken(token string) (*Payload, error) {
 	payload := &Payload{}
 
@@ -61,3 +61,31 @@ func TestInvalidToken(t *testing.T) {
 	require.EqualError(t, err, ErrInvalidToken.Error())
 	require.Nil(t, payload)
 }
+
+func TestScopedPasetoToken(t *testing.T) {
+	maker, err := newTestNewPasetoMaker()
+	require.NoError(t, err)
+
+	username := util.RandomOwner()
+	clientID := util.RandomString(16)
+	scopes := []string{"accounts:read"}
+
+	token, err := maker.CreateScopedToken(username, clientID, scopes, time.Minute)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+
+	payload, err := maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.True(t, payload.IsScoped())
+	require.Equal(t, username, payload.Username)
+	require.Equal(t, clientID, payload.ClientID)
+	require.Equal(t, scopes, payload.Scopes)
+
+	token, err = maker.CreateToken(username, time.Minute)
+	require.NoError(t, err)
+
+	payload, err = maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.False(t, payload.IsScoped())
+	require.Empty(t, payload.Scopes)
+}
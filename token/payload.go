@@ -18,6 +18,9 @@ type Payload struct {
 	Username  string    `json:"username"`
 	IssuedAt  time.Time `json:"issued_at"`
 	ExpiredAt time.Time `json:"expired_at"`
+	// ClientID and Scopes are only set on tokens issued to OAuth clients
+	ClientID string   `json:"client_id,omitempty"`
+	Scopes   []string `json:"scopes,omitempty"`
 }
 
 func NewPayload(username string, duration time.Duration) (*Payload, error) {
@@ -29,6 +32,22 @@ func NewPayload(username string, duration time.Duration) (*Payload, error) {
 	return payload, nil
 }
 
+// NewScopedPayload creates a payload of a token delegated to an OAuth client
+func NewScopedPayload(username string, clientID string, scopes []string, duration time.Duration) (*Payload, error) {
+	payload, err := NewPayload(username, duration)
+	if err != nil {
+		return nil, err
+	}
+	payload.ClientID = clientID
+	payload.Scopes = scopes
+	return payload, nil
+}
+
+// IsScoped reports whether the token was issued to an OAuth client
+func (payload *Payload) IsScoped() bool {
+	return payload.ClientID != ""
+}
+
 func (payload *Payload) Valid() error {
 	if time.Now().After(payload.ExpiredAt) {
 		return ErrExpireToken
@@ -0,0 +1,35 @@
+package util
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// apiKeyTag makes keys recognizable, e.g. by secret scanners
+const apiKeyTag = "sb"
+
+// NewAPIKey returns a key in the form sb_<prefix>_<secret>,
+// the prefix identifies the key and only the hash of the secret is stored
+func NewAPIKey() (key string, prefix string, secret string, err error) {
+	b := make([]byte, 6)
+	if _, err = rand.Read(b); err != nil {
+		return "", "", "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+	prefix = hex.EncodeToString(b)
+	secret, err = RandomToken(32)
+	if err != nil {
+		return "", "", "", err
+	}
+	return apiKeyTag + "_" + prefix + "_" + secret, prefix, secret, nil
+}
+
+// ParseAPIKey splits a key created by NewAPIKey
+func ParseAPIKey(key string) (prefix string, secret string, ok bool) {
+	parts := strings.SplitN(key, "_", 3)
+	if len(parts) != 3 || parts[0] != apiKeyTag || parts[1] == "" || parts[2] == "" {
+		return "", "", false
+	}
+	return parts[1], parts[2], true
+}
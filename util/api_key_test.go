@@ -0,0 +1,24 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKey(t *testing.T) {
+	key, prefix, secret, err := NewAPIKey()
+	require.NoError(t, err)
+	require.Len(t, prefix, 12)
+	require.NotEmpty(t, secret)
+
+	gotPrefix, gotSecret, ok := ParseAPIKey(key)
+	require.True(t, ok)
+	require.Equal(t, prefix, gotPrefix)
+	require.Equal(t, secret, gotSecret)
+
+	for _, invalid := range []string{"", "sb_", "sb_prefix", "xx_prefix_secret", "sb__secret"} {
+		_, _, ok = ParseAPIKey(invalid)
+		require.False(t, ok, invalid)
+	}
+}
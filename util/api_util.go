@@ -1,7 +0,0 @@
-package util
-
-import "github.com/gin-gonic/gin"
-
-func ErrorResponse(err error) gin.H {
-	return gin.H{"error": err.Error()}
-}
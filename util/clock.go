@@ -0,0 +1,53 @@
+package util
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells the current time. Jobs take it instead of calling time.Now,
+// so that tests can run them at a chosen time.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// NewClock returns the clock of the system
+func NewClock() Clock {
+	return systemClock{}
+}
+
+// FixedClock is a Clock for tests, it stays at its time until it is moved
+type FixedClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func NewFixedClock(now time.Time) *FixedClock {
+	return &FixedClock{now: now}
+}
+
+func (c *FixedClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Set moves the clock to now
+func (c *FixedClock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+}
+
+// Add moves the clock forward by d
+func (c *FixedClock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
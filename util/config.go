@@ -14,6 +14,81 @@ type Config struct {
 	ServerAddress       string        `mapstructure:"SERVER_ADDRESS"`
 	TokenSymmetricKey   string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
 	AccessTokenDuration time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	// CurrencySource is where the supported currencies are read from: config or postgres
+	CurrencySource string `mapstructure:"CURRENCY_SOURCE"`
+	// Currencies in the form of <code>:<exponent>:<symbol>[:<rounding>], separated by commas
+	Currencies string `mapstructure:"CURRENCIES"`
+	// RateLimitBackend is where token buckets are kept: memory or postgres
+	RateLimitBackend string `mapstructure:"RATE_LIMIT_BACKEND"`
+	// rate limit policies in the form of <limit>/<period>, empty to disable
+	RateLimitLogin    string `mapstructure:"RATE_LIMIT_LOGIN"`
+	RateLimitTransfer string `mapstructure:"RATE_LIMIT_TRANSFER"`
+	// TrustedProxies are the IPs or CIDRs of the reverse proxies whose X-Forwarded-For header is trusted,
+	// separated by commas, empty to key clients by the IP of the peer
+	TrustedProxies string `mapstructure:"TRUSTED_PROXIES"`
+	// login lockout: after LoginMaxFailedAttempts failures within LoginFailureWindow
+	// the user is locked for LoginLockoutDuration, doubled on every further failure
+	LoginMaxFailedAttempts int           `mapstructure:"LOGIN_MAX_FAILED_ATTEMPTS"`
+	LoginFailureWindow     time.Duration `mapstructure:"LOGIN_FAILURE_WINDOW"`
+	LoginLockoutDuration   time.Duration `mapstructure:"LOGIN_LOCKOUT_DURATION"`
+	// LoginAttemptRetention is how long login attempts are kept, at least LoginFailureWindow, 0 to keep them
+	LoginAttemptRetention time.Duration `mapstructure:"LOGIN_ATTEMPT_RETENTION"`
+	// Mailer is how emails are delivered: log or file
+	Mailer                     string        `mapstructure:"MAILER"`
+	MailFrom                   string        `mapstructure:"MAIL_FROM"`
+	MailOutboxDir              string        `mapstructure:"MAIL_OUTBOX_DIR"`
+	PasswordResetTokenDuration time.Duration `mapstructure:"PASSWORD_RESET_TOKEN_DURATION"`
+	// EmailVerificationRequired blocks creating accounts and transfers until the email is verified
+	EmailVerificationRequired      bool          `mapstructure:"EMAIL_VERIFICATION_REQUIRED"`
+	EmailVerificationTokenDuration time.Duration `mapstructure:"EMAIL_VERIFICATION_TOKEN_DURATION"`
+	// TOTPIssuer is the name shown by authenticator apps
+	TOTPIssuer                 string        `mapstructure:"TOTP_ISSUER"`
+	TwoFactorChallengeDuration time.Duration `mapstructure:"TWO_FACTOR_CHALLENGE_DURATION"`
+	// transfers above TwoFactorTransferThreshold require a TOTP code from users with 2FA enabled
+	TwoFactorTransferThreshold int64 `mapstructure:"TWO_FACTOR_TRANSFER_THRESHOLD"`
+	// argon2id parameters of new password hashes, older hashes are upgraded on login
+	PasswordArgon2Memory      uint32 `mapstructure:"PASSWORD_ARGON2_MEMORY"`
+	PasswordArgon2Iterations  uint32 `mapstructure:"PASSWORD_ARGON2_ITERATIONS"`
+	PasswordArgon2Parallelism uint8  `mapstructure:"PASSWORD_ARGON2_PARALLELISM"`
+	// password policy of new passwords
+	PasswordMinLength           int `mapstructure:"PASSWORD_MIN_LENGTH"`
+	PasswordMinCharacterClasses int `mapstructure:"PASSWORD_MIN_CHARACTER_CLASSES"`
+	// PasswordBreachFile is a sorted file of SHA-1 hashes of breached passwords, empty to skip the check
+	PasswordBreachFile string `mapstructure:"PASSWORD_BREACH_FILE"`
+	// OAuthAuthorizationCodeDuration is how long an authorization code can be exchanged for a token
+	OAuthAuthorizationCodeDuration time.Duration `mapstructure:"OAUTH_AUTHORIZATION_CODE_DURATION"`
+	// maximum number of accounts of a type a user can open per currency, 0 for no limit
+	AccountMaxCheckingPerCurrency int64 `mapstructure:"ACCOUNT_MAX_CHECKING_PER_CURRENCY"`
+	AccountMaxSavingsPerCurrency  int64 `mapstructure:"ACCOUNT_MAX_SAVINGS_PER_CURRENCY"`
+	// scheme of new account numbers: country code, check digits, bank code and random digits
+	AccountNumberCountryCode string `mapstructure:"ACCOUNT_NUMBER_COUNTRY_CODE"`
+	AccountNumberBankCode    string `mapstructure:"ACCOUNT_NUMBER_BANK_CODE"`
+	AccountNumberDigits      int    `mapstructure:"ACCOUNT_NUMBER_DIGITS"`
+	// BeneficiaryCoolingOffPeriod delays the first transfer to a new beneficiary, 0 to allow it at once
+	BeneficiaryCoolingOffPeriod time.Duration `mapstructure:"BENEFICIARY_COOLING_OFF_PERIOD"`
+	// ReconciliationInterval is how often the ledger is reconciled in the background, 0 to disable
+	ReconciliationInterval time.Duration `mapstructure:"RECONCILIATION_INTERVAL"`
+	// ReconciliationBatchSize is the number of rows checked per query
+	ReconciliationBatchSize int32 `mapstructure:"RECONCILIATION_BATCH_SIZE"`
+	// ReconciliationFreezeAccounts freezes the accounts with discrepancies found by the background job
+	ReconciliationFreezeAccounts bool `mapstructure:"RECONCILIATION_FREEZE_ACCOUNTS"`
+	// BalanceSnapshotInterval is how often the daily balance snapshots are caught up in the background, 0 to disable
+	BalanceSnapshotInterval time.Duration `mapstructure:"BALANCE_SNAPSHOT_INTERVAL"`
+	// BalanceSnapshotBatchSize is the number of snapshots written per query
+	BalanceSnapshotBatchSize int32 `mapstructure:"BALANCE_SNAPSHOT_BATCH_SIZE"`
+	// MonthlyStatementInterval is how often the statements of the last closed month are generated in the background, 0 to disable
+	MonthlyStatementInterval time.Duration `mapstructure:"MONTHLY_STATEMENT_INTERVAL"`
+	// InterestInterval is how often interest is accrued and the closed months are posted in the background, 0 to disable
+	InterestInterval time.Duration `mapstructure:"INTEREST_INTERVAL"`
+	// FeeTransfer in the form of <currency>:<fee>[:<min>-<max>], separated by commas, where the fee
+	// is <amount>, <percent>% or <amount>+<percent>% in major units, empty for no transfer fees
+	FeeTransfer string `mapstructure:"FEE_TRANSFER"`
+	// FeeMaintenance in the form of <account_type>:<currency>:<amount>, separated by commas, charged monthly
+	FeeMaintenance string `mapstructure:"FEE_MAINTENANCE"`
+	// FeeWaivers in the form of <tier>:<kind>, separated by commas, waive the fees of a kind for a user tier
+	FeeWaivers string `mapstructure:"FEE_WAIVERS"`
+	// MaintenanceFeeInterval is how often the maintenance fees of the last closed month are charged in the background, 0 to disable
+	MaintenanceFeeInterval time.Duration `mapstructure:"MAINTENANCE_FEE_INTERVAL"`
 }
 
 // relative path of app.env
@@ -1,20 +1,189 @@
 package util
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
 
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 )
 
-func HashPassword(password string) (string, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
+// ErrPasswordMismatch is returned when the password does not match the hash
+var ErrPasswordMismatch = errors.New("password does not match the hash")
+
+// ErrUnknownPasswordHash is returned for hashes of unsupported algorithms
+var ErrUnknownPasswordHash = errors.New("unknown password hash format")
+
+// argon2idPrefix starts every argon2id hash, they are encoded in the PHC string
+// format $argon2id$v=19$m=<memory>,t=<iterations>,p=<parallelism>$<salt>$<key>
+const argon2idPrefix = "$argon2id$"
+
+// Argon2Params are the cost parameters of argon2id
+type Argon2Params struct {
+	// Memory in KiB
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2Params follow the second recommendation of RFC 9106
+var DefaultArgon2Params = Argon2Params{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 4,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// PasswordHasher hashes new passwords with argon2id. Hashes are versioned by
+// their encoding, so bcrypt hashes and argon2id hashes of other parameters
+// are still verified and can be upgraded with NeedsRehash.
+type PasswordHasher struct {
+	params Argon2Params
+
+	dummyHashOnce *sync.Once
+	dummyHash     *string
+}
+
+// NewPasswordHasher creates a hasher with the argon2id parameters of the config,
+// parameters which are not set keep their default
+func NewPasswordHasher(config Config) PasswordHasher {
+	params := DefaultArgon2Params
+	if config.PasswordArgon2Memory > 0 {
+		params.Memory = config.PasswordArgon2Memory
+	}
+	if config.PasswordArgon2Iterations > 0 {
+		params.Iterations = config.PasswordArgon2Iterations
+	}
+	if config.PasswordArgon2Parallelism > 0 {
+		params.Parallelism = config.PasswordArgon2Parallelism
+	}
+	return newPasswordHasher(params)
+}
+
+func newPasswordHasher(params Argon2Params) PasswordHasher {
+	return PasswordHasher{
+		params:        params,
+		dummyHashOnce: &sync.Once{},
+		dummyHash:     new(string),
+	}
+}
+
+// Hash returns the argon2id hash of the password
+func (h PasswordHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
 		return "", fmt.Errorf("failed to hash password: %w", err)
 	}
-	return string(hashedPassword), nil
+	key := argon2.IDKey([]byte(password), salt, h.params.Iterations, h.params.Memory, h.params.Parallelism, h.params.KeyLength)
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix,
+		argon2.Version,
+		h.params.Memory,
+		h.params.Iterations,
+		h.params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// Check checks if the provided password is correct or not,
+// the algorithm and its parameters are taken from the hash
+func (h PasswordHasher) Check(password string, hashedPassword string) error {
+	if strings.HasPrefix(hashedPassword, argon2idPrefix) {
+		params, salt, key, err := decodeArgon2id(hashedPassword)
+		if err != nil {
+			return err
+		}
+		other := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+		if subtle.ConstantTimeCompare(key, other) != 1 {
+			return ErrPasswordMismatch
+		}
+		return nil
+	}
+
+	err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+	switch {
+	case errors.Is(err, bcrypt.ErrMismatchedHashAndPassword):
+		return ErrPasswordMismatch
+	case errors.Is(err, bcrypt.ErrHashTooShort):
+		return ErrUnknownPasswordHash
+	}
+	return err
+}
+
+// NeedsRehash reports whether the hash uses an outdated algorithm or
+// parameters, it should be replaced after the next successful check
+func (h PasswordHasher) NeedsRehash(hashedPassword string) bool {
+	if !strings.HasPrefix(hashedPassword, argon2idPrefix) {
+		return true
+	}
+	params, _, _, err := decodeArgon2id(hashedPassword)
+	if err != nil {
+		return true
+	}
+	params.SaltLength = h.params.SaltLength
+	return params != h.params
+}
+
+// CheckDummy takes as long as Check but always fails.
+// It is used when the user does not exist, so that response times
+// do not reveal which usernames are registered.
+func (h PasswordHasher) CheckDummy(password string) error {
+	h.dummyHashOnce.Do(func() {
+		*h.dummyHash, _ = h.Hash(RandomString(32))
+	})
+	if err := h.Check(password, *h.dummyHash); err != nil {
+		return err
+	}
+	return ErrPasswordMismatch
+}
+
+func decodeArgon2id(hashedPassword string) (params Argon2Params, salt []byte, key []byte, err error) {
+	// "", "argon2id", "v=19", "m=65536,t=3,p=4", salt, key
+	parts := strings.Split(hashedPassword, "$")
+	if len(parts) != 6 {
+		return params, nil, nil, ErrUnknownPasswordHash
+	}
+	var version int
+	if _, err = fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, ErrUnknownPasswordHash
+	}
+	if _, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, ErrUnknownPasswordHash
+	}
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return params, nil, nil, ErrUnknownPasswordHash
+	}
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return params, nil, nil, ErrUnknownPasswordHash
+	}
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+	return params, salt, key, nil
+}
+
+// defaultPasswordHasher serves the package level helpers
+var defaultPasswordHasher = newPasswordHasher(DefaultArgon2Params)
+
+// HashPassword hashes the password with the default argon2id parameters
+func HashPassword(password string) (string, error) {
+	return defaultPasswordHasher.Hash(password)
 }
 
 // CheckPassword checks if the provided password is correct or not
 func CheckPassword(password string, hashedPassword string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+	return defaultPasswordHasher.Check(password, hashedPassword)
+}
+
+// CheckDummyPassword takes as long as CheckPassword but always fails.
+func CheckDummyPassword(password string) error {
+	return defaultPasswordHasher.CheckDummy(password)
 }
@@ -0,0 +1,80 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// minPersonalInfoLength is the shortest username or email part a password may not contain,
+// shorter values would reject too many unrelated passwords
+const minPersonalInfoLength = 3
+
+// PasswordPolicy is the policy of new passwords
+type PasswordPolicy struct {
+	MinLength int
+	// MinCharacterClasses is the number of lower case letters, upper case letters,
+	// digits and symbols a password must mix
+	MinCharacterClasses int
+}
+
+// DefaultPasswordPolicy is the policy of a config which does not set it
+var DefaultPasswordPolicy = PasswordPolicy{
+	MinLength:           8,
+	MinCharacterClasses: 3,
+}
+
+// NewPasswordPolicy creates the password policy of the config,
+// values which are not set keep their default
+func NewPasswordPolicy(config Config) PasswordPolicy {
+	policy := DefaultPasswordPolicy
+	if config.PasswordMinLength > 0 {
+		policy.MinLength = config.PasswordMinLength
+	}
+	if config.PasswordMinCharacterClasses > 0 {
+		policy.MinCharacterClasses = config.PasswordMinCharacterClasses
+	}
+	return policy
+}
+
+// Check returns an error describing why the password violates the policy.
+// The password may not contain the personal info, e.g. the username or the email,
+// regardless of case. For emails the local part is checked as well.
+func (p PasswordPolicy) Check(password string, personal ...string) error {
+	if len([]rune(password)) < p.MinLength {
+		return fmt.Errorf("must be at least %d characters long", p.MinLength)
+	}
+	if characterClasses(password) < p.MinCharacterClasses {
+		return fmt.Errorf("must mix at least %d of lower case letters, upper case letters, digits and symbols", p.MinCharacterClasses)
+	}
+	lower := strings.ToLower(password)
+	for _, info := range personal {
+		values := []string{info}
+		if i := strings.LastIndexByte(info, '@'); i >= 0 {
+			values = append(values, info[:i])
+		}
+		for _, value := range values {
+			if len(value) >= minPersonalInfoLength && strings.Contains(lower, strings.ToLower(value)) {
+				return fmt.Errorf("must not contain the username or email")
+			}
+		}
+	}
+	return nil
+}
+
+func characterClasses(password string) int {
+	var lower, upper, digit, symbol int
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			symbol = 1
+		}
+	}
+	return lower + upper + digit + symbol
+}
@@ -0,0 +1,49 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordPolicy(t *testing.T) {
+	policy := PasswordPolicy{MinLength: 8, MinCharacterClasses: 3}
+
+	testCases := []struct {
+		name     string
+		password string
+		personal []string
+		ok       bool
+	}{
+		{name: "OK", password: "Secret-pass", ok: true},
+		{name: "ThreeClasses", password: "secret12AB", ok: true},
+		{name: "TooShort", password: "Ab1!", ok: false},
+		{name: "TooFewClasses", password: "secretpass12", ok: false},
+		{name: "ContainsUsername", password: "Alice-1234", personal: []string{"alice"}, ok: false},
+		{name: "ContainsEmail", password: "x-Bob.Smith1", personal: []string{"bob.smith@email.com"}, ok: false},
+		{name: "ShortUsername", password: "Jo-secret1", personal: []string{"jo"}, ok: true},
+		{name: "RandomPassword", password: RandomPassword(), personal: []string{RandomOwner(), RandomEmail()}, ok: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := policy.Check(tc.password, tc.personal...)
+			if tc.ok {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicyDisabled(t *testing.T) {
+	require.NoError(t, PasswordPolicy{}.Check("a"))
+}
+
+func TestNewPasswordPolicy(t *testing.T) {
+	// values which are not set keep their default
+	require.Equal(t, DefaultPasswordPolicy, NewPasswordPolicy(Config{}))
+	require.Equal(t, PasswordPolicy{MinLength: 12, MinCharacterClasses: 3}, NewPasswordPolicy(Config{PasswordMinLength: 12}))
+	require.Equal(t, PasswordPolicy{MinLength: 8, MinCharacterClasses: 4}, NewPasswordPolicy(Config{PasswordMinCharacterClasses: 4}))
+}
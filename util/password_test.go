@@ -12,15 +12,69 @@ func TestPassword(t *testing.T) {
 	hashedPassword1, err := HashPassword(password)
 	require.NoError(t, err)
 	require.NotEmpty(t, hashedPassword1)
+	require.Regexp(t, `^\$argon2id\$v=19\$m=65536,t=3,p=4\$[A-Za-z0-9+/]{22}\$[A-Za-z0-9+/]{43}$`, hashedPassword1)
 
 	err = CheckPassword(password, hashedPassword1)
 	require.NoError(t, err)
 
 	wrongPassword := RandomString(6)
 	err = CheckPassword(wrongPassword, hashedPassword1)
-	require.EqualError(t, err, bcrypt.ErrMismatchedHashAndPassword.Error())
+	require.EqualError(t, err, ErrPasswordMismatch.Error())
 
 	hashedPassword2, err := HashPassword(password)
 	require.NoError(t, err)
 	require.NotEqual(t, hashedPassword1, hashedPassword2)
 }
+
+func TestCheckBcryptPassword(t *testing.T) {
+	password := RandomString(6)
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	err = CheckPassword(password, string(hashedPassword))
+	require.NoError(t, err)
+
+	err = CheckPassword(RandomString(6), string(hashedPassword))
+	require.EqualError(t, err, ErrPasswordMismatch.Error())
+}
+
+func TestCheckMalformedPassword(t *testing.T) {
+	for _, hashedPassword := range []string{
+		"",
+		"plain",
+		"$argon2id$v=19$m=65536,t=3,p=4$salt",
+		"$argon2id$v=18$m=65536,t=3,p=4$c2FsdHNhbHRzYWx0c2FsdA$a2V5",
+		"$argon2id$v=19$m=65536,t=3,p=4$not base64!$a2V5",
+	} {
+		err := CheckPassword(RandomString(6), hashedPassword)
+		require.ErrorIs(t, err, ErrUnknownPasswordHash, hashedPassword)
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	password := RandomString(6)
+	hasher := NewPasswordHasher(Config{})
+
+	hashedPassword, err := hasher.Hash(password)
+	require.NoError(t, err)
+	require.False(t, hasher.NeedsRehash(hashedPassword))
+
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	require.NoError(t, err)
+	require.True(t, hasher.NeedsRehash(string(bcryptHash)))
+	require.True(t, hasher.NeedsRehash("malformed"))
+
+	// hashes of other parameters are still verified but upgraded
+	cheaper := NewPasswordHasher(Config{PasswordArgon2Memory: 1024, PasswordArgon2Iterations: 1, PasswordArgon2Parallelism: 1})
+	cheapHash, err := cheaper.Hash(password)
+	require.NoError(t, err)
+	require.Contains(t, cheapHash, "$m=1024,t=1,p=1$")
+	require.NoError(t, hasher.Check(password, cheapHash))
+	require.True(t, hasher.NeedsRehash(cheapHash))
+	require.False(t, cheaper.NeedsRehash(cheapHash))
+}
+
+func TestCheckDummyPassword(t *testing.T) {
+	err := CheckDummyPassword(RandomString(6))
+	require.EqualError(t, err, ErrPasswordMismatch.Error())
+}
@@ -44,3 +44,8 @@ func RandomCurrency() string {
 func RandomEmail() string {
 	return fmt.Sprintf("%s@email.com", RandomString(6))
 }
+
+// RandomPassword generates a password which satisfies the password policy of app.env
+func RandomPassword() string {
+	return fmt.Sprintf("%s%s%d", RandomString(6), strings.ToUpper(RandomString(2)), RandomInt(10, 99))
+}